@@ -12,12 +12,17 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/chistyakoviv/converter/internal/constants"
 	"github.com/chistyakoviv/converter/internal/di"
 	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/model"
 )
 
 type Application interface {
+	// Starts the server and blocks until the application is terminated
 	Run(ctx context.Context)
+	// Runs the command given by the command line arguments, the server is started if there is no command
+	Exec(ctx context.Context, args []string) error
 	Container() di.Container
 }
 
@@ -48,10 +53,29 @@ func (a *app) Run(ctx context.Context) {
 	cfg := resolveConfig(a.container)
 	logger := resolveLogger(a.container)
 	dq := resolveDeferredQ(a.container)
+	// Installs the global provider before anything is traced
+	resolveTracerProvider(a.container)
 	taskService := resolveTaskService(a.container)
 
 	logger.Debug("Application is running in DEBUG mode")
 
+	// The schema must match the migrations embedded in the binary
+	migrator := resolveMigrator(a.container)
+	if cfg.Postgres.MigrateOnStart {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			logger.Error("failed to migrate database", slogger.Err(err))
+			os.Exit(1)
+		}
+		for _, migration := range applied {
+			logger.Info("migration applied", slog.String("name", migration.Name), slog.String("duration", migration.Duration.String()))
+		}
+	}
+	if err := migrator.Check(ctx); err != nil {
+		logger.Error("refusing to start against an incompatible schema, run the migrations with the version of the binary", slogger.Err(err))
+		os.Exit(1)
+	}
+
 	initRoutes(ctx, a.container)
 
 	// Exec the command to empty the memory buffer: echo 3 | sudo tee /proc/sys/vm/drop_caches
@@ -107,6 +131,104 @@ func (a *app) Run(ctx context.Context) {
 		taskService.ProcessQueues(ctx)
 	}()
 
+	// Resuming of the queue paused due to low disk space
+	if cfg.Disk.Enabled {
+		diskGuardService := resolveDiskGuardService(a.container)
+
+		go func() {
+			logger.Info("disk space monitoring started", slog.String("interval", cfg.Disk.CheckInterval.String()))
+
+			ticker := time.NewTicker(cfg.Disk.CheckInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					if diskGuardService.Resume() {
+						taskService.TryQueueConversion()
+					}
+				case <-ctx.Done():
+					logger.Info("disk space monitoring stopped")
+					return
+				}
+			}
+		}()
+	}
+
+	// Filesystem watching
+	if cfg.Watcher.Enabled {
+		watcherService := resolveWatcherService(a.container)
+
+		go func() {
+			logger.Info("filesystem watching started", slog.String("root", constants.FilesRootDir))
+
+			// Watching automatically stops when the context is canceled
+			if err := watcherService.Watch(ctx, constants.FilesRootDir); err != nil {
+				logger.Error("filesystem watcher error", slogger.Err(err))
+			}
+			logger.Info("filesystem watching stopped")
+		}()
+	}
+
+	// Reloading of the conversion defaults
+	if cfg.DefaultsPath != "" {
+		reloaderService := resolveReloaderService(a.container)
+
+		if cfg.Reload.Watch {
+			go func() {
+				logger.Info("defaults watching started", slog.String("path", cfg.DefaultsPath))
+
+				if err := reloaderService.Watch(ctx); err != nil {
+					logger.Error("defaults watcher error", slogger.Err(err))
+				}
+				logger.Info("defaults watching stopped")
+			}()
+		}
+
+		go func() {
+			hangup := make(chan os.Signal, 1)
+			signal.Notify(hangup, syscall.SIGHUP)
+			defer signal.Stop(hangup)
+
+			for {
+				select {
+				case <-hangup:
+					logger.Info("reloading defaults: via signal")
+					// The error is logged by the service
+					_ = reloaderService.Reload()
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	// Periodic garbage collection
+	if cfg.GC.Enabled {
+		gcService := resolveGCService(a.container)
+
+		go func() {
+			logger.Info("periodic garbage collection started", slog.String("interval", cfg.GC.Interval.String()))
+
+			ticker := time.NewTicker(cfg.GC.Interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					// The report is logged by the service
+					_, err := gcService.Collect(ctx, constants.FilesRootDir, model.GCOptions{DryRun: cfg.GC.DryRun})
+					if err != nil && !errors.Is(err, context.Canceled) {
+						logger.Error("garbage collection error", slogger.Err(err))
+					}
+				case <-ctx.Done():
+					logger.Info("periodic garbage collection stopped")
+					return
+				}
+			}
+		}()
+	}
+
 	// Graceful Shutdown
 	select {
 	case <-ctx.Done():
@@ -116,6 +238,13 @@ func (a *app) Run(ctx context.Context) {
 		logger.Info("terminating: via signal")
 	}
 
+	// Fail the readiness first, so no new requests are routed to the application while it shuts down
+	resolveHealthService(a.container).Shutdown()
+	if cfg.Health.ShutdownDelay > 0 {
+		logger.Info("waiting before shutting down", slog.String("delay", cfg.Health.ShutdownDelay.String()))
+		time.Sleep(cfg.Health.ShutdownDelay)
+	}
+
 	// Cancel the context before executing the deferred functions,
 	// so that the queue processing goroutine stops polling the queue,
 	// otherwise deferred functions will call Shutdown on the task service
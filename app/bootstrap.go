@@ -10,29 +10,46 @@ import (
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/constants"
 	"github.com/chistyakoviv/converter/internal/converter"
 	converterService "github.com/chistyakoviv/converter/internal/converter/converter"
 	"github.com/chistyakoviv/converter/internal/converter/ffmpeggo"
 	"github.com/chistyakoviv/converter/internal/converter/govips"
 	"github.com/chistyakoviv/converter/internal/db"
+	"github.com/chistyakoviv/converter/internal/db/migrator"
 	"github.com/chistyakoviv/converter/internal/db/pg"
 	"github.com/chistyakoviv/converter/internal/db/transaction"
 	"github.com/chistyakoviv/converter/internal/di"
+	"github.com/chistyakoviv/converter/internal/file"
 	httpMiddleware "github.com/chistyakoviv/converter/internal/http-server/middleware"
 	"github.com/chistyakoviv/converter/internal/lib/deferredq"
 	"github.com/chistyakoviv/converter/internal/lib/panic_writer"
 	"github.com/chistyakoviv/converter/internal/lib/slogger"
 	"github.com/chistyakoviv/converter/internal/lib/stack_parser"
 	"github.com/chistyakoviv/converter/internal/repository"
+	apiKeyRepository "github.com/chistyakoviv/converter/internal/repository/apikey"
 	conversionRepository "github.com/chistyakoviv/converter/internal/repository/conversion"
 	deletionRepository "github.com/chistyakoviv/converter/internal/repository/deletion"
 	"github.com/chistyakoviv/converter/internal/service"
+	apiKeyService "github.com/chistyakoviv/converter/internal/service/apikey"
 	conversionQueueService "github.com/chistyakoviv/converter/internal/service/conversionq"
 	deletionQueueService "github.com/chistyakoviv/converter/internal/service/deletionq"
+	"github.com/chistyakoviv/converter/internal/service/diskguard"
+	"github.com/chistyakoviv/converter/internal/service/gc"
+	"github.com/chistyakoviv/converter/internal/service/health"
+	"github.com/chistyakoviv/converter/internal/service/reloader"
 	"github.com/chistyakoviv/converter/internal/service/task"
+	"github.com/chistyakoviv/converter/internal/service/watcher"
+	"github.com/chistyakoviv/converter/internal/storage"
+	localStorage "github.com/chistyakoviv/converter/internal/storage/local"
+	s3Storage "github.com/chistyakoviv/converter/internal/storage/s3"
+	"github.com/chistyakoviv/converter/internal/tracing"
+	"github.com/chistyakoviv/converter/migrations"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func bootstrap(ctx context.Context, c di.Container) {
@@ -127,6 +144,49 @@ func bootstrap(ctx context.Context, c di.Container) {
 		return client
 	})
 
+	c.RegisterSingleton("migrator", func(c di.Container) db.Migrator {
+		cfg := resolveConfig(c)
+		logger := resolveLogger(c)
+		dq := resolveDeferredQ(c)
+
+		m, err := migrator.NewMigrator(cfg.Postgres.Dsn, migrations.FS)
+		if err != nil {
+			logger.Error("failed to create migrator", slogger.Err(err))
+			os.Exit(1)
+		}
+
+		dq.Add(func() error {
+			return m.Close()
+		})
+
+		return m
+	})
+
+	c.RegisterSingleton("tracerProvider", func(c di.Container) trace.TracerProvider {
+		cfg := resolveConfig(c)
+		logger := resolveLogger(c)
+		dq := resolveDeferredQ(c)
+
+		// Spans are discarded by the global no-op provider unless the tracing is enabled
+		if !cfg.Tracing.Enabled {
+			return otel.GetTracerProvider()
+		}
+
+		provider, err := tracing.NewProvider(ctx, cfg)
+		if err != nil {
+			logger.Error("failed to create tracer provider", slogger.Err(err))
+			os.Exit(1)
+		}
+
+		// Flush the remaining spans
+		dq.Add(func() error {
+			defer logger.Info("tracer provider stopped")
+			return provider.Shutdown(context.Background())
+		})
+
+		return provider
+	})
+
 	c.RegisterSingleton("sq", func(c di.Container) sq.StatementBuilderType {
 		return sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 	})
@@ -138,6 +198,12 @@ func bootstrap(ctx context.Context, c di.Container) {
 		stackParser := resolveStackParser(c)
 
 		router.Use(middleware.RequestID)
+		if resolveConfig(c).Tracing.Enabled {
+			router.Use(httpMiddleware.NewTracing())
+		}
+		if resolveConfig(c).Metrics.Enabled {
+			router.Use(httpMiddleware.NewMetrics())
+		}
 		router.Use(httpMiddleware.New(logger))
 		router.Use(httpMiddleware.NewRecoverer(panicWriter, stackParser, logger))
 		router.Use(middleware.URLFormat)
@@ -199,6 +265,20 @@ func bootstrap(ctx context.Context, c di.Container) {
 		return serv
 	})
 
+	c.RegisterSingleton("storage", func(c di.Container) storage.Storage {
+		cfg := resolveConfig(c)
+
+		if cfg.Storage.Driver == config.StorageS3 {
+			s, err := s3Storage.NewStorage(cfg.Storage.S3)
+			if err != nil {
+				log.Fatalf("Couldn't create s3 storage: %v", err)
+			}
+			return s
+		}
+
+		return localStorage.NewStorage("")
+	})
+
 	// Repositories
 	c.RegisterSingleton("conversionQueueRepository", func(c di.Container) repository.ConversionQueueRepository {
 		return conversionRepository.NewRepository(resolveDbClient(c), resolveStatementBuilder(c))
@@ -214,6 +294,18 @@ func bootstrap(ctx context.Context, c di.Container) {
 			resolveConfig(c),
 			resolveTxManager(c),
 			resolveConversionQueueRepository(c),
+			resolveStorage(c),
+		)
+	})
+
+	c.RegisterSingleton("apiKeyRepository", func(c di.Container) repository.ApiKeyRepository {
+		return apiKeyRepository.NewRepository(resolveDbClient(c), resolveStatementBuilder(c))
+	})
+
+	c.RegisterSingleton("apiKeyService", func(c di.Container) service.ApiKeyService {
+		return apiKeyService.NewService(
+			resolveConfig(c),
+			resolveApiKeyRepository(c),
 		)
 	})
 
@@ -229,10 +321,73 @@ func bootstrap(ctx context.Context, c di.Container) {
 
 	c.RegisterSingleton("taskService", func(c di.Container) service.TaskService {
 		return task.NewService(
+			resolveConfig(c),
 			resolveLogger(c),
 			resolveConversionQueueService(c),
 			resolveDeletionQueueService(c),
 			resolveConverterService(c),
+			resolveDiskGuardService(c),
+			resolveStorage(c),
+		)
+	})
+
+	c.RegisterSingleton("watcherService", func(c di.Container) service.WatcherService {
+		serv := watcher.NewService(
+			resolveConfig(c),
+			resolveLogger(c),
+			resolveConversionQueueService(c),
+			resolveDeletionQueueService(c),
+			resolveTaskService(c),
+		)
+		dq := resolveDeferredQ(c)
+		logger := resolveLogger(c)
+
+		dq.Add(func() error {
+			defer logger.Info("watcher shutdown")
+			serv.Shutdown()
+			return nil
+		})
+
+		return serv
+	})
+
+	c.RegisterSingleton("reloaderService", func(c di.Container) service.ReloaderService {
+		return reloader.NewService(
+			resolveConfig(c),
+			resolveLogger(c),
+		)
+	})
+
+	c.RegisterSingleton("gcService", func(c di.Container) service.GCService {
+		return gc.NewService(
+			resolveConfig(c),
+			resolveLogger(c),
+			resolveConversionQueueRepository(c),
+			resolveDeletionQueueRepository(c),
+			resolveConversionQueueService(c),
+			resolveStorage(c),
+		)
+	})
+
+	c.RegisterSingleton("diskGuardService", func(c di.Container) service.DiskGuardService {
+		return diskguard.NewService(
+			resolveConfig(c),
+			resolveLogger(c),
+			resolveStorage(c),
+			file.FreeSpace,
+		)
+	})
+
+	c.RegisterSingleton("healthService", func(c di.Container) service.HealthService {
+		return health.NewService(
+			resolveConfig(c),
+			resolveLogger(c),
+			resolveDbClient(c),
+			resolveMigrator(c),
+			constants.FilesRootDir,
+			resolveImageConverter(c),
+			resolveVideoConverter(c),
+			resolveTaskService(c),
 		)
 	})
 
@@ -241,6 +396,9 @@ func bootstrap(ctx context.Context, c di.Container) {
 			resolveLogger(c),
 			resolveImageConverter(c),
 			resolveVideoConverter(c),
+			resolveConversionQueueService(c),
+			resolveDiskGuardService(c),
+			resolveStorage(c),
 		)
 
 		if err != nil {
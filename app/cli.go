@@ -0,0 +1,146 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/db"
+)
+
+const usage = `Usage: converter [command]
+
+Commands:
+  serve                   Start the server, the default command
+  convert <file>          Convert a local file with the configured defaults, no database is needed
+    --to webp,avif        Target formats, the defaults of the media type if omitted
+    --preset name         Preset of the defaults the target formats are taken from
+  enqueue <path>...       Enqueue files of the files directory for conversion
+    --to webp,avif        Target formats, the defaults of the media type if omitted
+    --preset name         Preset of the defaults the target formats are taken from
+  delete <path>...        Enqueue converted files for deletion
+    --source              Remove the source file as well
+    --record              Remove the conversion, so the file can be enqueued again
+    --recursive           Treat the path as a directory
+  scan [dir]              Scan the files directory, or a directory inside it, and wait for the result
+    --include, --exclude  Comma separated glob patterns of the file names
+    --max-depth n         Depth of the scanned subdirectories, unlimited if 0
+    --dry-run             Count the files without enqueuing them
+  status <id>             Show a conversion
+  retry <path>...         Enqueue the conversions of the files again
+    --canceled            Enqueue every canceled conversion again instead
+  queue ls                List the conversions
+    --status pending      One of pending, done, canceled or all
+    --limit 100           Maximum number of listed conversions
+  migrate up              Apply the pending migrations
+  migrate down            Roll back the last applied migration
+  migrate status          Show the applied and pending migrations
+  help                    Show this help`
+
+var ErrUsage = errors.New("invalid usage")
+
+func (a *app) Exec(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		a.Run(ctx)
+		return nil
+	}
+
+	switch args[0] {
+	case "serve":
+		a.Run(ctx)
+		return nil
+	case "convert":
+		return a.execConvert(ctx, os.Stdout, args[1:])
+	case "enqueue":
+		return a.execEnqueue(ctx, os.Stdout, args[1:])
+	case "delete":
+		return a.execDelete(ctx, os.Stdout, args[1:])
+	case "scan":
+		return a.execScan(ctx, os.Stdout, args[1:])
+	case "status":
+		return a.execStatus(ctx, os.Stdout, args[1:])
+	case "retry":
+		return a.execRetry(ctx, os.Stdout, args[1:])
+	case "queue":
+		return a.execQueue(ctx, os.Stdout, args[1:])
+	case "migrate":
+		return a.execMigrate(ctx, os.Stdout, args[1:])
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+		return nil
+	}
+
+	return fmt.Errorf("%w: unknown command '%s'\n\n%s", ErrUsage, args[0], usage)
+}
+
+// Releases the resources of the resolved dependencies, e.g. database connections, once the command is done
+func (a *app) release() {
+	dq := resolveDeferredQ(a.container)
+	dq.Release()
+	dq.Wait()
+}
+
+func (a *app) execMigrate(ctx context.Context, w io.Writer, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: migrate expects one of up, down or status\n\n%s", ErrUsage, usage)
+	}
+
+	migrator := resolveMigrator(a.container)
+	defer a.release()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(w, "no pending migrations")
+		}
+		for _, migration := range applied {
+			fmt.Fprintf(w, "applied %s (%s)\n", migration.Name, migration.Duration.Round(time.Millisecond))
+		}
+		return nil
+	case "down":
+		migration, err := migrator.Down(ctx)
+		if errors.Is(err, db.ErrNoMigration) {
+			fmt.Fprintln(w, "no applied migrations")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "rolled back %s (%s)\n", migration.Name, migration.Duration.Round(time.Millisecond))
+		return nil
+	case "status":
+		migrations, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tSTATE\tAPPLIED AT\tNAME")
+		for _, migration := range migrations {
+			state, appliedAt := "pending", "-"
+			if migration.Applied {
+				state, appliedAt = "applied", migration.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", migration.Version, state, appliedAt, migration.Name)
+		}
+		if err = tw.Flush(); err != nil {
+			return err
+		}
+
+		// Same check as on startup, so the status tells whether the server starts
+		if err = migrator.Check(ctx); err != nil {
+			fmt.Fprintf(w, "\n%v\n", err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%w: unknown migrate command '%s'\n\n%s", ErrUsage, args[0], usage)
+}
@@ -0,0 +1,455 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/constants"
+	converterService "github.com/chistyakoviv/converter/internal/converter/converter"
+	"github.com/chistyakoviv/converter/internal/db"
+	"github.com/chistyakoviv/converter/internal/file"
+	"github.com/chistyakoviv/converter/internal/http-server/converter"
+	"github.com/chistyakoviv/converter/internal/http-server/request"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service/conversionq"
+	"github.com/chistyakoviv/converter/internal/service/diskguard"
+	"github.com/chistyakoviv/converter/internal/storage"
+	localStorage "github.com/chistyakoviv/converter/internal/storage/local"
+)
+
+// Number of conversions listed by `queue ls` if no limit is given
+const defaultListLimit = 100
+
+var conversionStatuses = map[string]int{
+	"pending":  model.ConversionStatusPending,
+	"done":     model.ConversionStatusDone,
+	"canceled": model.ConversionStatusCanceled,
+}
+
+// Comma separated values, the flag may be repeated
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*f = append(*f, item)
+		}
+	}
+	return nil
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	// Errors are reported along with the usage by the caller
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// Parses the flags placed anywhere among the arguments, returns the positional arguments
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v\n\n%s", ErrUsage, fs.Name(), err, usage)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func usageError(msg string) error {
+	return fmt.Errorf("%w: %s\n\n%s", ErrUsage, msg, usage)
+}
+
+func statusName(status int) string {
+	for name, value := range conversionStatuses {
+		if value == status {
+			return name
+		}
+	}
+	return strconv.Itoa(status)
+}
+
+// Converts a local file with the configured defaults, neither the database nor the files directory is used
+func (a *app) execConvert(ctx context.Context, w io.Writer, args []string) error {
+	var (
+		to     listFlag
+		preset string
+	)
+	fs := newFlagSet("convert")
+	fs.Var(&to, "to", "")
+	fs.StringVar(&preset, "preset", "", "")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError("convert expects one file")
+	}
+
+	src, err := filepath.Abs(positional[0])
+	if err != nil {
+		return err
+	}
+
+	cfg := resolveConfig(a.container)
+	logger := resolveLogger(a.container)
+	defer a.release()
+
+	// The converted files are written next to the source as if its directory were the files directory
+	dir := filepath.Dir(src)
+	store := localStorage.NewStorage(dir)
+	key := "/" + filepath.Base(src)
+
+	mediaType, err := storage.DetectMediaType(ctx, store, key)
+	if err != nil {
+		return err
+	}
+
+	info := model.ToConversionInfoFromFileInfo(file.ExtractInfo(key))
+	info.Preset = preset
+	for _, ext := range to {
+		info.ConvertTo = append(info.ConvertTo, model.ConvertTo{Ext: strings.ToLower(ext)})
+	}
+	if err = conversionq.Prepare(cfg, info, mediaType); err != nil {
+		return err
+	}
+
+	conv, err := converterService.NewService(
+		cfg,
+		logger,
+		resolveImageConverter(a.container),
+		resolveVideoConverter(a.container),
+		converterService.NoDuplicates(),
+		diskguard.NewService(cfg, logger, store, file.FreeSpace),
+		store,
+	)
+	if err != nil {
+		return err
+	}
+
+	conversion := &model.Conversion{
+		Fullpath:  info.Fullpath,
+		Path:      info.Path,
+		Filestem:  info.Filestem,
+		Ext:       info.Ext,
+		ConvertTo: info.ConvertTo,
+	}
+	if err = conv.Convert(ctx, conversion); err != nil {
+		return err
+	}
+
+	naming := cfg.Naming()
+	for _, entry := range conversion.ConvertTo {
+		dest, err := conversion.DestinationFullpath(entry, naming)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, filepath.Join(dir, dest))
+	}
+	for _, result := range conversion.QualityResults {
+		fmt.Fprintf(w, "%s: %s %.4f at quality %d after %d attempts\n", result.Key, result.Metric, result.Score, result.Quality, result.Attempts)
+	}
+	return nil
+}
+
+// Enqueues the files for conversion, the running server picks them up on the next check of the queue
+func (a *app) execEnqueue(ctx context.Context, w io.Writer, args []string) error {
+	var (
+		to     listFlag
+		preset string
+	)
+	fs := newFlagSet("enqueue")
+	fs.Var(&to, "to", "")
+	fs.StringVar(&preset, "preset", "", "")
+	paths, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return usageError("enqueue expects at least one path")
+	}
+
+	var convertTo []model.ConvertTo
+	for _, ext := range to {
+		convertTo = append(convertTo, model.ConvertTo{Ext: strings.ToLower(ext)})
+	}
+
+	conversionQueueService := resolveConversionQueueService(a.container)
+	defer a.release()
+
+	var failed int
+	for _, path := range paths {
+		info, err := converter.ToConversionInfoFromRequest(request.ConversionRequest{
+			Path:      path,
+			ConvertTo: convertTo,
+			Preset:    preset,
+		})
+		if err == nil {
+			var id int64
+			if id, err = conversionQueueService.Add(ctx, info); err == nil {
+				fmt.Fprintf(w, "%d\t%s\n", id, info.Fullpath)
+				continue
+			}
+		}
+		failed++
+		fmt.Fprintf(w, "-\t%s: %v\n", path, err)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files are not enqueued", failed, len(paths))
+	}
+	return nil
+}
+
+// Enqueues the files for deletion, the running server picks them up on the next check of the queue
+func (a *app) execDelete(ctx context.Context, w io.Writer, args []string) error {
+	var deleteSource, deleteRecord, recursive bool
+	fs := newFlagSet("delete")
+	fs.BoolVar(&deleteSource, "source", false, "")
+	fs.BoolVar(&deleteRecord, "record", false, "")
+	fs.BoolVar(&recursive, "recursive", false, "")
+	paths, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return usageError("delete expects at least one path")
+	}
+
+	deletionQueueService := resolveDeletionQueueService(a.container)
+	defer a.release()
+
+	var failed int
+	for _, path := range paths {
+		info, err := converter.ToDeletionInfoFromRequest(request.DeletionRequest{
+			Path:         path,
+			DeleteSource: deleteSource,
+			DeleteRecord: deleteRecord,
+			Recursive:    recursive,
+		})
+		if err == nil {
+			if info.Recursive {
+				var count int64
+				if count, err = deletionQueueService.AddRecursive(ctx, info); err == nil {
+					fmt.Fprintf(w, "%d files\t%s\n", count, info.Fullpath)
+					continue
+				}
+			} else {
+				var id int64
+				if id, err = deletionQueueService.Add(ctx, info); err == nil {
+					fmt.Fprintf(w, "%d\t%s\n", id, info.Fullpath)
+					continue
+				}
+			}
+		}
+		failed++
+		fmt.Fprintf(w, "-\t%s: %v\n", path, err)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files are not enqueued for deletion", failed, len(paths))
+	}
+	return nil
+}
+
+// Scans the files directory and waits for the scan to complete, the outcome is printed as JSON
+func (a *app) execScan(ctx context.Context, w io.Writer, args []string) error {
+	var include, exclude listFlag
+	var opts model.ScanOptions
+	fs := newFlagSet("scan")
+	fs.Var(&include, "include", "")
+	fs.Var(&exclude, "exclude", "")
+	fs.IntVar(&opts.MaxDepth, "max-depth", 0, "")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		return usageError("scan expects at most one directory")
+	}
+	if len(positional) == 1 {
+		opts.Path = positional[0]
+	}
+	opts.Include = include
+	opts.Exclude = exclude
+
+	taskService := resolveTaskService(a.container)
+	defer a.release()
+
+	scan, err := taskService.ProcessScanfs(ctx, constants.FilesRootDir, opts)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(scan)
+}
+
+// Prints the conversion with the given id
+func (a *app) execStatus(ctx context.Context, w io.Writer, args []string) error {
+	if len(args) != 1 {
+		return usageError("status expects the id of a conversion")
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return usageError(fmt.Sprintf("invalid conversion id '%s'", args[0]))
+	}
+
+	conversionQueueService := resolveConversionQueueService(a.container)
+	defer a.release()
+
+	conversion, err := conversionQueueService.GetById(ctx, id)
+	if errors.Is(err, db.ErrNotFound) {
+		return fmt.Errorf("conversion %d not found", id)
+	}
+	if err != nil {
+		return err
+	}
+
+	targets := make([]string, 0, len(conversion.ConvertTo))
+	for _, entry := range conversion.ConvertTo {
+		targets = append(targets, entry.Key())
+	}
+	updatedAt := "-"
+	if conversion.UpdatedAt.Valid {
+		updatedAt = conversion.UpdatedAt.Time.Format(time.RFC3339)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "id:\t%d\n", conversion.Id)
+	fmt.Fprintf(tw, "path:\t%s\n", conversion.Fullpath)
+	fmt.Fprintf(tw, "status:\t%s\n", statusName(conversion.Status))
+	if conversion.IsCanceled() {
+		fmt.Fprintf(tw, "error code:\t%d\n", conversion.ErrorCode)
+	}
+	fmt.Fprintf(tw, "convert to:\t%s\n", strings.Join(targets, ", "))
+	fmt.Fprintf(tw, "created at:\t%s\n", conversion.CreatedAt.Format(time.RFC3339))
+	fmt.Fprintf(tw, "updated at:\t%s\n", updatedAt)
+	if conversion.SourceHash != "" {
+		fmt.Fprintf(tw, "source hash:\t%s\n", conversion.SourceHash)
+	}
+	if conversion.DedupOrigin != "" {
+		fmt.Fprintf(tw, "copied from:\t%s\n", conversion.DedupOrigin)
+	}
+	for _, result := range conversion.QualityResults {
+		fmt.Fprintf(tw, "quality of %s:\t%s %.4f at quality %d after %d attempts\n", result.Key, result.Metric, result.Score, result.Quality, result.Attempts)
+	}
+	return tw.Flush()
+}
+
+// Enqueues the given conversions again, or every canceled conversion
+func (a *app) execRetry(ctx context.Context, w io.Writer, args []string) error {
+	var canceled bool
+	fs := newFlagSet("retry")
+	fs.BoolVar(&canceled, "canceled", false, "")
+	paths, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if canceled == (len(paths) > 0) {
+		return usageError("retry expects either paths or --canceled")
+	}
+
+	conversionQueueService := resolveConversionQueueService(a.container)
+	defer a.release()
+
+	if canceled {
+		count, err := conversionQueueService.RequeueCanceled(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%d conversions requeued\n", count)
+		return nil
+	}
+
+	var failed int
+	for _, path := range paths {
+		fullpath, err := file.Canonicalize(path, constants.FilesRootDir)
+		if err == nil {
+			// Requeuing a path that is not in the queue affects nothing, so it is reported
+			_, err = conversionQueueService.Get(ctx, fullpath)
+		}
+		if err == nil {
+			if err = conversionQueueService.Requeue(ctx, fullpath); err == nil {
+				fmt.Fprintf(w, "requeued\t%s\n", fullpath)
+				continue
+			}
+		}
+		failed++
+		fmt.Fprintf(w, "-\t%s: %v\n", path, err)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d conversions are not requeued", failed, len(paths))
+	}
+	return nil
+}
+
+func (a *app) execQueue(ctx context.Context, w io.Writer, args []string) error {
+	if len(args) == 0 || args[0] != "ls" {
+		return usageError("queue expects ls")
+	}
+
+	var status string
+	var limit uint64
+	fs := newFlagSet("queue ls")
+	fs.StringVar(&status, "status", "pending", "")
+	fs.Uint64Var(&limit, "limit", defaultListLimit, "")
+	positional, err := parseFlags(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageError("queue ls expects no arguments")
+	}
+
+	var statuses []int
+	if status == "all" {
+		for _, value := range conversionStatuses {
+			statuses = append(statuses, value)
+		}
+	} else if value, ok := conversionStatuses[status]; ok {
+		statuses = append(statuses, value)
+	} else {
+		return usageError(fmt.Sprintf("unknown status '%s', expected pending, done, canceled or all", status))
+	}
+
+	conversionQueueService := resolveConversionQueueService(a.container)
+	defer a.release()
+
+	conversions, err := conversionQueueService.List(ctx, statuses, limit)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATUS\tERROR\tCREATED AT\tPATH")
+	for _, conversion := range conversions {
+		code := "-"
+		if conversion.IsCanceled() {
+			code = strconv.Itoa(conversion.ErrorCode)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", conversion.Id, statusName(conversion.Status), code, conversion.CreatedAt.Format(time.RFC3339), conversion.Fullpath)
+	}
+	return tw.Flush()
+}
@@ -15,8 +15,10 @@ import (
 	"github.com/chistyakoviv/converter/internal/lib/stack_parser"
 	"github.com/chistyakoviv/converter/internal/repository"
 	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/chistyakoviv/converter/internal/storage"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Retrieves the application configuration from the dependency injection container,
@@ -62,6 +64,16 @@ func resolvePanicWriter(c di.Container) io.Writer {
 	return panicWriter
 }
 
+func resolveTracerProvider(c di.Container) trace.TracerProvider {
+	provider, err := di.Resolve[trace.TracerProvider](c, "tracerProvider")
+
+	if err != nil {
+		log.Fatalf("Couldn't resolve tracer provider definition: %v", err)
+	}
+
+	return provider
+}
+
 func resolveDbClient(c di.Container) db.Client {
 	client, err := di.Resolve[db.Client](c, "db")
 
@@ -154,6 +166,16 @@ func resolveVideoConverter(c di.Container) converter.VideoConverter {
 }
 
 // Repositories
+func resolveStorage(c di.Container) storage.Storage {
+	s, err := di.Resolve[storage.Storage](c, "storage")
+
+	if err != nil {
+		log.Fatalf("Couldn't resolve storage definition: %v", err)
+	}
+
+	return s
+}
+
 func resolveConversionQueueRepository(c di.Container) repository.ConversionQueueRepository {
 	repo, err := di.Resolve[repository.ConversionQueueRepository](c, "conversionQueueRepository")
 
@@ -185,6 +207,26 @@ func resolveConversionQueueService(c di.Container) service.ConversionQueueServic
 	return serv
 }
 
+func resolveApiKeyRepository(c di.Container) repository.ApiKeyRepository {
+	repo, err := di.Resolve[repository.ApiKeyRepository](c, "apiKeyRepository")
+
+	if err != nil {
+		log.Fatalf("Couldn't resolve api key repository definition: %v", err)
+	}
+
+	return repo
+}
+
+func resolveApiKeyService(c di.Container) service.ApiKeyService {
+	serv, err := di.Resolve[service.ApiKeyService](c, "apiKeyService")
+
+	if err != nil {
+		log.Fatalf("Couldn't resolve api key service definition: %v", err)
+	}
+
+	return serv
+}
+
 func resolveDeletionQueueService(c di.Container) service.DeletionQueueService {
 	serv, err := di.Resolve[service.DeletionQueueService](c, "deletionQueueService")
 
@@ -205,6 +247,66 @@ func resolveTaskService(c di.Container) service.TaskService {
 	return serv
 }
 
+func resolveWatcherService(c di.Container) service.WatcherService {
+	serv, err := di.Resolve[service.WatcherService](c, "watcherService")
+
+	if err != nil {
+		log.Fatalf("Couldn't resolve watcher service definition: %v", err)
+	}
+
+	return serv
+}
+
+func resolveReloaderService(c di.Container) service.ReloaderService {
+	serv, err := di.Resolve[service.ReloaderService](c, "reloaderService")
+
+	if err != nil {
+		log.Fatalf("Couldn't resolve reloader service definition: %v", err)
+	}
+
+	return serv
+}
+
+func resolveGCService(c di.Container) service.GCService {
+	serv, err := di.Resolve[service.GCService](c, "gcService")
+
+	if err != nil {
+		log.Fatalf("Couldn't resolve gc service definition: %v", err)
+	}
+
+	return serv
+}
+
+func resolveDiskGuardService(c di.Container) service.DiskGuardService {
+	serv, err := di.Resolve[service.DiskGuardService](c, "diskGuardService")
+
+	if err != nil {
+		log.Fatalf("Couldn't resolve disk guard service definition: %v", err)
+	}
+
+	return serv
+}
+
+func resolveMigrator(c di.Container) db.Migrator {
+	m, err := di.Resolve[db.Migrator](c, "migrator")
+
+	if err != nil {
+		log.Fatalf("Couldn't resolve migrator definition: %v", err)
+	}
+
+	return m
+}
+
+func resolveHealthService(c di.Container) service.HealthService {
+	serv, err := di.Resolve[service.HealthService](c, "healthService")
+
+	if err != nil {
+		log.Fatalf("Couldn't resolve health service definition: %v", err)
+	}
+
+	return serv
+}
+
 func resolveConverterService(c di.Container) converter.Converter {
 	serv, err := di.Resolve[converter.Converter](c, "converterService")
 
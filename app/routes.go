@@ -3,26 +3,99 @@ package app
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/chistyakoviv/converter/internal/di"
 	"github.com/chistyakoviv/converter/internal/http-server/handlers/convert"
+	convertBatch "github.com/chistyakoviv/converter/internal/http-server/handlers/convert/batch"
 	"github.com/chistyakoviv/converter/internal/http-server/handlers/delete"
+	deleteBatch "github.com/chistyakoviv/converter/internal/http-server/handlers/delete/batch"
+	"github.com/chistyakoviv/converter/internal/http-server/handlers/gc"
+	"github.com/chistyakoviv/converter/internal/http-server/handlers/healthcheck"
+	keysCreate "github.com/chistyakoviv/converter/internal/http-server/handlers/keys/create"
+	keysList "github.com/chistyakoviv/converter/internal/http-server/handlers/keys/list"
+	keysRevoke "github.com/chistyakoviv/converter/internal/http-server/handlers/keys/revoke"
+	"github.com/chistyakoviv/converter/internal/http-server/handlers/livez"
+	"github.com/chistyakoviv/converter/internal/http-server/handlers/presets"
+	"github.com/chistyakoviv/converter/internal/http-server/handlers/readyz"
 	"github.com/chistyakoviv/converter/internal/http-server/handlers/scan"
-	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	scanCancel "github.com/chistyakoviv/converter/internal/http-server/handlers/scans/cancel"
+	scanGet "github.com/chistyakoviv/converter/internal/http-server/handlers/scans/get"
+	httpMiddleware "github.com/chistyakoviv/converter/internal/http-server/middleware"
+	"github.com/chistyakoviv/converter/internal/lib/ratelimit"
+	"github.com/chistyakoviv/converter/internal/metrics"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// Scrapes are answered without the queue depth if the queues cannot be counted in time
+const queueCountTimeout = 5 * time.Second
+
 func initRoutes(ctx context.Context, c di.Container) {
 	router := resolveRouter(c)
 
-	router.Get("/healthcheck", func(w http.ResponseWriter, r *http.Request) {
-		logger := resolveLogger(c)
-		if _, err := w.Write([]byte("alive")); err != nil {
-			// optional: log or handle the error
-			logger.Error("failed to write response: %v", slogger.Err(err))
+	// Every endpoint except the healthcheck and the probes requires an API key with the scope if the authentication is enabled
+	requireScope := func(scope string) func(next http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+	if resolveConfig(c).Auth.Enabled {
+		requireScope = httpMiddleware.NewAuth(resolveLogger(c), resolveApiKeyService(c))
+	}
+
+	// The convert, delete and scan endpoints have separate buckets, the single and batch endpoints share theirs
+	rateLimit := func() func(next http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+	if cfg := resolveConfig(c).RateLimit; cfg.Enabled {
+		rateLimit = func() func(next http.Handler) http.Handler {
+			return httpMiddleware.NewRateLimit(resolveLogger(c), ratelimit.New(cfg.Rate, cfg.Burst))
 		}
-	})
+	}
+	convertLimit, deleteLimit, scanLimit := rateLimit(), rateLimit(), rateLimit()
+
+	// Metrics are scraped without an API key, like the healthcheck
+	if resolveConfig(c).Metrics.Enabled {
+		prometheus.MustRegister(metrics.NewQueueCollector(
+			resolveLogger(c),
+			queueCountTimeout,
+			map[string]metrics.QueueCounter{
+				"conversion": resolveConversionQueueService(c).CountPending,
+				"deletion":   resolveDeletionQueueService(c).CountPending,
+			},
+		))
+		router.Handle("/metrics", promhttp.Handler())
+	}
+
+	router.Get("/healthcheck", healthcheck.New(
+		resolveLogger(c),
+		resolveDiskGuardService(c),
+	))
+
+	// Probes don't require an API key, like the healthcheck
+	router.Get("/livez", livez.New(
+		resolveLogger(c),
+		resolveHealthService(c),
+	))
+
+	router.Get("/readyz", readyz.New(
+		resolveLogger(c),
+		resolveHealthService(c),
+	))
+
+	router.With(requireScope(model.ApiKeyScopeConvert), convertLimit).Post("/convert", convert.New(
+		ctx,
+		resolveLogger(c),
+		resolveValidator(c),
+		resolveConversionQueueService(c),
+		resolveTaskService(c),
+	))
 
-	router.Post("/convert", convert.New(
+	router.With(requireScope(model.ApiKeyScopeConvert), convertLimit).Post("/convert/batch", convertBatch.New(
 		ctx,
 		resolveLogger(c),
 		resolveValidator(c),
@@ -30,7 +103,20 @@ func initRoutes(ctx context.Context, c di.Container) {
 		resolveTaskService(c),
 	))
 
-	router.Delete("/delete", delete.New(
+	router.With(requireScope(model.ApiKeyScopeConvert)).Get("/presets", presets.New(
+		resolveLogger(c),
+		resolveConversionQueueService(c),
+	))
+
+	router.With(requireScope(model.ApiKeyScopeDelete), deleteLimit).Delete("/delete", delete.New(
+		ctx,
+		resolveLogger(c),
+		resolveValidator(c),
+		resolveDeletionQueueService(c),
+		resolveTaskService(c),
+	))
+
+	router.With(requireScope(model.ApiKeyScopeDelete), deleteLimit).Post("/delete/batch", deleteBatch.New(
 		ctx,
 		resolveLogger(c),
 		resolveValidator(c),
@@ -38,9 +124,47 @@ func initRoutes(ctx context.Context, c di.Container) {
 		resolveTaskService(c),
 	))
 
-	router.Post("/scan", scan.New(
+	router.With(requireScope(model.ApiKeyScopeScan), scanLimit).Post("/scan", scan.New(
+		ctx,
+		resolveLogger(c),
+		resolveValidator(c),
+		resolveTaskService(c),
+	))
+
+	router.With(requireScope(model.ApiKeyScopeScan)).Get("/scans/{id}", scanGet.New(
 		ctx,
 		resolveLogger(c),
 		resolveTaskService(c),
 	))
+
+	router.With(requireScope(model.ApiKeyScopeScan)).Post("/scans/{id}/cancel", scanCancel.New(
+		ctx,
+		resolveLogger(c),
+		resolveTaskService(c),
+	))
+
+	router.With(requireScope(model.ApiKeyScopeAdmin)).Post("/gc", gc.New(
+		ctx,
+		resolveLogger(c),
+		resolveGCService(c),
+	))
+
+	router.With(requireScope(model.ApiKeyScopeAdmin)).Post("/keys", keysCreate.New(
+		ctx,
+		resolveLogger(c),
+		resolveValidator(c),
+		resolveApiKeyService(c),
+	))
+
+	router.With(requireScope(model.ApiKeyScopeAdmin)).Get("/keys", keysList.New(
+		ctx,
+		resolveLogger(c),
+		resolveApiKeyService(c),
+	))
+
+	router.With(requireScope(model.ApiKeyScopeAdmin)).Delete("/keys/{id}", keysRevoke.New(
+		ctx,
+		resolveLogger(c),
+		resolveApiKeyService(c),
+	))
 }
@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"os"
 
 	"github.com/chistyakoviv/converter/app"
 )
@@ -10,5 +12,8 @@ func main() {
 	ctx := context.Background()
 	// TODO: remove ctx from app and db client constructors and return error from http handler if context is canceled
 	a := app.NewApp(ctx)
-	a.Run(ctx)
+	if err := a.Exec(ctx, os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
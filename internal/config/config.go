@@ -3,24 +3,45 @@ package config
 import (
 	"log"
 	"os"
+	"sync/atomic"
 	"time"
 
-	"github.com/chistyakoviv/converter/internal/model"
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	Env        string     `yaml:"env" env:"ENV" env-required:"true"`
-	HTTPServer HTTPServer `yaml:"http_server"`
-	Postgres   Postgres   `yaml:"database"`
-	Task       Task       `yaml:"task"`
-	Image      Image      `yaml:"image"`
-	Video      Video      `yaml:"video"`
-	Defaults   *Defaults  `env:"-"`
+	Env        string             `yaml:"env" env:"ENV" env-required:"true"`
+	HTTPServer HTTPServer         `yaml:"http_server"`
+	Auth       Auth               `yaml:"auth"`
+	RateLimit  RateLimit          `yaml:"rate_limit"`
+	Quota      Quota              `yaml:"quota"`
+	Metrics    Metrics            `yaml:"metrics"`
+	Tracing    Tracing            `yaml:"tracing"`
+	Health     Health             `yaml:"health"`
+	Postgres   Postgres           `yaml:"database"`
+	Task       Task               `yaml:"task"`
+	Watcher    Watcher            `yaml:"watcher"`
+	Scan       Scan               `yaml:"scan"`
+	GC         GC                 `yaml:"gc"`
+	Disk       Disk               `yaml:"disk"`
+	Storage    Storage            `yaml:"storage"`
+	Output     Output             `yaml:"output"`
+	Image      Image              `yaml:"image"`
+	Video      Video              `yaml:"video"`
+	Overlays   map[string]Overlay `yaml:"overlays" env:"-"`
+	Reload     Reload             `yaml:"reload"`
+	// Defaults loaded on startup, see CurrentDefaults for the defaults in effect
+	Defaults *Defaults `env:"-"`
+	// File the defaults are loaded from, empty if there is none
+	DefaultsPath string `yaml:"-" env:"-"`
+	// Defaults swapped in by a reload
+	reloaded atomic.Pointer[Defaults]
 }
 
 type Postgres struct {
 	Dsn string `yaml:"dsn" env:"POSTGRES_DSN" env-required:"true"`
+	// Apply the pending migrations embedded in the binary before starting the server
+	MigrateOnStart bool `yaml:"migrate_on_start" env:"POSTGRES_MIGRATE_ON_START" env-default:"false"`
 }
 
 type HTTPServer struct {
@@ -30,45 +51,122 @@ type HTTPServer struct {
 	IdleTimeout  time.Duration `yaml:"idle_timeout" env:"IDLE_TIMEOUT" env-required:"true"`
 }
 
+type Auth struct {
+	// Require an API key for every endpoint except the healthcheck
+	Enabled bool `yaml:"enabled" env:"AUTH_ENABLED" env-default:"false"`
+	// Key with the admin scope that is not stored in the database, allows to create the first keys
+	AdminKey string `yaml:"admin_key" env:"AUTH_ADMIN_KEY"`
+}
+
+type Metrics struct {
+	// Expose the metrics in the Prometheus format at /metrics, enabled by default, see setDefaults
+	Enabled bool `yaml:"enabled" env:"METRICS_ENABLED"`
+}
+
+type Reload struct {
+	// Reload the defaults when their file changes, they are always reloaded on SIGHUP.
+	// Enabled by default, see setDefaults
+	Watch bool `yaml:"watch" env:"RELOAD_WATCH"`
+	// Quiet period after the last change of the file before it is reloaded
+	Debounce time.Duration `yaml:"debounce" env:"RELOAD_DEBOUNCE" env-default:"1s"`
+}
+
 type Task struct {
 	CheckTimeout time.Duration `yaml:"check_timeout" env:"TASK_CHECK_TIMEOUT" env-default:"5m"`
 }
 
-type Image struct {
-	Threads int `yaml:"threads" env:"IMAGE_THREADS" env-default:"1"`
+type Watcher struct {
+	Enabled bool `yaml:"enabled" env:"WATCHER_ENABLED" env-default:"false"`
+	// Quiet period after the last event, a file is enqueued once it has not changed for two periods
+	Debounce time.Duration `yaml:"debounce" env:"WATCHER_DEBOUNCE" env-default:"2s"`
+	// Interval of incremental scans used when the filesystem cannot be watched
+	ScanInterval time.Duration `yaml:"scan_interval" env:"WATCHER_SCAN_INTERVAL" env-default:"1m"`
 }
 
-type Video struct {
-	Threads int `yaml:"threads" env:"VIDEO_THREADS" env-default:"1"`
+type Scan struct {
+	// Number of goroutines sniffing file types, zero means the number of CPUs
+	Workers int `yaml:"workers" env:"SCAN_WORKERS" env-default:"0"`
+	// Number of files enqueued by a single statement
+	BatchSize int `yaml:"batch_size" env:"SCAN_BATCH_SIZE" env-default:"500"`
+	// Handling of symlinked files, symlinked directories are never descended into
+	Symlinks string `yaml:"symlinks" env:"SCAN_SYMLINKS" env-default:"confine"`
+}
+
+// Symlink policies of scans
+const (
+	// Symlinks are skipped
+	SymlinksSkip = "skip"
+	// Symlinks are scanned if they resolve inside the scanned root
+	SymlinksConfine = "confine"
+	// Symlinks are scanned wherever they lead
+	SymlinksFollow = "follow"
+)
+
+type GC struct {
+	// Run the collector periodically, it can always be run on demand
+	Enabled  bool          `yaml:"enabled" env:"GC_ENABLED" env-default:"false"`
+	Interval time.Duration `yaml:"interval" env:"GC_INTERVAL" env-default:"24h"`
+	// Only report the garbage found by the periodic runs
+	DryRun bool `yaml:"dry_run" env:"GC_DRY_RUN" env-default:"false"`
+	// Temporary files younger than the retention may belong to a running conversion
+	TmpRetention time.Duration `yaml:"tmp_retention" env:"GC_TMP_RETENTION" env-default:"1h"`
+	// Processed conversions are kept forever if zero, removing them allows the files to be enqueued again
+	ConversionRetention time.Duration `yaml:"conversion_retention" env:"GC_CONVERSION_RETENTION" env-default:"0"`
+	// Processed deletions are kept forever if zero, they are kept for 30 days by default, see setDefaults
+	DeletionRetention time.Duration `yaml:"deletion_retention" env:"GC_DELETION_RETENTION"`
 }
 
-type Defaults struct {
-	Image ImageDefaults `yaml:"image"`
-	Video VideoDefaults `yaml:"video"`
+type Image struct {
+	Threads  int           `yaml:"threads" env:"IMAGE_THREADS" env-default:"1"`
+	Metadata ImageMetadata `yaml:"metadata"`
 }
 
-type ImageDefaults struct {
-	Formats []model.ConvertTo `yaml:"formats"`
+// Global metadata handling, can be overridden per format with the metadata key in conv_conf
+type ImageMetadata struct {
+	Policy string `yaml:"policy" env:"IMAGE_METADATA_POLICY" env-default:"strip"`
+	// Enabled by default, see setDefaults
+	AutoRotate    bool `yaml:"auto_rotate" env:"IMAGE_AUTO_ROTATE"`
+	ConvertToSRGB bool `yaml:"convert_to_srgb" env:"IMAGE_CONVERT_TO_SRGB"`
 }
 
-type VideoDefaults struct {
-	Formats []model.ConvertTo `yaml:"formats"`
+type Video struct {
+	Threads int `yaml:"threads" env:"VIDEO_THREADS" env-default:"1"`
 }
 
+// Paths of a batch are passed as statement parameters, which are limited by Postgres
+const MaxScanBatchSize = 10000
+
 type ConfigOptions struct {
 	ConfigPath   string
 	DefaultsPath string
 }
 
+// Sets the defaults an explicit false or zero must be able to override.
+// cleanenv replaces every zero value with env-default, including the ones set in the file,
+// so such options have no env-default and are set before the config is read instead.
+func (c *Config) setDefaults() {
+	c.Image.Metadata.AutoRotate = true
+	c.Image.Metadata.ConvertToSRGB = true
+	c.Disk.Enabled = true
+	c.Disk.Reserve = defaultDiskReserve
+	c.Disk.OutputRatio = 1
+	c.GC.DeletionRetention = 720 * time.Hour
+	c.Tracing.SampleRatio = 1
+	c.Reload.Watch = true
+	c.Metrics.Enabled = true
+	c.Storage.S3.UseSSL = true
+}
+
 // Functions that start with the Must prefix require that the config is loaded, otherwise panic will be thrown.
 func MustLoad(opts *ConfigOptions) *Config {
 	var (
 		cfg          Config
-		dfs          Defaults
 		configPath   string
 		defaultsPath string
 	)
 
+	cfg.setDefaults()
+
 	if opts != nil {
 		configPath = opts.ConfigPath
 		defaultsPath = opts.DefaultsPath
@@ -100,25 +198,82 @@ func MustLoad(opts *ConfigOptions) *Config {
 		log.Fatalf("failed to load config from env: %v", err)
 	}
 
+	if !IsMetadataPolicy(cfg.Image.Metadata.Policy) {
+		log.Fatalf("unknown image metadata policy '%s'", cfg.Image.Metadata.Policy)
+	}
+
+	if err := cfg.RateLimit.Validate(); err != nil {
+		log.Fatalf("invalid rate limit: %v", err)
+	}
+	if err := cfg.Quota.Validate(); err != nil {
+		log.Fatalf("invalid quota: %v", err)
+	}
+
+	if err := cfg.Tracing.Validate(); err != nil {
+		log.Fatalf("invalid tracing: %v", err)
+	}
+	if err := cfg.Health.Validate(); err != nil {
+		log.Fatalf("invalid health checks: %v", err)
+	}
+
+	if cfg.Scan.Workers < 0 {
+		log.Fatalf("scan workers must not be negative")
+	}
+	if cfg.Scan.BatchSize < 1 || cfg.Scan.BatchSize > MaxScanBatchSize {
+		log.Fatalf("scan batch size must be in the range [1, %d]", MaxScanBatchSize)
+	}
+	if cfg.Scan.Symlinks != SymlinksSkip && cfg.Scan.Symlinks != SymlinksConfine && cfg.Scan.Symlinks != SymlinksFollow {
+		log.Fatalf("unknown scan symlink policy '%s'", cfg.Scan.Symlinks)
+	}
+
+	if cfg.GC.Enabled && cfg.GC.Interval <= 0 {
+		log.Fatalf("gc interval must be positive")
+	}
+	if cfg.GC.TmpRetention <= 0 {
+		log.Fatalf("gc tmp retention must be positive")
+	}
+	if cfg.GC.ConversionRetention < 0 || cfg.GC.DeletionRetention < 0 {
+		log.Fatalf("gc retention must not be negative")
+	}
+
+	if err := cfg.Disk.Validate(); err != nil {
+		log.Fatalf("invalid disk guard: %v", err)
+	}
+
+	if err := cfg.Storage.Validate(); err != nil {
+		log.Fatalf("invalid storage: %v", err)
+	}
+	// Only the local files can be watched
+	if cfg.Watcher.Enabled && cfg.Storage.Driver != StorageLocal {
+		log.Fatalf("watcher requires the local storage")
+	}
+
+	if err := cfg.Output.Validate(); err != nil {
+		log.Fatalf("invalid output: %v", err)
+	}
+
+	for name, overlay := range cfg.Overlays {
+		if err := overlay.Validate(); err != nil {
+			log.Fatalf("invalid overlay '%s': %v", name, err)
+		}
+	}
+
+	if cfg.Reload.Debounce <= 0 {
+		log.Fatalf("reload debounce must be positive")
+	}
+
 	if defaultsPath == "" {
 		defaultsPath = os.Getenv("DEFAULTS_PATH")
 	}
 
 	// Defaults are empty by default and are left unchanged if no path is provided.
-	if defaultsPath != "" {
-		// #nosec G703 -- defaultsPath comes from CLI flag or env variable
-		if _, err := os.Stat(defaultsPath); os.IsNotExist(err) {
-			// #nosec G706 -- defaultsPath comes from CLI flag or env variable
-			log.Fatalf("file with defaults %s does not exist", defaultsPath)
-		}
-
-		if err := cleanenv.ReadConfig(defaultsPath, &dfs); err != nil {
-			// #nosec G706 -- defaultsPath comes from CLI flag or env variable
-			log.Fatalf("failed to load file with defaults from %s: %v", defaultsPath, err)
-		}
+	dfs, err := LoadDefaults(defaultsPath)
+	if err != nil {
+		log.Fatalf("%v", err)
 	}
 
-	cfg.Defaults = &dfs
+	cfg.Defaults = dfs
+	cfg.DefaultsPath = defaultsPath
 
 	return &cfg
 }
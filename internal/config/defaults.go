@@ -0,0 +1,192 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/ilyakaznacheev/cleanenv"
+)
+
+type Defaults struct {
+	Image ImageDefaults `yaml:"image"`
+	Video VideoDefaults `yaml:"video"`
+	// Named sets of target formats keyed by name, the formats of a preset are resolved with the ones it extends on load
+	Presets map[string]*Preset `yaml:"presets"`
+}
+
+type ImageDefaults struct {
+	Formats []model.ConvertTo `yaml:"formats"`
+}
+
+type VideoDefaults struct {
+	Formats []model.ConvertTo `yaml:"formats"`
+}
+
+// Kinds of the changes of the default formats
+const (
+	DefaultsAdded   = "added"
+	DefaultsRemoved = "removed"
+	DefaultsChanged = "changed"
+)
+
+// A default format that differs between two versions of the defaults
+type DefaultsChange struct {
+	// Name of the preset, empty for the default formats
+	Preset string
+	// image or video
+	Media string
+	// Key of the format, see model.ConvertTo.Key
+	Key  string
+	Kind string
+	// Nil if the format is added
+	Before *model.ConvertTo
+	// Nil if the format is removed
+	After *model.ConvertTo
+}
+
+// LoadDefaults reads and validates the defaults, empty defaults are returned if the path is empty
+func LoadDefaults(path string) (*Defaults, error) {
+	var dfs Defaults
+	if path == "" {
+		return &dfs, nil
+	}
+
+	// #nosec G703 -- path comes from CLI flag or env variable
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("file with defaults %s does not exist", path)
+	}
+
+	if err := cleanenv.ReadConfig(path, &dfs); err != nil {
+		return nil, fmt.Errorf("failed to load file with defaults from %s: %w", path, err)
+	}
+
+	if err := dfs.resolvePresets(); err != nil {
+		return nil, err
+	}
+
+	if err := dfs.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &dfs, nil
+}
+
+func (d *Defaults) Validate() error {
+	if err := validateFormats("default format", d.Image.Formats, d.Video.Formats); err != nil {
+		return err
+	}
+	for _, name := range d.PresetNames() {
+		preset := d.Presets[name]
+		if err := validateFormats(fmt.Sprintf("format of preset '%s'", name), preset.Image.Formats, preset.Video.Formats); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateFormats(kind string, formats ...[]model.ConvertTo) error {
+	for _, entries := range formats {
+		for _, entry := range entries {
+			if template, ok := entry.Optional["template"].(string); ok {
+				if err := model.ValidateOutputTemplate(template); err != nil {
+					return fmt.Errorf("invalid %s '%s': %w", kind, entry.Key(), err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Diff returns the formats added, removed or changed by the next defaults
+func (d *Defaults) Diff(next *Defaults) []DefaultsChange {
+	if d == nil {
+		d = &Defaults{}
+	}
+	if next == nil {
+		next = &Defaults{}
+	}
+
+	var changes []DefaultsChange
+	changes = diffFormats(changes, "", "image", d.Image.Formats, next.Image.Formats)
+	changes = diffFormats(changes, "", "video", d.Video.Formats, next.Video.Formats)
+
+	// A removed preset is reported as the removal of its formats
+	names := next.PresetNames()
+	for _, name := range d.PresetNames() {
+		if _, ok := next.Presets[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	empty := &Preset{}
+	for _, name := range names {
+		previous, ok := d.Presets[name]
+		if !ok {
+			previous = empty
+		}
+		preset, ok := next.Presets[name]
+		if !ok {
+			preset = empty
+		}
+		changes = diffFormats(changes, name, "image", previous.Image.Formats, preset.Image.Formats)
+		changes = diffFormats(changes, name, "video", previous.Video.Formats, preset.Video.Formats)
+	}
+	return changes
+}
+
+func diffFormats(changes []DefaultsChange, preset string, media string, previous []model.ConvertTo, next []model.ConvertTo) []DefaultsChange {
+	before := make(map[string]*model.ConvertTo, len(previous))
+	for i := range previous {
+		before[previous[i].Key()] = &previous[i]
+	}
+	after := make(map[string]bool, len(next))
+
+	for i := range next {
+		entry := &next[i]
+		key := entry.Key()
+		after[key] = true
+
+		prev, ok := before[key]
+		if !ok {
+			changes = append(changes, DefaultsChange{Preset: preset, Media: media, Key: key, Kind: DefaultsAdded, After: entry})
+			continue
+		}
+		if !sameFormat(prev, entry) {
+			changes = append(changes, DefaultsChange{Preset: preset, Media: media, Key: key, Kind: DefaultsChanged, Before: prev, After: entry})
+		}
+	}
+
+	for i := range previous {
+		if key := previous[i].Key(); !after[key] {
+			changes = append(changes, DefaultsChange{Preset: preset, Media: media, Key: key, Kind: DefaultsRemoved, Before: &previous[i]})
+		}
+	}
+
+	return changes
+}
+
+// Formats are compared as JSON, since the maps of the settings may hold numbers of different types
+func sameFormat(a, b *model.ConvertTo) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(encodedA, encodedB)
+}
+
+// CurrentDefaults returns the defaults in effect, they are replaced when the file with defaults is reloaded
+func (c *Config) CurrentDefaults() *Defaults {
+	if dfs := c.reloaded.Load(); dfs != nil {
+		return dfs
+	}
+	return c.Defaults
+}
+
+// SwapDefaults replaces the defaults in effect and returns the previous ones.
+// Conversions that have already started keep the previous defaults.
+func (c *Config) SwapDefaults(dfs *Defaults) *Defaults {
+	if previous := c.reloaded.Swap(dfs); previous != nil {
+		return previous
+	}
+	return c.Defaults
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const defaultDiskReserve = 1 << 30
+
+// Conversions are paused while the free space of the filesystem the converted files are written to is low
+// Enabled with a reserve of 1 GiB and the output ratio of 1 by default, see setDefaults.
+type Disk struct {
+	Enabled bool `yaml:"enabled" env:"DISK_GUARD_ENABLED"`
+	// Free bytes that must be left on the filesystem after writing a converted file
+	Reserve uint64 `yaml:"reserve" env:"DISK_RESERVE"`
+	// Estimated size of a converted file relative to the size of its source
+	OutputRatio float64 `yaml:"output_ratio" env:"DISK_OUTPUT_RATIO"`
+	// Interval of the free space checks of the paused queue and of a running video encode
+	CheckInterval time.Duration `yaml:"check_interval" env:"DISK_CHECK_INTERVAL" env-default:"30s"`
+}
+
+func (d *Disk) Validate() error {
+	if d.OutputRatio < 0 {
+		return fmt.Errorf("output ratio must not be negative")
+	}
+	if d.CheckInterval <= 0 {
+		return fmt.Errorf("check interval must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Checks of the readiness and liveness endpoints
+type Health struct {
+	// Each check fails if it doesn't complete in time
+	CheckTimeout time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" env-default:"3s"`
+	// Delay between failing the readiness and stopping the server on shutdown,
+	// so load balancers stop routing requests to the instance first
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" env:"HEALTH_SHUTDOWN_DELAY" env-default:"0s"`
+}
+
+func (h *Health) Validate() error {
+	if h.CheckTimeout <= 0 {
+		return fmt.Errorf("check timeout must be positive")
+	}
+	if h.ShutdownDelay < 0 {
+		return fmt.Errorf("shutdown delay must not be negative")
+	}
+	return nil
+}
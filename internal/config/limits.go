@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Token bucket limits of the requests of a single client to the convert, delete and scan endpoints.
+// A client is identified by its api key if the authentication is enabled, by its ip address otherwise.
+type RateLimit struct {
+	Enabled bool `yaml:"enabled" env:"RATE_LIMIT_ENABLED" env-default:"false"`
+	// Requests per second refilling the bucket
+	Rate float64 `yaml:"rate" env:"RATE_LIMIT_RATE" env-default:"10"`
+	// Requests that may be made at once
+	Burst int `yaml:"burst" env:"RATE_LIMIT_BURST" env-default:"20"`
+}
+
+func (l *RateLimit) Validate() error {
+	if !l.Enabled {
+		return nil
+	}
+	if l.Rate <= 0 {
+		return fmt.Errorf("rate must be positive")
+	}
+	if l.Burst < 1 {
+		return fmt.Errorf("burst must be at least 1")
+	}
+	return nil
+}
+
+// Limits of the pending conversions enqueued by a single client, zero means unlimited.
+// Conversions enqueued by scans and the watcher are not counted.
+type Quota struct {
+	MaxPendingJobs int64 `yaml:"max_pending_jobs" env:"QUOTA_MAX_PENDING_JOBS" env-default:"0"`
+	// Total size of the sources of the pending conversions
+	MaxPendingBytes int64 `yaml:"max_pending_bytes" env:"QUOTA_MAX_PENDING_BYTES" env-default:"0"`
+	// Suggested delay before a rejected request is retried
+	RetryAfter time.Duration `yaml:"retry_after" env:"QUOTA_RETRY_AFTER" env-default:"1m"`
+}
+
+func (q *Quota) Enabled() bool {
+	return q.MaxPendingJobs > 0 || q.MaxPendingBytes > 0
+}
+
+func (q *Quota) Validate() error {
+	if q.MaxPendingJobs < 0 || q.MaxPendingBytes < 0 {
+		return fmt.Errorf("limits must not be negative")
+	}
+	if q.RetryAfter <= 0 {
+		return fmt.Errorf("retry after must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package config
+
+// Image metadata policies
+const (
+	// Remove all metadata including the ICC profile
+	MetadataStrip = "strip"
+	// Keep only the copyright related EXIF fields and the ICC profile
+	MetadataCopyright = "copyright"
+	// Keep all metadata
+	MetadataKeep = "keep"
+)
+
+// An empty policy falls back to MetadataStrip
+func IsMetadataPolicy(policy string) bool {
+	switch policy {
+	case "", MetadataStrip, MetadataCopyright, MetadataKeep:
+		return true
+	}
+	return false
+}
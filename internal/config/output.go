@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/chistyakoviv/converter/internal/constants"
+	"github.com/chistyakoviv/converter/internal/model"
+)
+
+type Output struct {
+	// Directory the converted files are written to relative to the working directory,
+	// the converted files are written next to their sources if empty
+	Root string `yaml:"root" env:"OUTPUT_ROOT"`
+	// Template of the converted files of the formats without their own template
+	Template string `yaml:"template" env:"OUTPUT_TEMPLATE"`
+}
+
+func (o *Output) Validate() error {
+	if o.Root != "" {
+		root := strings.Trim(o.Root, "/")
+		if root == "" || path.Clean(root) != root || root == ".." || strings.HasPrefix(root, "../") {
+			return fmt.Errorf("root '%s' must be a directory inside the working directory", o.Root)
+		}
+		// Scans would enqueue the converted files as new sources
+		if root == constants.FilesRootDir || strings.HasPrefix(root, constants.FilesRootDir+"/") {
+			return fmt.Errorf("root '%s' must be outside the '%s' directory", o.Root, constants.FilesRootDir)
+		}
+	}
+	if o.Template != "" {
+		return model.ValidateOutputTemplate(o.Template)
+	}
+	return nil
+}
+
+// Naming returns the naming of the converted files, the templates of the formats are taken from the defaults in effect
+func (c *Config) Naming() model.Naming {
+	return c.NamingOf(c.CurrentDefaults())
+}
+
+// NamingOf returns the naming of the converted files with the templates of the formats of the given defaults
+func (c *Config) NamingOf(dfs *Defaults) model.Naming {
+	naming := model.Naming{
+		Root:     strings.Trim(c.Output.Root, "/"),
+		Template: c.Output.Template,
+	}
+	if dfs == nil {
+		return naming
+	}
+
+	naming.Templates = make(map[string]string)
+	for _, formats := range [][]model.ConvertTo{dfs.Image.Formats, dfs.Video.Formats} {
+		for _, entry := range formats {
+			if template, ok := entry.Optional["template"].(string); ok {
+				naming.Templates[entry.Key()] = template
+			}
+		}
+	}
+	return naming
+}
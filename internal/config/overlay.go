@@ -0,0 +1,58 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const defaultOverlayColor = "#ffffff"
+
+// Overlay describes a watermark asset that conversions refer to by name.
+// Exactly one of Image and Text must be set.
+type Overlay struct {
+	// Path to the watermark image, preferably a png with an alpha channel
+	Image string `yaml:"image"`
+	Text  string `yaml:"text"`
+	// Font description of the text, e.g. "sans bold"
+	Font string `yaml:"font"`
+	// Font file used by FFmpeg, takes precedence over Font for videos
+	FontFile string `yaml:"font_file"`
+	// Text color in the #rrggbb format
+	Color string `yaml:"color"`
+}
+
+func (o Overlay) IsText() bool {
+	return o.Text != ""
+}
+
+func (o Overlay) Validate() error {
+	if (o.Image == "") == (o.Text == "") {
+		return errors.New("exactly one of image and text must be set")
+	}
+	if _, _, _, err := o.RGB(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Returns the text color, white by default
+func (o Overlay) RGB() (uint8, uint8, uint8, error) {
+	color := o.Color
+	if color == "" {
+		color = defaultOverlayColor
+	}
+
+	hex, ok := strings.CutPrefix(color, "#")
+	if !ok || len(hex) != 6 {
+		return 0, 0, 0, fmt.Errorf("color '%s' must be in the #rrggbb format", o.Color)
+	}
+
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("color '%s' must be in the #rrggbb format", o.Color)
+	}
+
+	return uint8(value >> 16), uint8(value >> 8), uint8(value), nil
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/chistyakoviv/converter/internal/file"
+	"github.com/chistyakoviv/converter/internal/model"
+)
+
+var ErrInvalidPreset = errors.New("invalid preset")
+
+// Names are used in requests and in URLs, so they are kept simple
+var presetNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Preset is a named set of target formats a request selects instead of sending them
+type Preset struct {
+	// Name of the preset whose formats are inherited, the formats of the same key are merged over them
+	Extends     string        `yaml:"extends"`
+	Description string        `yaml:"description"`
+	Image       ImageDefaults `yaml:"image"`
+	Video       VideoDefaults `yaml:"video"`
+}
+
+// Formats returns the target formats of the media type
+func (p *Preset) Formats(mediaType file.MediaType) []model.ConvertTo {
+	switch mediaType {
+	case file.MediaTypeImage:
+		return p.Image.Formats
+	case file.MediaTypeVideo:
+		return p.Video.Formats
+	}
+	return nil
+}
+
+// PresetNames returns the names of the presets in order
+func (d *Defaults) PresetNames() []string {
+	names := make([]string, 0, len(d.Presets))
+	for name := range d.Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MergeFormats merges the formats over the base ones. A format with the key of a base format
+// overrides its conversion config and optional settings key by key, other formats are appended.
+func MergeFormats(base []model.ConvertTo, formats []model.ConvertTo) []model.ConvertTo {
+	merged := make([]model.ConvertTo, 0, len(base)+len(formats))
+	index := make(map[string]int, len(base))
+	for _, entry := range base {
+		index[entry.Key()] = len(merged)
+		merged = append(merged, entry)
+	}
+
+	for _, entry := range formats {
+		i, ok := index[entry.Key()]
+		if !ok {
+			index[entry.Key()] = len(merged)
+			merged = append(merged, entry)
+			continue
+		}
+		merged[i] = model.ConvertTo{
+			Ext:      entry.Ext,
+			ConvConf: mergeMaps(merged[i].ConvConf, entry.ConvConf),
+			Optional: mergeMaps(merged[i].Optional, entry.Optional),
+		}
+	}
+
+	return merged
+}
+
+func mergeMaps(base, override map[string]interface{}) map[string]interface{} {
+	if len(base) == 0 && len(override) == 0 {
+		return nil
+	}
+	result := make(map[string]interface{}, len(base)+len(override))
+	for key, value := range base {
+		result[key] = value
+	}
+	for key, value := range override {
+		result[key] = value
+	}
+	return result
+}
+
+// Replaces the formats of each preset with the formats merged over the ones of the presets it extends
+func (d *Defaults) resolvePresets() error {
+	resolved := make(map[string]bool, len(d.Presets))
+
+	var resolve func(name string, chain []string) error
+	resolve = func(name string, chain []string) error {
+		if resolved[name] {
+			return nil
+		}
+		preset := d.Presets[name]
+		chain = append(chain, name)
+
+		if preset.Extends != "" {
+			if slices.Contains(chain, preset.Extends) {
+				return fmt.Errorf("%w: cyclic extends %s", ErrInvalidPreset, strings.Join(append(chain, preset.Extends), " -> "))
+			}
+			parent, ok := d.Presets[preset.Extends]
+			if !ok {
+				return fmt.Errorf("%w: '%s' extends unknown preset '%s'", ErrInvalidPreset, name, preset.Extends)
+			}
+			if err := resolve(preset.Extends, chain); err != nil {
+				return err
+			}
+			preset.Image.Formats = MergeFormats(parent.Image.Formats, preset.Image.Formats)
+			preset.Video.Formats = MergeFormats(parent.Video.Formats, preset.Video.Formats)
+		}
+
+		resolved[name] = true
+		return nil
+	}
+
+	for _, name := range d.PresetNames() {
+		if !presetNamePattern.MatchString(name) {
+			return fmt.Errorf("%w: malformed name '%s'", ErrInvalidPreset, name)
+		}
+		if d.Presets[name] == nil {
+			d.Presets[name] = &Preset{}
+		}
+	}
+	for _, name := range d.PresetNames() {
+		if err := resolve(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
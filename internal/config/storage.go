@@ -0,0 +1,48 @@
+package config
+
+import "fmt"
+
+// Storage drivers
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+// S3 requires every part of a multipart upload but the last one to be at least 5 MiB
+const MinS3PartSize = 5 * 1024 * 1024
+
+// The local storage keeps files in the working directory
+type Storage struct {
+	Driver string    `yaml:"driver" env:"STORAGE_DRIVER" env-default:"local"`
+	S3     S3Storage `yaml:"s3"`
+}
+
+type S3Storage struct {
+	Endpoint  string `yaml:"endpoint" env:"STORAGE_S3_ENDPOINT"`
+	Region    string `yaml:"region" env:"STORAGE_S3_REGION" env-default:"us-east-1"`
+	Bucket    string `yaml:"bucket" env:"STORAGE_S3_BUCKET"`
+	AccessKey string `yaml:"access_key" env:"STORAGE_S3_ACCESS_KEY"`
+	SecretKey string `yaml:"secret_key" env:"STORAGE_S3_SECRET_KEY"`
+	// Enabled by default, see setDefaults
+	UseSSL bool `yaml:"use_ssl" env:"STORAGE_S3_USE_SSL"`
+	// Objects are stored under the prefix followed by the path of a file
+	Prefix string `yaml:"prefix" env:"STORAGE_S3_PREFIX"`
+	// Files larger than the part size are uploaded in parts
+	PartSize uint64 `yaml:"part_size" env:"STORAGE_S3_PART_SIZE" env-default:"16777216"`
+}
+
+func (s *Storage) Validate() error {
+	switch s.Driver {
+	case StorageLocal:
+		return nil
+	case StorageS3:
+		if s.S3.Endpoint == "" || s.S3.Bucket == "" {
+			return fmt.Errorf("s3 endpoint and bucket are required")
+		}
+		if s.S3.PartSize < MinS3PartSize {
+			return fmt.Errorf("s3 part size must be at least %d bytes", MinS3PartSize)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown storage driver '%s'", s.Driver)
+}
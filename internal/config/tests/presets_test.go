@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/file"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadDefaults(t *testing.T, defaults string) (*config.Defaults, error) {
+	path := filepath.Join(t.TempDir(), "defaults.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(defaults), 0644))
+	return config.LoadDefaults(path)
+}
+
+func TestLoadPresets(t *testing.T) {
+	dfs, err := loadDefaults(t, `
+presets:
+  web:
+    description: "Formats for the web"
+    image:
+      formats:
+        - ext: "webp"
+          conv_conf:
+            quality: 80
+        - ext: "avif"
+    video:
+      formats:
+        - ext: "webm"
+  web-hero:
+    extends: "web"
+    image:
+      formats:
+        - ext: "webp"
+          conv_conf:
+            quality: 90
+          optional:
+            suffix: ".hero"
+        - ext: "jpg"
+  web-hero-lossless:
+    extends: "web-hero"
+    image:
+      formats:
+        - ext: "webp"
+          conv_conf:
+            lossless: true
+`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"web", "web-hero", "web-hero-lossless"}, dfs.PresetNames())
+
+	hero := dfs.Presets["web-hero"]
+	assert.Equal(t, []model.ConvertTo{
+		{Ext: "webp", ConvConf: map[string]interface{}{"quality": 80}},
+		{Ext: "avif"},
+		{Ext: "webp", ConvConf: map[string]interface{}{"quality": 90}, Optional: map[string]interface{}{"suffix": ".hero"}},
+		{Ext: "jpg"},
+	}, hero.Formats(file.MediaTypeImage))
+	assert.Equal(t, []model.ConvertTo{{Ext: "webm"}}, hero.Formats(file.MediaTypeVideo))
+
+	// Formats are merged key by key through the whole chain
+	lossless := dfs.Presets["web-hero-lossless"]
+	assert.Equal(t, model.ConvertTo{Ext: "webp", ConvConf: map[string]interface{}{"quality": 80, "lossless": true}}, lossless.Image.Formats[0])
+	assert.Len(t, lossless.Image.Formats, 4)
+
+	// The extended preset is left intact
+	assert.Len(t, dfs.Presets["web"].Image.Formats, 2)
+}
+
+func TestLoadInvalidPresets(t *testing.T) {
+	type testcase struct {
+		name     string
+		defaults string
+		err      string
+	}
+
+	cases := []testcase{
+		{
+			name: "Unknown preset extended",
+			defaults: `
+presets:
+  thumbnail:
+    extends: "web"
+`,
+			err: "invalid preset: 'thumbnail' extends unknown preset 'web'",
+		},
+		{
+			name: "Cycle",
+			defaults: `
+presets:
+  a:
+    extends: "b"
+  b:
+    extends: "c"
+  c:
+    extends: "a"
+`,
+			err: "invalid preset: cyclic extends a -> b -> c -> a",
+		},
+		{
+			name: "Malformed name",
+			defaults: `
+presets:
+  Web Hero:
+    image:
+      formats:
+        - ext: "webp"
+`,
+			err: "invalid preset: malformed name 'Web Hero'",
+		},
+		{
+			name: "Invalid template",
+			defaults: `
+presets:
+  web:
+    image:
+      formats:
+        - ext: "webp"
+          optional:
+            template: "{unknown}.{fmt}"
+`,
+			err: "invalid format of preset 'web' 'webp'",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := loadDefaults(t, tc.defaults)
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestDiffPresets(t *testing.T) {
+	previous := &config.Defaults{Presets: map[string]*config.Preset{
+		"web":     {Image: config.ImageDefaults{Formats: []model.ConvertTo{{Ext: "webp"}}}},
+		"archive": {Video: config.VideoDefaults{Formats: []model.ConvertTo{{Ext: "webm"}}}},
+	}}
+	next := &config.Defaults{Presets: map[string]*config.Preset{
+		"web": {Image: config.ImageDefaults{Formats: []model.ConvertTo{{Ext: "webp", ConvConf: map[string]interface{}{"quality": 70}}}}},
+	}}
+
+	changes := previous.Diff(next)
+
+	kinds := make(map[string]string, len(changes))
+	for _, change := range changes {
+		kinds[change.Preset+":"+change.Media+":"+change.Key] = change.Kind
+	}
+	assert.Equal(t, map[string]string{
+		"web:image:webp":     config.DefaultsChanged,
+		"archive:video:webm": config.DefaultsRemoved,
+	}, kinds)
+}
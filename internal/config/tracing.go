@@ -0,0 +1,28 @@
+package config
+
+import "fmt"
+
+// Spans are exported to an OpenTelemetry collector over OTLP/HTTP, they are discarded if the tracing is disabled
+type Tracing struct {
+	Enabled bool `yaml:"enabled" env:"TRACING_ENABLED" env-default:"false"`
+	// Host and port of the collector
+	Endpoint string `yaml:"endpoint" env:"TRACING_ENDPOINT" env-default:"localhost:4318"`
+	// Export over plain HTTP instead of HTTPS
+	Insecure bool `yaml:"insecure" env:"TRACING_INSECURE" env-default:"false"`
+	// Fraction of the traces started by the service that are sampled, the decision of a traced client is respected.
+	// All of them are sampled by default, see setDefaults
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+func (t *Tracing) Validate() error {
+	if !t.Enabled {
+		return nil
+	}
+	if t.Endpoint == "" {
+		return fmt.Errorf("endpoint is required")
+	}
+	if t.SampleRatio < 0 || t.SampleRatio > 1 {
+		return fmt.Errorf("sample ratio must be in the range [0, 1]")
+	}
+	return nil
+}
@@ -8,18 +8,35 @@ import (
 
 type ImageConverter interface {
 	Shutdowner
-	Convert(from string, to string, conf ConversionConfig) error
+	Checker
+	// Returns the result of the quality search if it is enabled in the config, otherwise nil
+	Convert(from string, to string, conf ConversionConfig) (*model.QualityResult, error)
 }
 
 type VideoConverter interface {
 	Shutdowner
-	Convert(from string, to string, conf ConversionConfig) error
+	Checker
+	// The encoder is stopped once the context is done
+	Convert(ctx context.Context, from string, to string, conf ConversionConfig) error
 }
 
 type Shutdowner interface {
 	Shutdown()
 }
 
+// Reports an error if the converter is unable to convert files, e.g. the library is not started or the binaries are missing
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
 type Converter interface {
 	Convert(ctx context.Context, info *model.Conversion) error
 }
+
+// Looks up the converted files of the same content, so they are copied instead of being converted again
+type DuplicateFinder interface {
+	// Returns the done conversions of the sources with the given content
+	FindDuplicates(ctx context.Context, hash string) ([]*model.Conversion, error)
+	// Reports whether the converted file of the conversion is also produced by another existing source
+	IsOutputShared(ctx context.Context, conversion *model.Conversion, dest string) (bool, error)
+}
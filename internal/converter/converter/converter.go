@@ -2,23 +2,90 @@ package converter
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync/atomic"
+	"time"
 
 	"github.com/chistyakoviv/converter/internal/config"
 	"github.com/chistyakoviv/converter/internal/converter"
 	"github.com/chistyakoviv/converter/internal/file"
+	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/metrics"
 	"github.com/chistyakoviv/converter/internal/model"
 	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/chistyakoviv/converter/internal/storage"
+	"github.com/chistyakoviv/converter/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Reported by an encode stopped since the free space ran low while it was running
+var errLowDiskSpace = errors.New("encode is stopped due to low disk space")
+
 type serv struct {
 	cfg            *config.Config
 	logger         *slog.Logger
 	imageConverter converter.ImageConverter
 	videoConverter converter.VideoConverter
-	imageConfigs   map[string]converter.ConversionConfig
-	videoConfigs   map[string]converter.ConversionConfig
+	duplicates     converter.DuplicateFinder
+	diskGuard      service.DiskGuardService
+	storage        storage.Storage
+	// Settings derived from the defaults in effect, rebuilt once the defaults are reloaded
+	settings atomic.Pointer[settings]
+}
+
+// The settings a conversion is started with, it keeps them even if the defaults are reloaded meanwhile
+type settings struct {
+	defaults     *config.Defaults
+	naming       model.Naming
+	imageConfigs map[string]converter.ConversionConfig
+	videoConfigs map[string]converter.ConversionConfig
+}
+
+func newSettings(cfg *config.Config, dfs *config.Defaults) *settings {
+	imageConfigs := make(map[string]converter.ConversionConfig)
+	videoConfigs := make(map[string]converter.ConversionConfig)
+	for _, entry := range dfs.Image.Formats {
+		imageConfigs[entry.Key()] = entry.ConvConf
+	}
+	for _, entry := range dfs.Video.Formats {
+		videoConfigs[entry.Key()] = entry.ConvConf
+	}
+	return &settings{
+		defaults:     dfs,
+		naming:       cfg.NamingOf(dfs),
+		imageConfigs: imageConfigs,
+		videoConfigs: videoConfigs,
+	}
+}
+
+// Returns the settings a conversion started now uses
+func (s *serv) currentSettings() *settings {
+	dfs := s.cfg.CurrentDefaults()
+	if current := s.settings.Load(); current != nil && current.defaults == dfs {
+		return current
+	}
+	next := newSettings(s.cfg, dfs)
+	s.settings.Store(next)
+	return next
+}
+
+// Offline conversions are not recorded, so there are no converted files to copy
+type noDuplicates struct{}
+
+// NoDuplicates is used to convert files without the database, every file is converted
+func NoDuplicates() converter.DuplicateFinder {
+	return noDuplicates{}
+}
+
+func (noDuplicates) FindDuplicates(ctx context.Context, hash string) ([]*model.Conversion, error) {
+	return nil, nil
+}
+
+func (noDuplicates) IsOutputShared(ctx context.Context, conversion *model.Conversion, dest string) (bool, error) {
+	return false, nil
 }
 
 func NewService(
@@ -26,68 +93,296 @@ func NewService(
 	logger *slog.Logger,
 	imageConverter converter.ImageConverter,
 	videoConverter converter.VideoConverter,
+	duplicates converter.DuplicateFinder,
+	diskGuard service.DiskGuardService,
+	storage storage.Storage,
 ) (converter.Converter, error) {
-	imageConfigs := make(map[string]converter.ConversionConfig)
-	videoConfigs := make(map[string]converter.ConversionConfig)
-	for _, entry := range cfg.Defaults.Image.Formats {
-		imageConfigs[entry.Key()] = entry.ConvConf
+	// A typo in the defaults is reported on startup instead of being ignored by the encoders
+	if err := converter.ValidateDefaults(cfg.CurrentDefaults(), cfg.Overlays); err != nil {
+		return nil, err
 	}
-	for _, entry := range cfg.Defaults.Video.Formats {
-		videoConfigs[entry.Key()] = entry.ConvConf
-	}
-	return &serv{
+
+	s := &serv{
 		cfg:            cfg,
 		logger:         logger,
 		imageConverter: imageConverter,
 		videoConverter: videoConverter,
-		imageConfigs:   imageConfigs,
-		videoConfigs:   videoConfigs,
-	}, nil
+		duplicates:     duplicates,
+		diskGuard:      diskGuard,
+		storage:        storage,
+	}
+	s.settings.Store(newSettings(cfg, cfg.CurrentDefaults()))
+	return s, nil
 }
 
 func (s *serv) Convert(ctx context.Context, info *model.Conversion) error {
-	src, err := info.AbsoluteSourcePath()
+	s.logger.Debug("convert", slog.String("src", info.Fullpath))
+
+	// Results are collected anew on each conversion
+	info.QualityResults = nil
+	info.ConfigDigests = make(map[string]string, len(info.ConvertTo))
+	settings := s.currentSettings()
+
+	source, err := s.storage.Stat(ctx, info.Fullpath)
+	if errors.Is(err, storage.ErrNotExist) {
+		return service.NewConverterError(fmt.Sprintf("file '%s' does not exist", info.Fullpath), service.ErrFileDoesNotExist)
+	}
+	if err != nil {
+		return service.NewConverterError(err.Error(), service.ErrUnableToConvertFile)
+	}
+
+	// The type is detected once for all target formats
+	mediaType, err := storage.DetectMediaType(ctx, s.storage, info.Fullpath)
+	if err != nil {
+		return service.NewConverterError(err.Error(), service.ErrInvalidConversionFormat)
+	}
+	if mediaType == file.MediaTypeUnknown {
+		return service.NewConverterError(fmt.Sprintf("the file is not an image or video: %s", info.Fullpath), service.ErrWrongSourceFile)
+	}
+
+	// The converters work with local files, so a file of a remote storage is downloaded once
+	if _, ok := s.storage.(storage.Local); !ok {
+		if err = s.diskGuard.Check(uint64(source.Size)); err != nil {
+			return service.NewConverterError(err.Error(), service.ErrInsufficientDiskSpace)
+		}
+	}
+	src, release, err := storage.Fetch(ctx, s.storage, info.Fullpath)
 	if err != nil {
 		return service.NewConverterError(err.Error(), service.ErrUnableToConvertFile)
 	}
-	s.logger.Debug("convert", slog.String("src", src))
+	defer release()
 
-	if !file.Exists(src) {
-		return service.NewConverterError(fmt.Sprintf("file '%s' does not exist", src), service.ErrFileDoesNotExist)
+	naming := settings.naming
+
+	// Names of the previous converted files are needed to remove them if the source hash changes.
+	// The hash is always computed anew since the source may have changed after it was enqueued.
+	previous := *info
+	if info.SourceHash, err = file.Hash(src); err != nil {
+		return service.NewConverterError(err.Error(), service.ErrUnableToConvertFile)
 	}
 
+	// Converted files of the same content with the same settings are copied instead of being converted again
+	duplicates, err := s.duplicates.FindDuplicates(ctx, info.SourceHash)
+	if err != nil {
+		return service.NewConverterError(err.Error(), service.ErrUnableToConvertFile)
+	}
+	info.DedupOrigin = ""
+	// #nosec G115 -- the size of an existing file is not negative
+	estimatedSize := uint64(float64(source.Size) * s.cfg.Disk.OutputRatio)
+
 	for _, entry := range info.ConvertTo {
-		dest, err := info.AbsoluteDestinationPath(entry)
+		dest, err := info.DestinationFullpath(entry, naming)
 		if err != nil {
-			return service.NewConverterError(err.Error(), service.ErrUnableToConvertFile)
+			return service.NewConverterError(err.Error(), service.ErrInvalidOutputName)
 		}
+		digest := settings.effectiveConfig(entry, mediaType).Digest()
+		info.ConfigDigests[entry.Key()] = digest
 
-		var filetypeErr error
-		var imageOk, videoOk bool
-
-		if imageOk, filetypeErr = file.IsImage(info.Fullpath); imageOk {
-			mergedConf := converter.MergeConfigs(s.imageConfigs[entry.Key()], entry.ConvConf)
-			if err := s.imageConverter.Convert(src, dest, mergedConf); err != nil {
-				return service.NewConverterError(err.Error(), service.ErrUnableToConvertFile)
-			}
+		copied, err := s.copyEntry(ctx, settings, info, entry, digest, dest, duplicates)
+		if err != nil {
+			return err
+		}
+		if copied {
+			continue
+		}
+		// The space is checked before each format, as the previous ones may have taken it
+		if err = s.diskGuard.Check(estimatedSize); err != nil {
+			return service.NewConverterError(err.Error(), service.ErrInsufficientDiskSpace)
 		}
-		if filetypeErr != nil {
-			return service.NewConverterError(filetypeErr.Error(), service.ErrInvalidConversionFormat)
+		if err = s.convertEntry(ctx, settings, info, entry, src, dest, mediaType); err != nil {
+			return err
 		}
+	}
 
-		if videoOk, filetypeErr = file.IsVideo(info.Fullpath); videoOk {
-			mergedConf := converter.MergeConfigs(s.videoConfigs[entry.Key()], entry.ConvConf)
-			if err := s.videoConverter.Convert(src, dest, mergedConf); err != nil {
-				return service.NewConverterError(err.Error(), service.ErrUnableToConvertFile)
+	if previous.SourceHash != "" && previous.SourceHash != info.SourceHash {
+		s.removePrevious(ctx, &previous, naming)
+	}
+	return nil
+}
+
+// Copies the converted file of a duplicate converted with the same effective settings,
+// reports whether the file is copied. The file is converted if the duplicate's file is gone.
+// The settings of the duplicate are the ones it was converted with, not the ones the defaults give now.
+func (s *serv) copyEntry(
+	ctx context.Context,
+	settings *settings,
+	info *model.Conversion,
+	entry model.ConvertTo,
+	digest string,
+	dest string,
+	duplicates []*model.Conversion,
+) (bool, error) {
+	if digest == "" {
+		return false, nil
+	}
+	for _, duplicate := range duplicates {
+		// The file itself is converted again when requeued
+		if duplicate.Fullpath == info.Fullpath {
+			continue
+		}
+		for _, candidate := range duplicate.ConvertTo {
+			if candidate.Ext != entry.Ext || duplicate.ConfigDigests[candidate.Key()] != digest {
+				continue
+			}
+			origin, err := duplicate.DestinationFullpath(candidate, settings.naming)
+			if err != nil {
+				continue
+			}
+
+			// The file may be shared if it is named after the content only
+			if origin != dest {
+				err = storage.Copy(ctx, s.storage, origin, dest)
+			} else {
+				_, err = s.storage.Stat(ctx, origin)
 			}
+			if errors.Is(err, storage.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return false, service.NewConverterError(err.Error(), service.ErrUnableToConvertFile)
+			}
+
+			for _, result := range duplicate.QualityResults {
+				if result.Key == candidate.Key() {
+					result.Key = entry.Key()
+					info.QualityResults = append(info.QualityResults, result)
+				}
+			}
+			if info.DedupOrigin == "" {
+				info.DedupOrigin = duplicate.Fullpath
+			}
+			s.logger.Debug("copy converted file", slog.String("src", origin), slog.String("dest", dest))
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Returns the settings the converter is called with for the target format
+func (s *settings) effectiveConfig(entry model.ConvertTo, mediaType file.MediaType) converter.ConversionConfig {
+	if mediaType == file.MediaTypeVideo {
+		return converter.MergeConfigs(s.videoConfigs[entry.Key()], entry.ConvConf)
+	}
+	return converter.MergeConfigs(s.imageConfigs[entry.Key()], entry.ConvConf)
+}
+
+// Removes the converted files named after the previous source hash
+func (s *serv) removePrevious(ctx context.Context, previous *model.Conversion, naming model.Naming) {
+	for _, entry := range previous.ConvertTo {
+		if !naming.NeedsHash(entry) {
+			continue
+		}
+		dest, err := previous.DestinationFullpath(entry, naming)
+		if err != nil {
+			continue
 		}
-		if filetypeErr != nil {
-			return service.NewConverterError(filetypeErr.Error(), service.ErrInvalidConversionFormat)
+		// Another source of the previous content may still produce the file
+		shared, err := s.duplicates.IsOutputShared(ctx, previous, dest)
+		if err != nil {
+			s.logger.Error("failed to check whether previous converted file is shared", slog.String("path", dest), slogger.Err(err))
+			continue
+		}
+		if shared {
+			continue
 		}
+		if err = s.storage.Remove(ctx, dest); err != nil {
+			s.logger.Error("failed to remove previous converted file", slog.String("path", dest), slogger.Err(err))
+		}
+	}
+}
 
-		if !imageOk && !videoOk {
-			return service.NewConverterError(fmt.Sprintf("the file is not an image or video: %s", src), service.ErrWrongSourceFile)
+func (s *serv) convertEntry(ctx context.Context, settings *settings, info *model.Conversion, entry model.ConvertTo, src string, fullpath string, mediaType file.MediaType) error {
+	dest, store, release, err := storage.Output(s.storage, fullpath)
+	if err != nil {
+		return service.NewConverterError(err.Error(), service.ErrUnableToConvertFile)
+	}
+	defer release()
+
+	if err = s.encode(ctx, settings, info, entry, src, dest, mediaType); err != nil {
+		// The conversion is postponed until the space is freed
+		if errors.Is(err, errLowDiskSpace) {
+			return service.NewConverterError(err.Error(), service.ErrInsufficientDiskSpace)
 		}
+		return service.NewConverterError(err.Error(), service.ErrUnableToConvertFile)
+	}
+
+	if err = store(ctx); err != nil {
+		return service.NewConverterError(err.Error(), service.ErrUnableToConvertFile)
 	}
 	return nil
 }
+
+// Runs the encoder of the media type within its own span
+func (s *serv) encode(ctx context.Context, settings *settings, info *model.Conversion, entry model.ConvertTo, src string, dest string, mediaType file.MediaType) (err error) {
+	encoder := metrics.ConverterImage
+	if mediaType == file.MediaTypeVideo {
+		encoder = metrics.ConverterVideo
+	}
+	_, span := tracing.Tracer().Start(ctx, "encode", trace.WithAttributes(
+		attribute.String("encoder.name", encoder),
+		attribute.String("encoder.source", info.Ext),
+		attribute.String("encoder.target", entry.Ext),
+		attribute.String("encoder.key", entry.Key()),
+	))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	mergedConf := settings.effectiveConfig(entry, mediaType)
+	switch mediaType {
+	case file.MediaTypeImage:
+		result, err := s.imageConverter.Convert(src, dest, mergedConf)
+		if err != nil {
+			return err
+		}
+		if result != nil {
+			result.Key = entry.Key()
+			info.QualityResults = append(info.QualityResults, *result)
+		}
+	case file.MediaTypeVideo:
+		return s.encodeVideo(ctx, src, dest, mergedConf)
+	}
+	return nil
+}
+
+// Images are encoded in memory and written at once, so the check before each format covers them.
+// A video is written while it is encoded, which may take long enough for the disk to fill up,
+// so the free space is checked periodically and the encode is stopped once it runs into the reserve.
+func (s *serv) encodeVideo(ctx context.Context, src string, dest string, conf converter.ConversionConfig) error {
+	if !s.cfg.Disk.Enabled || s.cfg.Disk.CheckInterval <= 0 {
+		return s.videoConverter.Convert(ctx, src, dest, conf)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	watched := make(chan struct{})
+
+	go func() {
+		defer close(watched)
+
+		ticker := time.NewTicker(s.cfg.Disk.CheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.diskGuard.Check(0); err != nil {
+					cancel(fmt.Errorf("%w: %w", errLowDiskSpace, err))
+					return
+				}
+			}
+		}
+	}()
+
+	err := s.videoConverter.Convert(ctx, src, dest, conf)
+	cause := context.Cause(ctx)
+	// No check outlives the encode
+	cancel(nil)
+	<-watched
+
+	if err != nil && errors.Is(cause, errLowDiskSpace) {
+		return cause
+	}
+	return err
+}
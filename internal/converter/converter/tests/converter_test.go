@@ -2,8 +2,6 @@ package tests
 
 import (
 	"context"
-	"fmt"
-	"os"
 	"testing"
 
 	"github.com/chistyakoviv/converter/internal/config"
@@ -12,17 +10,27 @@ import (
 	converterMocks "github.com/chistyakoviv/converter/internal/converter/mocks"
 	"github.com/chistyakoviv/converter/internal/logger/dummy"
 	"github.com/chistyakoviv/converter/internal/model"
+	serviceMocks "github.com/chistyakoviv/converter/internal/service/mocks"
+	"github.com/chistyakoviv/converter/internal/storage/local"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
-func TestConverterService(t *testing.T) {
-	wd, err := os.Getwd()
+// Returns the queue without duplicates of the converted files
+func newConversionQueueService(t *testing.T) *serviceMocks.MockConversionQueueService {
+	mockConversionQueueService := serviceMocks.NewMockConversionQueueService(t)
+	mockConversionQueueService.On("FindDuplicates", mock.Anything, mock.AnythingOfType("string")).Return(nil, nil).Maybe()
+	return mockConversionQueueService
+}
 
-	if err != nil {
-		t.Fatalf("failed to get working directory: %s", err)
-	}
+// Returns the disk guard of a disk with enough free space
+func newDiskGuard(t *testing.T) *serviceMocks.MockDiskGuardService {
+	mockDiskGuard := serviceMocks.NewMockDiskGuardService(t)
+	mockDiskGuard.On("Check", mock.AnythingOfType("uint64")).Return(nil).Maybe()
+	return mockDiskGuard
+}
 
+func TestConverterService(t *testing.T) {
 	var (
 		ctx          = context.Background()
 		logger       = dummy.NewDummyLogger()
@@ -33,6 +41,7 @@ func TestConverterService(t *testing.T) {
 	type testcase struct {
 		name               string
 		conversion         *model.Conversion
+		qualityResults     []model.QualityResult
 		err                string
 		configPath         string
 		defaultsPath       string
@@ -54,7 +63,7 @@ func TestConverterService(t *testing.T) {
 					},
 				},
 			},
-			err:          fmt.Sprintf("file '%s/files/images/non-existent.jpg' does not exist", wd),
+			err:          "file '/files/images/non-existent.jpg' does not exist",
 			configPath:   configPath,
 			defaultsPath: defaultsPath,
 			mockImageConverter: func(tc *testcase) *converterMocks.MockImageConverter {
@@ -79,7 +88,7 @@ func TestConverterService(t *testing.T) {
 					},
 				},
 			},
-			err:          fmt.Sprintf("the file is not an image or video: %s/files/other/test.txt", wd),
+			err:          "the file is not an image or video: /files/other/test.txt",
 			configPath:   configPath,
 			defaultsPath: defaultsPath,
 			mockImageConverter: func(tc *testcase) *converterMocks.MockImageConverter {
@@ -109,13 +118,74 @@ func TestConverterService(t *testing.T) {
 			mockImageConverter: func(tc *testcase) *converterMocks.MockImageConverter {
 				mockImageConverter := converterMocks.NewMockImageConverter(t)
 				src, _ := tc.conversion.AbsoluteSourcePath()
-				dest, _ := tc.conversion.AbsoluteDestinationPath(tc.conversion.ConvertTo[0])
+				dest, _ := tc.conversion.AbsoluteDestinationPath(tc.conversion.ConvertTo[0], model.Naming{})
 				mockImageConverter.On(
 					"Convert",
 					src,
 					dest,
 					mock.Anything,
-				).Return(nil).Once()
+				).Return(nil, nil).Once()
+				return mockImageConverter
+			},
+			mockVideoConverter: func(tc *testcase) *converterMocks.MockVideoConverter {
+				mockVideoConverter := converterMocks.NewMockVideoConverter(t)
+				return mockVideoConverter
+			},
+		},
+		{
+			name: "Record quality search results",
+			conversion: &model.Conversion{
+				Fullpath: "/files/images/gen.jpg",
+				Path:     "/files/images",
+				Filestem: "gen",
+				Ext:      "jpg",
+				ConvertTo: []model.ConvertTo{
+					{
+						Ext: "webp",
+						ConvConf: map[string]interface{}{
+							"quality_target": map[string]interface{}{
+								"min_score": 0.98,
+							},
+						},
+					},
+					{
+						Ext: "avif",
+					},
+				},
+			},
+			qualityResults: []model.QualityResult{
+				{
+					Key:      "webp",
+					Metric:   "ssim",
+					Quality:  62,
+					Score:    0.981,
+					Attempts: 5,
+				},
+			},
+			configPath:   configPath,
+			defaultsPath: defaultsPath,
+			mockImageConverter: func(tc *testcase) *converterMocks.MockImageConverter {
+				mockImageConverter := converterMocks.NewMockImageConverter(t)
+				src, _ := tc.conversion.AbsoluteSourcePath()
+				destWebp, _ := tc.conversion.AbsoluteDestinationPath(tc.conversion.ConvertTo[0], model.Naming{})
+				destAvif, _ := tc.conversion.AbsoluteDestinationPath(tc.conversion.ConvertTo[1], model.Naming{})
+				mockImageConverter.On(
+					"Convert",
+					src,
+					destWebp,
+					mock.Anything,
+				).Return(&model.QualityResult{
+					Metric:   "ssim",
+					Quality:  62,
+					Score:    0.981,
+					Attempts: 5,
+				}, nil).Once()
+				mockImageConverter.On(
+					"Convert",
+					src,
+					destAvif,
+					mock.Anything,
+				).Return(nil, nil).Once()
 				return mockImageConverter
 			},
 			mockVideoConverter: func(tc *testcase) *converterMocks.MockVideoConverter {
@@ -145,9 +215,10 @@ func TestConverterService(t *testing.T) {
 			mockVideoConverter: func(tc *testcase) *converterMocks.MockVideoConverter {
 				mockVideoConverter := converterMocks.NewMockVideoConverter(t)
 				src, _ := tc.conversion.AbsoluteSourcePath()
-				dest, _ := tc.conversion.AbsoluteDestinationPath(tc.conversion.ConvertTo[0])
+				dest, _ := tc.conversion.AbsoluteDestinationPath(tc.conversion.ConvertTo[0], model.Naming{})
 				mockVideoConverter.On(
 					"Convert",
+					mock.Anything,
 					src,
 					dest,
 					mock.Anything,
@@ -191,10 +262,11 @@ func TestConverterService(t *testing.T) {
 			mockVideoConverter: func(tc *testcase) *converterMocks.MockVideoConverter {
 				mockVideoConverter := converterMocks.NewMockVideoConverter(t)
 				src, _ := tc.conversion.AbsoluteSourcePath()
-				destVP9, _ := tc.conversion.AbsoluteDestinationPath(tc.conversion.ConvertTo[0])
-				destAV1, _ := tc.conversion.AbsoluteDestinationPath(tc.conversion.ConvertTo[1])
+				destVP9, _ := tc.conversion.AbsoluteDestinationPath(tc.conversion.ConvertTo[0], model.Naming{})
+				destAV1, _ := tc.conversion.AbsoluteDestinationPath(tc.conversion.ConvertTo[1], model.Naming{})
 				mockVideoConverter.On(
 					"Convert",
+					mock.Anything,
 					src,
 					destVP9,
 					converter.ConversionConfig{
@@ -205,6 +277,7 @@ func TestConverterService(t *testing.T) {
 				).Return(nil).Once()
 				mockVideoConverter.On(
 					"Convert",
+					mock.Anything,
 					src,
 					destAV1,
 					converter.ConversionConfig{
@@ -251,10 +324,11 @@ func TestConverterService(t *testing.T) {
 			mockVideoConverter: func(tc *testcase) *converterMocks.MockVideoConverter {
 				mockVideoConverter := converterMocks.NewMockVideoConverter(t)
 				src, _ := tc.conversion.AbsoluteSourcePath()
-				destVP9, _ := tc.conversion.AbsoluteDestinationPath(tc.conversion.ConvertTo[0])
-				destAV1, _ := tc.conversion.AbsoluteDestinationPath(tc.conversion.ConvertTo[1])
+				destVP9, _ := tc.conversion.AbsoluteDestinationPath(tc.conversion.ConvertTo[0], model.Naming{})
+				destAV1, _ := tc.conversion.AbsoluteDestinationPath(tc.conversion.ConvertTo[1], model.Naming{})
 				mockVideoConverter.On(
 					"Convert",
+					mock.Anything,
 					src,
 					destVP9,
 					converter.ConversionConfig{
@@ -265,6 +339,7 @@ func TestConverterService(t *testing.T) {
 				).Return(nil).Once()
 				mockVideoConverter.On(
 					"Convert",
+					mock.Anything,
 					src,
 					destAV1,
 					converter.ConversionConfig{
@@ -293,6 +368,9 @@ func TestConverterService(t *testing.T) {
 				logger,
 				mockImageConverter,
 				mockVideoConverter,
+				newConversionQueueService(t),
+				newDiskGuard(t),
+				local.NewStorage(""),
 			)
 
 			err := serv.Convert(ctx, tc.conversion)
@@ -300,6 +378,7 @@ func TestConverterService(t *testing.T) {
 				assert.Equal(t, err.Error(), tc.err)
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, tc.qualityResults, tc.conversion.QualityResults)
 			}
 
 			mockImageConverter.AssertExpectations(t)
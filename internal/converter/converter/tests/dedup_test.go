@@ -0,0 +1,237 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/converter"
+	converterService "github.com/chistyakoviv/converter/internal/converter/converter"
+	converterMocks "github.com/chistyakoviv/converter/internal/converter/mocks"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	serviceMocks "github.com/chistyakoviv/converter/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConverterServiceCopiesDuplicates(t *testing.T) {
+	ctx := context.Background()
+
+	source, err := os.ReadFile("files/images/gen.jpg")
+	require.NoError(t, err)
+	sum := sha256.Sum256(source)
+	hash := hex.EncodeToString(sum[:])
+
+	type testcase struct {
+		name string
+		// Target formats of the duplicate
+		convertTo []model.ConvertTo
+		// Template of the converted files, the default one if empty
+		template string
+		// Digests of the settings the duplicate was converted with, the current ones if nil
+		digests     map[string]string
+		files       map[string][]byte
+		converted   bool
+		expected    map[string][]byte
+		dedupOrigin string
+	}
+
+	origin := &model.Conversion{
+		Fullpath: "/files/uploads/gen.jpg",
+		Path:     "/files/uploads",
+		Filestem: "gen",
+		Ext:      "jpg",
+		ConvertTo: []model.ConvertTo{
+			{
+				Ext:      "webp",
+				ConvConf: map[string]interface{}{"Q": float64(80)},
+			},
+		},
+		Status:     model.ConversionStatusDone,
+		SourceHash: hash,
+		QualityResults: []model.QualityResult{
+			{Key: "webp", Metric: "ssimulacra2", Quality: 72, Score: 80.5},
+		},
+	}
+	// The defaults give no settings for webp, so the duplicate was converted with its own ones
+	originDigest := converter.ConversionConfig{"Q": 80}.Digest()
+	origin.ConfigDigests = map[string]string{"webp": originDigest}
+
+	cases := []testcase{
+		{
+			name: "Converted file is copied",
+			convertTo: []model.ConvertTo{
+				{
+					Ext:      "webp",
+					ConvConf: map[string]interface{}{"Q": 80},
+				},
+			},
+			files: map[string][]byte{
+				"/files/uploads/gen.jpg.webp": []byte("origin"),
+			},
+			expected: map[string][]byte{
+				"/files/images/gen.jpg.webp": []byte("origin"),
+			},
+			dedupOrigin: origin.Fullpath,
+		},
+		{
+			name: "Different settings are converted",
+			convertTo: []model.ConvertTo{
+				{
+					Ext:      "webp",
+					ConvConf: map[string]interface{}{"Q": 60},
+				},
+			},
+			files: map[string][]byte{
+				"/files/uploads/gen.jpg.webp": []byte("origin"),
+			},
+			converted: true,
+			expected: map[string][]byte{
+				"/files/images/gen.jpg.webp": []byte("converted"),
+			},
+		},
+		{
+			name: "Settings changed since the duplicate was converted",
+			convertTo: []model.ConvertTo{
+				{
+					Ext:      "webp",
+					ConvConf: map[string]interface{}{"Q": 80},
+				},
+			},
+			digests: map[string]string{"webp": converter.ConversionConfig{"Q": 80, "lossless": true}.Digest()},
+			files: map[string][]byte{
+				"/files/uploads/gen.jpg.webp": []byte("origin"),
+			},
+			converted: true,
+			expected: map[string][]byte{
+				"/files/images/gen.jpg.webp": []byte("converted"),
+			},
+		},
+		{
+			name: "Duplicate converted before the settings were recorded is not copied",
+			convertTo: []model.ConvertTo{
+				{
+					Ext:      "webp",
+					ConvConf: map[string]interface{}{"Q": 80},
+				},
+			},
+			digests: map[string]string{},
+			files: map[string][]byte{
+				"/files/uploads/gen.jpg.webp": []byte("origin"),
+			},
+			converted: true,
+			expected: map[string][]byte{
+				"/files/images/gen.jpg.webp": []byte("converted"),
+			},
+		},
+		{
+			name: "Removed converted file is converted again",
+			convertTo: []model.ConvertTo{
+				{
+					Ext:      "webp",
+					ConvConf: map[string]interface{}{"Q": 80},
+				},
+			},
+			files:     map[string][]byte{},
+			converted: true,
+			expected: map[string][]byte{
+				"/files/images/gen.jpg.webp": []byte("converted"),
+			},
+		},
+		{
+			name: "File named after the content is shared",
+			convertTo: []model.ConvertTo{
+				{
+					Ext:      "webp",
+					ConvConf: map[string]interface{}{"Q": 80},
+				},
+			},
+			template: "{hash}.{fmt}",
+			files: map[string][]byte{
+				"/public/" + hash[:8] + ".webp": []byte("origin"),
+			},
+			expected: map[string][]byte{
+				"/public/" + hash[:8] + ".webp": []byte("origin"),
+			},
+			dedupOrigin: origin.Fullpath,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			conversion := &model.Conversion{
+				Fullpath:   "/files/images/gen.jpg",
+				Path:       "/files/images",
+				Filestem:   "gen",
+				Ext:        "jpg",
+				ConvertTo:  tc.convertTo,
+				SourceHash: hash,
+			}
+			remote := &memStorage{files: map[string][]byte{conversion.Fullpath: source}}
+			for key, data := range tc.files {
+				remote.files[key] = data
+			}
+
+			mockImageConverter := converterMocks.NewMockImageConverter(t)
+			if tc.converted {
+				mockImageConverter.On("Convert", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.Anything).
+					Run(func(args mock.Arguments) {
+						require.NoError(t, os.WriteFile(args.String(1), []byte("converted"), 0o600))
+					}).
+					Return(nil, nil).
+					Once()
+			}
+
+			duplicate := *origin
+			if tc.digests != nil {
+				duplicate.ConfigDigests = tc.digests
+			}
+
+			mockConversionQueueService := serviceMocks.NewMockConversionQueueService(t)
+			mockConversionQueueService.On("FindDuplicates", ctx, hash).
+				Return([]*model.Conversion{conversion, &duplicate}, nil).
+				Once()
+
+			cfg := config.MustLoad(&config.ConfigOptions{
+				ConfigPath:   "config/local.yaml",
+				DefaultsPath: "config/defaults.yaml",
+			})
+			if tc.template != "" {
+				cfg.Output.Root = "public"
+				cfg.Output.Template = tc.template
+			}
+
+			serv, _ := converterService.NewService(
+				cfg,
+				dummy.NewDummyLogger(),
+				mockImageConverter,
+				converterMocks.NewMockVideoConverter(t),
+				mockConversionQueueService,
+				newDiskGuard(t),
+				remote,
+			)
+
+			require.NoError(t, serv.Convert(ctx, conversion))
+
+			for key, data := range tc.expected {
+				assert.Equal(t, data, remote.files[key], key)
+			}
+			assert.Equal(t, tc.dedupOrigin, conversion.DedupOrigin)
+			// The settings are recorded whether the file is copied or converted
+			assert.Equal(t, map[string]string{"webp": converter.ConversionConfig(tc.convertTo[0].ConvConf).Digest()}, conversion.ConfigDigests)
+			if tc.dedupOrigin != "" {
+				assert.Equal(t, origin.QualityResults, conversion.QualityResults)
+			}
+
+			mockImageConverter.AssertExpectations(t)
+			mockConversionQueueService.AssertExpectations(t)
+		})
+	}
+}
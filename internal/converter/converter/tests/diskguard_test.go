@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	converterService "github.com/chistyakoviv/converter/internal/converter/converter"
+	converterMocks "github.com/chistyakoviv/converter/internal/converter/mocks"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service"
+	serviceMocks "github.com/chistyakoviv/converter/internal/service/mocks"
+	"github.com/chistyakoviv/converter/internal/storage/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConverterServiceChecksDiskSpace(t *testing.T) {
+	ctx := context.Background()
+
+	source, err := os.ReadFile("files/images/gen.jpg")
+	require.NoError(t, err)
+	size := uint64(len(source))
+
+	type testcase struct {
+		name string
+		// Result of the check of the given size, the sizes not listed fit
+		checks map[uint64]error
+	}
+
+	cases := []testcase{
+		{
+			name:   "Source does not fit",
+			checks: map[uint64]error{size: errors.New("insufficient disk space")},
+		},
+		{
+			name:   "Output does not fit",
+			checks: map[uint64]error{size: nil, 2 * size: errors.New("insufficient disk space")},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			conversion := &model.Conversion{
+				Fullpath:  "/files/images/gen.jpg",
+				Path:      "/files/images",
+				Filestem:  "gen",
+				Ext:       "jpg",
+				ConvertTo: []model.ConvertTo{{Ext: "webp"}},
+			}
+			remote := &memStorage{files: map[string][]byte{conversion.Fullpath: source}}
+
+			mockDiskGuard := serviceMocks.NewMockDiskGuardService(t)
+			for checked, err := range tc.checks {
+				mockDiskGuard.On("Check", checked).Return(err).Once()
+			}
+
+			cfg := config.MustLoad(&config.ConfigOptions{
+				ConfigPath:   "config/local.yaml",
+				DefaultsPath: "config/defaults.yaml",
+			})
+			// The converted files are estimated to be twice as large as the sources
+			cfg.Disk.OutputRatio = 2
+
+			// Nothing is converted if the space is insufficient
+			serv, _ := converterService.NewService(
+				cfg,
+				dummy.NewDummyLogger(),
+				converterMocks.NewMockImageConverter(t),
+				converterMocks.NewMockVideoConverter(t),
+				newConversionQueueService(t),
+				mockDiskGuard,
+				remote,
+			)
+
+			err := serv.Convert(ctx, conversion)
+			require.Error(t, err)
+			assert.Equal(t, service.ErrInsufficientDiskSpace, service.GetConverterError(err).Code())
+			assert.NotContains(t, remote.files, "/files/images/gen.jpg.webp")
+
+			mockDiskGuard.AssertExpectations(t)
+		})
+	}
+}
+
+func TestConverterServiceStopsVideoOnLowDiskSpace(t *testing.T) {
+	ctx := context.Background()
+
+	conversion := &model.Conversion{
+		Fullpath:  "/files/videos/gen.mp4",
+		Path:      "/files/videos",
+		Filestem:  "gen",
+		Ext:       "mp4",
+		ConvertTo: []model.ConvertTo{{Ext: "webm"}},
+	}
+
+	// The space is checked before the format and runs low while the video is encoded
+	mockDiskGuard := serviceMocks.NewMockDiskGuardService(t)
+	mockDiskGuard.On("Check", mock.MatchedBy(func(size uint64) bool { return size > 0 })).Return(nil).Once()
+	mockDiskGuard.On("Check", uint64(0)).Return(errors.New("insufficient disk space")).Once()
+
+	mockVideoConverter := converterMocks.NewMockVideoConverter(t)
+	mockVideoConverter.On("Convert", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.Anything).
+		Run(func(args mock.Arguments) {
+			<-args.Get(0).(context.Context).Done()
+		}).
+		Return(errors.New("signal: killed")).
+		Once()
+
+	cfg := config.MustLoad(&config.ConfigOptions{
+		ConfigPath:   "config/local.yaml",
+		DefaultsPath: "config/defaults.yaml",
+	})
+	cfg.Disk.CheckInterval = 10 * time.Millisecond
+
+	serv, _ := converterService.NewService(
+		cfg,
+		dummy.NewDummyLogger(),
+		converterMocks.NewMockImageConverter(t),
+		mockVideoConverter,
+		newConversionQueueService(t),
+		mockDiskGuard,
+		local.NewStorage(""),
+	)
+
+	err := serv.Convert(ctx, conversion)
+	require.Error(t, err)
+	// The conversion is postponed instead of being canceled
+	assert.Equal(t, service.ErrInsufficientDiskSpace, service.GetConverterError(err).Code())
+
+	mockDiskGuard.AssertExpectations(t)
+	mockVideoConverter.AssertExpectations(t)
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	converterService "github.com/chistyakoviv/converter/internal/converter/converter"
+	converterMocks "github.com/chistyakoviv/converter/internal/converter/mocks"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConverterServiceNamesOutputsAfterHash(t *testing.T) {
+	ctx := context.Background()
+
+	source, err := os.ReadFile("files/images/gen.jpg")
+	require.NoError(t, err)
+	sum := sha256.Sum256(source)
+	hash := hex.EncodeToString(sum[:])
+
+	previousHash := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	previousOutput := "/public/converted/images/gen.01234567.webp"
+	conversion := &model.Conversion{
+		Fullpath: "/files/images/gen.jpg",
+		Path:     "/files/images",
+		Filestem: "gen",
+		Ext:      "jpg",
+		ConvertTo: []model.ConvertTo{
+			{
+				Ext:      "webp",
+				Optional: map[string]interface{}{"template": "{dir}/{stem}.{hash}.{fmt}"},
+			},
+		},
+		SourceHash: previousHash,
+	}
+	remote := &memStorage{files: map[string][]byte{
+		conversion.Fullpath: source,
+		previousOutput:      []byte("previous"),
+	}}
+
+	mockImageConverter := converterMocks.NewMockImageConverter(t)
+	mockImageConverter.On("Convert", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.Anything).
+		Run(func(args mock.Arguments) {
+			require.NoError(t, os.WriteFile(args.String(1), []byte("converted"), 0o600))
+		}).
+		Return(nil, nil).
+		Once()
+
+	// The previous output is not produced by other sources
+	mockConversionQueueService := newConversionQueueService(t)
+	mockConversionQueueService.On("IsOutputShared", ctx, mock.AnythingOfType("*model.Conversion"), previousOutput).Return(false, nil).Once()
+
+	cfg := config.MustLoad(&config.ConfigOptions{
+		ConfigPath:   "config/local.yaml",
+		DefaultsPath: "config/defaults.yaml",
+	})
+	cfg.Output.Root = "public/converted"
+
+	serv, _ := converterService.NewService(
+		cfg,
+		dummy.NewDummyLogger(),
+		mockImageConverter,
+		converterMocks.NewMockVideoConverter(t),
+		mockConversionQueueService,
+		newDiskGuard(t),
+		remote,
+	)
+
+	require.NoError(t, serv.Convert(ctx, conversion))
+
+	assert.Equal(t, hash, conversion.SourceHash)
+	assert.Equal(t, []byte("converted"), remote.files["/public/converted/images/gen."+hash[:8]+".webp"])
+	// The output named after the previous content is replaced
+	assert.NotContains(t, remote.files, previousOutput)
+
+	mockImageConverter.AssertExpectations(t)
+	mockConversionQueueService.AssertExpectations(t)
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/converter"
+	converterService "github.com/chistyakoviv/converter/internal/converter/converter"
+	converterMocks "github.com/chistyakoviv/converter/internal/converter/mocks"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConverterServiceKeepsDefaultsOfStartedConversion(t *testing.T) {
+	ctx := context.Background()
+
+	source, err := os.ReadFile("files/images/gen.jpg")
+	require.NoError(t, err)
+
+	cfg := config.MustLoad(&config.ConfigOptions{
+		ConfigPath:   "config/local.yaml",
+		DefaultsPath: "config/defaults.yaml",
+	})
+	reloaded := &config.Defaults{
+		Image: config.ImageDefaults{Formats: []model.ConvertTo{
+			{Ext: "webp", ConvConf: map[string]interface{}{"quality": 50}},
+			{Ext: "avif", ConvConf: map[string]interface{}{"quality": 40}},
+		}},
+	}
+
+	var confs []converter.ConversionConfig
+	mockImageConverter := converterMocks.NewMockImageConverter(t)
+	mockImageConverter.On("Convert", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.Anything).
+		Run(func(args mock.Arguments) {
+			// The defaults are reloaded while the first conversion is running
+			if len(confs) == 0 {
+				cfg.SwapDefaults(reloaded)
+			}
+			confs = append(confs, args.Get(2).(converter.ConversionConfig))
+			require.NoError(t, os.WriteFile(args.String(1), []byte("converted"), 0o600))
+		}).
+		Return(nil, nil)
+
+	serv, _ := converterService.NewService(
+		cfg,
+		dummy.NewDummyLogger(),
+		mockImageConverter,
+		converterMocks.NewMockVideoConverter(t),
+		converterService.NoDuplicates(),
+		newDiskGuard(t),
+		&memStorage{files: map[string][]byte{"/files/images/gen.jpg": source}},
+	)
+
+	newConversion := func() *model.Conversion {
+		return &model.Conversion{
+			Fullpath:  "/files/images/gen.jpg",
+			Path:      "/files/images",
+			Filestem:  "gen",
+			Ext:       "jpg",
+			ConvertTo: []model.ConvertTo{{Ext: "webp"}, {Ext: "avif"}},
+		}
+	}
+
+	require.NoError(t, serv.Convert(ctx, newConversion()))
+	require.NoError(t, serv.Convert(ctx, newConversion()))
+
+	require.Len(t, confs, 4)
+	// The started conversion finishes with the defaults loaded on startup
+	assert.NotContains(t, confs[0], "quality")
+	assert.NotContains(t, confs[1], "quality")
+	// The next one uses the reloaded defaults
+	assert.Equal(t, 50, confs[2]["quality"])
+	assert.Equal(t, 40, confs[3]["quality"])
+}
@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	converterService "github.com/chistyakoviv/converter/internal/converter/converter"
+	converterMocks "github.com/chistyakoviv/converter/internal/converter/mocks"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Keeps files in memory, so they are not accessible to the converters directly
+type memStorage struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func (s *memStorage) Stat(ctx context.Context, key string) (*storage.Object, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.files[key]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", key, storage.ErrNotExist)
+	}
+	return &storage.Object{Key: key, Size: int64(len(data))}, nil
+}
+
+func (s *memStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.files[key]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", key, storage.ErrNotExist)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memStorage) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.files[key] = data
+	return nil
+}
+
+func (s *memStorage) Remove(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.files, key)
+	return nil
+}
+
+func TestConverterServiceWithRemoteStorage(t *testing.T) {
+	ctx := context.Background()
+
+	source, err := os.ReadFile("files/images/gen.jpg")
+	require.NoError(t, err)
+
+	conversion := &model.Conversion{
+		Fullpath: "/files/images/gen.jpg",
+		Path:     "/files/images",
+		Filestem: "gen",
+		Ext:      "jpg",
+		ConvertTo: []model.ConvertTo{
+			{
+				Ext: "webp",
+			},
+		},
+	}
+	remote := &memStorage{files: map[string][]byte{conversion.Fullpath: source}}
+
+	var localSrc, localDest string
+	mockImageConverter := converterMocks.NewMockImageConverter(t)
+	mockImageConverter.On("Convert", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.Anything).
+		Run(func(args mock.Arguments) {
+			localSrc, localDest = args.String(0), args.String(1)
+
+			// The source is downloaded before the conversion
+			data, err := os.ReadFile(localSrc)
+			require.NoError(t, err)
+			assert.Equal(t, source, data)
+
+			require.NoError(t, os.WriteFile(localDest, []byte("converted"), 0o600))
+		}).
+		Return(nil, nil).
+		Once()
+
+	serv, _ := converterService.NewService(
+		config.MustLoad(&config.ConfigOptions{
+			ConfigPath:   "config/local.yaml",
+			DefaultsPath: "config/defaults.yaml",
+		}),
+		dummy.NewDummyLogger(),
+		mockImageConverter,
+		converterMocks.NewMockVideoConverter(t),
+		newConversionQueueService(t),
+		newDiskGuard(t),
+		remote,
+	)
+
+	require.NoError(t, serv.Convert(ctx, conversion))
+
+	assert.Equal(t, "gen.jpg.webp", filepath.Base(localDest))
+	assert.Equal(t, []byte("converted"), remote.files["/files/images/gen.jpg.webp"])
+
+	// Local copies are removed after the conversion
+	assert.NoFileExists(t, localSrc)
+	assert.NoFileExists(t, localDest)
+
+	mockImageConverter.AssertExpectations(t)
+}
@@ -1,14 +1,18 @@
 package ffmpeggo
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
+	"os/exec"
+	"time"
 
 	"github.com/chistyakoviv/converter/internal/config"
 	"github.com/chistyakoviv/converter/internal/converter"
 	"github.com/chistyakoviv/converter/internal/file"
 	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/metrics"
 	ffmpeg "github.com/u2takey/ffmpeg-go"
 )
 
@@ -24,11 +28,26 @@ func NewVideoConverter(cfg *config.Config, logger *slog.Logger) converter.VideoC
 	}
 }
 
-func (c *conv) Convert(from string, to string, conf converter.ConversionConfig) error {
+func (c *conv) Convert(ctx context.Context, from string, to string, conf converter.ConversionConfig) (err error) {
 	const op = "ffmpeg-go.Convert"
 
+	start := time.Now()
+	defer func() {
+		metrics.ObserveConversion(metrics.ConverterVideo, from, to, start, err)
+	}()
+
 	logger := c.logger.With(slog.String("op", op))
 
+	overlay, conf, err := converter.ExtractOverlay(conf, c.cfg.Overlays)
+	if err != nil {
+		return fmt.Errorf("failed to convert video: %w", err)
+	}
+
+	resize, conf, err := converter.ExtractResize(conf)
+	if err != nil {
+		return fmt.Errorf("failed to convert video: %w", err)
+	}
+
 	// Build args
 	args := ffmpeg.KwArgs{}
 
@@ -36,15 +55,39 @@ func (c *conv) Convert(from string, to string, conf converter.ConversionConfig)
 		args[key] = value
 	}
 
+	// The overlay is appended last, so it is placed relative to the resized video
+	if resize != nil {
+		if err := withResize(args, resize); err != nil {
+			return fmt.Errorf("failed to convert video: %w", err)
+		}
+	}
+
+	if overlay != nil {
+		if err := withOverlay(args, overlay); err != nil {
+			return fmt.Errorf("failed to convert video: %w", err)
+		}
+	}
+
 	args["threads"] = c.cfg.Video.Threads
 
 	tmpFile := file.ToTmpFilePath(to)
+	defer func() {
+		// A failed encode leaves a partial file, e.g. once the disk is full
+		if err != nil {
+			_ = os.Remove(tmpFile)
+		}
+	}()
 
 	// Build and run the FFmpeg command
-	err := ffmpeg.Input(from).
+	compiled := ffmpeg.Input(from).
 		Output(tmpFile, args).
 		OverWriteOutput(). // Overwrite the output file if it already exists
-		Run()
+		Compile()
+
+	// The command is bound to the context, so a stopped conversion kills FFmpeg
+	cmd := exec.CommandContext(ctx, compiled.Path, compiled.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = compiled.Stdin, compiled.Stdout, compiled.Stderr
+	err = cmd.Run()
 
 	if err != nil {
 		logger.Debug("failed to convert video", slog.String("from", from), slog.String("to", to), slogger.Err(err))
@@ -64,4 +107,14 @@ func (c *conv) Convert(from string, to string, conf converter.ConversionConfig)
 	return nil
 }
 
+// Checks that the binaries invoked by ffmpeg-go are callable
+func (c *conv) Check(ctx context.Context) error {
+	for _, bin := range []string{"ffmpeg", "ffprobe"} {
+		if err := exec.CommandContext(ctx, bin, "-version").Run(); err != nil {
+			return fmt.Errorf("failed to run %s: %w", bin, err)
+		}
+	}
+	return nil
+}
+
 func (c *conv) Shutdown() {}
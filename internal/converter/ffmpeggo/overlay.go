@@ -0,0 +1,134 @@
+package ffmpeggo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/chistyakoviv/converter/internal/converter"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// Keys of the video filter option, the long form is an alias of vf
+var videoFilterKeys = []string{"vf", "filter:v"}
+
+// Escapes a filter option value and then the filtergraph description containing it,
+// see https://ffmpeg.org/ffmpeg-filters.html#Notes-on-filtergraph-escaping
+var (
+	optionEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`, `:`, `\:`)
+	graphEscaper  = strings.NewReplacer(`\`, `\\`, `'`, `\'`, `[`, `\[`, `]`, `\]`, `,`, `\,`, `;`, `\;`)
+)
+
+// Appends the overlay to the video filter, so it is applied after any scaling configured in the args.
+func withOverlay(args ffmpeg.KwArgs, overlay *converter.Overlay) error {
+	if _, ok := args["filter_complex"]; ok {
+		return fmt.Errorf("overlay cannot be combined with filter_complex")
+	}
+
+	filters, err := takeVideoFilters(args)
+	if err != nil {
+		return err
+	}
+
+	base := "null"
+	if len(filters) > 0 {
+		base = strings.Join(filters, ",")
+	}
+
+	if overlay.IsText() {
+		args["vf"] = base + "," + drawTextFilter(overlay)
+	} else {
+		args["vf"] = overlayGraph(base, overlay)
+	}
+
+	return nil
+}
+
+// Removes the video filter from the args and returns its filters
+func takeVideoFilters(args ffmpeg.KwArgs) ([]string, error) {
+	var filters []string
+	for _, key := range videoFilterKeys {
+		value, ok := args[key]
+		if !ok {
+			continue
+		}
+		filter, isStr := value.(string)
+		if !isStr {
+			return nil, fmt.Errorf("%s must be a string", key)
+		}
+		if filter != "" {
+			filters = append(filters, filter)
+		}
+		delete(args, key)
+	}
+	return filters, nil
+}
+
+// Builds a filtergraph that reads the watermark with the movie source and composes it over the scaled video
+func overlayGraph(base string, overlay *converter.Overlay) string {
+	mark := "movie=" + escape(overlay.Asset.Image) + ",format=rgba"
+	if overlay.Opacity < 1 {
+		mark += ",colorchannelmixer=aa=" + formatFloat(overlay.Opacity)
+	}
+
+	horizontal, vertical := overlay.Alignment()
+	position := fmt.Sprintf("overlay=x=%s:y=%s:format=auto",
+		alignExpr(horizontal, "main_w", "overlay_w", overlay.Margin),
+		alignExpr(vertical, "main_h", "overlay_h", overlay.Margin),
+	)
+
+	if overlay.Scale == 0 {
+		return fmt.Sprintf("%s[base];%s[mark];[base][mark]%s", base, mark, position)
+	}
+
+	// Scale the watermark relative to the width of the video it is composed over
+	scale := fmt.Sprintf("scale2ref=w=main_w*%s:h=ow/a", formatFloat(overlay.Scale))
+	return fmt.Sprintf("%s[base];%s[mark];[mark][base]%s[scaled][ref];[ref][scaled]%s",
+		base, mark, scale, position)
+}
+
+func drawTextFilter(overlay *converter.Overlay) string {
+	horizontal, vertical := overlay.Alignment()
+
+	options := []string{
+		"text=" + escape(overlay.Asset.Text),
+		"expansion=none",
+		"fontsize=h*" + formatFloat(overlay.TextScale()),
+		"fontcolor=" + textColor(overlay),
+		"x=" + alignExpr(horizontal, "w", "text_w", overlay.Margin),
+		"y=" + alignExpr(vertical, "h", "text_h", overlay.Margin),
+	}
+	if overlay.Asset.FontFile != "" {
+		options = append(options, "fontfile="+escape(overlay.Asset.FontFile))
+	} else if overlay.Asset.Font != "" {
+		options = append(options, "font="+escape(overlay.Asset.Font))
+	}
+
+	return "drawtext=" + strings.Join(options, ":")
+}
+
+func textColor(overlay *converter.Overlay) string {
+	// The asset color is validated when the config is loaded
+	r, g, b, _ := overlay.Asset.RGB()
+	return fmt.Sprintf("0x%02x%02x%02x@%s", r, g, b, formatFloat(overlay.Opacity))
+}
+
+// Returns an expression that places an overlay of the given size within the video
+func alignExpr(alignment converter.Alignment, size, overlaySize string, margin int) string {
+	switch alignment {
+	case converter.AlignCenter:
+		return fmt.Sprintf("(%s-%s)/2", size, overlaySize)
+	case converter.AlignEnd:
+		return fmt.Sprintf("%s-%s-%d", size, overlaySize, margin)
+	default:
+		return strconv.Itoa(margin)
+	}
+}
+
+func escape(value string) string {
+	return graphEscaper.Replace(optionEscaper.Replace(value))
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,38 @@
+package ffmpeggo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chistyakoviv/converter/internal/converter"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// Appends the scaling to the video filter, so the video is resized after the filters configured in the args.
+func withResize(args ffmpeg.KwArgs, resize *converter.Resize) error {
+	if _, ok := args["filter_complex"]; ok {
+		return fmt.Errorf("width and height cannot be combined with filter_complex")
+	}
+
+	filters, err := takeVideoFilters(args)
+	if err != nil {
+		return err
+	}
+	args["vf"] = strings.Join(append(filters, scaleFilter(resize)), ",")
+
+	return nil
+}
+
+// Builds a scale filter that downscales the video to fit the box, the other dimension
+// keeps the aspect ratio and stays even, as most encoders require
+func scaleFilter(resize *converter.Resize) string {
+	switch {
+	case resize.Height == 0:
+		return fmt.Sprintf("scale=w='min(%d,iw)':h=-2", resize.Width)
+	case resize.Width == 0:
+		return fmt.Sprintf("scale=w=-2:h='min(%d,ih)'", resize.Height)
+	default:
+		return fmt.Sprintf("scale=w='min(%d,iw)':h='min(%d,ih)':force_original_aspect_ratio=decrease:force_divisible_by=2",
+			resize.Width, resize.Height)
+	}
+}
@@ -1,12 +1,15 @@
 package tests
 
 import (
+	"context"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/chistyakoviv/converter/internal/config"
 	"github.com/chistyakoviv/converter/internal/converter"
 	"github.com/chistyakoviv/converter/internal/converter/ffmpeggo"
+	"github.com/chistyakoviv/converter/internal/file"
 	"github.com/chistyakoviv/converter/internal/logger/dummy"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,6 +24,9 @@ func TestImageConverter(t *testing.T) {
 		videoConf      = config.Video{
 			Threads: 4,
 		}
+		overlays = map[string]config.Overlay{
+			"logo": {Image: "files/images/logo.png"},
+		}
 	)
 
 	// #nosec G301 -- this is test code and wide permissions are intentional
@@ -59,6 +65,71 @@ func TestImageConverter(t *testing.T) {
 				"crf": "40",
 			},
 		},
+		{
+			name: "Convert mp4 to mp4 with scaling and watermark image",
+			from: filesDir + "/gen.mp4",
+			to:   filesOutputDir + "/gen-mp4-to-mp4_overlay.mp4",
+			conf: converter.ConversionConfig{
+				"c:v": "libx264",
+				"crf": "40",
+				"vf":  "scale=320:-2",
+				"overlay": map[string]interface{}{
+					"name":    "logo",
+					"gravity": "north-west",
+					"margin":  8,
+					"opacity": 0.5,
+					"scale":   0.2,
+				},
+			},
+		},
+		{
+			name: "Convert mp4 to mp4 fitting into a box with watermark image",
+			from: filesDir + "/gen.mp4",
+			to:   filesOutputDir + "/gen-mp4-to-mp4_resized.mp4",
+			conf: converter.ConversionConfig{
+				"c:v":    "libx264",
+				"crf":    "40",
+				"vf":     "fps=24",
+				"width":  320,
+				"height": 240,
+				"overlay": map[string]interface{}{
+					"name": "logo",
+				},
+			},
+		},
+		{
+			name: "Unknown overlay asset",
+			from: filesDir + "/gen.mp4",
+			to:   filesOutputDir + "/gen-mp4-to-mp4_unknown_overlay.mp4",
+			err:  "failed to convert video: overlay: invalid overlay: unknown asset 'missing'",
+			conf: converter.ConversionConfig{
+				"overlay": map[string]interface{}{
+					"name": "missing",
+				},
+			},
+		},
+		{
+			name: "Overlay with filter_complex",
+			from: filesDir + "/gen.mp4",
+			to:   filesOutputDir + "/gen-mp4-to-mp4_filter_complex.mp4",
+			err:  "failed to convert video: overlay cannot be combined with filter_complex",
+			conf: converter.ConversionConfig{
+				"filter_complex": "[0:v]null",
+				"overlay": map[string]interface{}{
+					"name": "logo",
+				},
+			},
+		},
+		{
+			name: "Resize with filter_complex",
+			from: filesDir + "/gen.mp4",
+			to:   filesOutputDir + "/gen-mp4-to-mp4_resize_filter_complex.mp4",
+			err:  "failed to convert video: width and height cannot be combined with filter_complex",
+			conf: converter.ConversionConfig{
+				"filter_complex": "[0:v]null",
+				"width":          320,
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -68,8 +139,9 @@ func TestImageConverter(t *testing.T) {
 			t.Parallel()
 
 			cfg := &config.Config{
-				Env:   env,
-				Video: videoConf,
+				Env:      env,
+				Video:    videoConf,
+				Overlays: overlays,
 			}
 
 			t.Cleanup(func() {
@@ -80,7 +152,7 @@ func TestImageConverter(t *testing.T) {
 
 			converter := ffmpeggo.NewVideoConverter(cfg, logger)
 
-			err := converter.Convert(tc.from, tc.to, tc.conf)
+			err := converter.Convert(context.Background(), tc.from, tc.to, tc.conf)
 			if tc.err != "" {
 				assert.Equal(t, err.Error(), tc.err)
 				return
@@ -92,3 +164,19 @@ func TestImageConverter(t *testing.T) {
 		})
 	}
 }
+
+func TestVideoConverterRemovesPartialFile(t *testing.T) {
+	dir := t.TempDir()
+	to := filepath.Join(dir, "gen.mp4.webm")
+	tmpFile := file.ToTmpFilePath(to)
+
+	// The partial file of an encode that failed midway
+	require.NoError(t, os.WriteFile(tmpFile, []byte("partial"), 0o600))
+
+	converter := ffmpeggo.NewVideoConverter(&config.Config{Env: config.EnvLocal}, dummy.NewDummyLogger())
+
+	err := converter.Convert(context.Background(), filepath.Join(dir, "missing.mp4"), to, nil)
+	require.Error(t, err)
+	assert.NoFileExists(t, tmpFile)
+	assert.NoFileExists(t, to)
+}
@@ -1,5 +1,11 @@
 package converter
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
 type ConversionConfig map[string]interface{}
 
 func MergeConfigs(configs ...ConversionConfig) ConversionConfig {
@@ -11,3 +17,14 @@ func MergeConfigs(configs ...ConversionConfig) ConversionConfig {
 	}
 	return result
 }
+
+// Digest identifies the settings, it is empty if they cannot be encoded.
+// Settings read from the database and from the config differ in the types of numbers, so they are digested as JSON.
+func (c ConversionConfig) Digest() string {
+	encoded, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
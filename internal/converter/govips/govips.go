@@ -1,24 +1,50 @@
 package govips
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"os"
+	"sync/atomic"
+	"time"
 
 	"github.com/chistyakoviv/converter/internal/config"
 	"github.com/chistyakoviv/converter/internal/converter"
 	"github.com/chistyakoviv/converter/internal/file"
 	"github.com/chistyakoviv/converter/internal/lib/mapper"
 	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/lib/ssim"
+	"github.com/chistyakoviv/converter/internal/metrics"
+	"github.com/chistyakoviv/converter/internal/model"
 	"github.com/davidbyttow/govips/v2/vips"
 )
 
 const (
 	filePermissions = 0644
+	// Images are downscaled to about this number of pixels before scoring
+	scoreMaxPixels = 1024 * 1024
 )
 
+// EXIF fields kept by the copyright metadata policy
+var copyrightFields = []string{
+	"exif-ifd0-Copyright",
+	"exif-ifd0-Artist",
+}
+
+var textAlignments = map[converter.Alignment]vips.Align{
+	converter.AlignStart:  vips.AlignLow,
+	converter.AlignCenter: vips.AlignCenter,
+	converter.AlignEnd:    vips.AlignHigh,
+}
+
 type conv struct {
-	logger *slog.Logger
+	// libvips cannot be restarted once it is shut down
+	running  atomic.Bool
+	logger   *slog.Logger
+	metadata converter.MetadataOptions
+	overlays map[string]config.Overlay
 }
 
 func NewImageConverter(cfg *config.Config, logger *slog.Logger) converter.ImageConverter {
@@ -87,154 +113,458 @@ func NewImageConverter(cfg *config.Config, logger *slog.Logger) converter.ImageC
 	}
 	vips.Startup(conf)
 
-	return &conv{
-		logger: logger,
+	c := &conv{
+		logger:   logger,
+		metadata: converter.MetadataOptionsFromConfig(cfg.Image.Metadata),
+		overlays: cfg.Overlays,
 	}
+	c.running.Store(true)
+
+	return c
 }
 
-func (c *conv) Convert(from string, to string, conf converter.ConversionConfig) error {
+// Checks that libvips is running by creating a tiny image
+func (c *conv) Check(_ context.Context) error {
+	if !c.running.Load() {
+		return wrapError(errors.New("libvips is not running"))
+	}
+
+	img, err := vips.Black(1, 1)
+	if err != nil {
+		return wrapError(err)
+	}
+	img.Close()
+
+	return nil
+}
+
+func (c *conv) Convert(from string, to string, conf converter.ConversionConfig) (_ *model.QualityResult, err error) {
 	const op = "govips.Convert"
 
+	start := time.Now()
+	defer func() {
+		metrics.ObserveConversion(metrics.ConverterImage, from, to, start, err)
+	}()
+
 	logger := c.logger.With(slog.String("op", op))
 	ext := file.Ext(to)
 
 	toTmp := file.ToTmpFilePath(to)
+	defer func() {
+		// A failed write leaves a partial file, e.g. once the disk is full
+		if err != nil {
+			_ = os.Remove(toTmp)
+		}
+	}()
+
+	target, conf, err := converter.ExtractQualityTarget(conf)
+	if err != nil {
+		return nil, wrapError(err)
+	}
 
-	var err error
+	metadata, conf, err := converter.ExtractMetadataOptions(conf, c.metadata)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	overlay, conf, err := converter.ExtractOverlay(conf, c.overlays)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	resize, conf, err := converter.ExtractResize(conf)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	var export exportFunc
 	switch ext {
 	case "jpg", "jpeg":
-		err = c.toJpeg(from, toTmp, conf)
+		export, err = jpegExporter(conf, metadata.Strip())
 	case "png":
-		err = c.toPng(from, toTmp, conf)
+		export, err = pngExporter(conf, metadata.Strip())
 	case "webp":
-		err = c.toWebp(from, toTmp, conf)
+		export, err = webpExporter(conf, metadata.Strip())
 	case "avif":
-		err = c.toAvif(from, toTmp, conf)
+		export, err = avifExporter(conf, metadata.Strip())
 	default:
 		err = fmt.Errorf("unsupported format: %s", ext)
 	}
+	if err != nil {
+		logger.Debug("error", slogger.Err(err))
+		return nil, wrapError(err)
+	}
 
+	image, err := vips.NewImageFromFile(from)
 	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer image.Close()
+
+	if err = applyMetadataOptions(image, metadata); err != nil {
 		logger.Debug("error", slogger.Err(err))
-		return wrapError(err)
+		return nil, wrapError(err)
+	}
+
+	// The image is resized after it is rotated, so the box applies to the displayed orientation
+	if resize != nil {
+		if scale := resize.Scale(image.Width(), image.Height()); scale < 1 {
+			if err = image.Resize(scale, vips.KernelLanczos3); err != nil {
+				logger.Debug("failed to resize image", slogger.Err(err))
+				return nil, wrapError(fmt.Errorf("failed to resize image: %w", err))
+			}
+		}
+	}
+
+	// The overlay is the last stage, so it is placed relative to the final size of the output
+	if overlay != nil {
+		if overlay.IsText() {
+			err = drawText(image, overlay)
+		} else {
+			err = compositeImage(image, overlay)
+		}
+		if err != nil {
+			logger.Debug("failed to apply overlay", slog.String("overlay", overlay.Name), slogger.Err(err))
+			return nil, wrapError(fmt.Errorf("overlay '%s': %w", overlay.Name, err))
+		}
+	}
+
+	var (
+		imageBytes []byte
+		result     *model.QualityResult
+	)
+	if target != nil {
+		imageBytes, result, err = c.searchQuality(image, target, export)
+		if errors.Is(err, converter.ErrQualityNotReached) {
+			// Keep the best output, the score is recorded and can be inspected later
+			logger.Warn("quality target not reached", slog.String("to", to), slogger.Err(err))
+			err = nil
+		}
+	} else {
+		imageBytes, err = export(image, 0)
+	}
+	if err != nil {
+		logger.Debug("error", slogger.Err(err))
+		return nil, wrapError(err)
+	}
+
+	if err = os.WriteFile(toTmp, imageBytes, filePermissions); err != nil {
+		return nil, wrapError(err)
 	}
 
 	if err = os.Remove(to); err != nil && !os.IsNotExist(err) {
-		return wrapError(fmt.Errorf("failed to remove old file: %w", err))
+		return nil, wrapError(fmt.Errorf("failed to remove old file: %w", err))
 	}
 
 	if err = os.Rename(toTmp, to); err != nil {
-		return wrapError(fmt.Errorf("failed to rename tmp file: %w", err))
+		return nil, wrapError(fmt.Errorf("failed to rename tmp file: %w", err))
 	}
 
-	return nil
+	return result, nil
 }
 
-func (c *conv) toJpeg(from string, to string, conf converter.ConversionConfig) error {
-	image, err := vips.NewImageFromFile(from)
-	if err != nil {
-		return err
-	}
+// Encodes the image with the specified quality, a non-positive quality keeps the configured one.
+type exportFunc func(image *vips.ImageRef, quality int) ([]byte, error)
 
+func jpegExporter(conf converter.ConversionConfig, strip bool) (exportFunc, error) {
 	ep := vips.NewJpegExportParams()
 
-	err = mapper.MapToStruct(conf, ep)
-	if err != nil {
-		return err
+	if err := mapper.MapToStruct(conf, ep); err != nil {
+		return nil, err
 	}
+	ep.StripMetadata = ep.StripMetadata || strip
 
-	imageBytes, _, err := image.ExportJpeg(ep)
-	if err != nil {
-		return err
+	return func(image *vips.ImageRef, quality int) ([]byte, error) {
+		params := *ep
+		if quality > 0 {
+			params.Quality = quality
+		}
+		imageBytes, _, err := image.ExportJpeg(&params)
+		return imageBytes, err
+	}, nil
+}
+
+func pngExporter(conf converter.ConversionConfig, strip bool) (exportFunc, error) {
+	ep := vips.NewPngExportParams()
+
+	if err := mapper.MapToStruct(conf, ep); err != nil {
+		return nil, err
 	}
+	ep.StripMetadata = ep.StripMetadata || strip
+
+	return func(image *vips.ImageRef, quality int) ([]byte, error) {
+		params := *ep
+		if quality > 0 {
+			// Quality is only taken into account by the palette quantisation
+			params.Quality = quality
+			params.Palette = true
+		}
+		imageBytes, _, err := image.ExportPng(&params)
+		return imageBytes, err
+	}, nil
+}
 
-	err = os.WriteFile(to, imageBytes, filePermissions)
-	if err != nil {
-		return err
+func webpExporter(conf converter.ConversionConfig, strip bool) (exportFunc, error) {
+	ep := vips.NewWebpExportParams()
+
+	if err := mapper.MapToStruct(conf, ep); err != nil {
+		return nil, err
 	}
+	ep.StripMetadata = ep.StripMetadata || strip
 
-	return nil
+	return func(image *vips.ImageRef, quality int) ([]byte, error) {
+		params := *ep
+		if quality > 0 {
+			params.Quality = quality
+		}
+		imageBytes, _, err := image.ExportWebp(&params)
+		return imageBytes, err
+	}, nil
 }
 
-func (c *conv) toPng(from string, to string, conf converter.ConversionConfig) error {
-	image, err := vips.NewImageFromFile(from)
-	if err != nil {
-		return err
+func avifExporter(conf converter.ConversionConfig, strip bool) (exportFunc, error) {
+	ep := vips.NewAvifExportParams()
+
+	if err := mapper.MapToStruct(conf, ep); err != nil {
+		return nil, err
 	}
+	ep.StripMetadata = ep.StripMetadata || strip
 
-	ep := vips.NewPngExportParams()
+	return func(image *vips.ImageRef, quality int) ([]byte, error) {
+		params := *ep
+		if quality > 0 {
+			params.Quality = quality
+		}
+		imageBytes, _, err := image.ExportAvif(&params)
+		return imageBytes, err
+	}, nil
+}
 
-	err = mapper.MapToStruct(conf, ep)
+// Applies the orientation and color profile corrections and removes the metadata
+// that must not be exported. The export parameters take care of stripping everything else.
+func applyMetadataOptions(image *vips.ImageRef, options converter.MetadataOptions) error {
+	if options.AutoRotate {
+		// Rotates the pixels according to the EXIF orientation and removes the orientation tag
+		if err := image.AutoRotate(); err != nil {
+			return fmt.Errorf("failed to auto rotate image: %w", err)
+		}
+	}
+
+	if options.ConvertToSRGB && (image.HasICCProfile() || image.Interpretation() == vips.InterpretationCMYK) {
+		if err := image.TransformICCProfile(vips.SRGBIEC6196621ICCProfilePath); err != nil {
+			return fmt.Errorf("failed to convert image to sRGB: %w", err)
+		}
+	}
+
+	switch options.Policy {
+	case config.MetadataCopyright:
+		// Leaves the ICC profile in place
+		if err := image.RemoveMetadata(copyrightFields...); err != nil {
+			return fmt.Errorf("failed to remove metadata: %w", err)
+		}
+	case config.MetadataKeep:
+	default:
+		if err := image.RemoveMetadata(); err != nil {
+			return fmt.Errorf("failed to remove metadata: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Composes the watermark image over the output
+func compositeImage(image *vips.ImageRef, overlay *converter.Overlay) error {
+	mark, err := vips.NewImageFromFile(overlay.Asset.Image)
 	if err != nil {
 		return err
 	}
+	defer mark.Close()
 
-	imageBytes, _, err := image.ExportPng(ep)
-	if err != nil {
+	// Bring the watermark to 8 bit sRGB with an alpha channel, so the opacity can be applied to the last band
+	if err = mark.ToColorSpace(vips.InterpretationSRGB); err != nil {
 		return err
 	}
+	if !mark.HasAlpha() {
+		if err = mark.AddAlpha(); err != nil {
+			return err
+		}
+	}
 
-	err = os.WriteFile(to, imageBytes, filePermissions)
-	if err != nil {
+	if overlay.Scale > 0 {
+		scale := overlay.Scale * float64(image.Width()) / float64(mark.Width())
+		if err = mark.Resize(scale, vips.KernelLanczos3); err != nil {
+			return err
+		}
+	}
+
+	if overlay.Opacity < 1 {
+		multipliers := make([]float64, mark.Bands())
+		offsets := make([]float64, mark.Bands())
+		for i := range multipliers {
+			multipliers[i] = 1
+		}
+		multipliers[len(multipliers)-1] = overlay.Opacity
+		if err = mark.Linear(multipliers, offsets); err != nil {
+			return err
+		}
+		if err = mark.Cast(vips.BandFormatUchar); err != nil {
+			return err
+		}
+	}
+
+	hasAlpha := image.HasAlpha()
+	x, y := overlay.Position(image.Width(), image.Height(), mark.Width(), mark.Height())
+	if err = image.Composite(mark, vips.BlendModeOver, x, y); err != nil {
 		return err
 	}
 
+	// Composition always produces an alpha channel, drop it if the source had none
+	if !hasAlpha {
+		return image.ExtractBand(0, image.Bands()-1)
+	}
 	return nil
 }
 
-func (c *conv) toWebp(from string, to string, conf converter.ConversionConfig) error {
-	image, err := vips.NewImageFromFile(from)
+// Draws the watermark text over the output
+func drawText(image *vips.ImageRef, overlay *converter.Overlay) error {
+	r, g, b, err := overlay.Asset.RGB()
 	if err != nil {
 		return err
 	}
 
-	ep := vips.NewWebpExportParams()
+	// The text is painted with a 3 band color
+	if image.Bands() < 3 {
+		if err = image.ToColorSpace(vips.InterpretationSRGB); err != nil {
+			return err
+		}
+	}
 
-	err = mapper.MapToStruct(conf, ep)
-	if err != nil {
-		return err
+	// The label must match the color bands, so the alpha channel is joined back afterwards
+	var alpha *vips.ImageRef
+	if image.HasAlpha() {
+		alpha, err = image.ExtractBandToImage(image.Bands()-1, 1)
+		if err != nil {
+			return err
+		}
+		defer alpha.Close()
+
+		if err = image.ExtractBand(0, image.Bands()-1); err != nil {
+			return err
+		}
 	}
 
-	imageBytes, _, err := image.ExportWebp(ep)
-	if err != nil {
-		return err
+	// The text is fit into a box that spans the width of the output within the margins
+	width := image.Width() - 2*overlay.Margin
+	height := int(math.Round(overlay.TextScale() * float64(image.Height())))
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("output %dx%d is too small for the text", image.Width(), image.Height())
+	}
+
+	font := overlay.Asset.Font
+	if font == "" {
+		font = "sans"
 	}
 
-	err = os.WriteFile(to, imageBytes, filePermissions)
+	horizontal, _ := overlay.Alignment()
+	_, y := overlay.Position(image.Width(), image.Height(), width, height)
+	err = image.Label(&vips.LabelParams{
+		Text:      overlay.Asset.Text,
+		Font:      font,
+		Width:     vips.ValueOf(float64(width)),
+		Height:    vips.ValueOf(float64(height)),
+		OffsetX:   vips.ValueOf(float64(overlay.Margin)),
+		OffsetY:   vips.ValueOf(float64(y)),
+		Opacity:   float32(overlay.Opacity),
+		Color:     vips.Color{R: r, G: g, B: b},
+		Alignment: textAlignments[horizontal],
+	})
 	if err != nil {
 		return err
 	}
 
+	if alpha != nil {
+		return image.BandJoin(alpha)
+	}
 	return nil
 }
 
-func (c *conv) toAvif(from string, to string, conf converter.ConversionConfig) error {
-	image, err := vips.NewImageFromFile(from)
+// Binary searches the lowest encoder quality that reaches the target score.
+func (c *conv) searchQuality(image *vips.ImageRef, target *converter.QualityTarget, export exportFunc) ([]byte, *model.QualityResult, error) {
+	reference, width, height, err := grayPlane(image)
 	if err != nil {
-		return err
+		return nil, nil, fmt.Errorf("failed to prepare reference image: %w", err)
 	}
 
-	ep := vips.NewAvifExportParams()
+	return converter.SearchQuality(target, func(quality int) ([]byte, float64, error) {
+		imageBytes, err := export(image, quality)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		encoded, err := vips.NewImageFromBuffer(imageBytes)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode encoded image: %w", err)
+		}
+		defer encoded.Close()
+
+		candidate, candidateWidth, candidateHeight, err := grayPlane(encoded)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to prepare encoded image: %w", err)
+		}
+		if candidateWidth != width || candidateHeight != height {
+			return nil, 0, ssim.ErrSizeMismatch
+		}
+
+		score, err := ssim.Gray(reference, candidate, width, height)
+		if err != nil {
+			return nil, 0, err
+		}
 
-	err = mapper.MapToStruct(conf, ep)
+		c.logger.Debug("quality attempt", slog.Int("quality", quality), slog.Float64("score", score))
+
+		return imageBytes, score, nil
+	})
+}
+
+// Returns 8-bit luminance samples of the image. Large images are downscaled,
+// since the metric is stable enough at lower resolutions and it speeds up the search considerably.
+func grayPlane(image *vips.ImageRef) ([]byte, int, int, error) {
+	gray, err := image.Copy()
 	if err != nil {
-		return err
+		return nil, 0, 0, err
 	}
+	defer gray.Close()
 
-	imageBytes, _, err := image.ExportAvif(ep)
-	if err != nil {
-		return err
+	if gray.HasAlpha() {
+		if err := gray.Flatten(&vips.Color{R: 255, G: 255, B: 255}); err != nil {
+			return nil, 0, 0, err
+		}
 	}
 
-	err = os.WriteFile(to, imageBytes, filePermissions)
+	if pixels := gray.Width() * gray.Height(); pixels > scoreMaxPixels {
+		scale := math.Sqrt(float64(scoreMaxPixels) / float64(pixels))
+		if err := gray.Resize(scale, vips.KernelLinear); err != nil {
+			return nil, 0, 0, err
+		}
+	}
+
+	if err := gray.ToColorSpace(vips.InterpretationBW); err != nil {
+		return nil, 0, 0, err
+	}
+	if err := gray.Cast(vips.BandFormatUchar); err != nil {
+		return nil, 0, 0, err
+	}
+
+	samples, err := gray.ToBytes()
 	if err != nil {
-		return err
+		return nil, 0, 0, err
 	}
 
-	return nil
+	return samples, gray.Width(), gray.Height(), nil
 }
 
 func (c *conv) Shutdown() {
+	c.running.Store(false)
 	vips.Shutdown()
 }
 
@@ -8,6 +8,7 @@ import (
 	"github.com/chistyakoviv/converter/internal/converter"
 	"github.com/chistyakoviv/converter/internal/converter/govips"
 	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/davidbyttow/govips/v2/vips"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -20,6 +21,15 @@ func TestImageConverter(t *testing.T) {
 		env            = config.EnvLocal
 		imageConf      = config.Image{
 			Threads: 4,
+			Metadata: config.ImageMetadata{
+				Policy:        config.MetadataStrip,
+				AutoRotate:    true,
+				ConvertToSRGB: true,
+			},
+		}
+		overlays = map[string]config.Overlay{
+			"logo":      {Image: filesDir + "/logo.png"},
+			"copyright": {Text: "© Example", Color: "#ff0000"},
 		}
 	)
 
@@ -33,11 +43,14 @@ func TestImageConverter(t *testing.T) {
 	})
 
 	type testcase struct {
-		name string
-		from string
-		to   string
-		err  string
-		conf converter.ConversionConfig
+		name          string
+		from          string
+		to            string
+		err           string
+		conf          converter.ConversionConfig
+		qualitySearch bool
+		// Width of the output, it is not checked if zero
+		width int
 	}
 
 	cases := []testcase{
@@ -89,6 +102,136 @@ func TestImageConverter(t *testing.T) {
 			to:   filesOutputDir + "/gen-png-to-avif.avif",
 			conf: nil,
 		},
+		{
+			name: "Convert jpg to webp with quality target",
+			from: filesDir + "/gen.jpg",
+			to:   filesOutputDir + "/gen-jpg-to-webp-ssim.webp",
+			conf: converter.ConversionConfig{
+				"quality_target": map[string]interface{}{
+					"min_score":    0.9,
+					"max_attempts": 4,
+				},
+			},
+			qualitySearch: true,
+		},
+		{
+			name: "Convert png to avif with quality target",
+			from: filesDir + "/gen.png",
+			to:   filesOutputDir + "/gen-png-to-avif-ssim.avif",
+			conf: converter.ConversionConfig{
+				"quality_target": map[string]interface{}{
+					"min_score": 0.9,
+				},
+			},
+			qualitySearch: true,
+		},
+		{
+			name: "Invalid quality target",
+			from: filesDir + "/gen.jpg",
+			to:   filesOutputDir + "/gen-jpg-to-webp-invalid-target.webp",
+			err:  "govips: quality_target: invalid quality target: min_score must be in the range (0, 1]",
+			conf: converter.ConversionConfig{
+				"quality_target": map[string]interface{}{},
+			},
+		},
+		{
+			name: "Convert jpg to jpg keeping metadata",
+			from: filesDir + "/gen.jpg",
+			to:   filesOutputDir + "/gen-jpg-to-jpg-keep.jpg",
+			conf: converter.ConversionConfig{
+				"metadata": map[string]interface{}{
+					"policy":          "keep",
+					"auto_rotate":     false,
+					"convert_to_srgb": false,
+				},
+			},
+		},
+		{
+			name: "Convert png to webp keeping copyright",
+			from: filesDir + "/gen.png",
+			to:   filesOutputDir + "/gen-png-to-webp-copyright.webp",
+			conf: converter.ConversionConfig{
+				"metadata": map[string]interface{}{
+					"policy": "copyright",
+				},
+			},
+		},
+		{
+			name: "Invalid metadata policy",
+			from: filesDir + "/gen.jpg",
+			to:   filesOutputDir + "/gen-jpg-to-jpg-invalid-metadata.jpg",
+			err:  "govips: metadata.policy: invalid metadata options: unknown policy 'all'",
+			conf: converter.ConversionConfig{
+				"metadata": map[string]interface{}{
+					"policy": "all",
+				},
+			},
+		},
+		{
+			name: "Convert jpg to jpg with watermark image",
+			from: filesDir + "/gen.jpg",
+			to:   filesOutputDir + "/gen-jpg-to-jpg-overlay.jpg",
+			conf: converter.ConversionConfig{
+				"overlay": map[string]interface{}{
+					"name":    "logo",
+					"gravity": "south-east",
+					"margin":  8,
+					"opacity": 0.5,
+					"scale":   0.25,
+				},
+			},
+		},
+		{
+			name: "Convert png to webp with watermark text",
+			from: filesDir + "/gen.png",
+			to:   filesOutputDir + "/gen-png-to-webp-overlay.webp",
+			conf: converter.ConversionConfig{
+				"overlay": map[string]interface{}{
+					"name":    "copyright",
+					"gravity": "north",
+					"margin":  4,
+				},
+			},
+		},
+		{
+			name: "Unknown overlay asset",
+			from: filesDir + "/gen.jpg",
+			to:   filesOutputDir + "/gen-jpg-to-jpg-unknown-overlay.jpg",
+			err:  "govips: overlay: invalid overlay: unknown asset 'missing'",
+			conf: converter.ConversionConfig{
+				"overlay": map[string]interface{}{
+					"name": "missing",
+				},
+			},
+		},
+		{
+			name: "Convert jpg to webp fitting into a box",
+			from: filesDir + "/gen.jpg",
+			to:   filesOutputDir + "/gen-jpg-to-webp-resized.webp",
+			conf: converter.ConversionConfig{
+				"width":  200,
+				"height": 200,
+			},
+			width: 200,
+		},
+		{
+			name: "Convert png to png not upscaling",
+			from: filesDir + "/gen.png",
+			to:   filesOutputDir + "/gen-png-to-png-not-upscaled.png",
+			conf: converter.ConversionConfig{
+				"width": 2000,
+			},
+			width: 800,
+		},
+		{
+			name: "Invalid width",
+			from: filesDir + "/gen.jpg",
+			to:   filesOutputDir + "/gen-jpg-to-jpg-invalid-width.jpg",
+			err:  "govips: width must be positive: invalid resize",
+			conf: converter.ConversionConfig{
+				"width": 0,
+			},
+		},
 		{
 			name: "Unsupported format",
 			from: filesDir + "/gen.jpg",
@@ -105,8 +248,9 @@ func TestImageConverter(t *testing.T) {
 			t.Parallel()
 
 			cfg := &config.Config{
-				Env:   env,
-				Image: imageConf,
+				Env:      env,
+				Image:    imageConf,
+				Overlays: overlays,
 			}
 
 			t.Cleanup(func() {
@@ -117,15 +261,31 @@ func TestImageConverter(t *testing.T) {
 
 			converter := govips.NewImageConverter(cfg, logger)
 
-			err := converter.Convert(tc.from, tc.to, tc.conf)
+			result, err := converter.Convert(tc.from, tc.to, tc.conf)
 			if tc.err != "" {
 				assert.Equal(t, err.Error(), tc.err)
 				return
 			}
 			require.NoError(t, err)
 
+			if tc.qualitySearch {
+				require.NotNil(t, result)
+				assert.Equal(t, "ssim", result.Metric)
+				assert.GreaterOrEqual(t, result.Score, 0.9)
+				assert.LessOrEqual(t, result.Attempts, 6)
+			} else {
+				assert.Nil(t, result)
+			}
+
 			_, err = os.Stat(tc.to)
 			assert.NoError(t, err, "File %s should exist", tc.to)
+
+			if tc.width > 0 {
+				image, err := vips.NewImageFromFile(tc.to)
+				require.NoError(t, err)
+				defer image.Close()
+				assert.Equal(t, tc.width, image.Width())
+			}
 		})
 	}
 }
@@ -0,0 +1,92 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/chistyakoviv/converter/internal/config"
+)
+
+// The conversion config key that overrides the global metadata handling for a format.
+const MetadataKey = "metadata"
+
+var ErrInvalidMetadataOptions = errors.New("invalid metadata options")
+
+type MetadataOptions struct {
+	Policy        string
+	AutoRotate    bool
+	ConvertToSRGB bool
+}
+
+func MetadataOptionsFromConfig(cfg config.ImageMetadata) MetadataOptions {
+	return MetadataOptions{
+		Policy:        cfg.Policy,
+		AutoRotate:    cfg.AutoRotate,
+		ConvertToSRGB: cfg.ConvertToSRGB,
+	}
+}
+
+// Reports whether all metadata must be removed from the output.
+func (o MetadataOptions) Strip() bool {
+	return o.Policy == "" || o.Policy == config.MetadataStrip
+}
+
+// ExtractMetadataOptions removes the metadata options from the config and merges them
+// over the defaults. Options missing in the config are inherited from the defaults.
+func ExtractMetadataOptions(conf ConversionConfig, defaults MetadataOptions) (MetadataOptions, ConversionConfig, error) {
+	raw, ok := conf[MetadataKey]
+	if !ok {
+		return defaults, conf, nil
+	}
+
+	rest := withoutKey(conf, MetadataKey)
+	if raw == nil {
+		return defaults, rest, nil
+	}
+
+	values, ok := raw.(map[string]interface{})
+	if !ok {
+		return defaults, nil, fmt.Errorf("%s must be a map: %w", MetadataKey, ErrInvalidMetadataOptions)
+	}
+
+	options := defaults
+	for key, value := range values {
+		var err error
+		switch key {
+		case "policy":
+			policy, isStr := value.(string)
+			if !isStr || !config.IsMetadataPolicy(policy) {
+				err = fmt.Errorf("unknown policy '%v'", value)
+			}
+			options.Policy = policy
+		case "auto_rotate":
+			options.AutoRotate, err = toBool(value)
+		case "convert_to_srgb":
+			options.ConvertToSRGB, err = toBool(value)
+		default:
+			err = fmt.Errorf("unknown key '%s'", key)
+		}
+		if err != nil {
+			return defaults, nil, fmt.Errorf("%s.%s: %w: %w", MetadataKey, key, ErrInvalidMetadataOptions, err)
+		}
+	}
+
+	return options, rest, nil
+}
+
+func withoutKey(conf ConversionConfig, key string) ConversionConfig {
+	rest := make(ConversionConfig, len(conf))
+	for k, value := range conf {
+		if k != key {
+			rest[k] = value
+		}
+	}
+	return rest
+}
+
+func toBool(value interface{}) (bool, error) {
+	if v, ok := value.(bool); ok {
+		return v, nil
+	}
+	return false, fmt.Errorf("expected a boolean, got %T", value)
+}
@@ -3,8 +3,12 @@
 package mocks
 
 import (
+	context "context"
+
 	converter "github.com/chistyakoviv/converter/internal/converter"
 	mock "github.com/stretchr/testify/mock"
+
+	model "github.com/chistyakoviv/converter/internal/model"
 )
 
 // MockImageConverter is an autogenerated mock type for the ImageConverter type
@@ -20,22 +24,80 @@ func (_m *MockImageConverter) EXPECT() *MockImageConverter_Expecter {
 	return &MockImageConverter_Expecter{mock: &_m.Mock}
 }
 
+// Check provides a mock function with given fields: ctx
+func (_m *MockImageConverter) Check(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Check")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockImageConverter_Check_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Check'
+type MockImageConverter_Check_Call struct {
+	*mock.Call
+}
+
+// Check is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockImageConverter_Expecter) Check(ctx interface{}) *MockImageConverter_Check_Call {
+	return &MockImageConverter_Check_Call{Call: _e.mock.On("Check", ctx)}
+}
+
+func (_c *MockImageConverter_Check_Call) Run(run func(ctx context.Context)) *MockImageConverter_Check_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockImageConverter_Check_Call) Return(_a0 error) *MockImageConverter_Check_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockImageConverter_Check_Call) RunAndReturn(run func(context.Context) error) *MockImageConverter_Check_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Convert provides a mock function with given fields: from, to, conf
-func (_m *MockImageConverter) Convert(from string, to string, conf converter.ConversionConfig) error {
+func (_m *MockImageConverter) Convert(from string, to string, conf converter.ConversionConfig) (*model.QualityResult, error) {
 	ret := _m.Called(from, to, conf)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Convert")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string, converter.ConversionConfig) error); ok {
+	var r0 *model.QualityResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, converter.ConversionConfig) (*model.QualityResult, error)); ok {
+		return rf(from, to, conf)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, converter.ConversionConfig) *model.QualityResult); ok {
 		r0 = rf(from, to, conf)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.QualityResult)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(string, string, converter.ConversionConfig) error); ok {
+		r1 = rf(from, to, conf)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // MockImageConverter_Convert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Convert'
@@ -58,12 +120,12 @@ func (_c *MockImageConverter_Convert_Call) Run(run func(from string, to string,
 	return _c
 }
 
-func (_c *MockImageConverter_Convert_Call) Return(_a0 error) *MockImageConverter_Convert_Call {
-	_c.Call.Return(_a0)
+func (_c *MockImageConverter_Convert_Call) Return(_a0 *model.QualityResult, _a1 error) *MockImageConverter_Convert_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockImageConverter_Convert_Call) RunAndReturn(run func(string, string, converter.ConversionConfig) error) *MockImageConverter_Convert_Call {
+func (_c *MockImageConverter_Convert_Call) RunAndReturn(run func(string, string, converter.ConversionConfig) (*model.QualityResult, error)) *MockImageConverter_Convert_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	converter "github.com/chistyakoviv/converter/internal/converter"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -20,17 +22,63 @@ func (_m *MockVideoConverter) EXPECT() *MockVideoConverter_Expecter {
 	return &MockVideoConverter_Expecter{mock: &_m.Mock}
 }
 
-// Convert provides a mock function with given fields: from, to, conf
-func (_m *MockVideoConverter) Convert(from string, to string, conf converter.ConversionConfig) error {
-	ret := _m.Called(from, to, conf)
+// Check provides a mock function with given fields: ctx
+func (_m *MockVideoConverter) Check(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Check")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockVideoConverter_Check_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Check'
+type MockVideoConverter_Check_Call struct {
+	*mock.Call
+}
+
+// Check is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockVideoConverter_Expecter) Check(ctx interface{}) *MockVideoConverter_Check_Call {
+	return &MockVideoConverter_Check_Call{Call: _e.mock.On("Check", ctx)}
+}
+
+func (_c *MockVideoConverter_Check_Call) Run(run func(ctx context.Context)) *MockVideoConverter_Check_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockVideoConverter_Check_Call) Return(_a0 error) *MockVideoConverter_Check_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockVideoConverter_Check_Call) RunAndReturn(run func(context.Context) error) *MockVideoConverter_Check_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Convert provides a mock function with given fields: ctx, from, to, conf
+func (_m *MockVideoConverter) Convert(ctx context.Context, from string, to string, conf converter.ConversionConfig) error {
+	ret := _m.Called(ctx, from, to, conf)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Convert")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string, converter.ConversionConfig) error); ok {
-		r0 = rf(from, to, conf)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, converter.ConversionConfig) error); ok {
+		r0 = rf(ctx, from, to, conf)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -44,16 +92,17 @@ type MockVideoConverter_Convert_Call struct {
 }
 
 // Convert is a helper method to define mock.On call
+//   - ctx context.Context
 //   - from string
 //   - to string
 //   - conf converter.ConversionConfig
-func (_e *MockVideoConverter_Expecter) Convert(from interface{}, to interface{}, conf interface{}) *MockVideoConverter_Convert_Call {
-	return &MockVideoConverter_Convert_Call{Call: _e.mock.On("Convert", from, to, conf)}
+func (_e *MockVideoConverter_Expecter) Convert(ctx interface{}, from interface{}, to interface{}, conf interface{}) *MockVideoConverter_Convert_Call {
+	return &MockVideoConverter_Convert_Call{Call: _e.mock.On("Convert", ctx, from, to, conf)}
 }
 
-func (_c *MockVideoConverter_Convert_Call) Run(run func(from string, to string, conf converter.ConversionConfig)) *MockVideoConverter_Convert_Call {
+func (_c *MockVideoConverter_Convert_Call) Run(run func(ctx context.Context, from string, to string, conf converter.ConversionConfig)) *MockVideoConverter_Convert_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string), args[2].(converter.ConversionConfig))
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(converter.ConversionConfig))
 	})
 	return _c
 }
@@ -63,7 +112,7 @@ func (_c *MockVideoConverter_Convert_Call) Return(_a0 error) *MockVideoConverter
 	return _c
 }
 
-func (_c *MockVideoConverter_Convert_Call) RunAndReturn(run func(string, string, converter.ConversionConfig) error) *MockVideoConverter_Convert_Call {
+func (_c *MockVideoConverter_Convert_Call) RunAndReturn(run func(context.Context, string, string, converter.ConversionConfig) error) *MockVideoConverter_Convert_Call {
 	_c.Call.Return(run)
 	return _c
 }
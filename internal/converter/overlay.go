@@ -0,0 +1,181 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/chistyakoviv/converter/internal/config"
+)
+
+// The conversion config key that composes a watermark over the output.
+const OverlayKey = "overlay"
+
+const (
+	GravityNorthWest = "north-west"
+	GravityNorth     = "north"
+	GravityNorthEast = "north-east"
+	GravityWest      = "west"
+	GravityCenter    = "center"
+	GravityEast      = "east"
+	GravitySouthWest = "south-west"
+	GravitySouth     = "south"
+	GravitySouthEast = "south-east"
+
+	// Text height relative to the output height if no scale is given
+	defaultTextScale = 0.05
+)
+
+var ErrInvalidOverlay = errors.New("invalid overlay")
+
+type Alignment int
+
+const (
+	AlignStart Alignment = iota
+	AlignCenter
+	AlignEnd
+)
+
+var gravities = map[string][2]Alignment{
+	GravityNorthWest: {AlignStart, AlignStart},
+	GravityNorth:     {AlignCenter, AlignStart},
+	GravityNorthEast: {AlignEnd, AlignStart},
+	GravityWest:      {AlignStart, AlignCenter},
+	GravityCenter:    {AlignCenter, AlignCenter},
+	GravityEast:      {AlignEnd, AlignCenter},
+	GravitySouthWest: {AlignStart, AlignEnd},
+	GravitySouth:     {AlignCenter, AlignEnd},
+	GravitySouthEast: {AlignEnd, AlignEnd},
+}
+
+// Overlay is a watermark asset along with its placement on the output.
+type Overlay struct {
+	Name    string
+	Asset   config.Overlay
+	Gravity string
+	// Distance in pixels from the edges the overlay is aligned to
+	Margin int
+	// Opacity in the range (0, 1]
+	Opacity float64
+	// Overlay width relative to the output width for images and text height relative
+	// to the output height for text. Zero keeps the original size of an image.
+	Scale float64
+}
+
+// ExtractOverlay removes the overlay from the config and resolves its asset by name.
+// Returns a nil overlay if the stage is not enabled.
+func ExtractOverlay(conf ConversionConfig, assets map[string]config.Overlay) (*Overlay, ConversionConfig, error) {
+	raw, ok := conf[OverlayKey]
+	if !ok {
+		return nil, conf, nil
+	}
+
+	rest := withoutKey(conf, OverlayKey)
+
+	// Allow to disable the overlay inherited from the defaults
+	if raw == nil {
+		return nil, rest, nil
+	}
+
+	values, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("%s must be a map: %w", OverlayKey, ErrInvalidOverlay)
+	}
+
+	overlay := &Overlay{
+		Gravity: GravitySouthEast,
+		Opacity: 1,
+	}
+
+	for key, value := range values {
+		var err error
+		switch key {
+		case "name":
+			name, isStr := value.(string)
+			if !isStr {
+				err = fmt.Errorf("name must be a string")
+			}
+			overlay.Name = name
+		case "gravity":
+			gravity, isStr := value.(string)
+			if _, known := gravities[gravity]; !isStr || !known {
+				err = fmt.Errorf("unknown gravity '%v'", value)
+			}
+			overlay.Gravity = gravity
+		case "margin":
+			overlay.Margin, err = toInt(value)
+		case "opacity":
+			overlay.Opacity, err = toFloat(value)
+		case "scale":
+			overlay.Scale, err = toFloat(value)
+		default:
+			err = fmt.Errorf("unknown key '%s'", key)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s.%s: %w: %w", OverlayKey, key, ErrInvalidOverlay, err)
+		}
+	}
+
+	if err := overlay.validate(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w: %w", OverlayKey, ErrInvalidOverlay, err)
+	}
+
+	// Only assets from the config may be used, so a request cannot point at an arbitrary file
+	asset, ok := assets[overlay.Name]
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: %w: unknown asset '%s'", OverlayKey, ErrInvalidOverlay, overlay.Name)
+	}
+	overlay.Asset = asset
+
+	return overlay, rest, nil
+}
+
+func (o *Overlay) validate() error {
+	if o.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if o.Margin < 0 {
+		return fmt.Errorf("margin must not be negative")
+	}
+	if o.Opacity <= 0 || o.Opacity > 1 {
+		return fmt.Errorf("opacity must be in the range (0, 1]")
+	}
+	if o.Scale < 0 || o.Scale > 1 {
+		return fmt.Errorf("scale must be in the range [0, 1]")
+	}
+	return nil
+}
+
+func (o *Overlay) IsText() bool {
+	return o.Asset.IsText()
+}
+
+// Returns the text height relative to the output height
+func (o *Overlay) TextScale() float64 {
+	if o.Scale == 0 {
+		return defaultTextScale
+	}
+	return o.Scale
+}
+
+// Returns the horizontal and vertical alignment of the overlay
+func (o *Overlay) Alignment() (Alignment, Alignment) {
+	alignment := gravities[o.Gravity]
+	return alignment[0], alignment[1]
+}
+
+// Position returns the top left corner of an overlay of the given size placed over the output.
+func (o *Overlay) Position(width, height, overlayWidth, overlayHeight int) (int, int) {
+	horizontal, vertical := o.Alignment()
+	return align(horizontal, width, overlayWidth, o.Margin), align(vertical, height, overlayHeight, o.Margin)
+}
+
+func align(alignment Alignment, size, overlaySize, margin int) int {
+	switch alignment {
+	case AlignCenter:
+		return (size - overlaySize) / 2
+	case AlignEnd:
+		return size - overlaySize - margin
+	default:
+		return margin
+	}
+}
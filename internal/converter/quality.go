@@ -0,0 +1,193 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/chistyakoviv/converter/internal/model"
+)
+
+// The conversion config key that enables the perceptual quality search.
+// It lives in conv_conf, so it is merged with the defaults like any other encoder parameter.
+const QualityTargetKey = "quality_target"
+
+const (
+	MetricSSIM = "ssim"
+
+	defaultMinQuality  = 30
+	defaultMaxQuality  = 95
+	defaultMaxAttempts = 6
+)
+
+var (
+	ErrInvalidQualityTarget = errors.New("invalid quality target")
+	ErrQualityNotReached    = errors.New("quality target not reached")
+)
+
+// QualityTarget describes the minimal perceptual score an encoded image must reach.
+// The encoder quality is binary searched within [MinQuality, MaxQuality]
+// using at most MaxAttempts encodings.
+type QualityTarget struct {
+	Metric      string
+	MinScore    float64
+	MinQuality  int
+	MaxQuality  int
+	MaxAttempts int
+}
+
+// Encodes the image with the given quality and returns the encoded bytes along with their score.
+type QualityEncoder func(quality int) ([]byte, float64, error)
+
+// ExtractQualityTarget removes the quality target from the config, so the rest of the config
+// can be passed to the encoder as is. Returns a nil target if the search is not enabled.
+func ExtractQualityTarget(conf ConversionConfig) (*QualityTarget, ConversionConfig, error) {
+	raw, ok := conf[QualityTargetKey]
+	if !ok {
+		return nil, conf, nil
+	}
+
+	rest := withoutKey(conf, QualityTargetKey)
+
+	// Allow to disable the search inherited from the defaults
+	if raw == nil {
+		return nil, rest, nil
+	}
+
+	values, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("%s must be a map: %w", QualityTargetKey, ErrInvalidQualityTarget)
+	}
+
+	target := &QualityTarget{
+		Metric:      MetricSSIM,
+		MinQuality:  defaultMinQuality,
+		MaxQuality:  defaultMaxQuality,
+		MaxAttempts: defaultMaxAttempts,
+	}
+
+	for key, value := range values {
+		var err error
+		switch key {
+		case "metric":
+			metric, isStr := value.(string)
+			if !isStr {
+				err = fmt.Errorf("metric must be a string")
+			}
+			target.Metric = metric
+		case "min_score":
+			target.MinScore, err = toFloat(value)
+		case "min_quality":
+			target.MinQuality, err = toInt(value)
+		case "max_quality":
+			target.MaxQuality, err = toInt(value)
+		case "max_attempts":
+			target.MaxAttempts, err = toInt(value)
+		default:
+			err = fmt.Errorf("unknown key '%s'", key)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s.%s: %w: %w", QualityTargetKey, key, ErrInvalidQualityTarget, err)
+		}
+	}
+
+	if err := target.validate(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w: %w", QualityTargetKey, ErrInvalidQualityTarget, err)
+	}
+
+	return target, rest, nil
+}
+
+func (t *QualityTarget) validate() error {
+	if t.Metric != MetricSSIM {
+		return fmt.Errorf("unsupported metric '%s'", t.Metric)
+	}
+	if t.MinScore <= 0 || t.MinScore > 1 {
+		return fmt.Errorf("min_score must be in the range (0, 1]")
+	}
+	if t.MinQuality < 1 || t.MaxQuality > 100 || t.MinQuality > t.MaxQuality {
+		return fmt.Errorf("quality range must be within [1, 100] and min_quality must not exceed max_quality")
+	}
+	if t.MaxAttempts < 1 {
+		return fmt.Errorf("max_attempts must be positive")
+	}
+	return nil
+}
+
+// SearchQuality finds the lowest quality which output reaches the target score.
+// If the score is not reached within the allowed attempts, the output of the highest
+// quality tried is returned along with ErrQualityNotReached, so a caller may decide whether to keep it.
+func SearchQuality(target *QualityTarget, encode QualityEncoder) ([]byte, *model.QualityResult, error) {
+	var (
+		best        []byte
+		bestResult  *model.QualityResult
+		fallback    []byte
+		fallbackRes *model.QualityResult
+		attempts    int
+	)
+
+	lo, hi := target.MinQuality, target.MaxQuality
+	for lo <= hi && attempts < target.MaxAttempts {
+		quality := lo + (hi-lo)/2
+
+		data, score, err := encode(quality)
+		attempts++
+		if err != nil {
+			return nil, nil, err
+		}
+
+		result := &model.QualityResult{
+			Metric:  target.Metric,
+			Quality: quality,
+			Score:   score,
+		}
+
+		if score >= target.MinScore {
+			// Good enough, try to go lower
+			best, bestResult = data, result
+			hi = quality - 1
+		} else {
+			if fallbackRes == nil || quality > fallbackRes.Quality {
+				fallback, fallbackRes = data, result
+			}
+			lo = quality + 1
+		}
+	}
+
+	if bestResult != nil {
+		bestResult.Attempts = attempts
+		return best, bestResult, nil
+	}
+
+	fallbackRes.Attempts = attempts
+	return fallback, fallbackRes, fmt.Errorf("best %s score %.4f at quality %d is below %.4f: %w",
+		target.Metric, fallbackRes.Score, fallbackRes.Quality, target.MinScore, ErrQualityNotReached)
+}
+
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	}
+	return 0, fmt.Errorf("expected a number, got %T", value)
+}
+
+func toInt(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		if v != float64(int(v)) {
+			return 0, fmt.Errorf("expected an integer, got %v", v)
+		}
+		return int(v), nil
+	}
+	return 0, fmt.Errorf("expected an integer, got %T", value)
+}
@@ -0,0 +1,63 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The conversion config keys that downscale the output.
+// They are also the {width} and {height} variables of the naming templates.
+const (
+	WidthKey  = "width"
+	HeightKey = "height"
+)
+
+var ErrInvalidResize = errors.New("invalid resize")
+
+// Resize is the box the output is downscaled to fit in, the aspect ratio is preserved.
+// A zero dimension is not constrained. Sources that already fit keep their size.
+type Resize struct {
+	Width  int
+	Height int
+}
+
+// ExtractResize removes the size from the config. Returns a nil resize if no size is given.
+func ExtractResize(conf ConversionConfig) (*Resize, ConversionConfig, error) {
+	resize := &Resize{}
+	for _, dim := range []struct {
+		key   string
+		value *int
+	}{{WidthKey, &resize.Width}, {HeightKey, &resize.Height}} {
+		raw, ok := conf[dim.key]
+		if !ok {
+			continue
+		}
+		conf = withoutKey(conf, dim.key)
+
+		number, err := toInt(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w: %w", dim.key, ErrInvalidResize, err)
+		}
+		if number < 1 {
+			return nil, nil, fmt.Errorf("%s must be positive: %w", dim.key, ErrInvalidResize)
+		}
+		*dim.value = number
+	}
+
+	if resize.Width == 0 && resize.Height == 0 {
+		return nil, conf, nil
+	}
+	return resize, conf, nil
+}
+
+// Scale returns the factor a source of the given size is resized by, it never exceeds 1.
+func (r *Resize) Scale(width, height int) float64 {
+	scale := 1.0
+	if r.Width > 0 && width > r.Width {
+		scale = float64(r.Width) / float64(width)
+	}
+	if r.Height > 0 && height > r.Height {
+		scale = min(scale, float64(r.Height)/float64(height))
+	}
+	return scale
+}
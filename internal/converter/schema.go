@@ -0,0 +1,321 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/file"
+	"github.com/chistyakoviv/converter/internal/model"
+)
+
+var ErrInvalidConversionConfig = errors.New("invalid conversion config")
+
+type ParamKind int
+
+const (
+	ParamBool ParamKind = iota
+	ParamInt
+	ParamFloat
+	ParamString
+)
+
+// Param describes a value an encoder accepts in conv_conf
+type Param struct {
+	Kind ParamKind
+	// The range of a number, it is not checked if both bounds are zero
+	Min, Max float64
+	// The allowed values of a string
+	Values []string
+	// The format of a string without the allowed values
+	Pattern *regexp.Regexp
+	// A number may also be given as a string, FFmpeg receives every argument as a string anyway
+	AsString bool
+	// Checks a string the pattern cannot describe
+	Check func(string) error
+}
+
+var (
+	// Bitrates and sizes like 800k or 2M
+	bitratePattern = regexp.MustCompile(`^\d+(\.\d+)?[kKmMgG]?$`)
+	// Frame rates like 30 or 30000/1001
+	ratePattern = regexp.MustCompile(`^\d+(\.\d+)?(/\d+)?$`)
+	// Names of pixel formats, profiles and the like, so no other option can be smuggled into the command
+	tokenPattern = regexp.MustCompile(`^[A-Za-z0-9_.+:-]+$`)
+	// Link labels around a filter, e.g. [0:v]scale=320:-2[base]
+	leadingLabelsPattern  = regexp.MustCompile(`^(\[[A-Za-z0-9_:]+\])*`)
+	trailingLabelsPattern = regexp.MustCompile(`(\[[A-Za-z0-9_:]+\])*$`)
+	// Arguments of a filter are numbers, names and expressions, quoting and escaping are not supported,
+	// so an argument cannot hide a separator or another filter
+	filterArgsPattern = regexp.MustCompile(`^[A-Za-z0-9_.:=+*/()-]*$`)
+)
+
+// The filters a filtergraph of a request may use. None of them takes a file, so a request cannot read
+// an arbitrary file into its output, e.g. with movie, drawtext or subtitles. Watermarks are composed
+// with the overlay stage instead.
+var videoFilters = []string{
+	"scale", "crop", "pad", "fps", "format", "setsar", "setdar", "transpose", "hflip", "vflip", "yadif", "null",
+}
+
+// The fields of the govips export params keyed by the lowercased names, which are matched by the mapper.
+// Fields that take a file path are not allowed, so a request cannot point the encoder at an arbitrary file.
+var (
+	jpegParams = map[string]Param{
+		"stripmetadata":      {Kind: ParamBool},
+		"quality":            {Kind: ParamInt, Min: 1, Max: 100},
+		"interlace":          {Kind: ParamBool},
+		"optimizecoding":     {Kind: ParamBool},
+		"subsamplemode":      {Kind: ParamInt, Min: 0, Max: 2},
+		"trellisquant":       {Kind: ParamBool},
+		"overshootderinging": {Kind: ParamBool},
+		"optimizescans":      {Kind: ParamBool},
+		"quanttable":         {Kind: ParamInt, Min: 0, Max: 8},
+	}
+	pngParams = map[string]Param{
+		"stripmetadata": {Kind: ParamBool},
+		"compression":   {Kind: ParamInt, Min: 0, Max: 9},
+		"filter":        {Kind: ParamInt, Min: 0, Max: 0xf8},
+		"interlace":     {Kind: ParamBool},
+		"quality":       {Kind: ParamInt, Min: 1, Max: 100},
+		"palette":       {Kind: ParamBool},
+		"dither":        {Kind: ParamFloat, Min: 0, Max: 1},
+		"bitdepth":      {Kind: ParamInt, Min: 1, Max: 16},
+	}
+	webpParams = map[string]Param{
+		"stripmetadata":   {Kind: ParamBool},
+		"quality":         {Kind: ParamInt, Min: 1, Max: 100},
+		"lossless":        {Kind: ParamBool},
+		"nearlossless":    {Kind: ParamBool},
+		"reductioneffort": {Kind: ParamInt, Min: 0, Max: 6},
+		"minsize":         {Kind: ParamBool},
+		"minkeyframes":    {Kind: ParamInt, Min: 0, Max: math.MaxInt32},
+		"maxkeyframes":    {Kind: ParamInt, Min: 0, Max: math.MaxInt32},
+	}
+	avifParams = map[string]Param{
+		"stripmetadata": {Kind: ParamBool},
+		"quality":       {Kind: ParamInt, Min: 1, Max: 100},
+		"bitdepth":      {Kind: ParamInt, Min: 8, Max: 12},
+		"effort":        {Kind: ParamInt, Min: 0, Max: 9},
+		"lossless":      {Kind: ParamBool},
+		"speed":         {Kind: ParamInt, Min: 0, Max: 9},
+	}
+
+	imageParams = map[string]map[string]Param{
+		"jpg":  jpegParams,
+		"jpeg": jpegParams,
+		"png":  pngParams,
+		"webp": webpParams,
+		"avif": avifParams,
+	}
+)
+
+// The FFmpeg options that may be passed in conv_conf, every other key is rejected,
+// so inputs cannot be injected into the command
+var videoParams = map[string]Param{
+	"vf":             {Kind: ParamString, Check: validateFiltergraph},
+	"filter:v":       {Kind: ParamString, Check: validateFiltergraph},
+	"filter_complex": {Kind: ParamString, Check: validateFiltergraph},
+	"c:v":            {Kind: ParamString, Values: []string{"libvpx", "libvpx-vp9", "libaom-av1", "libsvtav1", "librav1e", "libx264", "libx265", "copy"}},
+	"c:a":            {Kind: ParamString, Values: []string{"libopus", "libvorbis", "aac", "libmp3lame", "copy"}},
+	"crf":            {Kind: ParamInt, Min: 0, Max: 63, AsString: true},
+	"qp":             {Kind: ParamInt, Min: 0, Max: 255, AsString: true},
+	"b:v":            {Kind: ParamString, Pattern: bitratePattern, AsString: true},
+	"b:a":            {Kind: ParamString, Pattern: bitratePattern, AsString: true},
+	"minrate":        {Kind: ParamString, Pattern: bitratePattern, AsString: true},
+	"maxrate":        {Kind: ParamString, Pattern: bitratePattern, AsString: true},
+	"bufsize":        {Kind: ParamString, Pattern: bitratePattern, AsString: true},
+	"preset":         {Kind: ParamString, Pattern: tokenPattern, AsString: true},
+	"tune":           {Kind: ParamString, Pattern: tokenPattern},
+	"deadline":       {Kind: ParamString, Values: []string{"good", "best", "realtime"}},
+	"cpu-used":       {Kind: ParamInt, Min: -16, Max: 16, AsString: true},
+	"row-mt":         {Kind: ParamInt, Min: 0, Max: 1, AsString: true},
+	"tile-columns":   {Kind: ParamInt, Min: 0, Max: 6, AsString: true},
+	"tile-rows":      {Kind: ParamInt, Min: 0, Max: 6, AsString: true},
+	"frame-parallel": {Kind: ParamInt, Min: 0, Max: 1, AsString: true},
+	"auto-alt-ref":   {Kind: ParamInt, Min: 0, Max: 6, AsString: true},
+	"lag-in-frames":  {Kind: ParamInt, Min: 0, Max: 25, AsString: true},
+	"g":              {Kind: ParamInt, Min: 1, Max: math.MaxInt32, AsString: true},
+	"r":              {Kind: ParamString, Pattern: ratePattern, AsString: true},
+	"pix_fmt":        {Kind: ParamString, Pattern: tokenPattern},
+	"profile:v":      {Kind: ParamString, Pattern: tokenPattern, AsString: true},
+	"level":          {Kind: ParamString, Pattern: tokenPattern, AsString: true},
+	"movflags":       {Kind: ParamString, Pattern: tokenPattern},
+	"ac":             {Kind: ParamInt, Min: 1, Max: 8, AsString: true},
+	"ar":             {Kind: ParamInt, Min: 8000, Max: 192000, AsString: true},
+}
+
+// ValidateConfig checks the conversion config of the target format against the parameters its encoder accepts.
+// The error names the offending key, so a typo in the defaults or in a request is reported instead of ignored.
+func ValidateConfig(mediaType file.MediaType, ext string, conf ConversionConfig, overlays map[string]config.Overlay) error {
+	var (
+		params map[string]Param
+		ok     bool
+		err    error
+	)
+	switch mediaType {
+	case file.MediaTypeImage:
+		if params, ok = imageParams[strings.ToLower(ext)]; !ok {
+			return fmt.Errorf("%w: no encoder for '%s'", ErrInvalidConversionConfig, ext)
+		}
+		// The stages applied before the encoder validate their own keys
+		if _, conf, err = ExtractQualityTarget(conf); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidConversionConfig, err)
+		}
+		if _, conf, err = ExtractMetadataOptions(conf, MetadataOptions{}); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidConversionConfig, err)
+		}
+	case file.MediaTypeVideo:
+		params = videoParams
+	default:
+		return fmt.Errorf("%w: unknown media type of '%s'", ErrInvalidConversionConfig, ext)
+	}
+
+	if _, conf, err = ExtractOverlay(conf, overlays); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConversionConfig, err)
+	}
+	if _, conf, err = ExtractResize(conf); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConversionConfig, err)
+	}
+
+	// Keys are checked in order, so the same key is reported for the same config
+	keys := make([]string, 0, len(conf))
+	for key := range conf {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		param, ok := params[strings.ToLower(key)]
+		if !ok {
+			return fmt.Errorf("%w: unknown key '%s' for '%s'", ErrInvalidConversionConfig, key, ext)
+		}
+		if err = param.validate(conf[key]); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalidConversionConfig, key, err)
+		}
+	}
+
+	return nil
+}
+
+func (p Param) validate(value interface{}) error {
+	// A number given as a string is checked as a number
+	if str, isStr := value.(string); isStr && p.AsString && (p.Kind == ParamInt || p.Kind == ParamFloat) {
+		number, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return fmt.Errorf("expected a number, got '%s'", str)
+		}
+		value = number
+	}
+
+	switch p.Kind {
+	case ParamBool:
+		_, err := toBool(value)
+		return err
+	case ParamInt:
+		number, err := toInt(value)
+		if err != nil {
+			return err
+		}
+		return p.checkRange(float64(number))
+	case ParamFloat:
+		number, err := toFloat(value)
+		if err != nil {
+			return err
+		}
+		return p.checkRange(number)
+	case ParamString:
+		return p.validateString(value)
+	}
+	return nil
+}
+
+func (p Param) checkRange(number float64) error {
+	if (p.Min != 0 || p.Max != 0) && (number < p.Min || number > p.Max) {
+		return fmt.Errorf("%v is out of the range [%v, %v]", number, p.Min, p.Max)
+	}
+	return nil
+}
+
+func (p Param) validateString(value interface{}) error {
+	var str string
+	switch v := value.(type) {
+	case string:
+		str = v
+	case int, int64, float64:
+		if !p.AsString {
+			return fmt.Errorf("expected a string, got %T", value)
+		}
+		str = fmt.Sprint(v)
+	default:
+		return fmt.Errorf("expected a string, got %T", value)
+	}
+
+	if len(p.Values) > 0 {
+		for _, allowed := range p.Values {
+			if str == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("'%s' is not one of %s", str, strings.Join(p.Values, ", "))
+	}
+	if p.Pattern != nil && !p.Pattern.MatchString(str) {
+		return fmt.Errorf("malformed value '%s'", str)
+	}
+	if p.Check != nil {
+		return p.Check(str)
+	}
+	return nil
+}
+
+// Checks that the filtergraph consists of the allowed filters only
+func validateFiltergraph(graph string) error {
+	for _, chain := range strings.Split(graph, ";") {
+		for _, filter := range strings.Split(chain, ",") {
+			filter = leadingLabelsPattern.ReplaceAllString(strings.TrimSpace(filter), "")
+			filter = trailingLabelsPattern.ReplaceAllString(filter, "")
+
+			name, args, _ := strings.Cut(filter, "=")
+			if !slices.Contains(videoFilters, name) {
+				return fmt.Errorf("filter '%s' is not one of %s", name, strings.Join(videoFilters, ", "))
+			}
+			if !filterArgsPattern.MatchString(args) {
+				return fmt.Errorf("malformed arguments of '%s': '%s'", name, args)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateDefaults checks the conversion configs of all default formats and presets
+func ValidateDefaults(dfs *config.Defaults, overlays map[string]config.Overlay) error {
+	if err := validateFormats("", dfs.Image.Formats, dfs.Video.Formats, overlays); err != nil {
+		return err
+	}
+	for _, name := range dfs.PresetNames() {
+		preset := dfs.Presets[name]
+		if err := validateFormats(fmt.Sprintf("preset '%s' ", name), preset.Image.Formats, preset.Video.Formats, overlays); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateFormats(prefix string, images, videos []model.ConvertTo, overlays map[string]config.Overlay) error {
+	for _, entry := range images {
+		if err := ValidateConfig(file.MediaTypeImage, entry.Ext, entry.ConvConf, overlays); err != nil {
+			return fmt.Errorf("%simage format '%s': %w", prefix, entry.Key(), err)
+		}
+	}
+	for _, entry := range videos {
+		if err := ValidateConfig(file.MediaTypeVideo, entry.Ext, entry.ConvConf, overlays); err != nil {
+			return fmt.Errorf("%svideo format '%s': %w", prefix, entry.Key(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/converter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractMetadataOptions(t *testing.T) {
+	defaults := converter.MetadataOptions{
+		Policy:        config.MetadataStrip,
+		AutoRotate:    true,
+		ConvertToSRGB: true,
+	}
+
+	type testcase struct {
+		name    string
+		conf    converter.ConversionConfig
+		options converter.MetadataOptions
+		rest    converter.ConversionConfig
+		err     string
+	}
+
+	cases := []testcase{
+		{
+			name:    "Defaults are used",
+			conf:    converter.ConversionConfig{"quality": 80},
+			options: defaults,
+			rest:    converter.ConversionConfig{"quality": 80},
+		},
+		{
+			name:    "Null options fall back to defaults",
+			conf:    converter.ConversionConfig{"quality": 80, "metadata": nil},
+			options: defaults,
+			rest:    converter.ConversionConfig{"quality": 80},
+		},
+		{
+			name: "Options override defaults",
+			conf: converter.ConversionConfig{
+				"metadata": map[string]interface{}{
+					"policy":      "copyright",
+					"auto_rotate": false,
+				},
+				"lossless": true,
+			},
+			options: converter.MetadataOptions{
+				Policy:        config.MetadataCopyright,
+				AutoRotate:    false,
+				ConvertToSRGB: true,
+			},
+			rest: converter.ConversionConfig{"lossless": true},
+		},
+		{
+			name: "Unknown policy",
+			conf: converter.ConversionConfig{
+				"metadata": map[string]interface{}{"policy": "all"},
+			},
+			err: "metadata.policy: invalid metadata options: unknown policy 'all'",
+		},
+		{
+			name: "Unknown key",
+			conf: converter.ConversionConfig{
+				"metadata": map[string]interface{}{"autorotate": true},
+			},
+			err: "metadata.autorotate: invalid metadata options: unknown key 'autorotate'",
+		},
+		{
+			name: "Wrong value type",
+			conf: converter.ConversionConfig{
+				"metadata": map[string]interface{}{"convert_to_srgb": "yes"},
+			},
+			err: "metadata.convert_to_srgb: invalid metadata options: expected a boolean, got string",
+		},
+		{
+			name: "Wrong type",
+			conf: converter.ConversionConfig{"metadata": "keep"},
+			err:  "metadata must be a map: invalid metadata options",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			options, rest, err := converter.ExtractMetadataOptions(tc.conf, defaults)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				assert.ErrorIs(t, err, converter.ErrInvalidMetadataOptions)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.options, options)
+			assert.Equal(t, tc.rest, rest)
+		})
+	}
+}
+
+func TestMetadataOptionsStrip(t *testing.T) {
+	assert.True(t, converter.MetadataOptions{}.Strip())
+	assert.True(t, converter.MetadataOptions{Policy: config.MetadataStrip}.Strip())
+	assert.False(t, converter.MetadataOptions{Policy: config.MetadataCopyright}.Strip())
+	assert.False(t, converter.MetadataOptions{Policy: config.MetadataKeep}.Strip())
+}
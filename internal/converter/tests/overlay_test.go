@@ -0,0 +1,164 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/converter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractOverlay(t *testing.T) {
+	assets := map[string]config.Overlay{
+		"logo":      {Image: "/etc/converter/logo.png"},
+		"copyright": {Text: "© Example", Color: "#000000"},
+	}
+
+	type testcase struct {
+		name    string
+		conf    converter.ConversionConfig
+		overlay *converter.Overlay
+		rest    converter.ConversionConfig
+		err     string
+	}
+
+	cases := []testcase{
+		{
+			name: "Overlay is disabled",
+			conf: converter.ConversionConfig{"quality": 80},
+			rest: converter.ConversionConfig{"quality": 80},
+		},
+		{
+			name: "Overlay inherited from defaults is disabled by a request",
+			conf: converter.ConversionConfig{"quality": 80, "overlay": nil},
+			rest: converter.ConversionConfig{"quality": 80},
+		},
+		{
+			name: "Default placement is applied",
+			conf: converter.ConversionConfig{
+				"overlay":  map[string]interface{}{"name": "logo"},
+				"lossless": false,
+			},
+			overlay: &converter.Overlay{
+				Name:    "logo",
+				Asset:   assets["logo"],
+				Gravity: converter.GravitySouthEast,
+				Opacity: 1,
+			},
+			rest: converter.ConversionConfig{"lossless": false},
+		},
+		{
+			name: "Placement from json",
+			conf: converter.ConversionConfig{
+				"overlay": map[string]interface{}{
+					"name":    "copyright",
+					"gravity": "north",
+					"margin":  float64(16),
+					"opacity": 0.5,
+					"scale":   0.1,
+				},
+			},
+			overlay: &converter.Overlay{
+				Name:    "copyright",
+				Asset:   assets["copyright"],
+				Gravity: converter.GravityNorth,
+				Margin:  16,
+				Opacity: 0.5,
+				Scale:   0.1,
+			},
+			rest: converter.ConversionConfig{},
+		},
+		{
+			name: "Unknown asset",
+			conf: converter.ConversionConfig{
+				"overlay": map[string]interface{}{"name": "/etc/passwd"},
+			},
+			err: "overlay: invalid overlay: unknown asset '/etc/passwd'",
+		},
+		{
+			name: "Name is required",
+			conf: converter.ConversionConfig{
+				"overlay": map[string]interface{}{"gravity": "center"},
+			},
+			err: "overlay: invalid overlay: name is required",
+		},
+		{
+			name: "Unknown gravity",
+			conf: converter.ConversionConfig{
+				"overlay": map[string]interface{}{"name": "logo", "gravity": "top"},
+			},
+			err: "overlay.gravity: invalid overlay: unknown gravity 'top'",
+		},
+		{
+			name: "Opacity out of range",
+			conf: converter.ConversionConfig{
+				"overlay": map[string]interface{}{"name": "logo", "opacity": 1.5},
+			},
+			err: "overlay: invalid overlay: opacity must be in the range (0, 1]",
+		},
+		{
+			name: "Unknown key",
+			conf: converter.ConversionConfig{
+				"overlay": map[string]interface{}{"name": "logo", "image": "/etc/passwd"},
+			},
+			err: "overlay.image: invalid overlay: unknown key 'image'",
+		},
+		{
+			name: "Wrong type",
+			conf: converter.ConversionConfig{"overlay": "logo"},
+			err:  "overlay must be a map: invalid overlay",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			overlay, rest, err := converter.ExtractOverlay(tc.conf, assets)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				assert.ErrorIs(t, err, converter.ErrInvalidOverlay)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.overlay, overlay)
+			assert.Equal(t, tc.rest, rest)
+		})
+	}
+}
+
+func TestOverlayPosition(t *testing.T) {
+	type testcase struct {
+		gravity string
+		x       int
+		y       int
+	}
+
+	cases := []testcase{
+		{gravity: converter.GravityNorthWest, x: 10, y: 10},
+		{gravity: converter.GravityNorth, x: 350, y: 10},
+		{gravity: converter.GravityNorthEast, x: 690, y: 10},
+		{gravity: converter.GravityWest, x: 10, y: 275},
+		{gravity: converter.GravityCenter, x: 350, y: 275},
+		{gravity: converter.GravityEast, x: 690, y: 275},
+		{gravity: converter.GravitySouthWest, x: 10, y: 540},
+		{gravity: converter.GravitySouth, x: 350, y: 540},
+		{gravity: converter.GravitySouthEast, x: 690, y: 540},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.gravity, func(t *testing.T) {
+			t.Parallel()
+
+			overlay := &converter.Overlay{Gravity: tc.gravity, Margin: 10}
+			x, y := overlay.Position(800, 600, 100, 50)
+			assert.Equal(t, tc.x, x)
+			assert.Equal(t, tc.y, y)
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/chistyakoviv/converter/internal/converter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractQualityTarget(t *testing.T) {
+	type testcase struct {
+		name   string
+		conf   converter.ConversionConfig
+		target *converter.QualityTarget
+		rest   converter.ConversionConfig
+		err    string
+	}
+
+	cases := []testcase{
+		{
+			name: "Quality search is disabled",
+			conf: converter.ConversionConfig{"quality": 80},
+			rest: converter.ConversionConfig{"quality": 80},
+		},
+		{
+			name: "Quality search inherited from defaults is disabled by a request",
+			conf: converter.ConversionConfig{"quality": 80, "quality_target": nil},
+			rest: converter.ConversionConfig{"quality": 80},
+		},
+		{
+			name: "Default search bounds are applied",
+			conf: converter.ConversionConfig{
+				"quality_target": map[string]interface{}{"min_score": 0.98},
+				"lossless":       false,
+			},
+			target: &converter.QualityTarget{
+				Metric:      converter.MetricSSIM,
+				MinScore:    0.98,
+				MinQuality:  30,
+				MaxQuality:  95,
+				MaxAttempts: 6,
+			},
+			rest: converter.ConversionConfig{"lossless": false},
+		},
+		{
+			name: "Search bounds from json",
+			conf: converter.ConversionConfig{
+				"quality_target": map[string]interface{}{
+					"metric":       "ssim",
+					"min_score":    0.95,
+					"min_quality":  float64(40),
+					"max_quality":  float64(90),
+					"max_attempts": float64(4),
+				},
+			},
+			target: &converter.QualityTarget{
+				Metric:      converter.MetricSSIM,
+				MinScore:    0.95,
+				MinQuality:  40,
+				MaxQuality:  90,
+				MaxAttempts: 4,
+			},
+			rest: converter.ConversionConfig{},
+		},
+		{
+			name: "Unknown key",
+			conf: converter.ConversionConfig{
+				"quality_target": map[string]interface{}{"min_score": 0.98, "min_qualty": 40},
+			},
+			err: "quality_target.min_qualty: invalid quality target: unknown key 'min_qualty'",
+		},
+		{
+			name: "Unsupported metric",
+			conf: converter.ConversionConfig{
+				"quality_target": map[string]interface{}{"min_score": 0.98, "metric": "psnr"},
+			},
+			err: "quality_target: invalid quality target: unsupported metric 'psnr'",
+		},
+		{
+			name: "Score is required",
+			conf: converter.ConversionConfig{
+				"quality_target": map[string]interface{}{},
+			},
+			err: "quality_target: invalid quality target: min_score must be in the range (0, 1]",
+		},
+		{
+			name: "Wrong type",
+			conf: converter.ConversionConfig{
+				"quality_target": 0.98,
+			},
+			err: "quality_target must be a map: invalid quality target",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			target, rest, err := converter.ExtractQualityTarget(tc.conf)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				assert.True(t, errors.Is(err, converter.ErrInvalidQualityTarget))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.target, target)
+			assert.Equal(t, tc.rest, rest)
+		})
+	}
+}
+
+func TestSearchQuality(t *testing.T) {
+	// The score grows linearly with the quality: 0.5 at 0 and 1 at 100
+	linearScore := func(quality int) float64 {
+		return 0.5 + float64(quality)/200
+	}
+
+	type testcase struct {
+		name     string
+		target   *converter.QualityTarget
+		quality  int
+		attempts int
+		err      error
+	}
+
+	cases := []testcase{
+		{
+			name:     "Find the lowest quality reaching the score",
+			target:   &converter.QualityTarget{Metric: "ssim", MinScore: 0.9, MinQuality: 1, MaxQuality: 100, MaxAttempts: 10},
+			quality:  80,
+			attempts: 7,
+		},
+		{
+			name:     "Stop after the allowed number of attempts",
+			target:   &converter.QualityTarget{Metric: "ssim", MinScore: 0.9, MinQuality: 1, MaxQuality: 100, MaxAttempts: 3},
+			quality:  88,
+			attempts: 3,
+		},
+		{
+			name:     "Lower bound is good enough",
+			target:   &converter.QualityTarget{Metric: "ssim", MinScore: 0.6, MinQuality: 50, MaxQuality: 90, MaxAttempts: 10},
+			quality:  50,
+			attempts: 5,
+		},
+		{
+			name:     "Return the best try when the score is unreachable",
+			target:   &converter.QualityTarget{Metric: "ssim", MinScore: 0.99, MinQuality: 30, MaxQuality: 90, MaxAttempts: 10},
+			quality:  90,
+			attempts: 6,
+			err:      converter.ErrQualityNotReached,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls int
+			data, result, err := converter.SearchQuality(tc.target, func(quality int) ([]byte, float64, error) {
+				calls++
+				return []byte{byte(quality)}, linearScore(quality), nil
+			})
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			require.NotNil(t, result)
+			assert.Equal(t, tc.quality, result.Quality)
+			assert.Equal(t, linearScore(tc.quality), result.Score)
+			assert.Equal(t, tc.attempts, result.Attempts)
+			assert.Equal(t, tc.attempts, calls)
+			assert.Equal(t, []byte{byte(tc.quality)}, data)
+		})
+	}
+
+	t.Run("Encoder error aborts the search", func(t *testing.T) {
+		t.Parallel()
+
+		target := &converter.QualityTarget{Metric: "ssim", MinScore: 0.9, MinQuality: 1, MaxQuality: 100, MaxAttempts: 10}
+		_, _, err := converter.SearchQuality(target, func(quality int) ([]byte, float64, error) {
+			return nil, 0, errors.New("encoder failed")
+		})
+		assert.EqualError(t, err, "encoder failed")
+	})
+}
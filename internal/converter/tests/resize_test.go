@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/chistyakoviv/converter/internal/converter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractResize(t *testing.T) {
+	type testcase struct {
+		name   string
+		conf   converter.ConversionConfig
+		resize *converter.Resize
+		rest   converter.ConversionConfig
+		err    string
+	}
+
+	cases := []testcase{
+		{
+			name: "Size is not given",
+			conf: converter.ConversionConfig{"quality": 80},
+			rest: converter.ConversionConfig{"quality": 80},
+		},
+		{
+			name:   "Width and height",
+			conf:   converter.ConversionConfig{"quality": 80, "width": 640, "height": float64(480)},
+			resize: &converter.Resize{Width: 640, Height: 480},
+			rest:   converter.ConversionConfig{"quality": 80},
+		},
+		{
+			name:   "Width only",
+			conf:   converter.ConversionConfig{"width": 320},
+			resize: &converter.Resize{Width: 320},
+			rest:   converter.ConversionConfig{},
+		},
+		{
+			name: "Fractional width",
+			conf: converter.ConversionConfig{"width": 320.5},
+			err:  "width: invalid resize: expected an integer, got 320.5",
+		},
+		{
+			name: "Height is not positive",
+			conf: converter.ConversionConfig{"height": -1},
+			err:  "height must be positive: invalid resize",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			resize, rest, err := converter.ExtractResize(tc.conf)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				assert.ErrorIs(t, err, converter.ErrInvalidResize)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.resize, resize)
+			assert.Equal(t, tc.rest, rest)
+		})
+	}
+}
+
+func TestResizeScale(t *testing.T) {
+	type testcase struct {
+		name   string
+		resize converter.Resize
+		scale  float64
+	}
+
+	cases := []testcase{
+		{name: "Fits the width", resize: converter.Resize{Width: 400}, scale: 0.5},
+		{name: "Fits the height", resize: converter.Resize{Height: 300}, scale: 0.5},
+		{name: "Fits the narrower side of the box", resize: converter.Resize{Width: 400, Height: 150}, scale: 0.25},
+		{name: "Smaller source is not upscaled", resize: converter.Resize{Width: 1600, Height: 1200}, scale: 1},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.scale, tc.resize.Scale(800, 600))
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/converter"
+	"github.com/chistyakoviv/converter/internal/file"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConfig(t *testing.T) {
+	overlays := map[string]config.Overlay{
+		"logo": {Image: "/assets/logo.png"},
+	}
+
+	type testcase struct {
+		name      string
+		mediaType file.MediaType
+		ext       string
+		conf      converter.ConversionConfig
+		err       string
+	}
+
+	cases := []testcase{
+		{
+			name:      "Empty config",
+			mediaType: file.MediaTypeImage,
+			ext:       "webp",
+		},
+		{
+			name:      "Export params of any case",
+			mediaType: file.MediaTypeImage,
+			ext:       "jpg",
+			conf:      converter.ConversionConfig{"quality": 80, "Interlace": true, "subsamplemode": float64(1)},
+		},
+		{
+			name:      "Stages and size",
+			mediaType: file.MediaTypeImage,
+			ext:       "avif",
+			conf: converter.ConversionConfig{
+				"quality_target": map[string]interface{}{"min_score": 0.98},
+				"metadata":       map[string]interface{}{"policy": "keep"},
+				"overlay":        map[string]interface{}{"name": "logo"},
+				"width":          640,
+			},
+		},
+		{
+			name:      "Size is not positive",
+			mediaType: file.MediaTypeVideo,
+			ext:       "mp4",
+			conf:      converter.ConversionConfig{"width": 0},
+			err:       "invalid conversion config: width must be positive: invalid resize",
+		},
+		{
+			name:      "Typo in a key",
+			mediaType: file.MediaTypeImage,
+			ext:       "webp",
+			conf:      converter.ConversionConfig{"qualty": 80},
+			err:       "invalid conversion config: unknown key 'qualty' for 'webp'",
+		},
+		{
+			name:      "Key of another encoder",
+			mediaType: file.MediaTypeImage,
+			ext:       "png",
+			conf:      converter.ConversionConfig{"lossless": true},
+			err:       "invalid conversion config: unknown key 'lossless' for 'png'",
+		},
+		{
+			name:      "Value out of range",
+			mediaType: file.MediaTypeImage,
+			ext:       "webp",
+			conf:      converter.ConversionConfig{"quality": 180},
+			err:       "invalid conversion config: quality: 180 is out of the range [1, 100]",
+		},
+		{
+			name:      "Fractional integer",
+			mediaType: file.MediaTypeImage,
+			ext:       "jpeg",
+			conf:      converter.ConversionConfig{"quality": 80.5},
+			err:       "invalid conversion config: quality: expected an integer, got 80.5",
+		},
+		{
+			name:      "Value of a wrong type",
+			mediaType: file.MediaTypeImage,
+			ext:       "avif",
+			conf:      converter.ConversionConfig{"lossless": "yes"},
+			err:       "invalid conversion config: lossless: expected a boolean, got string",
+		},
+		{
+			name:      "Invalid stage",
+			mediaType: file.MediaTypeImage,
+			ext:       "webp",
+			conf:      converter.ConversionConfig{"overlay": map[string]interface{}{"name": "missing"}},
+			err:       "invalid conversion config: overlay: invalid overlay: unknown asset 'missing'",
+		},
+		{
+			name:      "Stage of another media type",
+			mediaType: file.MediaTypeVideo,
+			ext:       "webm",
+			conf:      converter.ConversionConfig{"quality_target": map[string]interface{}{"min_score": 0.98}},
+			err:       "invalid conversion config: unknown key 'quality_target' for 'webm'",
+		},
+		{
+			name:      "Format without encoder",
+			mediaType: file.MediaTypeImage,
+			ext:       "gif",
+			err:       "invalid conversion config: no encoder for 'gif'",
+		},
+		{
+			name:      "FFmpeg options given as strings and numbers",
+			mediaType: file.MediaTypeVideo,
+			ext:       "webm",
+			conf: converter.ConversionConfig{
+				"c:v":      "libvpx-vp9",
+				"crf":      "40",
+				"b:v":      0,
+				"cpu-used": 4,
+				"r":        "30000/1001",
+				"vf":       "scale=320:-2",
+			},
+		},
+		{
+			name:      "FFmpeg option outside the allow-list",
+			mediaType: file.MediaTypeVideo,
+			ext:       "mp4",
+			conf:      converter.ConversionConfig{"i": "/etc/passwd"},
+			err:       "invalid conversion config: unknown key 'i' for 'mp4'",
+		},
+		{
+			name:      "Unknown codec",
+			mediaType: file.MediaTypeVideo,
+			ext:       "webm",
+			conf:      converter.ConversionConfig{"c:v": "vp9"},
+			err:       "invalid conversion config: c:v: 'vp9' is not one of libvpx, libvpx-vp9, libaom-av1, libsvtav1, librav1e, libx264, libx265, copy",
+		},
+		{
+			name:      "Numeric string out of range",
+			mediaType: file.MediaTypeVideo,
+			ext:       "webm",
+			conf:      converter.ConversionConfig{"crf": "99"},
+			err:       "invalid conversion config: crf: 99 is out of the range [0, 63]",
+		},
+		{
+			name:      "Malformed bitrate",
+			mediaType: file.MediaTypeVideo,
+			ext:       "mp4",
+			conf:      converter.ConversionConfig{"b:v": "2 megabits"},
+			err:       "invalid conversion config: b:v: malformed value '2 megabits'",
+		},
+		{
+			name:      "Allowed filtergraphs",
+			mediaType: file.MediaTypeVideo,
+			ext:       "mp4",
+			conf: converter.ConversionConfig{
+				"vf":             "scale=w=iw/2:h=-2,fps=30000/1001,format=yuv420p",
+				"filter_complex": "[0:v]crop=in_w-100:in_h,pad=ceil(iw/2)*2:ceil(ih/2)*2[out]",
+			},
+		},
+		{
+			name:      "Filter reading a file",
+			mediaType: file.MediaTypeVideo,
+			ext:       "mp4",
+			conf:      converter.ConversionConfig{"vf": "scale=320:-2,movie=/etc/passwd"},
+			err:       "invalid conversion config: vf: filter 'movie' is not one of scale, crop, pad, fps, format, setsar, setdar, transpose, hflip, vflip, yadif, null",
+		},
+		{
+			name:      "Text read from a file",
+			mediaType: file.MediaTypeVideo,
+			ext:       "mp4",
+			conf:      converter.ConversionConfig{"vf": "drawtext=textfile=/etc/passwd"},
+			err:       "invalid conversion config: vf: filter 'drawtext' is not one of scale, crop, pad, fps, format, setsar, setdar, transpose, hflip, vflip, yadif, null",
+		},
+		{
+			name:      "Font read from a file",
+			mediaType: file.MediaTypeVideo,
+			ext:       "mp4",
+			conf:      converter.ConversionConfig{"filter:v": "drawtext=fontfile=/etc/passwd:text=a"},
+			err:       "invalid conversion config: filter:v: filter 'drawtext' is not one of scale, crop, pad, fps, format, setsar, setdar, transpose, hflip, vflip, yadif, null",
+		},
+		{
+			name:      "Subtitles read from a file",
+			mediaType: file.MediaTypeVideo,
+			ext:       "mp4",
+			conf:      converter.ConversionConfig{"vf": "subtitles=/etc/passwd"},
+			err:       "invalid conversion config: vf: filter 'subtitles' is not one of scale, crop, pad, fps, format, setsar, setdar, transpose, hflip, vflip, yadif, null",
+		},
+		{
+			name:      "ASS subtitles read from a file",
+			mediaType: file.MediaTypeVideo,
+			ext:       "mp4",
+			conf:      converter.ConversionConfig{"vf": "ass=/etc/passwd"},
+			err:       "invalid conversion config: vf: filter 'ass' is not one of scale, crop, pad, fps, format, setsar, setdar, transpose, hflip, vflip, yadif, null",
+		},
+		{
+			name:      "LUT read from a file",
+			mediaType: file.MediaTypeVideo,
+			ext:       "mp4",
+			conf:      converter.ConversionConfig{"vf": "lut3d=/etc/passwd"},
+			err:       "invalid conversion config: vf: filter 'lut3d' is not one of scale, crop, pad, fps, format, setsar, setdar, transpose, hflip, vflip, yadif, null",
+		},
+		{
+			name:      "Hald CLUT in a complex filtergraph",
+			mediaType: file.MediaTypeVideo,
+			ext:       "mp4",
+			conf:      converter.ConversionConfig{"filter_complex": "[0:v][0:v]haldclut"},
+			err:       "invalid conversion config: filter_complex: filter 'haldclut' is not one of scale, crop, pad, fps, format, setsar, setdar, transpose, hflip, vflip, yadif, null",
+		},
+		{
+			name:      "Curves read from a file",
+			mediaType: file.MediaTypeVideo,
+			ext:       "mp4",
+			conf:      converter.ConversionConfig{"vf": "scale=320:-2;curves=psfile=/etc/passwd"},
+			err:       "invalid conversion config: vf: filter 'curves' is not one of scale, crop, pad, fps, format, setsar, setdar, transpose, hflip, vflip, yadif, null",
+		},
+		{
+			name:      "Commands read from a file",
+			mediaType: file.MediaTypeVideo,
+			ext:       "mp4",
+			conf:      converter.ConversionConfig{"vf": "sendcmd=f=/etc/passwd,scale=320:-2"},
+			err:       "invalid conversion config: vf: filter 'sendcmd' is not one of scale, crop, pad, fps, format, setsar, setdar, transpose, hflip, vflip, yadif, null",
+		},
+		{
+			name:      "Quoted filter arguments",
+			mediaType: file.MediaTypeVideo,
+			ext:       "mp4",
+			conf:      converter.ConversionConfig{"vf": "scale='320,drawtext=textfile=/etc/passwd'"},
+			err:       "invalid conversion config: vf: malformed arguments of 'scale': ''320'",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := converter.ValidateConfig(tc.mediaType, tc.ext, tc.conf, overlays)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				assert.ErrorIs(t, err, converter.ErrInvalidConversionConfig)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestValidateDefaults(t *testing.T) {
+	dfs := &config.Defaults{
+		Image: config.ImageDefaults{Formats: []model.ConvertTo{
+			{Ext: "webp", ConvConf: map[string]interface{}{"quality": 80}},
+		}},
+		Video: config.VideoDefaults{Formats: []model.ConvertTo{
+			{Ext: "webm", ConvConf: map[string]interface{}{"crf": "40", "preset": "slow", "threads": 4}, Optional: map[string]interface{}{"suffix": ".vp9"}},
+		}},
+	}
+
+	err := converter.ValidateDefaults(dfs, nil)
+
+	assert.EqualError(t, err, "video format 'webm;.vp9': invalid conversion config: unknown key 'threads' for 'webm'")
+
+	dfs = &config.Defaults{Presets: map[string]*config.Preset{
+		"thumbnail": {Image: config.ImageDefaults{Formats: []model.ConvertTo{
+			{Ext: "avif", ConvConf: map[string]interface{}{"quality": 0}},
+		}}},
+	}}
+
+	err = converter.ValidateDefaults(dfs, nil)
+
+	assert.EqualError(t, err, "preset 'thumbnail' image format 'avif': invalid conversion config: quality: 0 is out of the range [1, 100]")
+}
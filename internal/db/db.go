@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 
+	"github.com/chistyakoviv/converter/internal/model"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
@@ -16,6 +17,19 @@ type Client interface {
 	Close() error
 }
 
+// Migrator applies the schema migrations embedded in the binary
+type Migrator interface {
+	// Applies the pending migrations and returns them
+	Up(ctx context.Context) ([]model.Migration, error)
+	// Rolls back the last applied migration and returns it
+	Down(ctx context.Context) (*model.Migration, error)
+	// Returns all the migrations in the order of their versions
+	Status(ctx context.Context) ([]model.Migration, error)
+	// Reports ErrSchemaBehind or ErrSchemaAhead if the schema version doesn't match the latest migration
+	Check(ctx context.Context) error
+	Close() error
+}
+
 type TxManager interface {
 	ReadCommitted(ctx context.Context, f TxHandler) error
 }
@@ -24,6 +38,7 @@ type TxManager interface {
 type DB interface {
 	QueryExecutor
 	Transactor
+	Pinger
 	Close()
 }
 
@@ -37,6 +52,10 @@ type Transactor interface {
 	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
 }
 
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
 type QueryExecutor interface {
 	Exec(ctx context.Context, q Query, args ...interface{}) (pgconn.CommandTag, error)
 	Query(ctx context.Context, q Query, args ...interface{}) (pgx.Rows, error)
@@ -6,4 +6,9 @@ import "errors"
 var (
 	ErrNotFound    = errors.New("not found")
 	ErrEntryExists = errors.New("entry already exists")
+	// The binary is older or newer than the schema
+	ErrSchemaBehind = errors.New("schema is behind the migrations")
+	ErrSchemaAhead  = errors.New("schema is ahead of the migrations")
+	// There is no applied migration to roll back
+	ErrNoMigration = errors.New("no migration to roll back")
 )
@@ -0,0 +1,10 @@
+package db
+
+import "strings"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// DirPattern returns the LIKE pattern matching the paths under the directory
+func DirPattern(dir string) string {
+	return likeEscaper.Replace(strings.TrimSuffix(dir, "/")) + "/%"
+}
@@ -0,0 +1,121 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/db"
+	"github.com/chistyakoviv/converter/internal/model"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+type migrator struct {
+	provider *goose.Provider
+}
+
+// NewMigrator creates a migrator of the migrations in the file system, e.g. the embedded ones.
+// Goose works with database/sql, so the migrator has a connection pool of its own.
+func NewMigrator(dsn string, migrations fs.FS) (db.Migrator, error) {
+	sqlDB, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Instances starting at once apply the migrations one by one
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		_ = sqlDB.Close()
+		return nil, fmt.Errorf("failed to create migration lock: %w", err)
+	}
+
+	provider, err := goose.NewProvider(goose.DialectPostgres, sqlDB, migrations, goose.WithSessionLocker(locker))
+	if err != nil {
+		_ = sqlDB.Close()
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	return &migrator{provider: provider}, nil
+}
+
+func (m *migrator) Up(ctx context.Context) ([]model.Migration, error) {
+	results, err := m.provider.Up(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	applied := make([]model.Migration, 0, len(results))
+	for _, result := range results {
+		applied = append(applied, toMigration(result.Source, true, result.Duration))
+	}
+	return applied, nil
+}
+
+func (m *migrator) Down(ctx context.Context) (*model.Migration, error) {
+	result, err := m.provider.Down(ctx)
+	if errors.Is(err, goose.ErrNoNextVersion) {
+		return nil, db.ErrNoMigration
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to roll back migration: %w", err)
+	}
+
+	migration := toMigration(result.Source, false, result.Duration)
+	return &migration, nil
+}
+
+func (m *migrator) Status(ctx context.Context) ([]model.Migration, error) {
+	statuses, err := m.provider.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migrations status: %w", err)
+	}
+
+	migrations := make([]model.Migration, 0, len(statuses))
+	for _, status := range statuses {
+		migration := toMigration(status.Source, status.State == goose.StateApplied, 0)
+		migration.AppliedAt = status.AppliedAt
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
+
+// The schema is behind if the binary is newer than the last deployment that migrated it,
+// and ahead if the binary is older, e.g. after a rollback of the deployment without rolling back the schema
+func (m *migrator) Check(ctx context.Context) error {
+	current, err := m.provider.GetDBVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	var latest int64
+	if sources := m.provider.ListSources(); len(sources) > 0 {
+		latest = sources[len(sources)-1].Version
+	}
+
+	switch {
+	case current < latest:
+		return fmt.Errorf("schema version %d, latest migration %d: %w", current, latest, db.ErrSchemaBehind)
+	case current > latest:
+		return fmt.Errorf("schema version %d, latest migration %d: %w", current, latest, db.ErrSchemaAhead)
+	}
+	return nil
+}
+
+func (m *migrator) Close() error {
+	return m.provider.Close()
+}
+
+func toMigration(source *goose.Source, applied bool, duration time.Duration) model.Migration {
+	return model.Migration{
+		Version:  source.Version,
+		Name:     path.Base(source.Path),
+		Applied:  applied,
+		Duration: duration,
+	}
+}
@@ -0,0 +1,127 @@
+// Code generated by mockery v2.50.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	db "github.com/chistyakoviv/converter/internal/db"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockClient is an autogenerated mock type for the Client type
+type MockClient struct {
+	mock.Mock
+}
+
+type MockClient_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockClient) EXPECT() *MockClient_Expecter {
+	return &MockClient_Expecter{mock: &_m.Mock}
+}
+
+// Close provides a mock function with no fields
+func (_m *MockClient) Close() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Close")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockClient_Close_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Close'
+type MockClient_Close_Call struct {
+	*mock.Call
+}
+
+// Close is a helper method to define mock.On call
+func (_e *MockClient_Expecter) Close() *MockClient_Close_Call {
+	return &MockClient_Close_Call{Call: _e.mock.On("Close")}
+}
+
+func (_c *MockClient_Close_Call) Run(run func()) *MockClient_Close_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockClient_Close_Call) Return(_a0 error) *MockClient_Close_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockClient_Close_Call) RunAndReturn(run func() error) *MockClient_Close_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DB provides a mock function with no fields
+func (_m *MockClient) DB() db.DB {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for DB")
+	}
+
+	var r0 db.DB
+	if rf, ok := ret.Get(0).(func() db.DB); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(db.DB)
+		}
+	}
+
+	return r0
+}
+
+// MockClient_DB_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DB'
+type MockClient_DB_Call struct {
+	*mock.Call
+}
+
+// DB is a helper method to define mock.On call
+func (_e *MockClient_Expecter) DB() *MockClient_DB_Call {
+	return &MockClient_DB_Call{Call: _e.mock.On("DB")}
+}
+
+func (_c *MockClient_DB_Call) Run(run func()) *MockClient_DB_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockClient_DB_Call) Return(_a0 db.DB) *MockClient_DB_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockClient_DB_Call) RunAndReturn(run func() db.DB) *MockClient_DB_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockClient creates a new instance of MockClient. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockClient(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockClient {
+	mock := &MockClient{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
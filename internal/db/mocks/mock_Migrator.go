@@ -0,0 +1,303 @@
+// Code generated by mockery v2.50.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	model "github.com/chistyakoviv/converter/internal/model"
+)
+
+// MockMigrator is an autogenerated mock type for the Migrator type
+type MockMigrator struct {
+	mock.Mock
+}
+
+type MockMigrator_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockMigrator) EXPECT() *MockMigrator_Expecter {
+	return &MockMigrator_Expecter{mock: &_m.Mock}
+}
+
+// Check provides a mock function with given fields: ctx
+func (_m *MockMigrator) Check(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Check")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockMigrator_Check_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Check'
+type MockMigrator_Check_Call struct {
+	*mock.Call
+}
+
+// Check is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockMigrator_Expecter) Check(ctx interface{}) *MockMigrator_Check_Call {
+	return &MockMigrator_Check_Call{Call: _e.mock.On("Check", ctx)}
+}
+
+func (_c *MockMigrator_Check_Call) Run(run func(ctx context.Context)) *MockMigrator_Check_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockMigrator_Check_Call) Return(_a0 error) *MockMigrator_Check_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockMigrator_Check_Call) RunAndReturn(run func(context.Context) error) *MockMigrator_Check_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Close provides a mock function with no fields
+func (_m *MockMigrator) Close() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Close")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockMigrator_Close_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Close'
+type MockMigrator_Close_Call struct {
+	*mock.Call
+}
+
+// Close is a helper method to define mock.On call
+func (_e *MockMigrator_Expecter) Close() *MockMigrator_Close_Call {
+	return &MockMigrator_Close_Call{Call: _e.mock.On("Close")}
+}
+
+func (_c *MockMigrator_Close_Call) Run(run func()) *MockMigrator_Close_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockMigrator_Close_Call) Return(_a0 error) *MockMigrator_Close_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockMigrator_Close_Call) RunAndReturn(run func() error) *MockMigrator_Close_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Down provides a mock function with given fields: ctx
+func (_m *MockMigrator) Down(ctx context.Context) (*model.Migration, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Down")
+	}
+
+	var r0 *model.Migration
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*model.Migration, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *model.Migration); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Migration)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockMigrator_Down_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Down'
+type MockMigrator_Down_Call struct {
+	*mock.Call
+}
+
+// Down is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockMigrator_Expecter) Down(ctx interface{}) *MockMigrator_Down_Call {
+	return &MockMigrator_Down_Call{Call: _e.mock.On("Down", ctx)}
+}
+
+func (_c *MockMigrator_Down_Call) Run(run func(ctx context.Context)) *MockMigrator_Down_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockMigrator_Down_Call) Return(_a0 *model.Migration, _a1 error) *MockMigrator_Down_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockMigrator_Down_Call) RunAndReturn(run func(context.Context) (*model.Migration, error)) *MockMigrator_Down_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Status provides a mock function with given fields: ctx
+func (_m *MockMigrator) Status(ctx context.Context) ([]model.Migration, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Status")
+	}
+
+	var r0 []model.Migration
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]model.Migration, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []model.Migration); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Migration)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockMigrator_Status_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Status'
+type MockMigrator_Status_Call struct {
+	*mock.Call
+}
+
+// Status is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockMigrator_Expecter) Status(ctx interface{}) *MockMigrator_Status_Call {
+	return &MockMigrator_Status_Call{Call: _e.mock.On("Status", ctx)}
+}
+
+func (_c *MockMigrator_Status_Call) Run(run func(ctx context.Context)) *MockMigrator_Status_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockMigrator_Status_Call) Return(_a0 []model.Migration, _a1 error) *MockMigrator_Status_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockMigrator_Status_Call) RunAndReturn(run func(context.Context) ([]model.Migration, error)) *MockMigrator_Status_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Up provides a mock function with given fields: ctx
+func (_m *MockMigrator) Up(ctx context.Context) ([]model.Migration, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Up")
+	}
+
+	var r0 []model.Migration
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]model.Migration, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []model.Migration); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Migration)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockMigrator_Up_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Up'
+type MockMigrator_Up_Call struct {
+	*mock.Call
+}
+
+// Up is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockMigrator_Expecter) Up(ctx interface{}) *MockMigrator_Up_Call {
+	return &MockMigrator_Up_Call{Call: _e.mock.On("Up", ctx)}
+}
+
+func (_c *MockMigrator_Up_Call) Run(run func(ctx context.Context)) *MockMigrator_Up_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockMigrator_Up_Call) Return(_a0 []model.Migration, _a1 error) *MockMigrator_Up_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockMigrator_Up_Call) RunAndReturn(run func(context.Context) ([]model.Migration, error)) *MockMigrator_Up_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockMigrator creates a new instance of MockMigrator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockMigrator(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockMigrator {
+	mock := &MockMigrator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -2,12 +2,18 @@ package pg
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"time"
 
 	"github.com/chistyakoviv/converter/internal/db"
+	"github.com/chistyakoviv/converter/internal/metrics"
+	"github.com/chistyakoviv/converter/internal/tracing"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type key string
@@ -33,34 +39,52 @@ func NewDB(name string, dbc *pgxpool.Pool, logger *slog.Logger) db.DB {
 func (p *pg) Exec(ctx context.Context, q db.Query, args ...interface{}) (pgconn.CommandTag, error) {
 	p.logger.Debug("query debug", slog.Attr{Key: "name", Value: slog.StringValue(q.Name)}, slog.Attr{Key: "sql", Value: slog.StringValue(q.QueryRaw)})
 
+	ctx, o := observe(ctx, q)
+	var (
+		tag pgconn.CommandTag
+		err error
+	)
 	tx, ok := ctx.Value(TxKey).(pgx.Tx)
 	if ok {
-		return tx.Exec(ctx, q.QueryRaw, args...)
+		tag, err = tx.Exec(ctx, q.QueryRaw, args...)
+	} else {
+		tag, err = p.dbc.Exec(ctx, q.QueryRaw, args...)
 	}
+	o.end(err)
 
-	return p.dbc.Exec(ctx, q.QueryRaw, args...)
+	return tag, err
 }
 
 func (p *pg) Query(ctx context.Context, q db.Query, args ...interface{}) (pgx.Rows, error) {
 	p.logger.Debug("query debug", slog.Attr{Key: "name", Value: slog.StringValue(q.Name)}, slog.Attr{Key: "sql", Value: slog.StringValue(q.QueryRaw)})
 
+	// Rows are streamed, so only the time to the first response is recorded
+	ctx, o := observe(ctx, q)
+	var (
+		rows pgx.Rows
+		err  error
+	)
 	tx, ok := ctx.Value(TxKey).(pgx.Tx)
 	if ok {
-		return tx.Query(ctx, q.QueryRaw, args...)
+		rows, err = tx.Query(ctx, q.QueryRaw, args...)
+	} else {
+		rows, err = p.dbc.Query(ctx, q.QueryRaw, args...)
 	}
+	o.end(err)
 
-	return p.dbc.Query(ctx, q.QueryRaw, args...)
+	return rows, err
 }
 
 func (p *pg) QueryRow(ctx context.Context, q db.Query, args ...interface{}) pgx.Row {
 	p.logger.Debug("query debug", slog.Attr{Key: "name", Value: slog.StringValue(q.Name)}, slog.Attr{Key: "sql", Value: slog.StringValue(q.QueryRaw)})
 
+	ctx, o := observe(ctx, q)
 	tx, ok := ctx.Value(TxKey).(pgx.Tx)
 	if ok {
-		return tx.QueryRow(ctx, q.QueryRaw, args...)
+		return &row{Row: tx.QueryRow(ctx, q.QueryRaw, args...), observation: o}
 	}
 
-	return p.dbc.QueryRow(ctx, q.QueryRaw, args...)
+	return &row{Row: p.dbc.QueryRow(ctx, q.QueryRaw, args...), observation: o}
 }
 
 /**
@@ -81,10 +105,57 @@ func (p *pg) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, erro
 	return p.dbc.BeginTx(ctx, txOptions)
 }
 
+// Acquires a connection from the pool and checks that the database responds
+func (p *pg) Ping(ctx context.Context) error {
+	return p.dbc.Ping(ctx)
+}
+
 func (p *pg) Close() {
 	p.dbc.Close()
 }
 
+// The query of a row is executed when the row is scanned, so the observation is ended by Scan
+type row struct {
+	pgx.Row
+	observation *observation
+}
+
+func (r *row) Scan(dest ...any) error {
+	err := r.Row.Scan(dest...)
+	r.observation.end(err)
+	return err
+}
+
+// Duration and span of a query
+type observation struct {
+	query db.Query
+	start time.Time
+	span  trace.Span
+}
+
+// Starts the span of the query named after the query, the span is a child of the span of the context
+func observe(ctx context.Context, q db.Query) (context.Context, *observation) {
+	ctx, span := tracing.Tracer().Start(ctx, q.Name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.String("db.query.text", q.QueryRaw),
+		),
+	)
+	return ctx, &observation{query: q, start: time.Now(), span: span}
+}
+
+// Records the duration and ends the span of the query, missing rows are not counted as errors
+func (o *observation) end(err error) {
+	metrics.DBQueryDuration.WithLabelValues(o.query.Name).Observe(time.Since(o.start).Seconds())
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		metrics.DBQueryErrors.WithLabelValues(o.query.Name).Inc()
+		tracing.End(o.span, err)
+		return
+	}
+	o.span.End()
+}
+
 func MakeContextTx(ctx context.Context, tx pgx.Tx) context.Context {
 	return context.WithValue(ctx, TxKey, tx)
 }
@@ -1,7 +1,10 @@
 package file
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -64,6 +67,34 @@ func ToTmpFilePath(src string) string {
 	return fmt.Sprintf("%s.tmp%s", path, fileExt)
 }
 
+// Reports whether the path is produced by ToTmpFilePath
+func IsTmpFilePath(src string) bool {
+	fileExt := filepath.Ext(src)
+	return fileExt != "" && strings.HasSuffix(strings.TrimSuffix(src, fileExt), ".tmp")
+}
+
+// Returns the path of the file the temporary file produced by ToTmpFilePath is written for
+func FromTmpFilePath(src string) string {
+	fileExt := filepath.Ext(src)
+
+	return strings.TrimSuffix(strings.TrimSuffix(src, fileExt), ".tmp") + fileExt
+}
+
 func Ext(src string) string {
 	return strings.ToLower(strings.TrimPrefix(filepath.Ext(src), "."))
 }
+
+// Returns the hex encoded SHA-256 of the file content
+func Hash(src string) (string, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
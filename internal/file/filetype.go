@@ -1,7 +1,9 @@
 package file
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/h2non/filetype"
@@ -9,6 +11,14 @@ import (
 
 const headSize = 261
 
+type MediaType int
+
+const (
+	MediaTypeUnknown MediaType = iota
+	MediaTypeImage
+	MediaTypeVideo
+)
+
 func readHead(src string) ([]byte, error) {
 	wd, err := os.Getwd()
 	if err != nil {
@@ -19,14 +29,20 @@ func readHead(src string) ([]byte, error) {
 		return nil, err
 	}
 
-	head := make([]byte, headSize)
 	defer func() {
 		// TODO: log error
 		_ = file.Close()
 	}()
 
-	_, err = file.Read(head)
-	if err != nil {
+	return readHeadFrom(file)
+}
+
+// A file shorter than the head is not an error, the rest of the head is left zeroed
+func readHeadFrom(r io.Reader) ([]byte, error) {
+	head := make([]byte, headSize)
+
+	_, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
 		return nil, err
 	}
 
@@ -50,3 +66,35 @@ func IsVideo(src string) (bool, error) {
 
 	return filetype.IsVideo(head), nil
 }
+
+// DetectMediaType reads the head of the file once and reports whether it is an image or a video.
+func DetectMediaType(src string) (MediaType, error) {
+	head, err := readHead(src)
+	if err != nil {
+		return MediaTypeUnknown, err
+	}
+
+	return detectMediaType(head), nil
+}
+
+// DetectReaderMediaType is the same as DetectMediaType for a file being read, e.g. from an object storage.
+// Only the head of the file is consumed.
+func DetectReaderMediaType(r io.Reader) (MediaType, error) {
+	head, err := readHeadFrom(r)
+	if err != nil {
+		return MediaTypeUnknown, err
+	}
+
+	return detectMediaType(head), nil
+}
+
+func detectMediaType(head []byte) MediaType {
+	switch {
+	case filetype.IsImage(head):
+		return MediaTypeImage
+	case filetype.IsVideo(head):
+		return MediaTypeVideo
+	}
+
+	return MediaTypeUnknown
+}
@@ -0,0 +1,91 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Files matching the patterns of this file are skipped by scans of the directory and its subdirectories
+const IgnoreFileName = ".converterignore"
+
+type ignorePattern struct {
+	pattern string
+	// The pattern contains a slash, so it is matched against the path relative to the directory
+	anchored bool
+	dirOnly  bool
+}
+
+// IgnoreRules are the patterns of an ignore file. A pattern without a slash is matched against
+// the name of a file at any depth, otherwise against the path relative to the directory of the ignore file.
+// A pattern with a trailing slash matches only directories. Empty lines and lines starting with # are skipped.
+type IgnoreRules struct {
+	patterns []ignorePattern
+}
+
+// ReadIgnoreFile returns the rules of the ignore file in the directory or nil if there is no such file.
+func ReadIgnoreFile(dir string) (*IgnoreRules, error) {
+	data, err := os.ReadFile(filepath.Join(dir, IgnoreFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseIgnoreRules(data)
+}
+
+func ParseIgnoreRules(data []byte) (*IgnoreRules, error) {
+	rules := &IgnoreRules{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var p ignorePattern
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			p.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if _, err := filepath.Match(line, ""); err != nil {
+			return nil, err
+		}
+		p.pattern = line
+
+		rules.patterns = append(rules.patterns, p)
+	}
+
+	return rules, scanner.Err()
+}
+
+// Match reports whether the path relative to the directory of the ignore file is ignored.
+func (r *IgnoreRules) Match(rel string, isDir bool) bool {
+	rel = filepath.ToSlash(rel)
+	name := rel[strings.LastIndex(rel, "/")+1:]
+
+	for _, p := range r.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		target := name
+		if p.anchored {
+			target = rel
+		}
+		if ok, _ := filepath.Match(p.pattern, target); ok {
+			return true
+		}
+	}
+
+	return false
+}
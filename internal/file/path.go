@@ -0,0 +1,87 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+var (
+	ErrInvalidPath     = errors.New("invalid path")
+	ErrPathOutsideRoot = errors.New("path is outside the root directory")
+)
+
+// Canonicalize cleans the path of a file relative to the working directory and confines it to the root directory.
+// The existing part of the path is resolved, so symlinks leading outside the root are rejected as well.
+func Canonicalize(src string, rootDir string) (string, error) {
+	if src == "" || strings.ContainsRune(src, 0) {
+		return "", fmt.Errorf("%w: '%s'", ErrInvalidPath, src)
+	}
+	for _, segment := range strings.Split(src, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("%w: '%s' contains '..'", ErrInvalidPath, src)
+		}
+	}
+
+	fullpath := path.Clean(EnsureLeadingSlash(src))
+	root := path.Clean(EnsureLeadingSlash(rootDir))
+	if !IsWithin(root, fullpath) {
+		return "", fmt.Errorf("%w: '%s'", ErrPathOutsideRoot, src)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	// There is nothing to resolve if the files are not kept locally
+	realRoot, err := filepath.EvalSymlinks(wd + root)
+	if os.IsNotExist(err) {
+		return fullpath, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	realPath, err := resolveExisting(wd + fullpath)
+	if err != nil {
+		return "", err
+	}
+	if !IsWithin(realRoot, realPath) {
+		return "", fmt.Errorf("%w: '%s' is a symlink to '%s'", ErrPathOutsideRoot, src, realPath)
+	}
+
+	return fullpath, nil
+}
+
+// Reports whether the cleaned path is the directory itself or lies inside it
+func IsWithin(dir string, src string) bool {
+	return src == dir || strings.HasPrefix(src, strings.TrimSuffix(dir, "/")+"/")
+}
+
+// Resolves the symlinks of the longest existing prefix of the path, the rest is appended as is
+func resolveExisting(src string) (string, error) {
+	var rest []string
+	for {
+		resolved, err := filepath.EvalSymlinks(src)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...), nil
+		}
+		// A file in the middle of the path cannot be resolved further, the same as a missing one
+		if !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, syscall.ENOTDIR) {
+			return "", err
+		}
+
+		parent := filepath.Dir(src)
+		if parent == src {
+			return filepath.Join(append([]string{src}, rest...)...), nil
+		}
+		rest = append([]string{filepath.Base(src)}, rest...)
+		src = parent
+	}
+}
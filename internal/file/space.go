@@ -0,0 +1,30 @@
+package file
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"syscall"
+)
+
+// FreeSpace returns the number of bytes available to unprivileged users on the filesystem of the directory.
+// The directory may not exist yet, so its nearest existing parent is checked instead.
+func FreeSpace(dir string) (uint64, error) {
+	for {
+		var stat syscall.Statfs_t
+		err := syscall.Statfs(dir, &stat)
+		if err == nil {
+			// #nosec G115 -- the block size is positive
+			return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return 0, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return 0, err
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chistyakoviv/converter/internal/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalize(t *testing.T) {
+	dir, err := os.MkdirTemp(".", "files")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	root := filepath.Clean(dir)
+
+	outside, err := os.MkdirTemp("", "outside")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(outside) })
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "images"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "images", "photo.jpg"), []byte("photo"), 0o600))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "escape")))
+	require.NoError(t, os.Symlink("images", filepath.Join(root, "alias")))
+	require.NoError(t, os.Symlink("/etc/passwd", filepath.Join(root, "passwd.jpg")))
+
+	type testcase struct {
+		name     string
+		path     string
+		fullpath string
+		err      error
+	}
+
+	cases := []testcase{
+		{
+			name:     "Clean path",
+			path:     "/" + root + "/images/photo.jpg",
+			fullpath: "/" + root + "/images/photo.jpg",
+		},
+		{
+			name:     "Missing leading slash, duplicate and current directory segments",
+			path:     root + "//images/./photo.jpg",
+			fullpath: "/" + root + "/images/photo.jpg",
+		},
+		{
+			name:     "Missing file",
+			path:     "/" + root + "/images/missing/photo.jpg",
+			fullpath: "/" + root + "/images/missing/photo.jpg",
+		},
+		{
+			name:     "Symlink inside the root",
+			path:     "/" + root + "/alias/photo.jpg",
+			fullpath: "/" + root + "/alias/photo.jpg",
+		},
+		{
+			name:     "Root itself",
+			path:     "/" + root,
+			fullpath: "/" + root,
+		},
+		{
+			name: "Empty path",
+			path: "",
+			err:  file.ErrInvalidPath,
+		},
+		{
+			name: "Null byte",
+			path: "/" + root + "/images/photo.jpg\x00.png",
+			err:  file.ErrInvalidPath,
+		},
+		{
+			name: "Parent directory",
+			path: "/" + root + "/../etc/passwd",
+			err:  file.ErrInvalidPath,
+		},
+		{
+			name: "Parent directory inside the root",
+			path: "/" + root + "/images/../images/photo.jpg",
+			err:  file.ErrInvalidPath,
+		},
+		{
+			name: "Path outside the root",
+			path: "/etc/passwd",
+			err:  file.ErrPathOutsideRoot,
+		},
+		{
+			name: "Sibling with the root as prefix",
+			path: "/" + root + "-other/photo.jpg",
+			err:  file.ErrPathOutsideRoot,
+		},
+		{
+			name: "Symlinked directory outside the root",
+			path: "/" + root + "/escape/photo.jpg",
+			err:  file.ErrPathOutsideRoot,
+		},
+		{
+			name: "Symlinked file outside the root",
+			path: "/" + root + "/passwd.jpg",
+			err:  file.ErrPathOutsideRoot,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fullpath, err := file.Canonicalize(tc.path, root)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.fullpath, fullpath)
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package converter
+
+import (
+	"github.com/chistyakoviv/converter/internal/http-server/request"
+	"github.com/chistyakoviv/converter/internal/model"
+)
+
+func ToApiKeyInfoFromRequest(dto request.ApiKeyRequest) *model.ApiKeyInfo {
+	return &model.ApiKeyInfo{
+		Name:         dto.Name,
+		Scopes:       dto.Scopes,
+		PathPrefixes: dto.PathPrefixes,
+	}
+}
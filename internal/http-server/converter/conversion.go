@@ -1,14 +1,20 @@
 package converter
 
 import (
+	"github.com/chistyakoviv/converter/internal/constants"
 	"github.com/chistyakoviv/converter/internal/file"
 	"github.com/chistyakoviv/converter/internal/http-server/request"
 	"github.com/chistyakoviv/converter/internal/model"
 )
 
-func ToConversionInfoFromRequest(dto request.ConversionRequest) *model.ConversionInfo {
-	finfo := file.ExtractInfo(dto.Path)
+func ToConversionInfoFromRequest(dto request.ConversionRequest) (*model.ConversionInfo, error) {
+	fullpath, err := file.Canonicalize(dto.Path, constants.FilesRootDir)
+	if err != nil {
+		return nil, err
+	}
+	finfo := file.ExtractInfo(fullpath)
 	cinfo := model.ToConversionInfoFromFileInfo(finfo)
 	cinfo.ConvertTo = dto.ConvertTo
-	return cinfo
+	cinfo.Preset = dto.Preset
+	return cinfo, nil
 }
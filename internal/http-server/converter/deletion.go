@@ -1,12 +1,21 @@
 package converter
 
 import (
+	"github.com/chistyakoviv/converter/internal/constants"
+	"github.com/chistyakoviv/converter/internal/file"
 	"github.com/chistyakoviv/converter/internal/http-server/request"
 	"github.com/chistyakoviv/converter/internal/model"
 )
 
-func ToDeletionInfoFromRequest(dto request.DeletionRequest) *model.DeletionInfo {
-	return &model.DeletionInfo{
-		Fullpath: dto.Path,
+func ToDeletionInfoFromRequest(dto request.DeletionRequest) (*model.DeletionInfo, error) {
+	fullpath, err := file.Canonicalize(dto.Path, constants.FilesRootDir)
+	if err != nil {
+		return nil, err
 	}
+	return &model.DeletionInfo{
+		Fullpath:     fullpath,
+		DeleteSource: dto.DeleteSource,
+		DeleteRecord: dto.DeleteRecord,
+		Recursive:    dto.Recursive,
+	}, nil
 }
@@ -0,0 +1,17 @@
+package converter
+
+import (
+	"github.com/chistyakoviv/converter/internal/http-server/request"
+	"github.com/chistyakoviv/converter/internal/model"
+)
+
+func ToScanOptionsFromRequest(dto request.ScanRequest) model.ScanOptions {
+	return model.ScanOptions{
+		Path:          dto.Path,
+		Include:       dto.Include,
+		Exclude:       dto.Exclude,
+		MaxDepth:      dto.MaxDepth,
+		ModifiedSince: dto.ModifiedSince,
+		DryRun:        dto.DryRun,
+	}
+}
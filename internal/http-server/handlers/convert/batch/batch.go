@@ -0,0 +1,140 @@
+package batch
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/chistyakoviv/converter/internal/http-server/converter"
+	loggerDecorator "github.com/chistyakoviv/converter/internal/http-server/decorators/logger"
+	validationrDecorator "github.com/chistyakoviv/converter/internal/http-server/decorators/validation"
+	"github.com/chistyakoviv/converter/internal/http-server/handlers"
+	"github.com/chistyakoviv/converter/internal/http-server/handlers/convert"
+	httpMiddleware "github.com/chistyakoviv/converter/internal/http-server/middleware"
+	"github.com/chistyakoviv/converter/internal/http-server/request"
+	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
+	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/chistyakoviv/converter/internal/tracing"
+	"github.com/go-chi/render"
+)
+
+type ItemResponse struct {
+	resp.Response
+	Path string `json:"path"`
+	Id   int64  `json:"id,omitempty"`
+	// Status code of the error the item would be responded with by POST /convert
+	Code int `json:"code,omitempty"`
+}
+
+type ConversionBatchResponse struct {
+	resp.Response
+	// Results in the order of the request items
+	Items []ItemResponse `json:"items"`
+	// The number of enqueued files
+	Enqueued int `json:"enqueued"`
+}
+
+func New(
+	ctx context.Context,
+	logger *slog.Logger,
+	validation handlers.Validator,
+	conversionService service.ConversionQueueService,
+	taskService service.TaskService,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := tracing.WithSpanOf(ctx, r.Context())
+		decoratedLogger := loggerDecorator.LoggerDecorator("handlers.conversion.batch.New", logger, r)
+
+		var req request.ConversionBatchRequest
+
+		err := validationrDecorator.ValidationDecorator(decoratedLogger, validation, &req, w, r)
+		if err != nil {
+			return
+		}
+
+		key := httpMiddleware.ApiKeyFromContext(r.Context())
+		client := httpMiddleware.ClientId(r)
+		traceParent := tracing.TraceParent(r.Context())
+		items := make([]ItemResponse, len(req.Items))
+		// Indexes of the items with resolved paths
+		indexes := make([]int, 0, len(req.Items))
+		infos := make([]*model.ConversionInfo, 0, len(req.Items))
+
+		for i, item := range req.Items {
+			info, err := converter.ToConversionInfoFromRequest(item)
+			if err == nil {
+				err = httpMiddleware.AuthorizePath(r.Context(), info.Fullpath)
+			}
+			if err != nil {
+				items[i] = itemError(decoratedLogger, item.Path, err, "failed to resolve path")
+				continue
+			}
+			info.ApiKeyId = key.AuditId()
+			info.Client = client
+			info.TraceParent = traceParent
+			indexes = append(indexes, i)
+			infos = append(infos, info)
+		}
+
+		var results []model.BatchResult
+		if len(infos) > 0 {
+			results, err = conversionService.AddMany(ctx, infos)
+			if err != nil {
+				decoratedLogger.Error("failed to add files to conversion queue", slogger.Err(err))
+
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to add files to conversion queue"))
+
+				return
+			}
+		}
+
+		enqueued := 0
+		for j, result := range results {
+			i := indexes[j]
+			if result.Err != nil {
+				items[i] = itemError(decoratedLogger, req.Items[i].Path, result.Err, "failed to add file to conversion queue")
+				convert.SetRetryAfter(w, result.Err)
+				continue
+			}
+			items[i] = ItemResponse{
+				Response: resp.OK(),
+				Path:     req.Items[i].Path,
+				Id:       result.Id,
+			}
+			enqueued++
+		}
+
+		decoratedLogger.Debug("files added", slog.Int("enqueued", enqueued), slog.Int("total", len(items)))
+
+		// Try to process the files immediately
+		if enqueued > 0 {
+			taskService.TryQueueConversion()
+		}
+
+		render.JSON(w, r, ConversionBatchResponse{
+			Response: resp.OK(),
+			Items:    items,
+			Enqueued: enqueued,
+		})
+	}
+}
+
+// itemError describes the error of the item, the details of unexpected errors are replaced with the fallback message
+func itemError(logger *slog.Logger, path string, err error, fallback string) ItemResponse {
+	statusCode, msg, ok := convert.DescribeError(err)
+	if ok {
+		logger.Debug(msg, slog.String("path", path), slogger.Err(err))
+	} else {
+		logger.Error(fallback, slog.String("path", path), slogger.Err(err))
+		msg = fallback
+	}
+
+	return ItemResponse{
+		Response: resp.Error(msg),
+		Path:     path,
+		Code:     statusCode,
+	}
+}
@@ -0,0 +1,193 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chistyakoviv/converter/internal/http-server/handlers/convert/batch"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service/conversionq"
+	serviceMocks "github.com/chistyakoviv/converter/internal/service/mocks"
+)
+
+func TestConvertBatchHandler(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		logger     = dummy.NewDummyLogger()
+		validation = validator.New()
+	)
+
+	type testcase struct {
+		name       string
+		input      string
+		respError  string
+		statusCode int
+		// Expected results of the items
+		items                 []batch.ItemResponse
+		enqueued              int
+		mockConversionService func(tc *testcase) *serviceMocks.MockConversionQueueService
+		mockTaskService       func(tc *testcase) *serviceMocks.MockTaskService
+	}
+
+	cases := []testcase{
+		{
+			name:       "Incorrect request: empty data",
+			input:      "",
+			respError:  "empty request",
+			statusCode: http.StatusBadRequest,
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				return serviceMocks.NewMockConversionQueueService(t)
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				return serviceMocks.NewMockTaskService(t)
+			},
+		},
+		{
+			name:       "Incorrect request: no items",
+			input:      `{"items": []}`,
+			respError:  "field Items is not valid",
+			statusCode: http.StatusBadRequest,
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				return serviceMocks.NewMockConversionQueueService(t)
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				return serviceMocks.NewMockTaskService(t)
+			},
+		},
+		{
+			name:       "Incorrect request: item without path",
+			input:      `{"items": [{"path": "/files/a.jpg"}, {"fullpath": "/files/b.jpg"}]}`,
+			respError:  "field Path is a required field",
+			statusCode: http.StatusBadRequest,
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				return serviceMocks.NewMockConversionQueueService(t)
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				return serviceMocks.NewMockTaskService(t)
+			},
+		},
+		{
+			name:       "Failed request: transaction is rolled back",
+			input:      `{"items": [{"path": "/files/a.jpg"}]}`,
+			respError:  "failed to add files to conversion queue",
+			statusCode: http.StatusInternalServerError,
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("AddMany", ctx, mock.Anything).Return(nil, errors.New("unexpected error")).Once()
+				return mockConversionService
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				return serviceMocks.NewMockTaskService(t)
+			},
+		},
+		{
+			name:       "Invalid paths are not enqueued",
+			input:      `{"items": [{"path": "/etc/passwd"}, {"path": "/files/../etc/passwd"}]}`,
+			statusCode: http.StatusOK,
+			items: []batch.ItemResponse{
+				{Path: "/etc/passwd", Code: http.StatusBadRequest},
+				{Path: "/files/../etc/passwd", Code: http.StatusBadRequest},
+			},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				return serviceMocks.NewMockConversionQueueService(t)
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				return serviceMocks.NewMockTaskService(t)
+			},
+		},
+		{
+			name: "Results are reported per item",
+			input: `{"items": [
+				{"path": "/files/a.jpg"},
+				{"path": "/etc/passwd"},
+				{"path": "/files/b.jpg"},
+				{"path": "/files/c.txt"},
+				{"path": "/files/d.jpg"}
+			]}`,
+			statusCode: http.StatusOK,
+			items: []batch.ItemResponse{
+				{Path: "/files/a.jpg", Id: 1},
+				{Path: "/etc/passwd", Code: http.StatusBadRequest},
+				{Path: "/files/b.jpg", Code: http.StatusConflict},
+				{Path: "/files/c.txt", Code: http.StatusBadRequest},
+				{Path: "/files/d.jpg", Code: http.StatusInternalServerError},
+			},
+			enqueued: 1,
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("AddMany", ctx, mock.MatchedBy(func(infos []*model.ConversionInfo) bool {
+					// The item with the invalid path is left out
+					return len(infos) == 4 && infos[1].Fullpath == "/files/b.jpg"
+				})).Return([]model.BatchResult{
+					{Id: 1},
+					{Id: -1, Err: fmt.Errorf("add failed: %w", conversionq.ErrPathAlreadyExist)},
+					{Id: -1, Err: conversionq.ErrFileTypeNotSupported},
+					{Id: -1, Err: errors.New("unexpected error")},
+				}, nil).Once()
+				return mockConversionService
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				mockTaskService := serviceMocks.NewMockTaskService(t)
+				mockTaskService.On("TryQueueConversion").Return(true).Once()
+				return mockTaskService
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockConversionService := tc.mockConversionService(&tc)
+			mockTaskService := tc.mockTaskService(&tc)
+
+			handler := batch.New(
+				ctx,
+				logger,
+				validation,
+				mockConversionService,
+				mockTaskService,
+			)
+			req, err := http.NewRequest(http.MethodPost, "/convert/batch", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp batch.ConversionBatchResponse
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			assert.Equal(t, tc.respError, resp.Error)
+			assert.Equal(t, tc.statusCode, rr.Result().StatusCode)
+			require.Len(t, resp.Items, len(tc.items))
+			for i, item := range tc.items {
+				assert.Equal(t, item.Path, resp.Items[i].Path)
+				assert.Equal(t, item.Id, resp.Items[i].Id)
+				assert.Equal(t, item.Code, resp.Items[i].Code)
+				if item.Code == 0 {
+					assert.Empty(t, resp.Items[i].Error)
+				} else {
+					assert.NotEmpty(t, resp.Items[i].Error)
+				}
+			}
+			assert.Equal(t, tc.enqueued, resp.Enqueued)
+			mockConversionService.AssertExpectations(t)
+			mockTaskService.AssertExpectations(t)
+		})
+	}
+}
@@ -2,7 +2,6 @@ package convert
 
 import (
 	"context"
-	"errors"
 	"log/slog"
 	"net/http"
 
@@ -10,11 +9,12 @@ import (
 	loggerDecorator "github.com/chistyakoviv/converter/internal/http-server/decorators/logger"
 	validationrDecorator "github.com/chistyakoviv/converter/internal/http-server/decorators/validation"
 	"github.com/chistyakoviv/converter/internal/http-server/handlers"
+	httpMiddleware "github.com/chistyakoviv/converter/internal/http-server/middleware"
 	"github.com/chistyakoviv/converter/internal/http-server/request"
 	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
 	"github.com/chistyakoviv/converter/internal/lib/slogger"
 	"github.com/chistyakoviv/converter/internal/service"
-	"github.com/chistyakoviv/converter/internal/service/conversionq"
+	"github.com/chistyakoviv/converter/internal/tracing"
 	"github.com/go-chi/render"
 )
 
@@ -31,6 +31,8 @@ func New(
 	taskService service.TaskService,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		// Services run with the application context, but their spans belong to the trace of the request
+		ctx := tracing.WithSpanOf(ctx, r.Context())
 		decoratedLogger := loggerDecorator.LoggerDecorator("handlers.conversion.New", logger, r)
 
 		var req request.ConversionRequest
@@ -40,52 +42,37 @@ func New(
 			return
 		}
 
-		id, err := conversionService.Add(ctx, converter.ToConversionInfoFromRequest(req))
-		if errors.Is(err, conversionq.ErrPathAlreadyExist) {
-			decoratedLogger.Debug("file with the specified path or filestem already exists", slog.String("path", req.Path))
-
-			render.Status(r, http.StatusConflict) // 409
-			render.JSON(w, r, resp.Error("file with the specified path already exists in the conversion queue"))
-
-			return
-		}
-		if errors.Is(err, conversionq.ErrFileDoesNotExist) {
-			decoratedLogger.Debug("file does not exist", slog.String("path", req.Path))
-
-			render.Status(r, http.StatusNotFound) // 404
-			render.JSON(w, r, resp.Error("file does not exist"))
-
-			return
+		info, err := converter.ToConversionInfoFromRequest(req)
+		if err == nil {
+			err = httpMiddleware.AuthorizePath(r.Context(), info.Fullpath)
 		}
-		if errors.Is(err, conversionq.ErrFileTypeNotSupported) {
-			decoratedLogger.Debug("file type not supported", slog.String("path", req.Path))
+		if statusCode, msg, ok := DescribeError(err); ok {
+			decoratedLogger.Debug(msg, slog.String("path", req.Path), slogger.Err(err))
 
-			render.Status(r, http.StatusBadRequest) // 400
-			render.JSON(w, r, resp.Error("file type not supported"))
+			render.Status(r, statusCode)
+			render.JSON(w, r, resp.Error(msg))
 
 			return
 		}
-		if errors.Is(err, conversionq.ErrFailedDetermineFileType) {
-			decoratedLogger.Debug("failed to determine file type", slog.String("path", req.Path))
+		if err != nil {
+			decoratedLogger.Error("failed to resolve path", slogger.Err(err))
 
-			render.Status(r, http.StatusUnprocessableEntity) // 422
-			render.JSON(w, r, resp.Error("failed to determine file type"))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to resolve path"))
 
 			return
 		}
-		if errors.Is(err, conversionq.ErrInvalidConversionFormat) {
-			decoratedLogger.Debug("cannot convert to the specified format", slog.String("path", req.Path))
+		info.ApiKeyId = httpMiddleware.ApiKeyFromContext(r.Context()).AuditId()
+		info.Client = httpMiddleware.ClientId(r)
+		info.TraceParent = tracing.TraceParent(r.Context())
 
-			render.Status(r, http.StatusBadRequest) // 400
-			render.JSON(w, r, resp.Error("cannot convert to the specified format"))
-
-			return
-		}
-		if errors.Is(err, conversionq.ErrEmptyTargetFormatList) {
-			decoratedLogger.Debug("target format list is empty", slog.String("path", req.Path))
+		id, err := conversionService.Add(ctx, info)
+		if statusCode, msg, ok := DescribeError(err); ok {
+			decoratedLogger.Debug(msg, slog.String("path", req.Path), slogger.Err(err))
 
-			render.Status(r, http.StatusBadRequest) // 400
-			render.JSON(w, r, resp.Error("target format list is empty"))
+			SetRetryAfter(w, err)
+			render.Status(r, statusCode)
+			render.JSON(w, r, resp.Error(msg))
 
 			return
 		}
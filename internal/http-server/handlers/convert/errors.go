@@ -0,0 +1,57 @@
+package convert
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/chistyakoviv/converter/internal/converter"
+	"github.com/chistyakoviv/converter/internal/file"
+	httpMiddleware "github.com/chistyakoviv/converter/internal/http-server/middleware"
+	"github.com/chistyakoviv/converter/internal/service/apikey"
+	"github.com/chistyakoviv/converter/internal/service/conversionq"
+)
+
+// Errors of a conversion request reported to clients
+var requestErrors = []struct {
+	err        error
+	statusCode int
+	msg        string
+}{
+	{file.ErrInvalidPath, http.StatusBadRequest, "invalid path"},
+	{file.ErrPathOutsideRoot, http.StatusBadRequest, "path is outside the files directory"},
+	{apikey.ErrPathNotAllowed, http.StatusForbidden, "path is not allowed for the api key"},
+	{conversionq.ErrPathAlreadyExist, http.StatusConflict, "file with the specified path already exists in the conversion queue"},
+	{conversionq.ErrFileDoesNotExist, http.StatusNotFound, "file does not exist"},
+	{conversionq.ErrFileTypeNotSupported, http.StatusBadRequest, "file type not supported"},
+	{conversionq.ErrFailedDetermineFileType, http.StatusUnprocessableEntity, "failed to determine file type"},
+	{conversionq.ErrInvalidConversionFormat, http.StatusBadRequest, "cannot convert to the specified format"},
+	{conversionq.ErrEmptyTargetFormatList, http.StatusBadRequest, "target format list is empty"},
+	{conversionq.ErrUnknownPreset, http.StatusBadRequest, ""},
+	{conversionq.ErrInvalidOutputName, http.StatusBadRequest, ""},
+	{converter.ErrInvalidConversionConfig, http.StatusBadRequest, ""},
+	{conversionq.ErrQuotaExceeded, http.StatusTooManyRequests, "pending conversion quota exceeded"},
+}
+
+// DescribeError returns the status code and the message of an error caused by the request.
+// Reports false for unexpected errors, their details must not be exposed to clients.
+func DescribeError(err error) (int, string, bool) {
+	for _, e := range requestErrors {
+		if !errors.Is(err, e.err) {
+			continue
+		}
+		// The message explains which template, format or parameter is invalid
+		if e.msg == "" {
+			return e.statusCode, err.Error(), true
+		}
+		return e.statusCode, e.msg, true
+	}
+	return http.StatusInternalServerError, "", false
+}
+
+// SetRetryAfter tells the client when to retry a request rejected by the quota
+func SetRetryAfter(w http.ResponseWriter, err error) {
+	var quotaErr *conversionq.QuotaError
+	if errors.As(err, &quotaErr) {
+		httpMiddleware.SetRetryAfter(w, quotaErr.RetryAfter)
+	}
+}
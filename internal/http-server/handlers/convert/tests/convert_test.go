@@ -3,19 +3,24 @@ package tests
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/chistyakoviv/converter/internal/converter"
 	"github.com/chistyakoviv/converter/internal/http-server/handlers"
 	"github.com/chistyakoviv/converter/internal/http-server/handlers/convert"
 	handlersMocks "github.com/chistyakoviv/converter/internal/http-server/handlers/mocks"
+	httpMiddleware "github.com/chistyakoviv/converter/internal/http-server/middleware"
 	"github.com/chistyakoviv/converter/internal/http-server/request"
 	"github.com/chistyakoviv/converter/internal/logger/dummy"
 	"github.com/chistyakoviv/converter/internal/model"
@@ -30,16 +35,22 @@ func TestConvertHandler(t *testing.T) {
 		ctx              = context.Background()
 		logger           = dummy.NewDummyLogger()
 		validation       = validator.New()
-		convertTo        = []model.ConvertTo{{Ext: "123", Optional: map[string]interface{}{"replace_orig_ext": true}, ConvConf: map[string]interface{}{"quality": float64(100)}}}
+		// Requests without an api key are limited by the remote address
+		client    = "ip:192.0.2.1"
+		convertTo = []model.ConvertTo{{Ext: "123", Optional: map[string]interface{}{"replace_orig_ext": true}, ConvConf: map[string]interface{}{"quality": float64(100)}}}
 	)
 
 	type testcase struct {
-		name                  string
-		input                 string
-		respError             string
-		statusCode            int
-		conversionInfo        *model.ConversionInfo
-		conversionReq         *request.ConversionRequest
+		name           string
+		input          string
+		respError      string
+		statusCode     int
+		conversionInfo *model.ConversionInfo
+		conversionReq  *request.ConversionRequest
+		// Key of the authenticated request, nil if the authentication is disabled
+		key *model.ApiKey
+		// Expected Retry-After header
+		retryAfter            string
 		mockValidator         func(tc *testcase) handlers.Validator
 		mockConversionService func(tc *testcase) *serviceMocks.MockConversionQueueService
 		mockTaskService       func(tc *testcase) *serviceMocks.MockTaskService
@@ -64,9 +75,77 @@ func TestConvertHandler(t *testing.T) {
 				return mockTaskService
 			},
 		},
+		{
+			name:       "Hostile path: parent directory",
+			input:      `{"path": "/files/../etc/passwd"}`,
+			respError:  "invalid path",
+			statusCode: http.StatusBadRequest,
+			mockValidator: func(tc *testcase) handlers.Validator {
+				return validation
+			},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				return mockConversionService
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				mockTaskService := serviceMocks.NewMockTaskService(t)
+				return mockTaskService
+			},
+		},
+		{
+			name:       "Hostile path: parent directory without leading slash",
+			input:      `{"path": "files/images/../../../etc/passwd"}`,
+			respError:  "invalid path",
+			statusCode: http.StatusBadRequest,
+			mockValidator: func(tc *testcase) handlers.Validator {
+				return validation
+			},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				return mockConversionService
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				mockTaskService := serviceMocks.NewMockTaskService(t)
+				return mockTaskService
+			},
+		},
+		{
+			name:       "Hostile path: outside the files directory",
+			input:      `{"path": "/etc/passwd"}`,
+			respError:  "path is outside the files directory",
+			statusCode: http.StatusBadRequest,
+			mockValidator: func(tc *testcase) handlers.Validator {
+				return validation
+			},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				return mockConversionService
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				mockTaskService := serviceMocks.NewMockTaskService(t)
+				return mockTaskService
+			},
+		},
+		{
+			name:       "Hostile path: files directory prefix",
+			input:      `{"path": "/files-other/image.jpg"}`,
+			respError:  "path is outside the files directory",
+			statusCode: http.StatusBadRequest,
+			mockValidator: func(tc *testcase) handlers.Validator {
+				return validation
+			},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				return mockConversionService
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				mockTaskService := serviceMocks.NewMockTaskService(t)
+				return mockTaskService
+			},
+		},
 		{
 			name:       "Incorrect request: invalid data",
-			input:      `{"fullpath": "/path/to/file.ext"}`,
+			input:      `{"fullpath": "/files/path/to/file.ext"}`,
 			respError:  "field Path is a required field",
 			statusCode: http.StatusBadRequest,
 			mockValidator: func(tc *testcase) handlers.Validator {
@@ -83,11 +162,11 @@ func TestConvertHandler(t *testing.T) {
 		},
 		{
 			name:           "Incorrect request: path duplicate",
-			input:          `{"path": "/path/to/file.ext"}`,
+			input:          `{"path": "/files/path/to/file.ext"}`,
 			respError:      "file with the specified path already exists in the conversion queue",
 			statusCode:     http.StatusConflict,
-			conversionInfo: &model.ConversionInfo{Fullpath: "/path/to/file.ext", Path: "/path/to", Filestem: "file", Ext: "ext"},
-			conversionReq:  &request.ConversionRequest{Path: "/path/to/file.ext"},
+			conversionInfo: &model.ConversionInfo{Fullpath: "/files/path/to/file.ext", Path: "/files/path/to", Filestem: "file", Ext: "ext", Client: client},
+			conversionReq:  &request.ConversionRequest{Path: "/files/path/to/file.ext"},
 			mockValidator: func(tc *testcase) handlers.Validator {
 				mockValidator := handlersMocks.NewMockValidator(t)
 				mockValidator.On("Struct", tc.conversionReq).Return(nil).Once()
@@ -105,11 +184,11 @@ func TestConvertHandler(t *testing.T) {
 		},
 		{
 			name:           "Incorrect request: non-existent file",
-			input:          `{"path": "/path/to/file.ext"}`,
+			input:          `{"path": "/files/path/to/file.ext"}`,
 			respError:      "file does not exist",
 			statusCode:     http.StatusNotFound,
-			conversionInfo: &model.ConversionInfo{Fullpath: "/path/to/file.ext", Path: "/path/to", Filestem: "file", Ext: "ext"},
-			conversionReq:  &request.ConversionRequest{Path: "/path/to/file.ext"},
+			conversionInfo: &model.ConversionInfo{Fullpath: "/files/path/to/file.ext", Path: "/files/path/to", Filestem: "file", Ext: "ext", Client: client},
+			conversionReq:  &request.ConversionRequest{Path: "/files/path/to/file.ext"},
 			mockValidator: func(tc *testcase) handlers.Validator {
 				mockValidator := handlersMocks.NewMockValidator(t)
 				mockValidator.On("Struct", tc.conversionReq).Return(nil).Once()
@@ -127,11 +206,11 @@ func TestConvertHandler(t *testing.T) {
 		},
 		{
 			name:           "Incorrect request: unsupported extension",
-			input:          `{"path": "/path/to/file.ext"}`,
+			input:          `{"path": "/files/path/to/file.ext"}`,
 			respError:      "file type not supported",
 			statusCode:     http.StatusBadRequest,
-			conversionInfo: &model.ConversionInfo{Fullpath: "/path/to/file.ext", Path: "/path/to", Filestem: "file", Ext: "ext"},
-			conversionReq:  &request.ConversionRequest{Path: "/path/to/file.ext"},
+			conversionInfo: &model.ConversionInfo{Fullpath: "/files/path/to/file.ext", Path: "/files/path/to", Filestem: "file", Ext: "ext", Client: client},
+			conversionReq:  &request.ConversionRequest{Path: "/files/path/to/file.ext"},
 			mockValidator: func(tc *testcase) handlers.Validator {
 				mockValidator := handlersMocks.NewMockValidator(t)
 				mockValidator.On("Struct", tc.conversionReq).Return(nil).Once()
@@ -149,11 +228,11 @@ func TestConvertHandler(t *testing.T) {
 		},
 		{
 			name:           "Incorrect request: unknown file type",
-			input:          `{"path": "/path/to/file.ext"}`,
+			input:          `{"path": "/files/path/to/file.ext"}`,
 			respError:      "failed to determine file type",
 			statusCode:     http.StatusUnprocessableEntity,
-			conversionInfo: &model.ConversionInfo{Fullpath: "/path/to/file.ext", Path: "/path/to", Filestem: "file", Ext: "ext"},
-			conversionReq:  &request.ConversionRequest{Path: "/path/to/file.ext"},
+			conversionInfo: &model.ConversionInfo{Fullpath: "/files/path/to/file.ext", Path: "/files/path/to", Filestem: "file", Ext: "ext", Client: client},
+			conversionReq:  &request.ConversionRequest{Path: "/files/path/to/file.ext"},
 			mockValidator: func(tc *testcase) handlers.Validator {
 				mockValidator := handlersMocks.NewMockValidator(t)
 				mockValidator.On("Struct", tc.conversionReq).Return(nil).Once()
@@ -171,11 +250,11 @@ func TestConvertHandler(t *testing.T) {
 		},
 		{
 			name:           "Incorrect request: wrong conversion format",
-			input:          `{"path": "/path/to/file.ext", "convert_to": [{"ext": "123", "optional": {"replace_orig_ext": true}, "conv_conf": {"quality": 100}}]}`,
+			input:          `{"path": "/files/path/to/file.ext", "convert_to": [{"ext": "123", "optional": {"replace_orig_ext": true}, "conv_conf": {"quality": 100}}]}`,
 			respError:      "cannot convert to the specified format",
 			statusCode:     http.StatusBadRequest,
-			conversionInfo: &model.ConversionInfo{Fullpath: "/path/to/file.ext", Path: "/path/to", Filestem: "file", Ext: "ext", ConvertTo: convertTo},
-			conversionReq:  &request.ConversionRequest{Path: "/path/to/file.ext", ConvertTo: convertTo},
+			conversionInfo: &model.ConversionInfo{Fullpath: "/files/path/to/file.ext", Path: "/files/path/to", Filestem: "file", Ext: "ext", ConvertTo: convertTo, Client: client},
+			conversionReq:  &request.ConversionRequest{Path: "/files/path/to/file.ext", ConvertTo: convertTo},
 			mockValidator: func(tc *testcase) handlers.Validator {
 				mockValidator := handlersMocks.NewMockValidator(t)
 				mockValidator.On("Struct", tc.conversionReq).Return(nil).Once()
@@ -193,11 +272,11 @@ func TestConvertHandler(t *testing.T) {
 		},
 		{
 			name:           "Incorrect request: no target formats specified",
-			input:          `{"path": "/path/to/file.ext"}`,
+			input:          `{"path": "/files/path/to/file.ext"}`,
 			respError:      "target format list is empty",
 			statusCode:     http.StatusBadRequest,
-			conversionInfo: &model.ConversionInfo{Fullpath: "/path/to/file.ext", Path: "/path/to", Filestem: "file", Ext: "ext"},
-			conversionReq:  &request.ConversionRequest{Path: "/path/to/file.ext"},
+			conversionInfo: &model.ConversionInfo{Fullpath: "/files/path/to/file.ext", Path: "/files/path/to", Filestem: "file", Ext: "ext", Client: client},
+			conversionReq:  &request.ConversionRequest{Path: "/files/path/to/file.ext"},
 			mockValidator: func(tc *testcase) handlers.Validator {
 				mockValidator := handlersMocks.NewMockValidator(t)
 				mockValidator.On("Struct", tc.conversionReq).Return(nil).Once()
@@ -213,13 +292,61 @@ func TestConvertHandler(t *testing.T) {
 				return mockTaskService
 			},
 		},
+		{
+			name:           "Incorrect request: converted files cannot be named",
+			input:          `{"path": "/files/path/to/file.ext"}`,
+			respError:      "converted files cannot be named: invalid output template",
+			statusCode:     http.StatusBadRequest,
+			conversionInfo: &model.ConversionInfo{Fullpath: "/files/path/to/file.ext", Path: "/files/path/to", Filestem: "file", Ext: "ext", Client: client},
+			conversionReq:  &request.ConversionRequest{Path: "/files/path/to/file.ext"},
+			mockValidator: func(tc *testcase) handlers.Validator {
+				mockValidator := handlersMocks.NewMockValidator(t)
+				mockValidator.On("Struct", tc.conversionReq).Return(nil).Once()
+				return mockValidator
+			},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("Add", ctx, tc.conversionInfo).
+					Return(errorId, fmt.Errorf("%w: %w", conversionq.ErrInvalidOutputName, model.ErrInvalidOutputTemplate)).
+					Once()
+				return mockConversionService
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				mockTaskService := serviceMocks.NewMockTaskService(t)
+				return mockTaskService
+			},
+		},
+		{
+			name:           "Incorrect request: unknown encoder parameter",
+			input:          `{"path": "/files/path/to/file.jpg", "convert_to": [{"ext": "webp", "conv_conf": {"qualty": 80}}]}`,
+			respError:      "target format 'webp': invalid conversion config: unknown key 'qualty' for 'webp'",
+			statusCode:     http.StatusBadRequest,
+			conversionInfo: &model.ConversionInfo{Fullpath: "/files/path/to/file.jpg", Path: "/files/path/to", Filestem: "file", Ext: "jpg", ConvertTo: []model.ConvertTo{{Ext: "webp", ConvConf: map[string]interface{}{"qualty": float64(80)}}}, Client: client},
+			conversionReq:  &request.ConversionRequest{Path: "/files/path/to/file.jpg", ConvertTo: []model.ConvertTo{{Ext: "webp", ConvConf: map[string]interface{}{"qualty": float64(80)}}}},
+			mockValidator: func(tc *testcase) handlers.Validator {
+				mockValidator := handlersMocks.NewMockValidator(t)
+				mockValidator.On("Struct", tc.conversionReq).Return(nil).Once()
+				return mockValidator
+			},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("Add", ctx, tc.conversionInfo).
+					Return(errorId, fmt.Errorf("target format 'webp': %w: unknown key 'qualty' for 'webp'", converter.ErrInvalidConversionConfig)).
+					Once()
+				return mockConversionService
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				mockTaskService := serviceMocks.NewMockTaskService(t)
+				return mockTaskService
+			},
+		},
 		{
 			name:           "Incorrect request: unknown error",
-			input:          `{"path": "/path/to/file.ext"}`,
+			input:          `{"path": "/files/path/to/file.ext"}`,
 			respError:      "failed to add file to conversion queue",
 			statusCode:     http.StatusInternalServerError,
-			conversionInfo: &model.ConversionInfo{Fullpath: "/path/to/file.ext", Path: "/path/to", Filestem: "file", Ext: "ext"},
-			conversionReq:  &request.ConversionRequest{Path: "/path/to/file.ext"},
+			conversionInfo: &model.ConversionInfo{Fullpath: "/files/path/to/file.ext", Path: "/files/path/to", Filestem: "file", Ext: "ext", Client: client},
+			conversionReq:  &request.ConversionRequest{Path: "/files/path/to/file.ext"},
 			mockValidator: func(tc *testcase) handlers.Validator {
 				mockValidator := handlersMocks.NewMockValidator(t)
 				mockValidator.On("Struct", tc.conversionReq).Return(nil).Once()
@@ -237,16 +364,80 @@ func TestConvertHandler(t *testing.T) {
 		},
 		{
 			name:           "Successful request",
-			input:          `{"path": "/path/to/file.ext"}`,
+			input:          `{"path": "/files/path/to/file.ext"}`,
 			respError:      "",
 			statusCode:     http.StatusOK,
-			conversionInfo: &model.ConversionInfo{Fullpath: "/path/to/file.ext", Path: "/path/to", Filestem: "file", Ext: "ext"},
-			conversionReq:  &request.ConversionRequest{Path: "/path/to/file.ext"},
+			conversionInfo: &model.ConversionInfo{Fullpath: "/files/path/to/file.ext", Path: "/files/path/to", Filestem: "file", Ext: "ext", Client: client},
+			conversionReq:  &request.ConversionRequest{Path: "/files/path/to/file.ext"},
+			mockValidator: func(tc *testcase) handlers.Validator {
+				mockValidator := handlersMocks.NewMockValidator(t)
+				mockValidator.On("Struct", tc.conversionReq).Return(nil).Once()
+				return mockValidator
+			},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("Add", ctx, tc.conversionInfo).Return(successId, nil).Once()
+				return mockConversionService
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				mockTaskService := serviceMocks.NewMockTaskService(t)
+				mockTaskService.On("TryQueueConversion").Return(true).Once()
+				return mockTaskService
+			},
+		},
+		{
+			name:           "Rejected request: pending conversion quota exceeded",
+			input:          `{"path": "/files/path/to/file.ext"}`,
+			respError:      "pending conversion quota exceeded",
+			statusCode:     http.StatusTooManyRequests,
+			retryAfter:     "90",
+			conversionInfo: &model.ConversionInfo{Fullpath: "/files/path/to/file.ext", Path: "/files/path/to", Filestem: "file", Ext: "ext", Client: client},
+			conversionReq:  &request.ConversionRequest{Path: "/files/path/to/file.ext"},
 			mockValidator: func(tc *testcase) handlers.Validator {
 				mockValidator := handlersMocks.NewMockValidator(t)
 				mockValidator.On("Struct", tc.conversionReq).Return(nil).Once()
 				return mockValidator
 			},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("Add", ctx, tc.conversionInfo).
+					Return(errorId, &conversionq.QuotaError{Client: client, RetryAfter: 90 * time.Second}).
+					Once()
+				return mockConversionService
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				mockTaskService := serviceMocks.NewMockTaskService(t)
+				return mockTaskService
+			},
+		},
+		{
+			name:       "Forbidden request: path is not allowed for the api key",
+			input:      `{"path": "/files/private/file.jpg"}`,
+			respError:  "path is not allowed for the api key",
+			statusCode: http.StatusForbidden,
+			key:        &model.ApiKey{Id: 7, PathPrefixes: []string{"/files/uploads"}},
+			mockValidator: func(tc *testcase) handlers.Validator {
+				return validation
+			},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				return mockConversionService
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				mockTaskService := serviceMocks.NewMockTaskService(t)
+				return mockTaskService
+			},
+		},
+		{
+			name:           "Successful request: the api key is recorded",
+			input:          `{"path": "/files/uploads/file.jpg"}`,
+			respError:      "",
+			statusCode:     http.StatusOK,
+			key:            &model.ApiKey{Id: 7, PathPrefixes: []string{"/files/uploads"}},
+			conversionInfo: &model.ConversionInfo{Fullpath: "/files/uploads/file.jpg", Path: "/files/uploads", Filestem: "file", Ext: "jpg", ApiKeyId: sql.NullInt64{Int64: 7, Valid: true}, Client: "key:7"},
+			mockValidator: func(tc *testcase) handlers.Validator {
+				return validation
+			},
 			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
 				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
 				mockConversionService.On("Add", ctx, tc.conversionInfo).Return(successId, nil).Once()
@@ -279,6 +470,8 @@ func TestConvertHandler(t *testing.T) {
 			)
 			req, err := http.NewRequest(http.MethodPost, "/convert", bytes.NewReader([]byte(tc.input)))
 			require.NoError(t, err)
+			req.RemoteAddr = "192.0.2.1:1234"
+			req = req.WithContext(httpMiddleware.WithApiKey(req.Context(), tc.key))
 
 			rr := httptest.NewRecorder()
 			handler.ServeHTTP(rr, req)
@@ -291,6 +484,7 @@ func TestConvertHandler(t *testing.T) {
 
 			assert.Equal(t, tc.respError, resp.Error)
 			assert.Equal(t, tc.statusCode, rr.Result().StatusCode)
+			assert.Equal(t, tc.retryAfter, rr.Header().Get("Retry-After"))
 			mockConversionService.AssertExpectations(t)
 			mockTaskService.AssertExpectations(t)
 		})
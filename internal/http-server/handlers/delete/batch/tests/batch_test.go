@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chistyakoviv/converter/internal/http-server/handlers/delete/batch"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service/deletionq"
+	serviceMocks "github.com/chistyakoviv/converter/internal/service/mocks"
+)
+
+func TestDeleteBatchHandler(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		logger     = dummy.NewDummyLogger()
+		validation = validator.New()
+	)
+
+	type testcase struct {
+		name       string
+		input      string
+		respError  string
+		statusCode int
+		// Expected results of the items
+		items               []batch.ItemResponse
+		enqueued            int64
+		mockDeletionService func(tc *testcase) *serviceMocks.MockDeletionQueueService
+		mockTaskService     func(tc *testcase) *serviceMocks.MockTaskService
+	}
+
+	cases := []testcase{
+		{
+			name:       "Incorrect request: no items",
+			input:      `{}`,
+			respError:  "field Items is a required field",
+			statusCode: http.StatusBadRequest,
+			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
+				return serviceMocks.NewMockDeletionQueueService(t)
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				return serviceMocks.NewMockTaskService(t)
+			},
+		},
+		{
+			name:       "Failed request: transaction is rolled back",
+			input:      `{"items": [{"path": "/files/a.jpg"}]}`,
+			respError:  "failed to add files to deletion queue",
+			statusCode: http.StatusInternalServerError,
+			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
+				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
+				mockDeletionService.On("AddMany", ctx, []*model.DeletionInfo{{Fullpath: "/files/a.jpg"}}).
+					Return(nil, errors.New("unexpected error")).
+					Once()
+				return mockDeletionService
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				return serviceMocks.NewMockTaskService(t)
+			},
+		},
+		{
+			name:       "Nothing is enqueued",
+			input:      `{"items": [{"path": "/etc/passwd"}, {"path": "/files/a.jpg"}]}`,
+			statusCode: http.StatusOK,
+			items: []batch.ItemResponse{
+				{Path: "/etc/passwd", Code: http.StatusBadRequest},
+				{Path: "/files/a.jpg", Code: http.StatusNotFound},
+			},
+			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
+				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
+				mockDeletionService.On("AddMany", ctx, []*model.DeletionInfo{{Fullpath: "/files/a.jpg"}}).
+					Return([]model.BatchResult{{Id: -1, Err: deletionq.ErrFileDoesNotExist}}, nil).
+					Once()
+				return mockDeletionService
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				return serviceMocks.NewMockTaskService(t)
+			},
+		},
+		{
+			name: "Results are reported per item",
+			input: `{"items": [
+				{"path": "/files/a.jpg", "delete_source": true},
+				{"path": "/files/dir", "recursive": true},
+				{"path": "/files/b.jpg"}
+			]}`,
+			statusCode: http.StatusOK,
+			items: []batch.ItemResponse{
+				{Path: "/files/a.jpg", Id: 1},
+				{Path: "/files/dir", Count: 3},
+				{Path: "/files/b.jpg", Code: http.StatusConflict},
+			},
+			enqueued: 4,
+			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
+				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
+				mockDeletionService.On("AddMany", ctx, []*model.DeletionInfo{
+					{Fullpath: "/files/a.jpg", DeleteSource: true},
+					{Fullpath: "/files/dir", Recursive: true},
+					{Fullpath: "/files/b.jpg"},
+				}).Return([]model.BatchResult{
+					{Id: 1},
+					{Count: 3},
+					{Id: -1, Err: deletionq.ErrPathAlreadyExist},
+				}, nil).Once()
+				return mockDeletionService
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				mockTaskService := serviceMocks.NewMockTaskService(t)
+				mockTaskService.On("TryQueueDeletion").Return(true).Once()
+				return mockTaskService
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockDeletionService := tc.mockDeletionService(&tc)
+			mockTaskService := tc.mockTaskService(&tc)
+
+			handler := batch.New(
+				ctx,
+				logger,
+				validation,
+				mockDeletionService,
+				mockTaskService,
+			)
+			req, err := http.NewRequest(http.MethodPost, "/delete/batch", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp batch.DeletionBatchResponse
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			assert.Equal(t, tc.respError, resp.Error)
+			assert.Equal(t, tc.statusCode, rr.Result().StatusCode)
+			require.Len(t, resp.Items, len(tc.items))
+			for i, item := range tc.items {
+				assert.Equal(t, item.Path, resp.Items[i].Path)
+				assert.Equal(t, item.Id, resp.Items[i].Id)
+				assert.Equal(t, item.Count, resp.Items[i].Count)
+				assert.Equal(t, item.Code, resp.Items[i].Code)
+			}
+			assert.Equal(t, tc.enqueued, resp.Enqueued)
+			mockDeletionService.AssertExpectations(t)
+			mockTaskService.AssertExpectations(t)
+		})
+	}
+}
@@ -2,7 +2,6 @@ package delete
 
 import (
 	"context"
-	"errors"
 	"log/slog"
 	"net/http"
 
@@ -10,17 +9,20 @@ import (
 	loggerDecorator "github.com/chistyakoviv/converter/internal/http-server/decorators/logger"
 	validationrDecorator "github.com/chistyakoviv/converter/internal/http-server/decorators/validation"
 	"github.com/chistyakoviv/converter/internal/http-server/handlers"
+	httpMiddleware "github.com/chistyakoviv/converter/internal/http-server/middleware"
 	"github.com/chistyakoviv/converter/internal/http-server/request"
 	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
 	"github.com/chistyakoviv/converter/internal/lib/slogger"
 	"github.com/chistyakoviv/converter/internal/service"
-	"github.com/chistyakoviv/converter/internal/service/deletionq"
+	"github.com/chistyakoviv/converter/internal/tracing"
 	"github.com/go-chi/render"
 )
 
 type DeletionResponse struct {
 	resp.Response
 	Id int64 `json:"id"`
+	// The number of enqueued files for a recursive deletion
+	Count int64 `json:"count,omitempty"`
 }
 
 func New(
@@ -31,6 +33,7 @@ func New(
 	taskService service.TaskService,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := tracing.WithSpanOf(ctx, r.Context())
 		decoratedLogger := loggerDecorator.LoggerDecorator("handlers.deletion.New", logger, r)
 
 		var req request.DeletionRequest
@@ -40,20 +43,39 @@ func New(
 			return
 		}
 
-		id, err := deletionService.Add(ctx, converter.ToDeletionInfoFromRequest(req))
-		if errors.Is(err, deletionq.ErrPathAlreadyExist) {
-			decoratedLogger.Debug("file with the specified path already exists in the deletion queue", slog.String("path", req.Path))
+		info, err := converter.ToDeletionInfoFromRequest(req)
+		if err == nil {
+			err = httpMiddleware.AuthorizePath(r.Context(), info.Fullpath)
+		}
+		if statusCode, msg, ok := DescribeError(err); ok {
+			decoratedLogger.Debug(msg, slog.String("path", req.Path), slogger.Err(err))
 
-			render.Status(r, http.StatusConflict) // 409
-			render.JSON(w, r, resp.Error("file with the specified path already exists in the deletion queue"))
+			render.Status(r, statusCode)
+			render.JSON(w, r, resp.Error(msg))
 
 			return
 		}
-		if errors.Is(err, deletionq.ErrFileDoesNotExist) {
-			decoratedLogger.Debug("file does not exist", slog.String("path", req.Path))
+		if err != nil {
+			decoratedLogger.Error("failed to resolve path", slogger.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to resolve path"))
+
+			return
+		}
+		info.ApiKeyId = httpMiddleware.ApiKeyFromContext(r.Context()).AuditId()
+
+		var id, count int64
+		if info.Recursive {
+			count, err = deletionService.AddRecursive(ctx, info)
+		} else {
+			id, err = deletionService.Add(ctx, info)
+		}
+		if statusCode, msg, ok := DescribeError(err); ok {
+			decoratedLogger.Debug(msg, slog.String("path", req.Path), slogger.Err(err))
 
-			render.Status(r, http.StatusNotFound) // 404
-			render.JSON(w, r, resp.Error("file does not exist"))
+			render.Status(r, statusCode)
+			render.JSON(w, r, resp.Error(msg))
 
 			return
 		}
@@ -66,7 +88,7 @@ func New(
 			return
 		}
 
-		decoratedLogger.Debug("file added to deletion queue", slog.Int64("id", id))
+		decoratedLogger.Debug("file added to deletion queue", slog.Int64("id", id), slog.Int64("count", count))
 
 		// Try to process the file immediately
 		taskService.TryQueueDeletion()
@@ -74,6 +96,7 @@ func New(
 		render.JSON(w, r, DeletionResponse{
 			Response: resp.OK(),
 			Id:       id,
+			Count:    count,
 		})
 	}
 }
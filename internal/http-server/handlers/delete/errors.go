@@ -0,0 +1,34 @@
+package delete
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/chistyakoviv/converter/internal/file"
+	"github.com/chistyakoviv/converter/internal/service/apikey"
+	"github.com/chistyakoviv/converter/internal/service/deletionq"
+)
+
+// Errors of a deletion request reported to clients
+var requestErrors = []struct {
+	err        error
+	statusCode int
+	msg        string
+}{
+	{file.ErrInvalidPath, http.StatusBadRequest, "invalid path"},
+	{file.ErrPathOutsideRoot, http.StatusBadRequest, "path is outside the files directory"},
+	{apikey.ErrPathNotAllowed, http.StatusForbidden, "path is not allowed for the api key"},
+	{deletionq.ErrPathAlreadyExist, http.StatusConflict, "file with the specified path already exists in the deletion queue"},
+	{deletionq.ErrFileDoesNotExist, http.StatusNotFound, "file does not exist"},
+}
+
+// DescribeError returns the status code and the message of an error caused by the request.
+// Reports false for unexpected errors, their details must not be exposed to clients.
+func DescribeError(err error) (int, string, bool) {
+	for _, e := range requestErrors {
+		if errors.Is(err, e.err) {
+			return e.statusCode, e.msg, true
+		}
+	}
+	return http.StatusInternalServerError, "", false
+}
@@ -63,9 +63,77 @@ func TestDeleteHandler(t *testing.T) {
 				return mockTaskService
 			},
 		},
+		{
+			name:       "Hostile path: parent directory",
+			input:      `{"path": "/files/../etc/passwd"}`,
+			respError:  "invalid path",
+			statusCode: http.StatusBadRequest,
+			mockValidator: func(tc *testcase) handlers.Validator {
+				return validation
+			},
+			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
+				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
+				return mockDeletionService
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				mockTaskService := serviceMocks.NewMockTaskService(t)
+				return mockTaskService
+			},
+		},
+		{
+			name:       "Hostile path: parent directory without leading slash",
+			input:      `{"path": "files/images/../../../etc/passwd"}`,
+			respError:  "invalid path",
+			statusCode: http.StatusBadRequest,
+			mockValidator: func(tc *testcase) handlers.Validator {
+				return validation
+			},
+			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
+				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
+				return mockDeletionService
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				mockTaskService := serviceMocks.NewMockTaskService(t)
+				return mockTaskService
+			},
+		},
+		{
+			name:       "Hostile path: outside the files directory",
+			input:      `{"path": "/etc/passwd"}`,
+			respError:  "path is outside the files directory",
+			statusCode: http.StatusBadRequest,
+			mockValidator: func(tc *testcase) handlers.Validator {
+				return validation
+			},
+			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
+				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
+				return mockDeletionService
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				mockTaskService := serviceMocks.NewMockTaskService(t)
+				return mockTaskService
+			},
+		},
+		{
+			name:       "Hostile path: files directory prefix",
+			input:      `{"path": "/files-other/image.jpg"}`,
+			respError:  "path is outside the files directory",
+			statusCode: http.StatusBadRequest,
+			mockValidator: func(tc *testcase) handlers.Validator {
+				return validation
+			},
+			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
+				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
+				return mockDeletionService
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				mockTaskService := serviceMocks.NewMockTaskService(t)
+				return mockTaskService
+			},
+		},
 		{
 			name:       "Incorrect request: invalid data",
-			input:      `{"fullpath": "/path/to/file.ext"}`,
+			input:      `{"fullpath": "/files/path/to/file.ext"}`,
 			respError:  "field Path is a required field",
 			statusCode: http.StatusBadRequest,
 			mockValidator: func(tc *testcase) handlers.Validator {
@@ -82,11 +150,11 @@ func TestDeleteHandler(t *testing.T) {
 		},
 		{
 			name:         "Incorrect request: path duplicate",
-			input:        `{"path": "/path/to/file.ext"}`,
+			input:        `{"path": "/files/path/to/file.ext"}`,
 			respError:    "file with the specified path already exists in the deletion queue",
 			statusCode:   http.StatusConflict,
-			deletionInfo: &model.DeletionInfo{Fullpath: "/path/to/file.ext"},
-			deletionReq:  &request.DeletionRequest{Path: "/path/to/file.ext"},
+			deletionInfo: &model.DeletionInfo{Fullpath: "/files/path/to/file.ext"},
+			deletionReq:  &request.DeletionRequest{Path: "/files/path/to/file.ext"},
 			mockValidator: func(tc *testcase) handlers.Validator {
 				mockValidator := handlersMocks.NewMockValidator(t)
 				mockValidator.On("Struct", tc.deletionReq).Return(nil).Once()
@@ -104,11 +172,11 @@ func TestDeleteHandler(t *testing.T) {
 		},
 		{
 			name:         "Incorrect request: non-existent file",
-			input:        `{"path": "/path/to/file.ext"}`,
+			input:        `{"path": "/files/path/to/file.ext"}`,
 			respError:    "file does not exist",
 			statusCode:   http.StatusNotFound,
-			deletionInfo: &model.DeletionInfo{Fullpath: "/path/to/file.ext"},
-			deletionReq:  &request.DeletionRequest{Path: "/path/to/file.ext"},
+			deletionInfo: &model.DeletionInfo{Fullpath: "/files/path/to/file.ext"},
+			deletionReq:  &request.DeletionRequest{Path: "/files/path/to/file.ext"},
 			mockValidator: func(tc *testcase) handlers.Validator {
 				mockValidator := handlersMocks.NewMockValidator(t)
 				mockValidator.On("Struct", tc.deletionReq).Return(nil).Once()
@@ -126,11 +194,11 @@ func TestDeleteHandler(t *testing.T) {
 		},
 		{
 			name:         "Incorrect request: unknown error",
-			input:        `{"path": "/path/to/file.ext"}`,
+			input:        `{"path": "/files/path/to/file.ext"}`,
 			respError:    "failed to add file to deletion queue",
 			statusCode:   http.StatusInternalServerError,
-			deletionInfo: &model.DeletionInfo{Fullpath: "/path/to/file.ext"},
-			deletionReq:  &request.DeletionRequest{Path: "/path/to/file.ext"},
+			deletionInfo: &model.DeletionInfo{Fullpath: "/files/path/to/file.ext"},
+			deletionReq:  &request.DeletionRequest{Path: "/files/path/to/file.ext"},
 			mockValidator: func(tc *testcase) handlers.Validator {
 				mockValidator := handlersMocks.NewMockValidator(t)
 				mockValidator.On("Struct", tc.deletionReq).Return(nil).Once()
@@ -148,11 +216,11 @@ func TestDeleteHandler(t *testing.T) {
 		},
 		{
 			name:         "Successful request",
-			input:        `{"path": "/path/to/file.ext"}`,
+			input:        `{"path": "/files/path/to/file.ext"}`,
 			respError:    "",
 			statusCode:   http.StatusOK,
-			deletionInfo: &model.DeletionInfo{Fullpath: "/path/to/file.ext"},
-			deletionReq:  &request.DeletionRequest{Path: "/path/to/file.ext"},
+			deletionInfo: &model.DeletionInfo{Fullpath: "/files/path/to/file.ext"},
+			deletionReq:  &request.DeletionRequest{Path: "/files/path/to/file.ext"},
 			mockValidator: func(tc *testcase) handlers.Validator {
 				mockValidator := handlersMocks.NewMockValidator(t)
 				mockValidator.On("Struct", tc.deletionReq).Return(nil).Once()
@@ -169,6 +237,51 @@ func TestDeleteHandler(t *testing.T) {
 				return mockTaskService
 			},
 		},
+		{
+			name:         "Incorrect request: empty directory",
+			input:        `{"path": "/files/path/to", "recursive": true}`,
+			respError:    "file does not exist",
+			statusCode:   http.StatusNotFound,
+			deletionInfo: &model.DeletionInfo{Fullpath: "/files/path/to", Recursive: true},
+			deletionReq:  &request.DeletionRequest{Path: "/files/path/to", Recursive: true},
+			mockValidator: func(tc *testcase) handlers.Validator {
+				mockValidator := handlersMocks.NewMockValidator(t)
+				mockValidator.On("Struct", tc.deletionReq).Return(nil).Once()
+				return mockValidator
+			},
+			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
+				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
+				mockDeletionService.On("AddRecursive", ctx, tc.deletionInfo).Return(errorId, deletionq.ErrFileDoesNotExist).Once()
+				return mockDeletionService
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				mockTaskService := serviceMocks.NewMockTaskService(t)
+				return mockTaskService
+			},
+		},
+		{
+			name:         "Successful recursive request",
+			input:        `{"path": "/files/path/to", "recursive": true, "delete_source": true, "delete_record": true}`,
+			respError:    "",
+			statusCode:   http.StatusOK,
+			deletionInfo: &model.DeletionInfo{Fullpath: "/files/path/to", DeleteSource: true, DeleteRecord: true, Recursive: true},
+			deletionReq:  &request.DeletionRequest{Path: "/files/path/to", Recursive: true, DeleteSource: true, DeleteRecord: true},
+			mockValidator: func(tc *testcase) handlers.Validator {
+				mockValidator := handlersMocks.NewMockValidator(t)
+				mockValidator.On("Struct", tc.deletionReq).Return(nil).Once()
+				return mockValidator
+			},
+			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
+				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
+				mockDeletionService.On("AddRecursive", ctx, tc.deletionInfo).Return(int64(3), nil).Once()
+				return mockDeletionService
+			},
+			mockTaskService: func(tc *testcase) *serviceMocks.MockTaskService {
+				mockTaskService := serviceMocks.NewMockTaskService(t)
+				mockTaskService.On("TryQueueDeletion").Return(true).Once()
+				return mockTaskService
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -0,0 +1,73 @@
+package gc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/chistyakoviv/converter/internal/constants"
+	loggerDecorator "github.com/chistyakoviv/converter/internal/http-server/decorators/logger"
+	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
+	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/chistyakoviv/converter/internal/service/gc"
+	"github.com/chistyakoviv/converter/internal/tracing"
+	"github.com/go-chi/render"
+)
+
+type GCResponse struct {
+	resp.Response
+	Report *model.GCReport `json:"report,omitempty"`
+}
+
+func New(
+	ctx context.Context,
+	logger *slog.Logger,
+	gcService service.GCService,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := tracing.WithSpanOf(ctx, r.Context())
+		decoratedLogger := loggerDecorator.LoggerDecorator("handlers.gc.New", logger, r)
+
+		var opts model.GCOptions
+
+		if value := r.URL.Query().Get("dry_run"); value != "" {
+			dryRun, err := strconv.ParseBool(value)
+			if err != nil {
+				decoratedLogger.Debug("invalid dry_run parameter", slog.String("dry_run", value))
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("dry_run must be a boolean"))
+
+				return
+			}
+			opts.DryRun = dryRun
+		}
+
+		report, err := gcService.Collect(ctx, constants.FilesRootDir, opts)
+		if errors.Is(err, gc.ErrAlreadyRunning) {
+			decoratedLogger.Debug("garbage collection is already running")
+
+			render.Status(r, http.StatusConflict) // 409
+			render.JSON(w, r, resp.Error("garbage collection is already running"))
+
+			return
+		}
+		if err != nil {
+			decoratedLogger.Error("failed to collect garbage", slogger.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to collect garbage"))
+
+			return
+		}
+
+		render.JSON(w, r, GCResponse{
+			Response: resp.OK(),
+			Report:   report,
+		})
+	}
+}
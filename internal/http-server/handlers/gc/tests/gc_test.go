@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chistyakoviv/converter/internal/constants"
+	gcHandler "github.com/chistyakoviv/converter/internal/http-server/handlers/gc"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service/gc"
+	"github.com/chistyakoviv/converter/internal/service/mocks"
+)
+
+func TestGCHandler(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		logger = dummy.NewDummyLogger()
+	)
+
+	type testcase struct {
+		name          string
+		query         string
+		opts          model.GCOptions
+		respError     string
+		statusCode    int
+		mockGCService func(tc *testcase) *mocks.MockGCService
+	}
+
+	cases := []testcase{
+		{
+			name:       "Incorrect request: invalid dry run",
+			query:      "?dry_run=maybe",
+			respError:  "dry_run must be a boolean",
+			statusCode: http.StatusBadRequest,
+			mockGCService: func(tc *testcase) *mocks.MockGCService {
+				mockGCService := mocks.NewMockGCService(t)
+				return mockGCService
+			},
+		},
+		{
+			name:       "Failed request: collection is already running",
+			respError:  "garbage collection is already running",
+			statusCode: http.StatusConflict,
+			mockGCService: func(tc *testcase) *mocks.MockGCService {
+				mockGCService := mocks.NewMockGCService(t)
+				mockGCService.On("Collect", ctx, constants.FilesRootDir, tc.opts).Return(nil, gc.ErrAlreadyRunning).Once()
+				return mockGCService
+			},
+		},
+		{
+			name:       "Failed request: unexpected error",
+			respError:  "failed to collect garbage",
+			statusCode: http.StatusInternalServerError,
+			mockGCService: func(tc *testcase) *mocks.MockGCService {
+				mockGCService := mocks.NewMockGCService(t)
+				mockGCService.On("Collect", ctx, constants.FilesRootDir, tc.opts).Return(nil, errors.New("unexpected error")).Once()
+				return mockGCService
+			},
+		},
+		{
+			name:       "Successful dry run",
+			query:      "?dry_run=true",
+			opts:       model.GCOptions{DryRun: true},
+			respError:  "",
+			statusCode: http.StatusOK,
+			mockGCService: func(tc *testcase) *mocks.MockGCService {
+				mockGCService := mocks.NewMockGCService(t)
+				mockGCService.On("Collect", ctx, constants.FilesRootDir, tc.opts).
+					Return(&model.GCReport{DryRun: true, Removed: map[string]int64{model.GCCategoryTmpFile: 1}}, nil).
+					Once()
+				return mockGCService
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockGCService := tc.mockGCService(&tc)
+
+			handler := gcHandler.New(
+				ctx,
+				logger,
+				mockGCService,
+			)
+			req, err := http.NewRequest(http.MethodPost, "/gc"+tc.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			body := rr.Body.String()
+
+			var resp gcHandler.GCResponse
+
+			require.NoError(t, json.Unmarshal([]byte(body), &resp))
+
+			assert.Equal(t, tc.respError, resp.Error)
+			assert.Equal(t, tc.statusCode, rr.Result().StatusCode)
+			if tc.statusCode == http.StatusOK {
+				require.NotNil(t, resp.Report)
+				assert.Equal(t, tc.opts.DryRun, resp.Report.DryRun)
+			}
+			mockGCService.AssertExpectations(t)
+		})
+	}
+}
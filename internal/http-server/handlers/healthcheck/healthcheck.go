@@ -0,0 +1,38 @@
+package healthcheck
+
+import (
+	"log/slog"
+	"net/http"
+
+	loggerDecorator "github.com/chistyakoviv/converter/internal/http-server/decorators/logger"
+	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/go-chi/render"
+)
+
+type HealthcheckResponse struct {
+	resp.Response
+	Disk model.DiskState `json:"disk"`
+}
+
+func New(
+	logger *slog.Logger,
+	diskGuardService service.DiskGuardService,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		decoratedLogger := loggerDecorator.LoggerDecorator("handlers.healthcheck.New", logger, r)
+
+		state := diskGuardService.State()
+		if state.Paused {
+			decoratedLogger.Debug("queue is paused due to low disk space", slog.Uint64("free", state.Free))
+		}
+
+		// The application is alive even if the queue is paused,
+		// so the state is only reported
+		render.JSON(w, r, HealthcheckResponse{
+			Response: resp.OK(),
+			Disk:     state,
+		})
+	}
+}
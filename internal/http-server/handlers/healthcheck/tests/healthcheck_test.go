@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	healthcheckHandler "github.com/chistyakoviv/converter/internal/http-server/handlers/healthcheck"
+	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service/mocks"
+)
+
+func TestHealthcheckHandler(t *testing.T) {
+	logger := dummy.NewDummyLogger()
+
+	type testcase struct {
+		name  string
+		state model.DiskState
+	}
+
+	cases := []testcase{
+		{
+			name:  "Disk guard is disabled",
+			state: model.DiskState{},
+		},
+		{
+			name: "Enough disk space",
+			state: model.DiskState{
+				Enabled: true,
+				Path:    "/files",
+				Free:    10 << 30,
+				Reserve: 1 << 30,
+			},
+		},
+		{
+			name: "Queue is paused due to low disk space",
+			state: model.DiskState{
+				Enabled:  true,
+				Paused:   true,
+				Path:     "/files",
+				Free:     512 << 20,
+				Reserve:  1 << 30,
+				Required: 256 << 20,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockDiskGuardService := mocks.NewMockDiskGuardService(t)
+			mockDiskGuardService.On("State").Return(tc.state).Once()
+
+			handler := healthcheckHandler.New(logger, mockDiskGuardService)
+			req, err := http.NewRequest(http.MethodGet, "/healthcheck", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var response healthcheckHandler.HealthcheckResponse
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+			assert.Equal(t, http.StatusOK, rr.Result().StatusCode)
+			assert.Equal(t, resp.StatusOK, response.Status)
+			assert.Equal(t, tc.state, response.Disk)
+			mockDiskGuardService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package create
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/chistyakoviv/converter/internal/http-server/converter"
+	loggerDecorator "github.com/chistyakoviv/converter/internal/http-server/decorators/logger"
+	validationrDecorator "github.com/chistyakoviv/converter/internal/http-server/decorators/validation"
+	"github.com/chistyakoviv/converter/internal/http-server/handlers"
+	"github.com/chistyakoviv/converter/internal/http-server/request"
+	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
+	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/chistyakoviv/converter/internal/service/apikey"
+	"github.com/chistyakoviv/converter/internal/tracing"
+	"github.com/go-chi/render"
+)
+
+type CreateResponse struct {
+	resp.Response
+	Key *model.ApiKey `json:"key,omitempty"`
+	// The key itself, it is not stored and cannot be retrieved later
+	Token string `json:"token,omitempty"`
+}
+
+func New(
+	ctx context.Context,
+	logger *slog.Logger,
+	validation handlers.Validator,
+	apiKeyService service.ApiKeyService,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := tracing.WithSpanOf(ctx, r.Context())
+		decoratedLogger := loggerDecorator.LoggerDecorator("handlers.keys.create.New", logger, r)
+
+		var req request.ApiKeyRequest
+
+		err := validationrDecorator.ValidationDecorator(decoratedLogger, validation, &req, w, r)
+		if err != nil {
+			return
+		}
+
+		key, token, err := apiKeyService.Create(ctx, converter.ToApiKeyInfoFromRequest(req))
+		if errors.Is(err, apikey.ErrInvalidScope) || errors.Is(err, apikey.ErrInvalidPathPrefix) {
+			decoratedLogger.Debug("invalid api key", slogger.Err(err))
+
+			render.Status(r, http.StatusBadRequest) // 400
+			render.JSON(w, r, resp.Error(err.Error()))
+
+			return
+		}
+		if err != nil {
+			decoratedLogger.Error("failed to create api key", slogger.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to create api key"))
+
+			return
+		}
+
+		decoratedLogger.Info("api key created", slog.Int64("id", key.Id), slog.String("name", key.Name))
+
+		render.Status(r, http.StatusCreated) // 201
+		render.JSON(w, r, CreateResponse{
+			Response: resp.OK(),
+			Key:      key,
+			Token:    token,
+		})
+	}
+}
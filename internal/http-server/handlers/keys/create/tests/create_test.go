@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chistyakoviv/converter/internal/http-server/handlers/keys/create"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service/apikey"
+	"github.com/chistyakoviv/converter/internal/service/mocks"
+)
+
+func TestCreateApiKeyHandler(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		logger     = dummy.NewDummyLogger()
+		validation = validator.New()
+	)
+
+	type testcase struct {
+		name              string
+		input             string
+		info              *model.ApiKeyInfo
+		respError         string
+		statusCode        int
+		mockApiKeyService func(tc *testcase) *mocks.MockApiKeyService
+	}
+
+	cases := []testcase{
+		{
+			name:       "Incorrect request: no scopes",
+			input:      `{"name": "uploader"}`,
+			respError:  "field Scopes is a required field",
+			statusCode: http.StatusBadRequest,
+			mockApiKeyService: func(tc *testcase) *mocks.MockApiKeyService {
+				return mocks.NewMockApiKeyService(t)
+			},
+		},
+		{
+			name:       "Incorrect request: unknown scope",
+			input:      `{"name": "uploader", "scopes": ["upload"]}`,
+			info:       &model.ApiKeyInfo{Name: "uploader", Scopes: []string{"upload"}},
+			respError:  "invalid scope: 'upload'",
+			statusCode: http.StatusBadRequest,
+			mockApiKeyService: func(tc *testcase) *mocks.MockApiKeyService {
+				mockApiKeyService := mocks.NewMockApiKeyService(t)
+				mockApiKeyService.On("Create", ctx, tc.info).Return(nil, "", fmt.Errorf("%w: 'upload'", apikey.ErrInvalidScope)).Once()
+				return mockApiKeyService
+			},
+		},
+		{
+			name:       "Failed request: unexpected error",
+			input:      `{"name": "uploader", "scopes": ["convert"]}`,
+			info:       &model.ApiKeyInfo{Name: "uploader", Scopes: []string{"convert"}},
+			respError:  "failed to create api key",
+			statusCode: http.StatusInternalServerError,
+			mockApiKeyService: func(tc *testcase) *mocks.MockApiKeyService {
+				mockApiKeyService := mocks.NewMockApiKeyService(t)
+				mockApiKeyService.On("Create", ctx, tc.info).Return(nil, "", errors.New("unexpected error")).Once()
+				return mockApiKeyService
+			},
+		},
+		{
+			name:       "Successful request",
+			input:      `{"name": "uploader", "scopes": ["convert"], "path_prefixes": ["/files/uploads"]}`,
+			info:       &model.ApiKeyInfo{Name: "uploader", Scopes: []string{"convert"}, PathPrefixes: []string{"/files/uploads"}},
+			statusCode: http.StatusCreated,
+			mockApiKeyService: func(tc *testcase) *mocks.MockApiKeyService {
+				mockApiKeyService := mocks.NewMockApiKeyService(t)
+				mockApiKeyService.On("Create", ctx, tc.info).
+					Return(&model.ApiKey{Id: 1, Name: tc.info.Name, Prefix: "cvt_abcdefgh", Scopes: tc.info.Scopes, PathPrefixes: tc.info.PathPrefixes}, "cvt_abcdefghsecret", nil).
+					Once()
+				return mockApiKeyService
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockApiKeyService := tc.mockApiKeyService(&tc)
+
+			handler := create.New(ctx, logger, validation, mockApiKeyService)
+			req, err := http.NewRequest(http.MethodPost, "/keys", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp create.CreateResponse
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			assert.Equal(t, tc.respError, resp.Error)
+			assert.Equal(t, tc.statusCode, rr.Result().StatusCode)
+			if tc.statusCode == http.StatusCreated {
+				require.NotNil(t, resp.Key)
+				assert.Equal(t, int64(1), resp.Key.Id)
+				assert.Equal(t, "cvt_abcdefghsecret", resp.Token)
+			}
+			mockApiKeyService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package list
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	loggerDecorator "github.com/chistyakoviv/converter/internal/http-server/decorators/logger"
+	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
+	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/chistyakoviv/converter/internal/tracing"
+	"github.com/go-chi/render"
+)
+
+type ListResponse struct {
+	resp.Response
+	Keys []*model.ApiKey `json:"keys"`
+}
+
+func New(
+	ctx context.Context,
+	logger *slog.Logger,
+	apiKeyService service.ApiKeyService,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := tracing.WithSpanOf(ctx, r.Context())
+		decoratedLogger := loggerDecorator.LoggerDecorator("handlers.keys.list.New", logger, r)
+
+		keys, err := apiKeyService.List(ctx)
+		if err != nil {
+			decoratedLogger.Error("failed to list api keys", slogger.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to list api keys"))
+
+			return
+		}
+
+		render.JSON(w, r, ListResponse{
+			Response: resp.OK(),
+			Keys:     keys,
+		})
+	}
+}
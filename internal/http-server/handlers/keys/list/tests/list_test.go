@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chistyakoviv/converter/internal/http-server/handlers/keys/list"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service/mocks"
+)
+
+func TestListApiKeysHandler(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		logger = dummy.NewDummyLogger()
+	)
+
+	type testcase struct {
+		name              string
+		keys              []*model.ApiKey
+		respError         string
+		statusCode        int
+		mockApiKeyService func(tc *testcase) *mocks.MockApiKeyService
+	}
+
+	cases := []testcase{
+		{
+			name:       "Failed request: unexpected error",
+			respError:  "failed to list api keys",
+			statusCode: http.StatusInternalServerError,
+			mockApiKeyService: func(tc *testcase) *mocks.MockApiKeyService {
+				mockApiKeyService := mocks.NewMockApiKeyService(t)
+				mockApiKeyService.On("List", ctx).Return(nil, errors.New("unexpected error")).Once()
+				return mockApiKeyService
+			},
+		},
+		{
+			name:       "Successful request",
+			keys:       []*model.ApiKey{{Id: 1, Name: "uploader", Prefix: "cvt_abcdefgh", Scopes: []string{"convert"}}},
+			statusCode: http.StatusOK,
+			mockApiKeyService: func(tc *testcase) *mocks.MockApiKeyService {
+				mockApiKeyService := mocks.NewMockApiKeyService(t)
+				mockApiKeyService.On("List", ctx).Return(tc.keys, nil).Once()
+				return mockApiKeyService
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockApiKeyService := tc.mockApiKeyService(&tc)
+
+			handler := list.New(ctx, logger, mockApiKeyService)
+			req, err := http.NewRequest(http.MethodGet, "/keys", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp list.ListResponse
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			assert.Equal(t, tc.respError, resp.Error)
+			assert.Equal(t, tc.statusCode, rr.Result().StatusCode)
+			if tc.statusCode == http.StatusOK {
+				require.Len(t, resp.Keys, len(tc.keys))
+				assert.Equal(t, tc.keys[0].Prefix, resp.Keys[0].Prefix)
+			}
+			mockApiKeyService.AssertExpectations(t)
+		})
+	}
+}
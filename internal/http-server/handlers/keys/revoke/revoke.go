@@ -0,0 +1,61 @@
+package revoke
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	loggerDecorator "github.com/chistyakoviv/converter/internal/http-server/decorators/logger"
+	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
+	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/chistyakoviv/converter/internal/service/apikey"
+	"github.com/chistyakoviv/converter/internal/tracing"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func New(
+	ctx context.Context,
+	logger *slog.Logger,
+	apiKeyService service.ApiKeyService,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := tracing.WithSpanOf(ctx, r.Context())
+		decoratedLogger := loggerDecorator.LoggerDecorator("handlers.keys.revoke.New", logger, r)
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			decoratedLogger.Debug("invalid api key id", slog.String("id", chi.URLParam(r, "id")))
+
+			render.Status(r, http.StatusBadRequest) // 400
+			render.JSON(w, r, resp.Error("invalid api key id"))
+
+			return
+		}
+
+		err = apiKeyService.Revoke(ctx, id)
+		if errors.Is(err, apikey.ErrKeyNotFound) {
+			decoratedLogger.Debug("api key not found", slog.Int64("id", id))
+
+			render.Status(r, http.StatusNotFound) // 404
+			render.JSON(w, r, resp.Error("api key not found"))
+
+			return
+		}
+		if err != nil {
+			decoratedLogger.Error("failed to revoke api key", slogger.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to revoke api key"))
+
+			return
+		}
+
+		decoratedLogger.Info("api key revoked", slog.Int64("id", id))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
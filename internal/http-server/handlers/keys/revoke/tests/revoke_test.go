@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chistyakoviv/converter/internal/http-server/handlers/keys/revoke"
+	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/service/apikey"
+	"github.com/chistyakoviv/converter/internal/service/mocks"
+)
+
+func TestRevokeApiKeyHandler(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		logger = dummy.NewDummyLogger()
+	)
+
+	type testcase struct {
+		name              string
+		id                string
+		respError         string
+		statusCode        int
+		mockApiKeyService func(tc *testcase) *mocks.MockApiKeyService
+	}
+
+	cases := []testcase{
+		{
+			name:       "Incorrect request: invalid id",
+			id:         "abc",
+			respError:  "invalid api key id",
+			statusCode: http.StatusBadRequest,
+			mockApiKeyService: func(tc *testcase) *mocks.MockApiKeyService {
+				return mocks.NewMockApiKeyService(t)
+			},
+		},
+		{
+			name:       "Failed request: key not found",
+			id:         "2",
+			respError:  "api key not found",
+			statusCode: http.StatusNotFound,
+			mockApiKeyService: func(tc *testcase) *mocks.MockApiKeyService {
+				mockApiKeyService := mocks.NewMockApiKeyService(t)
+				mockApiKeyService.On("Revoke", ctx, int64(2)).Return(apikey.ErrKeyNotFound).Once()
+				return mockApiKeyService
+			},
+		},
+		{
+			name:       "Failed request: unexpected error",
+			id:         "1",
+			respError:  "failed to revoke api key",
+			statusCode: http.StatusInternalServerError,
+			mockApiKeyService: func(tc *testcase) *mocks.MockApiKeyService {
+				mockApiKeyService := mocks.NewMockApiKeyService(t)
+				mockApiKeyService.On("Revoke", ctx, int64(1)).Return(errors.New("unexpected error")).Once()
+				return mockApiKeyService
+			},
+		},
+		{
+			name:       "Successful request",
+			id:         "1",
+			statusCode: http.StatusOK,
+			mockApiKeyService: func(tc *testcase) *mocks.MockApiKeyService {
+				mockApiKeyService := mocks.NewMockApiKeyService(t)
+				mockApiKeyService.On("Revoke", ctx, int64(1)).Return(nil).Once()
+				return mockApiKeyService
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockApiKeyService := tc.mockApiKeyService(&tc)
+
+			handler := revoke.New(ctx, logger, mockApiKeyService)
+			req, err := http.NewRequest(http.MethodDelete, "/keys/"+tc.id, nil)
+			require.NoError(t, err)
+
+			// The id is taken from the route, so it must be set as if the request were routed
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tc.id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var response resp.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+			assert.Equal(t, tc.respError, response.Error)
+			assert.Equal(t, tc.statusCode, rr.Result().StatusCode)
+			mockApiKeyService.AssertExpectations(t)
+		})
+	}
+}
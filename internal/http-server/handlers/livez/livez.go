@@ -0,0 +1,46 @@
+package livez
+
+import (
+	"log/slog"
+	"net/http"
+
+	loggerDecorator "github.com/chistyakoviv/converter/internal/http-server/decorators/logger"
+	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/go-chi/render"
+)
+
+type LiveResponse struct {
+	resp.Response
+	Checks map[string]model.HealthCheck `json:"checks"`
+}
+
+// Responds with 503 if the application must be restarted
+func New(
+	logger *slog.Logger,
+	healthService service.HealthService,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		decoratedLogger := loggerDecorator.LoggerDecorator("handlers.livez.New", logger, r)
+
+		// The checks are abandoned if the prober disconnects
+		report := healthService.Live(r.Context())
+		if !report.IsHealthy() {
+			decoratedLogger.Debug("application is not alive")
+
+			render.Status(r, http.StatusServiceUnavailable)
+			render.JSON(w, r, LiveResponse{
+				Response: resp.Error("application is not alive"),
+				Checks:   report.Checks,
+			})
+
+			return
+		}
+
+		render.JSON(w, r, LiveResponse{
+			Response: resp.OK(),
+			Checks:   report.Checks,
+		})
+	}
+}
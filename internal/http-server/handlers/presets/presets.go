@@ -0,0 +1,35 @@
+package presets
+
+import (
+	"log/slog"
+	"net/http"
+
+	loggerDecorator "github.com/chistyakoviv/converter/internal/http-server/decorators/logger"
+	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/go-chi/render"
+)
+
+type PresetsResponse struct {
+	resp.Response
+	Presets []*model.Preset `json:"presets"`
+}
+
+// Lists the presets a conversion request may select, the formats include the inherited ones
+func New(
+	logger *slog.Logger,
+	conversionQueueService service.ConversionQueueService,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		decoratedLogger := loggerDecorator.LoggerDecorator("handlers.presets.New", logger, r)
+
+		presets := conversionQueueService.Presets()
+		decoratedLogger.Debug("presets listed", slog.Int("count", len(presets)))
+
+		render.JSON(w, r, PresetsResponse{
+			Response: resp.OK(),
+			Presets:  presets,
+		})
+	}
+}
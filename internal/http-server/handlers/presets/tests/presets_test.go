@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chistyakoviv/converter/internal/http-server/handlers/presets"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service/mocks"
+)
+
+func TestPresetsHandler(t *testing.T) {
+	logger := dummy.NewDummyLogger()
+
+	type testcase struct {
+		name    string
+		presets []*model.Preset
+	}
+
+	cases := []testcase{
+		{
+			name:    "No presets",
+			presets: []*model.Preset{},
+		},
+		{
+			name: "Presets with inherited formats",
+			presets: []*model.Preset{
+				{Name: "thumbnail", Extends: "web", Image: []model.ConvertTo{{Ext: "webp", ConvConf: map[string]interface{}{"quality": float64(60)}}}},
+				{Name: "web", Image: []model.ConvertTo{{Ext: "webp"}}},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockConversionService := mocks.NewMockConversionQueueService(t)
+			mockConversionService.On("Presets").Return(tc.presets).Once()
+
+			handler := presets.New(logger, mockConversionService)
+			req, err := http.NewRequest(http.MethodGet, "/presets", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp presets.PresetsResponse
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			assert.Equal(t, http.StatusOK, rr.Result().StatusCode)
+			assert.Equal(t, tc.presets, resp.Presets)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package readyz
+
+import (
+	"log/slog"
+	"net/http"
+
+	loggerDecorator "github.com/chistyakoviv/converter/internal/http-server/decorators/logger"
+	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/go-chi/render"
+)
+
+type ReadyResponse struct {
+	resp.Response
+	Checks map[string]model.HealthCheck `json:"checks"`
+}
+
+// Responds with 503 if no requests should be routed to the application, e.g. while it shuts down
+func New(
+	logger *slog.Logger,
+	healthService service.HealthService,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		decoratedLogger := loggerDecorator.LoggerDecorator("handlers.readyz.New", logger, r)
+
+		// The checks are abandoned if the prober disconnects
+		report := healthService.Ready(r.Context())
+		if !report.IsHealthy() {
+			decoratedLogger.Debug("application is not ready")
+
+			render.Status(r, http.StatusServiceUnavailable)
+			render.JSON(w, r, ReadyResponse{
+				Response: resp.Error("application is not ready"),
+				Checks:   report.Checks,
+			})
+
+			return
+		}
+
+		render.JSON(w, r, ReadyResponse{
+			Response: resp.OK(),
+			Checks:   report.Checks,
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	readyzHandler "github.com/chistyakoviv/converter/internal/http-server/handlers/readyz"
+	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service/mocks"
+)
+
+func TestReadyzHandler(t *testing.T) {
+	logger := dummy.NewDummyLogger()
+
+	type testcase struct {
+		name       string
+		report     *model.HealthReport
+		statusCode int
+		status     string
+		error      string
+	}
+
+	cases := []testcase{
+		{
+			name: "All checks pass",
+			report: &model.HealthReport{
+				Status: model.HealthStatusOK,
+				Checks: map[string]model.HealthCheck{
+					"tasks": {Status: model.HealthStatusOK},
+				},
+			},
+			statusCode: http.StatusOK,
+			status:     resp.StatusOK,
+		},
+		{
+			name: "Failed check",
+			report: &model.HealthReport{
+				Status: model.HealthStatusFailed,
+				Checks: map[string]model.HealthCheck{
+					"tasks":    {Status: model.HealthStatusOK},
+					"shutdown": {Status: model.HealthStatusFailed, Error: "application is shutting down"},
+				},
+			},
+			statusCode: http.StatusServiceUnavailable,
+			status:     resp.StatusError,
+			error:      "application is not ready",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockHealthService := mocks.NewMockHealthService(t)
+			mockHealthService.On("Ready", mock.Anything).Return(tc.report).Once()
+
+			handler := readyzHandler.New(logger, mockHealthService)
+			req, err := http.NewRequest(http.MethodGet, "/readyz", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var response readyzHandler.ReadyResponse
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+			assert.Equal(t, tc.statusCode, rr.Result().StatusCode)
+			assert.Equal(t, tc.status, response.Status)
+			assert.Equal(t, tc.error, response.Error)
+			assert.Equal(t, tc.report.Checks, response.Checks)
+			mockHealthService.AssertExpectations(t)
+		})
+	}
+}
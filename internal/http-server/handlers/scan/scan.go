@@ -2,30 +2,69 @@ package scan
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
+	"path"
 
 	"github.com/chistyakoviv/converter/internal/constants"
+	"github.com/chistyakoviv/converter/internal/http-server/converter"
 	loggerDecorator "github.com/chistyakoviv/converter/internal/http-server/decorators/logger"
+	validationrDecorator "github.com/chistyakoviv/converter/internal/http-server/decorators/validation"
+	"github.com/chistyakoviv/converter/internal/http-server/handlers"
+	httpMiddleware "github.com/chistyakoviv/converter/internal/http-server/middleware"
+	"github.com/chistyakoviv/converter/internal/http-server/request"
 	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
 	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/model"
 	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/chistyakoviv/converter/internal/service/task"
+	"github.com/chistyakoviv/converter/internal/tracing"
 	"github.com/go-chi/render"
 )
 
 type ScanResponse struct {
 	resp.Response
+	Scan *model.Scan `json:"scan,omitempty"`
 }
 
 func New(
 	ctx context.Context,
 	logger *slog.Logger,
+	validation handlers.Validator,
 	taskService service.TaskService,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := tracing.WithSpanOf(ctx, r.Context())
 		decoratedLogger := loggerDecorator.LoggerDecorator("handlers.scan.New", logger, r)
 
-		if taskService.IsScanning() {
+		var req request.ScanRequest
+
+		// The body is optional, the whole files directory is scanned without it
+		if r.ContentLength != 0 {
+			err := validationrDecorator.ValidationDecorator(decoratedLogger, validation, &req, w, r)
+			if err != nil {
+				return
+			}
+		}
+
+		opts := converter.ToScanOptionsFromRequest(req)
+
+		// The scanned directory is validated by the task service, only the restrictions of the key are checked here
+		scanDir := path.Join("/", constants.FilesRootDir, path.Clean("/"+opts.Path))
+		if err := httpMiddleware.AuthorizePath(r.Context(), scanDir); err != nil {
+			decoratedLogger.Debug("path is not allowed for the api key", slog.String("path", scanDir))
+
+			render.Status(r, http.StatusForbidden) // 403
+			render.JSON(w, r, resp.Error("path is not allowed for the api key"))
+
+			return
+		}
+		opts.ApiKeyId = httpMiddleware.ApiKeyFromContext(r.Context()).AuditId().Int64
+
+		// Do not wait for the scan to complete
+		scan, err := taskService.StartScan(ctx, constants.FilesRootDir, opts)
+		if errors.Is(err, task.ErrScanAlreadyRunning) {
 			decoratedLogger.Debug("scan is already running")
 
 			render.Status(r, http.StatusConflict) // 409
@@ -33,22 +72,28 @@ func New(
 
 			return
 		}
+		if errors.Is(err, task.ErrInvalidScanOptions) {
+			decoratedLogger.Debug("invalid scan options", slogger.Err(err))
 
-		// Do not wait for the scan to complete
-		go func() {
-			err := taskService.ProcessScanfs(ctx, constants.FilesRootDir)
-			if err != nil {
-				decoratedLogger.Error("failed to scan filesystem: ", slogger.Err(err))
-			}
+			render.Status(r, http.StatusBadRequest) // 400
+			render.JSON(w, r, resp.Error(err.Error()))
+
+			return
+		}
+		if err != nil {
+			decoratedLogger.Error("failed to start scan", slogger.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to start scan"))
 
-			decoratedLogger.Debug("scan completed")
+			return
+		}
 
-			// Try to process the files immediately
-			taskService.TryQueueConversion()
-		}()
+		decoratedLogger.Debug("scan started", slog.String("id", scan.Id))
 
 		render.JSON(w, r, ScanResponse{
 			Response: resp.OK(),
+			Scan:     scan,
 		})
 	}
 }
@@ -4,23 +4,30 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/chistyakoviv/converter/internal/constants"
 	"github.com/chistyakoviv/converter/internal/http-server/handlers/scan"
 	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
 	"github.com/chistyakoviv/converter/internal/service/mocks"
+	"github.com/chistyakoviv/converter/internal/service/task"
 )
 
 func TestScanHandler(t *testing.T) {
 	var (
-		ctx    = context.Background()
-		logger = dummy.NewDummyLogger()
+		ctx        = context.Background()
+		logger     = dummy.NewDummyLogger()
+		validation = validator.New()
+		startedId  = "0123456789abcdef"
 	)
 
 	type testcase struct {
@@ -28,6 +35,7 @@ func TestScanHandler(t *testing.T) {
 		input           string
 		respError       string
 		statusCode      int
+		opts            model.ScanOptions
 		mockTaskService func(tc *testcase) *mocks.MockTaskService
 	}
 
@@ -38,7 +46,41 @@ func TestScanHandler(t *testing.T) {
 			statusCode: http.StatusConflict,
 			mockTaskService: func(tc *testcase) *mocks.MockTaskService {
 				mockTaskService := mocks.NewMockTaskService(t)
-				mockTaskService.On("IsScanning").Return(true).Once()
+				mockTaskService.On("StartScan", ctx, constants.FilesRootDir, tc.opts).Return(nil, task.ErrScanAlreadyRunning).Once()
+				return mockTaskService
+			},
+		},
+		{
+			name:       "Incorrect request: invalid data",
+			input:      `{"max_depth": -1}`,
+			respError:  "field MaxDepth is not valid",
+			statusCode: http.StatusBadRequest,
+			mockTaskService: func(tc *testcase) *mocks.MockTaskService {
+				return mocks.NewMockTaskService(t)
+			},
+		},
+		{
+			name:       "Incorrect request: invalid scan options",
+			input:      `{"path": "missing"}`,
+			respError:  "invalid scan options: directory 'missing' does not exist",
+			statusCode: http.StatusBadRequest,
+			opts:       model.ScanOptions{Path: "missing"},
+			mockTaskService: func(tc *testcase) *mocks.MockTaskService {
+				mockTaskService := mocks.NewMockTaskService(t)
+				mockTaskService.
+					On("StartScan", ctx, constants.FilesRootDir, tc.opts).
+					Return(nil, fmt.Errorf("%w: directory 'missing' does not exist", task.ErrInvalidScanOptions)).
+					Once()
+				return mockTaskService
+			},
+		},
+		{
+			name:       "Failed request: unexpected error",
+			respError:  "failed to start scan",
+			statusCode: http.StatusInternalServerError,
+			mockTaskService: func(tc *testcase) *mocks.MockTaskService {
+				mockTaskService := mocks.NewMockTaskService(t)
+				mockTaskService.On("StartScan", ctx, constants.FilesRootDir, tc.opts).Return(nil, errors.New("unexpected error")).Once()
 				return mockTaskService
 			},
 		},
@@ -48,9 +90,31 @@ func TestScanHandler(t *testing.T) {
 			statusCode: http.StatusOK,
 			mockTaskService: func(tc *testcase) *mocks.MockTaskService {
 				mockTaskService := mocks.NewMockTaskService(t)
-				mockTaskService.On("IsScanning").Return(false).Once()
-				mockTaskService.On("ProcessScanfs", ctx, constants.FilesRootDir).Return(nil).Maybe()
-				mockTaskService.On("TryQueueConversion").Return(true).Maybe()
+				mockTaskService.
+					On("StartScan", ctx, constants.FilesRootDir, tc.opts).
+					Return(&model.Scan{Id: startedId, Status: model.ScanStatusRunning}, nil).
+					Once()
+				return mockTaskService
+			},
+		},
+		{
+			name:       "Successful request with options",
+			input:      `{"path": "images", "include": ["*.png"], "exclude": ["tmp/*"], "max_depth": 2, "dry_run": true}`,
+			respError:  "",
+			statusCode: http.StatusOK,
+			opts: model.ScanOptions{
+				Path:     "images",
+				Include:  []string{"*.png"},
+				Exclude:  []string{"tmp/*"},
+				MaxDepth: 2,
+				DryRun:   true,
+			},
+			mockTaskService: func(tc *testcase) *mocks.MockTaskService {
+				mockTaskService := mocks.NewMockTaskService(t)
+				mockTaskService.
+					On("StartScan", ctx, constants.FilesRootDir, tc.opts).
+					Return(&model.Scan{Id: startedId, Status: model.ScanStatusRunning, Options: tc.opts}, nil).
+					Once()
 				return mockTaskService
 			},
 		},
@@ -67,6 +131,7 @@ func TestScanHandler(t *testing.T) {
 			handler := scan.New(
 				ctx,
 				logger,
+				validation,
 				mockTaskService,
 			)
 			req, err := http.NewRequest(http.MethodPost, "/scan", bytes.NewReader([]byte(tc.input)))
@@ -83,6 +148,10 @@ func TestScanHandler(t *testing.T) {
 
 			assert.Equal(t, tc.respError, resp.Error)
 			assert.Equal(t, tc.statusCode, rr.Result().StatusCode)
+			if tc.statusCode == http.StatusOK {
+				require.NotNil(t, resp.Scan)
+				assert.Equal(t, startedId, resp.Scan.Id)
+			}
 			mockTaskService.AssertExpectations(t)
 		})
 	}
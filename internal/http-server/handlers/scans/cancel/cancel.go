@@ -0,0 +1,68 @@
+package cancel
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	loggerDecorator "github.com/chistyakoviv/converter/internal/http-server/decorators/logger"
+	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
+	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/chistyakoviv/converter/internal/service/task"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type CancelResponse struct {
+	resp.Response
+	Scan *model.Scan `json:"scan,omitempty"`
+}
+
+func New(
+	ctx context.Context,
+	logger *slog.Logger,
+	taskService service.TaskService,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		decoratedLogger := loggerDecorator.LoggerDecorator("handlers.scans.cancel.New", logger, r)
+
+		id := chi.URLParam(r, "id")
+
+		// The scan stops asynchronously, so the returned state may still be running
+		scan, err := taskService.CancelScan(id)
+		if errors.Is(err, task.ErrScanNotFound) {
+			decoratedLogger.Debug("scan not found", slog.String("id", id))
+
+			render.Status(r, http.StatusNotFound) // 404
+			render.JSON(w, r, resp.Error("scan not found"))
+
+			return
+		}
+		if errors.Is(err, task.ErrScanNotRunning) {
+			decoratedLogger.Debug("scan is not running", slog.String("id", id))
+
+			render.Status(r, http.StatusConflict) // 409
+			render.JSON(w, r, resp.Error("scan is not running"))
+
+			return
+		}
+		if err != nil {
+			decoratedLogger.Error("failed to cancel scan", slogger.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to cancel scan"))
+
+			return
+		}
+
+		decoratedLogger.Debug("scan canceled", slog.String("id", id))
+
+		render.JSON(w, r, CancelResponse{
+			Response: resp.OK(),
+			Scan:     scan,
+		})
+	}
+}
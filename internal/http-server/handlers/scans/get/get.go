@@ -0,0 +1,57 @@
+package get
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	loggerDecorator "github.com/chistyakoviv/converter/internal/http-server/decorators/logger"
+	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
+	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/chistyakoviv/converter/internal/service/task"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type ScanResponse struct {
+	resp.Response
+	Scan *model.Scan `json:"scan,omitempty"`
+}
+
+func New(
+	ctx context.Context,
+	logger *slog.Logger,
+	taskService service.TaskService,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		decoratedLogger := loggerDecorator.LoggerDecorator("handlers.scans.get.New", logger, r)
+
+		id := chi.URLParam(r, "id")
+
+		scan, err := taskService.GetScan(id)
+		if errors.Is(err, task.ErrScanNotFound) {
+			decoratedLogger.Debug("scan not found", slog.String("id", id))
+
+			render.Status(r, http.StatusNotFound) // 404
+			render.JSON(w, r, resp.Error("scan not found"))
+
+			return
+		}
+		if err != nil {
+			decoratedLogger.Error("failed to get scan", slogger.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to get scan"))
+
+			return
+		}
+
+		render.JSON(w, r, ScanResponse{
+			Response: resp.OK(),
+			Scan:     scan,
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chistyakoviv/converter/internal/http-server/handlers/scans/get"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service/mocks"
+	"github.com/chistyakoviv/converter/internal/service/task"
+)
+
+func TestGetScanHandler(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		logger = dummy.NewDummyLogger()
+	)
+
+	type testcase struct {
+		name            string
+		id              string
+		respError       string
+		statusCode      int
+		mockTaskService func(tc *testcase) *mocks.MockTaskService
+	}
+
+	cases := []testcase{
+		{
+			name:       "Failed request: scan not found",
+			id:         "unknown",
+			respError:  "scan not found",
+			statusCode: http.StatusNotFound,
+			mockTaskService: func(tc *testcase) *mocks.MockTaskService {
+				mockTaskService := mocks.NewMockTaskService(t)
+				mockTaskService.On("GetScan", tc.id).Return(nil, task.ErrScanNotFound).Once()
+				return mockTaskService
+			},
+		},
+		{
+			name:       "Failed request: unexpected error",
+			id:         "0123456789abcdef",
+			respError:  "failed to get scan",
+			statusCode: http.StatusInternalServerError,
+			mockTaskService: func(tc *testcase) *mocks.MockTaskService {
+				mockTaskService := mocks.NewMockTaskService(t)
+				mockTaskService.On("GetScan", tc.id).Return(nil, errors.New("unexpected error")).Once()
+				return mockTaskService
+			},
+		},
+		{
+			name:       "Successful request",
+			id:         "0123456789abcdef",
+			respError:  "",
+			statusCode: http.StatusOK,
+			mockTaskService: func(tc *testcase) *mocks.MockTaskService {
+				mockTaskService := mocks.NewMockTaskService(t)
+				mockTaskService.On("GetScan", tc.id).Return(&model.Scan{Id: tc.id, Status: model.ScanStatusRunning}, nil).Once()
+				return mockTaskService
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockTaskService := tc.mockTaskService(&tc)
+
+			handler := get.New(
+				ctx,
+				logger,
+				mockTaskService,
+			)
+			req, err := http.NewRequest(http.MethodGet, "/scans/"+tc.id, nil)
+			require.NoError(t, err)
+
+			// The id is taken from the route, so it must be set as if the request were routed
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tc.id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			body := rr.Body.String()
+
+			var resp get.ScanResponse
+
+			require.NoError(t, json.Unmarshal([]byte(body), &resp))
+
+			assert.Equal(t, tc.respError, resp.Error)
+			assert.Equal(t, tc.statusCode, rr.Result().StatusCode)
+			if tc.statusCode == http.StatusOK {
+				require.NotNil(t, resp.Scan)
+				assert.Equal(t, tc.id, resp.Scan.Id)
+			}
+			mockTaskService.AssertExpectations(t)
+		})
+	}
+}
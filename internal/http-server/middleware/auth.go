@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/chistyakoviv/converter/internal/constants"
+	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
+	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/chistyakoviv/converter/internal/service/apikey"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type apiKeyCtxKey struct{}
+
+// ApiKeyFromContext returns the key of the authenticated request, nil if the authentication is disabled
+func ApiKeyFromContext(ctx context.Context) *model.ApiKey {
+	key, _ := ctx.Value(apiKeyCtxKey{}).(*model.ApiKey)
+	return key
+}
+
+func WithApiKey(ctx context.Context, key *model.ApiKey) context.Context {
+	return context.WithValue(ctx, apiKeyCtxKey{}, key)
+}
+
+// AuthorizePath reports an error if the key of the request is confined to other directories.
+// The path must be canonical.
+func AuthorizePath(ctx context.Context, fullpath string) error {
+	if !ApiKeyFromContext(ctx).Allows(fullpath) {
+		return fmt.Errorf("%w: '%s'", apikey.ErrPathNotAllowed, fullpath)
+	}
+	return nil
+}
+
+// NewAuth returns a constructor of middlewares rejecting the requests without an API key of the given scope.
+// The key is accepted in the Authorization header as a bearer token or in the X-API-Key header.
+func NewAuth(logger *slog.Logger, apiKeyService service.ApiKeyService) func(scope string) func(next http.Handler) http.Handler {
+	logger = logger.With(
+		slog.String("component", "middleware/auth"),
+	)
+
+	logger.Info("auth middleware enabled")
+
+	return func(scope string) func(next http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			fn := func(w http.ResponseWriter, r *http.Request) {
+				entry := logger.With(
+					slog.String("path", r.URL.Path),
+					slog.String(constants.RequestID, middleware.GetReqID(r.Context())),
+				)
+
+				key, err := apiKeyService.Authenticate(r.Context(), token(r))
+				if errors.Is(err, apikey.ErrUnauthorized) {
+					entry.Debug("request is not authenticated", slogger.Err(err))
+
+					w.Header().Set("WWW-Authenticate", "Bearer")
+					render.Status(r, http.StatusUnauthorized) // 401
+					render.JSON(w, r, resp.Error("invalid api key"))
+
+					return
+				}
+				if err != nil {
+					entry.Error("failed to authenticate request", slogger.Err(err))
+
+					render.Status(r, http.StatusInternalServerError)
+					render.JSON(w, r, resp.Error("failed to authenticate request"))
+
+					return
+				}
+
+				if !key.HasScope(scope) {
+					entry.Debug("api key lacks the scope", slog.Int64("api_key_id", key.Id), slog.String("scope", scope))
+
+					render.Status(r, http.StatusForbidden) // 403
+					render.JSON(w, r, resp.Error("api key lacks the "+scope+" scope"))
+
+					return
+				}
+
+				entry.Debug("request is authenticated", slog.Int64("api_key_id", key.Id), slog.String("api_key", key.Name))
+
+				next.ServeHTTP(w, r.WithContext(WithApiKey(r.Context(), key)))
+			}
+
+			return http.HandlerFunc(fn)
+		}
+	}
+}
+
+func token(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, found := strings.Cut(header, " ")
+		if found && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	return r.Header.Get("X-API-Key")
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/metrics"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Route label of the requests that match no route, so arbitrary paths do not create new series
+const unmatchedRoute = "unmatched"
+
+// NewMetrics returns a middleware recording the number and the duration of requests per route pattern
+func NewMetrics() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			start := time.Now()
+			next.ServeHTTP(ww, r)
+
+			// The pattern is known once the request is routed
+			route := unmatchedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			status := ww.Status()
+			// The status is not written explicitly by handlers responding with 200
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			metrics.HTTPRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+			metrics.HTTPRequestDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/constants"
+	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
+	"github.com/chistyakoviv/converter/internal/lib/ratelimit"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// ClientId identifies the client of the request for the rate limits and the quotas.
+// Authenticated requests are identified by their key, the others by the remote address.
+func ClientId(r *http.Request) string {
+	if key := ApiKeyFromContext(r.Context()); key != nil {
+		return "key:" + strconv.FormatInt(key.Id, 10)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// SetRetryAfter tells the client when to retry a rejected request, the delay is rounded up to seconds
+func SetRetryAfter(w http.ResponseWriter, delay time.Duration) {
+	seconds := int64(math.Ceil(delay.Seconds()))
+	w.Header().Set("Retry-After", strconv.FormatInt(max(seconds, 1), 10))
+}
+
+// NewRateLimit returns a middleware rejecting the requests of a client exceeding the limiter.
+// Must follow the auth middleware, so the requests are limited per key.
+func NewRateLimit(logger *slog.Logger, limiter ratelimit.Limiter) func(next http.Handler) http.Handler {
+	logger = logger.With(
+		slog.String("component", "middleware/ratelimit"),
+	)
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			client := ClientId(r)
+
+			allowed, wait := limiter.Allow(client)
+			if !allowed {
+				logger.Debug(
+					"rate limit exceeded",
+					slog.String("path", r.URL.Path),
+					slog.String("client", client),
+					slog.String(constants.RequestID, middleware.GetReqID(r.Context())),
+				)
+
+				SetRetryAfter(w, wait)
+				render.Status(r, http.StatusTooManyRequests) // 429
+				render.JSON(w, r, resp.Error("rate limit exceeded"))
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
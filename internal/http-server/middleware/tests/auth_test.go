@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	httpMiddleware "github.com/chistyakoviv/converter/internal/http-server/middleware"
+	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service/apikey"
+	"github.com/chistyakoviv/converter/internal/service/mocks"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	var (
+		logger = dummy.NewDummyLogger()
+		key    = &model.ApiKey{Id: 1, Name: "uploader", Scopes: []string{model.ApiKeyScopeConvert}}
+	)
+
+	type testcase struct {
+		name      string
+		headers   map[string]string
+		scope     string
+		respError string
+		// The status of the next handler is 200
+		statusCode        int
+		mockApiKeyService func(tc *testcase) *mocks.MockApiKeyService
+	}
+
+	cases := []testcase{
+		{
+			name:       "Missing key",
+			scope:      model.ApiKeyScopeConvert,
+			respError:  "invalid api key",
+			statusCode: http.StatusUnauthorized,
+			mockApiKeyService: func(tc *testcase) *mocks.MockApiKeyService {
+				mockApiKeyService := mocks.NewMockApiKeyService(t)
+				mockApiKeyService.On("Authenticate", mock.Anything, "").Return(nil, apikey.ErrUnauthorized).Once()
+				return mockApiKeyService
+			},
+		},
+		{
+			name:       "Unsupported authorization scheme",
+			headers:    map[string]string{"Authorization": "Basic dXNlcjpwYXNz", "X-API-Key": "cvt_secret"},
+			scope:      model.ApiKeyScopeConvert,
+			respError:  "invalid api key",
+			statusCode: http.StatusUnauthorized,
+			mockApiKeyService: func(tc *testcase) *mocks.MockApiKeyService {
+				mockApiKeyService := mocks.NewMockApiKeyService(t)
+				mockApiKeyService.On("Authenticate", mock.Anything, "").Return(nil, apikey.ErrUnauthorized).Once()
+				return mockApiKeyService
+			},
+		},
+		{
+			name:       "Failed authentication",
+			headers:    map[string]string{"X-API-Key": "cvt_secret"},
+			scope:      model.ApiKeyScopeConvert,
+			respError:  "failed to authenticate request",
+			statusCode: http.StatusInternalServerError,
+			mockApiKeyService: func(tc *testcase) *mocks.MockApiKeyService {
+				mockApiKeyService := mocks.NewMockApiKeyService(t)
+				mockApiKeyService.On("Authenticate", mock.Anything, "cvt_secret").Return(nil, errors.New("unexpected error")).Once()
+				return mockApiKeyService
+			},
+		},
+		{
+			name:       "Key lacks the scope",
+			headers:    map[string]string{"Authorization": "Bearer cvt_secret"},
+			scope:      model.ApiKeyScopeDelete,
+			respError:  "api key lacks the delete scope",
+			statusCode: http.StatusForbidden,
+			mockApiKeyService: func(tc *testcase) *mocks.MockApiKeyService {
+				mockApiKeyService := mocks.NewMockApiKeyService(t)
+				mockApiKeyService.On("Authenticate", mock.Anything, "cvt_secret").Return(key, nil).Once()
+				return mockApiKeyService
+			},
+		},
+		{
+			name:       "Bearer token",
+			headers:    map[string]string{"Authorization": "bearer cvt_secret"},
+			scope:      model.ApiKeyScopeConvert,
+			statusCode: http.StatusOK,
+			mockApiKeyService: func(tc *testcase) *mocks.MockApiKeyService {
+				mockApiKeyService := mocks.NewMockApiKeyService(t)
+				mockApiKeyService.On("Authenticate", mock.Anything, "cvt_secret").Return(key, nil).Once()
+				return mockApiKeyService
+			},
+		},
+		{
+			name:       "X-API-Key header",
+			headers:    map[string]string{"X-API-Key": "cvt_secret"},
+			scope:      model.ApiKeyScopeConvert,
+			statusCode: http.StatusOK,
+			mockApiKeyService: func(tc *testcase) *mocks.MockApiKeyService {
+				mockApiKeyService := mocks.NewMockApiKeyService(t)
+				mockApiKeyService.On("Authenticate", mock.Anything, "cvt_secret").Return(key, nil).Once()
+				return mockApiKeyService
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockApiKeyService := tc.mockApiKeyService(&tc)
+
+			var authenticated *model.ApiKey
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authenticated = httpMiddleware.ApiKeyFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			handler := httpMiddleware.NewAuth(logger, mockApiKeyService)(tc.scope)(next)
+			req, err := http.NewRequest(http.MethodPost, "/convert", nil)
+			require.NoError(t, err)
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.statusCode, rr.Result().StatusCode)
+			if tc.statusCode == http.StatusOK {
+				assert.Equal(t, key, authenticated)
+			} else {
+				var response resp.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, tc.respError, response.Error)
+				assert.Nil(t, authenticated)
+			}
+			mockApiKeyService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	httpMiddleware "github.com/chistyakoviv/converter/internal/http-server/middleware"
+	"github.com/chistyakoviv/converter/internal/metrics"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(httpMiddleware.NewMetrics())
+	router.Get("/scans/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	router.Post("/metrics-test", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	type testcase struct {
+		name   string
+		method string
+		path   string
+		// Labels of the counted request
+		route  string
+		status string
+	}
+
+	cases := []testcase{
+		{
+			name:   "Requests are counted by the route pattern",
+			method: http.MethodGet,
+			path:   "/scans/b7f1",
+			route:  "/scans/{id}",
+			status: "404",
+		},
+		{
+			name:   "Implicit status is counted as 200",
+			method: http.MethodPost,
+			path:   "/metrics-test",
+			route:  "/metrics-test",
+			status: "200",
+		},
+		{
+			name:   "Unmatched paths share a route",
+			method: http.MethodGet,
+			path:   "/unknown/path",
+			route:  "unmatched",
+			status: "404",
+		},
+	}
+
+	// The collectors are global, so the cases run sequentially and compare the increments
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			counter := metrics.HTTPRequests.WithLabelValues(tc.method, tc.route, tc.status)
+			before := testutil.ToFloat64(counter)
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tc.method, tc.path, nil))
+
+			assert.Equal(t, before+1, testutil.ToFloat64(counter))
+		})
+	}
+}
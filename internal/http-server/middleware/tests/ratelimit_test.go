@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	httpMiddleware "github.com/chistyakoviv/converter/internal/http-server/middleware"
+	resp "github.com/chistyakoviv/converter/internal/lib/http/response"
+	"github.com/chistyakoviv/converter/internal/lib/ratelimit"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	var (
+		logger = dummy.NewDummyLogger()
+		key    = &model.ApiKey{Id: 1, Name: "uploader"}
+	)
+
+	type request struct {
+		remoteAddr string
+		key        *model.ApiKey
+		statusCode int
+		retryAfter string
+	}
+
+	type testcase struct {
+		name     string
+		requests []request
+	}
+
+	cases := []testcase{
+		{
+			name: "Requests are limited per ip address",
+			requests: []request{
+				{remoteAddr: "10.0.0.1:5000", statusCode: http.StatusOK},
+				{remoteAddr: "10.0.0.1:5001", statusCode: http.StatusTooManyRequests, retryAfter: "60"},
+				{remoteAddr: "10.0.0.2:5000", statusCode: http.StatusOK},
+			},
+		},
+		{
+			name: "Requests are limited per key",
+			requests: []request{
+				{remoteAddr: "10.0.0.1:5000", key: key, statusCode: http.StatusOK},
+				{remoteAddr: "10.0.0.2:5000", key: key, statusCode: http.StatusTooManyRequests, retryAfter: "60"},
+				{remoteAddr: "10.0.0.1:5000", statusCode: http.StatusOK},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// A request per minute
+			handler := httpMiddleware.NewRateLimit(logger, ratelimit.New(1.0/60, 1))(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				}),
+			)
+
+			for i, req := range tc.requests {
+				r := httptest.NewRequest(http.MethodPost, "/convert", nil)
+				r.RemoteAddr = req.remoteAddr
+				if req.key != nil {
+					r = r.WithContext(httpMiddleware.WithApiKey(r.Context(), req.key))
+				}
+
+				rr := httptest.NewRecorder()
+				handler.ServeHTTP(rr, r)
+
+				require.Equal(t, req.statusCode, rr.Result().StatusCode, "request %d", i)
+				assert.Equal(t, req.retryAfter, rr.Header().Get("Retry-After"), "request %d", i)
+				if req.statusCode == http.StatusTooManyRequests {
+					var response resp.Response
+					require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+					assert.Equal(t, "rate limit exceeded", response.Error)
+				}
+			}
+		})
+	}
+}
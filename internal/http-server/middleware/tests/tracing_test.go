@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	httpMiddleware "github.com/chistyakoviv/converter/internal/http-server/middleware"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { _ = provider.Shutdown(t.Context()) })
+
+	router := chi.NewRouter()
+	router.Use(httpMiddleware.NewTracing())
+	router.Get("/scans/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	router.Post("/gc", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	type testcase struct {
+		name        string
+		method      string
+		path        string
+		traceParent string
+		// Expected span
+		spanName string
+		status   codes.Code
+		traceId  string
+	}
+
+	cases := []testcase{
+		{
+			name:     "Span is named after the route pattern",
+			method:   http.MethodGet,
+			path:     "/scans/b7f1",
+			spanName: "GET /scans/{id}",
+			status:   codes.Unset,
+		},
+		{
+			name:     "Server errors fail the span",
+			method:   http.MethodPost,
+			path:     "/gc",
+			spanName: "POST /gc",
+			status:   codes.Error,
+		},
+		{
+			name:        "Trace context of the client is continued",
+			method:      http.MethodGet,
+			path:        "/scans/b7f1",
+			traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			spanName:    "GET /scans/{id}",
+			status:      codes.Unset,
+			traceId:     "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name:     "Unmatched paths share a span name",
+			method:   http.MethodGet,
+			path:     "/unknown/path",
+			spanName: "GET unmatched",
+			status:   codes.Unset,
+		},
+	}
+
+	// The provider is global, so the cases run sequentially
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			exporter.Reset()
+
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.traceParent != "" {
+				req.Header.Set("traceparent", tc.traceParent)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tc.spanName, spans[0].Name)
+			assert.Equal(t, tc.status, spans[0].Status.Code)
+			if tc.traceId != "" {
+				assert.Equal(t, tc.traceId, spans[0].SpanContext.TraceID().String())
+				assert.True(t, spans[0].Parent.IsRemote())
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package request
+
+type ApiKeyRequest struct {
+	Name string `json:"name" validate:"required"`
+	// convert, delete, scan or admin
+	Scopes []string `json:"scopes" validate:"required,min=1"`
+	// Directories inside the files directory the key is confined to
+	PathPrefixes []string `json:"path_prefixes,omitempty"`
+}
@@ -5,4 +5,9 @@ import "github.com/chistyakoviv/converter/internal/model"
 type ConversionRequest struct {
 	Path      string            `json:"path" validate:"required"`
 	ConvertTo []model.ConvertTo `json:"convert_to,omitempty"`
+	Preset    string            `json:"preset,omitempty"`
+}
+
+type ConversionBatchRequest struct {
+	Items []ConversionRequest `json:"items" validate:"required,min=1,max=1000,dive"`
 }
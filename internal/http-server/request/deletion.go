@@ -2,4 +2,14 @@ package request
 
 type DeletionRequest struct {
 	Path string `json:"path" validate:"required"`
+	// Remove the source file along with the converted files
+	DeleteSource bool `json:"delete_source,omitempty"`
+	// Remove the conversion once the files are deleted, so the path can be enqueued again
+	DeleteRecord bool `json:"delete_record,omitempty"`
+	// Treat the path as a directory and delete every converted file under it
+	Recursive bool `json:"recursive,omitempty"`
+}
+
+type DeletionBatchRequest struct {
+	Items []DeletionRequest `json:"items" validate:"required,min=1,max=1000,dive"`
 }
@@ -0,0 +1,12 @@
+package request
+
+import "time"
+
+type ScanRequest struct {
+	Path          string     `json:"path,omitempty"`
+	Include       []string   `json:"include,omitempty"`
+	Exclude       []string   `json:"exclude,omitempty"`
+	MaxDepth      int        `json:"max_depth,omitempty" validate:"min=0"`
+	ModifiedSince *time.Time `json:"modified_since,omitempty"`
+	DryRun        bool       `json:"dry_run,omitempty"`
+}
@@ -2,10 +2,13 @@ package mapper
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"strings"
 )
 
+// MapToStruct assigns the values of the map to the fields of the struct with the same lowercased names.
+// Unknown keys and values that cannot be converted to the type of the field without a loss are reported.
 func MapToStruct(input map[string]interface{}, output interface{}) error {
 	// Ensure output is a pointer to a struct
 	val := reflect.ValueOf(output)
@@ -21,30 +24,61 @@ func MapToStruct(input map[string]interface{}, output interface{}) error {
 	fieldMap := make(map[string]int)
 	for i := 0; i < structType.NumField(); i++ {
 		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
 		fieldMap[strings.ToLower(field.Name)] = i
 	}
 
 	// Iterate over the map and assign matching fields
 	for key, value := range input {
-		lowerKey := strings.ToLower(key)
-
-		// Check if the struct has a field matching the lowercase key
-		if fieldIndex, ok := fieldMap[lowerKey]; ok {
-			field := structVal.Field(fieldIndex)
-			if !field.CanSet() {
-				continue // Skip if the field can't be set
-			}
-
-			// Convert the value to the field's type
-			fieldType := field.Type()
-			val := reflect.ValueOf(value)
-
-			// Ignore not convertible types
-			if val.Type().ConvertibleTo(fieldType) {
-				field.Set(val.Convert(fieldType))
-			}
+		fieldIndex, ok := fieldMap[strings.ToLower(key)]
+		if !ok {
+			return fmt.Errorf("unknown key '%s'", key)
+		}
+
+		field := structVal.Field(fieldIndex)
+		converted, err := convert(value, field.Type())
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
 		}
+		field.Set(converted)
 	}
 
 	return nil
 }
+
+// Converts the value to the type of the field, only values of the same kind are converted,
+// e.g. a number is not turned into a string and a fractional number is not truncated
+func convert(value interface{}, fieldType reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Value{}, fmt.Errorf("expected %s, got null", fieldType)
+	}
+	val := reflect.ValueOf(value)
+
+	switch {
+	case isInt(fieldType.Kind()):
+		switch {
+		case isInt(val.Kind()):
+			return val.Convert(fieldType), nil
+		case isFloat(val.Kind()) && val.Float() == math.Trunc(val.Float()):
+			return val.Convert(fieldType), nil
+		}
+	case isFloat(fieldType.Kind()):
+		if isInt(val.Kind()) || isFloat(val.Kind()) {
+			return val.Convert(fieldType), nil
+		}
+	case fieldType.Kind() == val.Kind() && val.Type().ConvertibleTo(fieldType):
+		return val.Convert(fieldType), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("expected %s, got %v", fieldType, value)
+}
+
+func isInt(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Uint64
+}
+
+func isFloat(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter keeps a token bucket per key. A bucket holds up to burst tokens and is refilled at rate tokens per second,
+// each allowed request takes a token.
+type Limiter interface {
+	// Reports whether a request of the key is allowed, otherwise how long to wait for the next token
+	Allow(key string) (bool, time.Duration)
+	AllowAt(key string, now time.Time) (bool, time.Duration)
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+type limiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func New(rate float64, burst int) Limiter {
+	return &limiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (l *limiter) Allow(key string) (bool, time.Duration) {
+	return l.AllowAt(key, time.Now())
+}
+
+func (l *limiter) AllowAt(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, updatedAt: now}
+		l.buckets[key] = b
+	}
+
+	if elapsed := now.Sub(b.updatedAt).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(l.burst, b.tokens+elapsed*l.rate)
+		b.updatedAt = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// Forgets the buckets that have been refilled, they are recreated full when needed.
+// Runs at most once per refill period to keep requests cheap.
+func (l *limiter) sweep(now time.Time) {
+	period := time.Duration(l.burst / l.rate * float64(time.Second))
+	if now.Sub(l.lastSweep) < period {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updatedAt) >= period {
+			delete(l.buckets, key)
+		}
+	}
+}
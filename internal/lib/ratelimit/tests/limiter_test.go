@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/chistyakoviv/converter/internal/lib/ratelimit"
+)
+
+func TestLimiter(t *testing.T) {
+	start := time.Date(2024, 6, 2, 10, 0, 0, 0, time.UTC)
+
+	type request struct {
+		key     string
+		at      time.Duration
+		allowed bool
+		wait    time.Duration
+	}
+
+	type testcase struct {
+		name     string
+		rate     float64
+		burst    int
+		requests []request
+	}
+
+	cases := []testcase{
+		{
+			name:  "Burst is allowed at once",
+			rate:  1,
+			burst: 2,
+			requests: []request{
+				{key: "a", allowed: true},
+				{key: "a", allowed: true},
+				{key: "a", allowed: false, wait: time.Second},
+			},
+		},
+		{
+			name:  "Bucket is refilled over time",
+			rate:  2,
+			burst: 1,
+			requests: []request{
+				{key: "a", allowed: true},
+				{key: "a", at: 250 * time.Millisecond, allowed: false, wait: 250 * time.Millisecond},
+				{key: "a", at: 500 * time.Millisecond, allowed: true},
+			},
+		},
+		{
+			name:  "Keys have separate buckets",
+			rate:  1,
+			burst: 1,
+			requests: []request{
+				{key: "a", allowed: true},
+				{key: "b", allowed: true},
+				{key: "a", allowed: false, wait: time.Second},
+			},
+		},
+		{
+			name:  "Idle buckets are refilled up to the burst",
+			rate:  1,
+			burst: 1,
+			requests: []request{
+				{key: "a", allowed: true},
+				{key: "a", at: time.Hour, allowed: true},
+				{key: "a", at: time.Hour, allowed: false, wait: time.Second},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			limiter := ratelimit.New(tc.rate, tc.burst)
+			for i, req := range tc.requests {
+				allowed, wait := limiter.AllowAt(req.key, start.Add(req.at))
+				assert.Equal(t, req.allowed, allowed, "request %d", i)
+				assert.Equal(t, req.wait, wait, "request %d", i)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package ssim
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// Side of the square window the local statistics are computed over
+	windowSize = 8
+	// Windows overlap by half, which is a common trade-off between accuracy and speed
+	windowStep = windowSize / 2
+
+	// Stabilization constants for 8-bit samples, see https://www.cns.nyu.edu/pub/eero/wang03-reprint.pdf
+	c1 = (0.01 * 255) * (0.01 * 255)
+	c2 = (0.03 * 255) * (0.03 * 255)
+)
+
+var ErrSizeMismatch = errors.New("images have different sizes")
+
+// Gray computes the mean structural similarity index of two 8-bit grayscale planes
+// stored row by row. The result is in the range [-1, 1], where 1 means the planes are identical.
+func Gray(a, b []byte, width, height int) (float64, error) {
+	if width <= 0 || height <= 0 {
+		return 0, fmt.Errorf("invalid image size %dx%d", width, height)
+	}
+	if len(a) != width*height || len(b) != width*height {
+		return 0, ErrSizeMismatch
+	}
+
+	// Images smaller than a window are compared as a single window
+	winW, winH := min(windowSize, width), min(windowSize, height)
+
+	var total float64
+	var windows int
+	for y := 0; y+winH <= height; y += windowStep {
+		for x := 0; x+winW <= width; x += windowStep {
+			total += window(a, b, width, x, y, winW, winH)
+			windows++
+		}
+	}
+
+	return total / float64(windows), nil
+}
+
+func window(a, b []byte, stride, x0, y0, w, h int) float64 {
+	var sumA, sumB, sumAA, sumBB, sumAB float64
+	for y := y0; y < y0+h; y++ {
+		row := y * stride
+		for x := x0; x < x0+w; x++ {
+			pa := float64(a[row+x])
+			pb := float64(b[row+x])
+			sumA += pa
+			sumB += pb
+			sumAA += pa * pa
+			sumBB += pb * pb
+			sumAB += pa * pb
+		}
+	}
+
+	n := float64(w * h)
+	meanA := sumA / n
+	meanB := sumB / n
+	varA := sumAA/n - meanA*meanA
+	varB := sumBB/n - meanB*meanB
+	covar := sumAB/n - meanA*meanB
+
+	return ((2*meanA*meanB + c1) * (2*covar + c2)) /
+		((meanA*meanA + meanB*meanB + c1) * (varA + varB + c2))
+}
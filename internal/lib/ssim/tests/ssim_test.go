@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/chistyakoviv/converter/internal/lib/ssim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gradient(width, height int) []byte {
+	plane := make([]byte, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			plane[y*width+x] = byte((x*7 + y*3) % 256)
+		}
+	}
+	return plane
+}
+
+func TestGray(t *testing.T) {
+	const width, height = 32, 24
+
+	reference := gradient(width, height)
+
+	t.Run("Identical planes", func(t *testing.T) {
+		score, err := ssim.Gray(reference, reference, width, height)
+		require.NoError(t, err)
+		assert.InDelta(t, 1.0, score, 1e-9)
+	})
+
+	t.Run("Distortion lowers the score", func(t *testing.T) {
+		slightly := make([]byte, len(reference))
+		heavily := make([]byte, len(reference))
+		for i, v := range reference {
+			// Deterministic pseudo noise
+			noise := (i*31)%9 - 4
+			slightly[i] = byte(max(0, min(255, int(v)+noise)))
+			heavily[i] = byte(max(0, min(255, int(v)+noise*12)))
+		}
+
+		slightScore, err := ssim.Gray(reference, slightly, width, height)
+		require.NoError(t, err)
+		heavyScore, err := ssim.Gray(reference, heavily, width, height)
+		require.NoError(t, err)
+
+		assert.Less(t, slightScore, 1.0)
+		assert.Less(t, heavyScore, slightScore)
+	})
+
+	t.Run("Images smaller than a window", func(t *testing.T) {
+		small := gradient(3, 2)
+		score, err := ssim.Gray(small, small, 3, 2)
+		require.NoError(t, err)
+		assert.InDelta(t, 1.0, score, 1e-9)
+	})
+
+	t.Run("Size mismatch", func(t *testing.T) {
+		_, err := ssim.Gray(reference, reference[:10], width, height)
+		assert.ErrorIs(t, err, ssim.ErrSizeMismatch)
+	})
+
+	t.Run("Invalid size", func(t *testing.T) {
+		_, err := ssim.Gray(nil, nil, 0, height)
+		assert.EqualError(t, err, "invalid image size 0x24")
+	})
+}
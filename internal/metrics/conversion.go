@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"os"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/file"
+)
+
+// Results of the conversions to a target format
+const (
+	resultSuccess = "success"
+	resultError   = "error"
+)
+
+// ObserveConversion records the duration of converting the file to a target format,
+// and the sizes of the source and the converted file if the conversion succeeded
+func ObserveConversion(converter string, from string, to string, start time.Time, err error) {
+	source, target := file.Ext(from), file.Ext(to)
+
+	result := resultSuccess
+	if err != nil {
+		result = resultError
+	}
+	ConversionDuration.WithLabelValues(converter, source, target, result).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		return
+	}
+	if info, statErr := os.Stat(from); statErr == nil {
+		BytesRead.WithLabelValues(converter, source, target).Add(float64(info.Size()))
+	}
+	if info, statErr := os.Stat(to); statErr == nil {
+		BytesWritten.WithLabelValues(converter, source, target).Add(float64(info.Size()))
+	}
+}
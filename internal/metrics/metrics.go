@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "converter"
+
+// Outcomes of the processed tasks
+const (
+	StatusDone     = "done"
+	StatusCanceled = "canceled"
+	// The conversion is left pending until the disk space is freed
+	StatusPostponed = "postponed"
+	// The record of the deletion is removed
+	StatusPurged = "purged"
+)
+
+// Converters of the duration and size metrics
+const (
+	ConverterImage = "govips"
+	ConverterVideo = "ffmpeg"
+)
+
+// The collectors are registered in the default registry exposed by the /metrics endpoint.
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of handled HTTP requests.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of handling HTTP requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// The code is 0 for the done and postponed conversions
+	Conversions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "conversions_total",
+		Help:      "Number of processed conversions by status and error code.",
+	}, []string{"status", "code"})
+
+	Deletions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "deletions_total",
+		Help:      "Number of processed deletions by status and error code.",
+	}, []string{"status", "code"})
+
+	// Videos take minutes, so the buckets span from 10ms to about 45 minutes
+	ConversionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "conversion_duration_seconds",
+		Help:      "Duration of converting a file to a target format.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 3, 12),
+	}, []string{"converter", "source", "target", "result"})
+
+	BytesRead = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "read_bytes_total",
+		Help:      "Size of the sources of the successful conversions.",
+	}, []string{"converter", "source", "target"})
+
+	BytesWritten = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "written_bytes_total",
+		Help:      "Size of the converted files.",
+	}, []string{"converter", "source", "target"})
+
+	ScansRunning = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "scan",
+		Name:      "running",
+		Help:      "Whether a scan is running.",
+	})
+
+	Scans = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scan",
+		Name:      "finished_total",
+		Help:      "Number of finished scans by status.",
+	}, []string{"status"})
+
+	ScanVisited = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scan",
+		Name:      "visited_files_total",
+		Help:      "Number of files visited by scans.",
+	})
+
+	ScanEnqueued = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scan",
+		Name:      "enqueued_files_total",
+		Help:      "Number of files enqueued by scans, dry runs are not counted.",
+	})
+
+	ScanSkipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scan",
+		Name:      "skipped_files_total",
+		Help:      "Number of files skipped by scans by reason.",
+	}, []string{"reason"})
+
+	ScanErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scan",
+		Name:      "errors_total",
+		Help:      "Number of errors encountered by scans.",
+	})
+
+	// The query is the name of the repository method, e.g. repository.conversion_queue.Create
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Duration of database queries.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+	}, []string{"query"})
+
+	DBQueryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_errors_total",
+		Help:      "Number of failed database queries, missing rows are not counted.",
+	}, []string{"query"})
+)
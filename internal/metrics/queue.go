@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Counts the pending tasks of a queue
+type QueueCounter func(ctx context.Context) (int64, error)
+
+type queueCollector struct {
+	logger  *slog.Logger
+	timeout time.Duration
+	queues  map[string]QueueCounter
+	depth   *prometheus.Desc
+}
+
+// NewQueueCollector returns a collector counting the pending tasks of the queues when the metrics are scraped,
+// so the depth is exact regardless of which instance enqueued the tasks.
+// A queue that cannot be counted within the timeout is left out of the scrape.
+func NewQueueCollector(logger *slog.Logger, timeout time.Duration, queues map[string]QueueCounter) prometheus.Collector {
+	return &queueCollector{
+		logger:  logger,
+		timeout: timeout,
+		queues:  queues,
+		depth: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "queue", "pending"),
+			"Number of pending tasks in the queue.",
+			[]string{"queue"},
+			nil,
+		),
+	}
+}
+
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.depth
+}
+
+func (c *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	for queue, count := range c.queues {
+		pending, err := count(ctx)
+		if err != nil {
+			c.logger.Error("failed to count pending tasks", slog.String("queue", queue), slogger.Err(err))
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.depth, prometheus.GaugeValue, float64(pending), queue)
+	}
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/metrics"
+)
+
+func TestQueueCollector(t *testing.T) {
+	logger := dummy.NewDummyLogger()
+
+	type testcase struct {
+		name     string
+		queues   map[string]metrics.QueueCounter
+		expected string
+	}
+
+	cases := []testcase{
+		{
+			name: "Pending tasks of each queue are reported",
+			queues: map[string]metrics.QueueCounter{
+				"conversion": func(ctx context.Context) (int64, error) { return 12, nil },
+				"deletion":   func(ctx context.Context) (int64, error) { return 0, nil },
+			},
+			expected: `
+# HELP converter_queue_pending Number of pending tasks in the queue.
+# TYPE converter_queue_pending gauge
+converter_queue_pending{queue="conversion"} 12
+converter_queue_pending{queue="deletion"} 0
+`,
+		},
+		{
+			name: "Queue that cannot be counted is left out",
+			queues: map[string]metrics.QueueCounter{
+				"conversion": func(ctx context.Context) (int64, error) { return 3, nil },
+				"deletion":   func(ctx context.Context) (int64, error) { return -1, errors.New("connection refused") },
+			},
+			expected: `
+# HELP converter_queue_pending Number of pending tasks in the queue.
+# TYPE converter_queue_pending gauge
+converter_queue_pending{queue="conversion"} 3
+`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			collector := metrics.NewQueueCollector(logger, time.Second, tc.queues)
+
+			assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(tc.expected)))
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/file"
+)
+
+// Scopes of the API keys
+const (
+	ApiKeyScopeConvert = "convert"
+	ApiKeyScopeDelete  = "delete"
+	ApiKeyScopeScan    = "scan"
+	// Grants every other scope and the key management
+	ApiKeyScopeAdmin = "admin"
+)
+
+type ApiKey struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
+	// The beginning of the key, allows to tell the keys apart without storing them
+	Prefix string   `json:"prefix"`
+	Scopes []string `json:"scopes"`
+	// Directories the requests of the key are confined to, the whole files directory is allowed if empty
+	PathPrefixes []string   `json:"path_prefixes,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (k *ApiKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+func (k *ApiKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, ApiKeyScopeAdmin) || slices.Contains(k.Scopes, scope)
+}
+
+// Allows reports whether the key may access the file or directory, the path must be canonical.
+// A nil key stands for unauthenticated requests when the authentication is disabled.
+func (k *ApiKey) Allows(fullpath string) bool {
+	if k == nil || len(k.PathPrefixes) == 0 {
+		return true
+	}
+	for _, prefix := range k.PathPrefixes {
+		if file.IsWithin(prefix, fullpath) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditId returns the id recorded with the jobs enqueued by the key.
+// Nothing is recorded for the configured admin key and unauthenticated requests.
+func (k *ApiKey) AuditId() sql.NullInt64 {
+	if k == nil || k.Id == 0 {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: k.Id, Valid: true}
+}
+
+type ApiKeyInfo struct {
+	Name         string
+	Scopes       []string
+	PathPrefixes []string
+}
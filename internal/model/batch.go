@@ -0,0 +1,11 @@
+package model
+
+// BatchResult is the outcome of a single item of a batch request
+type BatchResult struct {
+	// Id of the enqueued row
+	Id int64
+	// Number of the enqueued files of a recursive deletion
+	Count int64
+	// Reason the item is not enqueued
+	Err error
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -16,16 +17,32 @@ const (
 )
 
 type Conversion struct {
-	Id        int64
-	Fullpath  string
-	Path      string
-	Filestem  string
-	Ext       string
-	ConvertTo []ConvertTo
-	Status    int
-	ErrorCode int
-	CreatedAt time.Time
-	UpdatedAt sql.NullTime
+	Id             int64
+	Fullpath       string
+	Path           string
+	Filestem       string
+	Ext            string
+	ConvertTo      []ConvertTo
+	Status         int
+	ErrorCode      int
+	CreatedAt      time.Time
+	UpdatedAt      sql.NullTime
+	QualityResults []QualityResult
+	// SHA-256 of the source as of the last conversion, used by the {hash} variable of the output templates
+	SourceHash string
+	// Path of the source with the same content whose converted files were copied instead of converting the file
+	DedupOrigin string
+	// Digests of the settings the converted files were produced with keyed by the target format, see ConvertTo.Key.
+	// A converted file of a duplicate is copied only if it was produced with the same settings.
+	ConfigDigests map[string]string
+	// Key that enqueued the conversion, null if the authentication is disabled or the file is found by the watcher
+	ApiKeyId sql.NullInt64
+	// Client that enqueued the conversion, empty if the file is found by a scan or the watcher
+	Client string
+	// Size of the source when it was enqueued, counted against the quota of the client
+	SourceSize int64
+	// W3C traceparent of the request that enqueued the conversion, the job span is its child
+	TraceParent string
 }
 
 func (c *Conversion) IsDone() bool {
@@ -52,37 +69,31 @@ func (c *Conversion) AbsoluteSourcePath(optionalPathPrefix ...string) (string, e
 
 // Since Go does not support optional parameters, a variadic parameter is used instead.
 // If optionalPathPrefix is not provided or empty, the default path prefix will be the working directory.
-func (c *Conversion) AbsoluteDestinationPath(entry ConvertTo, optionalPathPrefix ...string) (string, error) {
+func (c *Conversion) AbsoluteDestinationPath(entry ConvertTo, naming Naming, optionalPathPrefix ...string) (string, error) {
 	pathPrefix, err := constructPathPrefix(optionalPathPrefix...)
 	if err != nil {
 		return "", err
 	}
-
-	dest := fmt.Sprintf("%s%s/%s", pathPrefix, c.Path, c.Filestem)
-
-	// Preserve the original extension if specified
-	var hasReplaceOrigExt, isReplaceOrigExtBool, replaceOrigExt bool
-	var replaceOrigExtValue interface{}
-	if replaceOrigExtValue, hasReplaceOrigExt = entry.Optional["replace_orig_ext"]; hasReplaceOrigExt {
-		replaceOrigExt, isReplaceOrigExtBool = replaceOrigExtValue.(bool)
-	}
-	if !hasReplaceOrigExt || !isReplaceOrigExtBool || !replaceOrigExt {
-		// Append the original extension
-		dest = dest + "." + c.Ext
+	dest, err := c.DestinationFullpath(entry, naming)
+	if err != nil {
+		return "", err
 	}
+	return pathPrefix + dest, nil
+}
 
-	// Add suffix if specified
-	var hasSuffix, isSuffixStr bool
-	var suffix string
-	var suffixValue interface{}
-	if suffixValue, hasSuffix = entry.Optional["suffix"]; hasSuffix {
-		suffix, isSuffixStr = suffixValue.(string)
-	}
-	if hasSuffix && isSuffixStr {
-		dest = dest + suffix
-	}
+// Returns the path of the converted file relative to the working directory.
+// Templates with the {hash} variable require the source hash to be known.
+func (c *Conversion) DestinationFullpath(entry ConvertTo, naming Naming) (string, error) {
+	return naming.destination(c.Path, c.Filestem, c.Ext, c.SourceHash, entry)
+}
 
-	return dest + "." + entry.Ext, nil
+// The outcome of the perceptual quality search for a single target format
+type QualityResult struct {
+	Key      string  `json:"key"` // The key of the target format, see ConvertTo.Key
+	Metric   string  `json:"metric"`
+	Quality  int     `json:"quality"`
+	Score    float64 `json:"score"`
+	Attempts int     `json:"attempts"`
 }
 
 type ConversionInfo struct {
@@ -91,6 +102,16 @@ type ConversionInfo struct {
 	Filestem  string
 	Ext       string
 	ConvertTo []ConvertTo
+	// Name of the preset the target formats are taken from, the formats of ConvertTo are merged over them
+	Preset string
+	// SHA-256 of the source at the time it is enqueued, empty if unknown
+	SourceHash string
+	ApiKeyId   sql.NullInt64
+	// Client the quota is enforced for, no quota is enforced if empty
+	Client     string
+	SourceSize int64
+	// W3C traceparent of the enqueuing request, empty if it is not traced
+	TraceParent string
 }
 
 // There is no way to makke optional parameters, so use variadic parameter
@@ -102,6 +123,14 @@ func (c *ConversionInfo) AbsoluteSourcePath(optionalPathPrefix ...string) (strin
 	return pathPrefix + c.Fullpath, nil
 }
 
+// Pending conversions of a client
+type QueueUsage struct {
+	Jobs  int64
+	Bytes int64
+}
+
+var ErrInvalidFormat = errors.New("invalid target format")
+
 type ConvertTo struct {
 	Ext      string                 `json:"ext"`       // Required field
 	ConvConf map[string]interface{} `json:"conv_conf"` // Optional conf with arbitrary fields
@@ -116,7 +145,8 @@ func (item *ConvertTo) Key() string {
 	return key
 }
 
-// UnmarshalYAML implements custom unmarshaling for ConvertTo
+// UnmarshalYAML implements custom unmarshaling for ConvertTo.
+// Fields of a wrong type and unknown fields are reported, so a typo in the defaults is not ignored.
 func (item *ConvertTo) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	// First, we unmarshal into a map to capture all fields
 	rawData := make(map[string]interface{})
@@ -124,22 +154,39 @@ func (item *ConvertTo) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		return err
 	}
 
-	// Now, attempt to extract the known fields
-	if ext, ok := rawData["ext"]; ok {
-		item.Ext = ext.(string) // Assuming ext is a string
-	}
-
-	if conf, ok := rawData["conv_conf"]; ok {
-		item.ConvConf = conf.(map[string]interface{}) // Assuming conf is a map
+	for key, value := range rawData {
+		var ok bool
+		switch key {
+		case "ext":
+			item.Ext, ok = value.(string)
+		case "conv_conf":
+			item.ConvConf, ok = optionalMap(value)
+		case "optional":
+			item.Optional, ok = optionalMap(value)
+		default:
+			return fmt.Errorf("%w: unknown key '%s'", ErrInvalidFormat, key)
+		}
+		if !ok {
+			return fmt.Errorf("%w: %s has the wrong type %T", ErrInvalidFormat, key, value)
+		}
 	}
 
-	if optional, ok := rawData["optional"]; ok {
-		item.Optional = optional.(map[string]interface{}) // Assuming optional is a map
+	if item.Ext == "" {
+		return fmt.Errorf("%w: ext is required", ErrInvalidFormat)
 	}
 
 	return nil
 }
 
+// A null value of a map field leaves it empty
+func optionalMap(value interface{}) (map[string]interface{}, bool) {
+	if value == nil {
+		return nil, true
+	}
+	m, ok := value.(map[string]interface{})
+	return m, ok
+}
+
 func constructPathPrefix(optionalPathPrefix ...string) (string, error) {
 	var pathPrefix string
 	if len(optionalPathPrefix) > 0 && optionalPathPrefix[0] != "" {
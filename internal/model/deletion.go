@@ -12,12 +12,16 @@ const (
 )
 
 type Deletion struct {
-	Id        int64
-	Fullpath  string
-	Status    int
-	ErrorCode int
-	CreatedAt time.Time
-	UpdatedAt sql.NullTime
+	Id           int64
+	Fullpath     string
+	Status       int
+	ErrorCode    int
+	CreatedAt    time.Time
+	UpdatedAt    sql.NullTime
+	DeleteSource bool
+	DeleteRecord bool
+	// Key that enqueued the deletion, null if the authentication is disabled or the file is removed outside the API
+	ApiKeyId sql.NullInt64
 }
 
 func (c *Deletion) IsDone() bool {
@@ -34,4 +38,11 @@ func (c *Deletion) IsPending() bool {
 
 type DeletionInfo struct {
 	Fullpath string
+	// Remove the source file along with the converted files
+	DeleteSource bool
+	// Remove the conversion once the files are deleted, so the path can be enqueued again
+	DeleteRecord bool
+	// Treat the path as a directory and delete every converted file under it
+	Recursive bool
+	ApiKeyId  sql.NullInt64
 }
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// DiskState is the outcome of the last free space check of the disk guard
+type DiskState struct {
+	Enabled bool `json:"enabled"`
+	// Conversions stay pending while the queue is paused
+	Paused bool `json:"paused"`
+	// Directory on the filesystem the converted files are written to
+	Path string `json:"path"`
+	// Free bytes available to the application
+	Free uint64 `json:"free_bytes"`
+	// Free bytes that must be left after writing a converted file
+	Reserve uint64 `json:"reserve_bytes"`
+	// Estimated size of the file that could not be written, the queue resumes once it fits
+	Required  uint64     `json:"required_bytes,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+}
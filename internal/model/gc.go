@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// Categories of garbage found by the collector
+const (
+	// A converted file of a conversion which source no longer exists
+	GCCategoryOrphanOutput = "orphan_output"
+	// A conversion which source no longer exists
+	GCCategoryMissingSource = "missing_source"
+	// A temporary file left by an interrupted conversion
+	GCCategoryTmpFile = "tmp_file"
+	// A processed conversion older than the retention period
+	GCCategoryDoneConversion = "done_conversion"
+	// A processed deletion older than the retention period
+	GCCategoryDoneDeletion = "done_deletion"
+)
+
+type GCOptions struct {
+	// Report the garbage without removing it
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+type GCItem struct {
+	Category string `json:"category"`
+	// Path relative to the working directory, the same as the path of a conversion
+	Path  string `json:"path"`
+	Error string `json:"error,omitempty"`
+}
+
+type GCReport struct {
+	DryRun bool `json:"dry_run"`
+	// Number of removed items per category, or the items that would be removed in a dry run
+	Removed map[string]int64 `json:"removed"`
+	Errors  int64            `json:"errors"`
+	// Files and conversions found, rows removed by the retention are only counted
+	Items []GCItem `json:"items,omitempty"`
+	// Items beyond the limit are counted but not listed
+	Truncated  bool      `json:"truncated,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
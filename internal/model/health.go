@@ -0,0 +1,24 @@
+package model
+
+const (
+	HealthStatusOK     = "ok"
+	HealthStatusFailed = "failed"
+)
+
+// HealthCheck is the outcome of checking a dependency of the application
+type HealthCheck struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Duration of the check in milliseconds
+	Duration int64 `json:"duration_ms"`
+}
+
+// HealthReport is the outcome of the checks of a probe, it fails if any check fails
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks"`
+}
+
+func (r *HealthReport) IsHealthy() bool {
+	return r.Status == HealthStatusOK
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Migration is a schema migration embedded in the binary
+type Migration struct {
+	Version int64
+	// Name of the SQL file
+	Name    string
+	Applied bool
+	// Zero if the migration is pending
+	AppliedAt time.Time
+	// Time taken to apply or roll back the migration, only reported by the migrations run
+	Duration time.Duration
+}
@@ -0,0 +1,198 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/chistyakoviv/converter/internal/constants"
+	"github.com/chistyakoviv/converter/internal/file"
+)
+
+// Names the converted files the same way as before the templates were introduced,
+// e.g. /files/images/photo.jpg.webp
+const DefaultOutputTemplate = "{dir}/{stem}{orig_ext}{suffix}.{fmt}"
+
+// Number of hex digits of the source hash used by the {hash} variable
+const outputHashLength = 8
+
+var ErrInvalidOutputTemplate = errors.New("invalid output template")
+
+// Naming determines the paths of the converted files
+type Naming struct {
+	// Directory the converted files are written to, relative to the working directory.
+	// The converted files are written next to their sources if empty.
+	Root string
+	// Template of the target formats without their own template
+	Template string
+	// Templates of the target formats keyed by ConvertTo.Key
+	Templates map[string]string
+}
+
+// Returns the template of the target format, the template of the entry takes precedence
+func (n Naming) TemplateOf(entry ConvertTo) string {
+	if template, ok := entry.Optional["template"].(string); ok && template != "" {
+		return template
+	}
+	if template := n.Templates[entry.Key()]; template != "" {
+		return template
+	}
+	if n.Template != "" {
+		return n.Template
+	}
+	return DefaultOutputTemplate
+}
+
+// Reports whether the name of the converted file depends on the content of the source
+func (n Naming) NeedsHash(entry ConvertTo) bool {
+	return strings.Contains(n.TemplateOf(entry), "{hash}")
+}
+
+// Validate checks that the converted file of the source can be named and returns its path.
+// The source hash is not known before the conversion, so a placeholder is used instead.
+func (n Naming) Validate(info *ConversionInfo, entry ConvertTo) (string, error) {
+	dest, err := n.destination(info.Path, info.Filestem, info.Ext, strings.Repeat("0", outputHashLength), entry)
+	if err != nil {
+		return "", err
+	}
+	if dest == info.Fullpath {
+		return "", fmt.Errorf("%w: '%s' overwrites the source", ErrInvalidOutputTemplate, n.TemplateOf(entry))
+	}
+
+	// A template of a request must not write outside of the directories the converted files are looked for in
+	if n.Root != "" {
+		if root := path.Join("/", n.Root); !strings.HasPrefix(dest, root+"/") {
+			return "", fmt.Errorf("%w: '%s' leaves the output root", ErrInvalidOutputTemplate, n.TemplateOf(entry))
+		}
+		return dest, nil
+	}
+
+	// Scans and deletions look for the converted files next to the sources only
+	if path.Dir(dest) != info.Path {
+		return "", fmt.Errorf("%w: '%s' must write to the directory of the source if the output root is not set", ErrInvalidOutputTemplate, n.TemplateOf(entry))
+	}
+
+	// Scans recognize the converted files placed next to the sources by the stem of their names
+	name := path.Base(dest)
+	if len(name) <= len(info.Filestem) || !strings.HasPrefix(name, info.Filestem) || !isOutputNameSeparator(name[len(info.Filestem)]) {
+		return "", fmt.Errorf("%w: '%s' must start with the stem followed by one of '%s' if the output root is not set", ErrInvalidOutputTemplate, n.TemplateOf(entry), OutputNameSeparators)
+	}
+	return dest, nil
+}
+
+// Separators that may follow the stem in the names of the converted files
+const OutputNameSeparators = ".-_@"
+
+func isOutputNameSeparator(c byte) bool {
+	return strings.IndexByte(OutputNameSeparators, c) >= 0
+}
+
+// Returns the path of the converted file relative to the working directory
+func (n Naming) destination(dir, stem, ext, hash string, entry ConvertTo) (string, error) {
+	template := n.TemplateOf(entry)
+
+	// Directories of the sources are mirrored under the output root
+	if n.Root != "" {
+		filesDir := "/" + constants.FilesRootDir
+		if dir == filesDir || strings.HasPrefix(dir, filesDir+"/") {
+			dir = dir[len(filesDir):]
+		}
+	}
+
+	vars := map[string]string{
+		"dir":      dir,
+		"stem":     stem,
+		"ext":      ext,
+		"orig_ext": "." + ext,
+		"suffix":   "",
+		"fmt":      entry.Ext,
+	}
+	if replaceOrigExt, ok := entry.Optional["replace_orig_ext"].(bool); ok && replaceOrigExt {
+		vars["orig_ext"] = ""
+	}
+	if suffix, ok := entry.Optional["suffix"].(string); ok {
+		vars["suffix"] = suffix
+	}
+	if hash != "" {
+		vars["hash"] = hash[:min(len(hash), outputHashLength)]
+	}
+	for _, name := range []string{"width", "height"} {
+		if value, ok := entry.ConvConf[name]; ok {
+			vars[name] = fmt.Sprint(value)
+		}
+	}
+
+	rendered, err := renderOutputTemplate(template, vars)
+	if err != nil {
+		return "", err
+	}
+
+	for _, segment := range strings.Split(rendered, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("%w: '%s' leaves the output directory", ErrInvalidOutputTemplate, template)
+		}
+	}
+	// The converters choose the format by the extension of the converted file
+	if file.Ext(rendered) != strings.ToLower(entry.Ext) || strings.HasSuffix(rendered, "/."+entry.Ext) {
+		return "", fmt.Errorf("%w: '%s' must end with '.{fmt}'", ErrInvalidOutputTemplate, template)
+	}
+
+	return path.Join("/", n.Root, rendered), nil
+}
+
+// Variables that may be used in the templates
+var outputTemplateVariables = map[string]bool{
+	"dir":      true,
+	"stem":     true,
+	"ext":      true,
+	"orig_ext": true,
+	"suffix":   true,
+	"fmt":      true,
+	"hash":     true,
+	"width":    true,
+	"height":   true,
+}
+
+// ValidateOutputTemplate checks the syntax of the template
+func ValidateOutputTemplate(template string) error {
+	vars := make(map[string]string, len(outputTemplateVariables))
+	for name := range outputTemplateVariables {
+		vars[name] = name
+	}
+	_, err := renderOutputTemplate(template, vars)
+	return err
+}
+
+func renderOutputTemplate(template string, vars map[string]string) (string, error) {
+	var b strings.Builder
+
+	rest := template
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			b.WriteString(rest)
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("%w: unclosed '{' in '%s'", ErrInvalidOutputTemplate, template)
+		}
+		end += start
+
+		name := rest[start+1 : end]
+		if !outputTemplateVariables[name] {
+			return "", fmt.Errorf("%w: unknown variable '{%s}' in '%s'", ErrInvalidOutputTemplate, name, template)
+		}
+		value, ok := vars[name]
+		if !ok {
+			return "", fmt.Errorf("%w: variable '{%s}' of '%s' has no value", ErrInvalidOutputTemplate, name, template)
+		}
+
+		b.WriteString(rest[:start])
+		b.WriteString(value)
+		rest = rest[end+1:]
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,10 @@
+package model
+
+// A named set of target formats with the formats inherited from the presets it extends
+type Preset struct {
+	Name        string      `json:"name"`
+	Extends     string      `json:"extends,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Image       []ConvertTo `json:"image"`
+	Video       []ConvertTo `json:"video"`
+}
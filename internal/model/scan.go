@@ -0,0 +1,59 @@
+package model
+
+import "time"
+
+const (
+	ScanStatusRunning  = "running"
+	ScanStatusDone     = "done"
+	ScanStatusCanceled = "canceled"
+	ScanStatusFailed   = "failed"
+)
+
+// Reasons a visited file is not enqueued
+const (
+	ScanSkipExcluded        = "excluded"
+	ScanSkipNotIncluded     = "not_included"
+	ScanSkipNotModified     = "not_modified"
+	ScanSkipUnsupportedType = "unsupported_type"
+	ScanSkipAlreadyQueued   = "already_queued"
+	// The file is an output of a conversion
+	ScanSkipGenerated = "generated"
+	// The file matches a pattern of an ignore file
+	ScanSkipIgnored = "ignored"
+	// The file is a symlink not allowed by the symlink policy
+	ScanSkipSymlink = "symlink"
+)
+
+type ScanOptions struct {
+	// Directory to scan relative to the files root, the whole root is scanned if empty
+	Path string `json:"path,omitempty"`
+	// Glob patterns, a pattern without a slash is matched against the file name,
+	// otherwise against the path relative to the files root
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+	// Maximal depth of files relative to the scanned directory, zero means no limit
+	MaxDepth      int        `json:"max_depth,omitempty"`
+	ModifiedSince *time.Time `json:"modified_since,omitempty"`
+	// Report the files that would be enqueued without enqueuing them
+	DryRun bool `json:"dry_run,omitempty"`
+	// Key that started the scan, recorded with the enqueued conversions
+	ApiKeyId int64 `json:"api_key_id,omitempty"`
+}
+
+type Scan struct {
+	Id      string      `json:"id"`
+	Status  string      `json:"status"`
+	Options ScanOptions `json:"options"`
+	Visited int64       `json:"visited"`
+	// Files enqueued for conversion, or the files that would be enqueued in a dry run
+	Enqueued      int64            `json:"enqueued"`
+	Skipped       map[string]int64 `json:"skipped"`
+	Errors        int64            `json:"errors"`
+	ErrorMessages []string         `json:"error_messages,omitempty"`
+	StartedAt     time.Time        `json:"started_at"`
+	FinishedAt    *time.Time       `json:"finished_at,omitempty"`
+}
+
+func (s *Scan) IsRunning() bool {
+	return s.Status == ScanStatusRunning
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiKeyAllows(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name     string
+		key      *model.ApiKey
+		fullpath string
+		allowed  bool
+	}
+
+	confined := &model.ApiKey{PathPrefixes: []string{"/files/images", "/files/videos/public"}}
+
+	cases := []testcase{
+		{
+			name:     "Authentication is disabled",
+			fullpath: "/files/images/photo.jpg",
+			allowed:  true,
+		},
+		{
+			name:     "Key without path prefixes",
+			key:      &model.ApiKey{},
+			fullpath: "/files/images/photo.jpg",
+			allowed:  true,
+		},
+		{
+			name:     "File inside a prefix",
+			key:      confined,
+			fullpath: "/files/videos/public/clip.mp4",
+			allowed:  true,
+		},
+		{
+			name:     "The prefix itself",
+			key:      confined,
+			fullpath: "/files/images",
+			allowed:  true,
+		},
+		{
+			name:     "Sibling directory with the same beginning",
+			key:      confined,
+			fullpath: "/files/images-private/photo.jpg",
+		},
+		{
+			name:     "Parent of a prefix",
+			key:      confined,
+			fullpath: "/files/videos",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.allowed, tc.key.Allows(tc.fullpath))
+		})
+	}
+}
+
+func TestApiKeyScopesAndAudit(t *testing.T) {
+	t.Parallel()
+
+	admin := &model.ApiKey{Scopes: []string{model.ApiKeyScopeAdmin}}
+	assert.True(t, admin.HasScope(model.ApiKeyScopeDelete))
+	// The configured admin key is not stored, so nothing is recorded
+	assert.Equal(t, sql.NullInt64{}, admin.AuditId())
+
+	key := &model.ApiKey{Id: 7, Scopes: []string{model.ApiKeyScopeConvert}}
+	assert.True(t, key.HasScope(model.ApiKeyScopeConvert))
+	assert.False(t, key.HasScope(model.ApiKeyScopeDelete))
+	assert.False(t, key.HasScope(model.ApiKeyScopeAdmin))
+	assert.Equal(t, sql.NullInt64{Int64: 7, Valid: true}, key.AuditId())
+
+	var disabled *model.ApiKey
+	assert.Equal(t, sql.NullInt64{}, disabled.AuditId())
+}
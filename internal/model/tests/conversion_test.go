@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertToUnmarshalYAML(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name  string
+		raw   map[string]interface{}
+		entry model.ConvertTo
+		err   string
+	}
+
+	cases := []testcase{
+		{
+			name: "All fields",
+			raw: map[string]interface{}{
+				"ext":       "webm",
+				"conv_conf": map[string]interface{}{"crf": "40"},
+				"optional":  map[string]interface{}{"suffix": ".vp9"},
+			},
+			entry: model.ConvertTo{
+				Ext:      "webm",
+				ConvConf: map[string]interface{}{"crf": "40"},
+				Optional: map[string]interface{}{"suffix": ".vp9"},
+			},
+		},
+		{
+			name:  "Null conv_conf",
+			raw:   map[string]interface{}{"ext": "webp", "conv_conf": nil},
+			entry: model.ConvertTo{Ext: "webp"},
+		},
+		{
+			name: "Ext of a wrong type",
+			raw:  map[string]interface{}{"ext": []interface{}{"webp"}},
+			err:  "ext has the wrong type",
+		},
+		{
+			name: "Conv_conf of a wrong type",
+			raw:  map[string]interface{}{"ext": "webp", "conv_conf": "quality=80"},
+			err:  "conv_conf has the wrong type",
+		},
+		{
+			name: "Unknown field",
+			raw:  map[string]interface{}{"ext": "webp", "conf": map[string]interface{}{"quality": 80}},
+			err:  "unknown key 'conf'",
+		},
+		{
+			name: "Missing ext",
+			raw:  map[string]interface{}{"conv_conf": map[string]interface{}{"quality": 80}},
+			err:  "ext is required",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var entry model.ConvertTo
+			err := entry.UnmarshalYAML(func(v interface{}) error {
+				*v.(*map[string]interface{}) = tc.raw
+				return nil
+			})
+
+			if tc.err != "" {
+				assert.ErrorIs(t, err, model.ErrInvalidFormat)
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.entry, entry)
+		})
+	}
+}
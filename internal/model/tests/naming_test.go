@@ -0,0 +1,203 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDestinationFullpath(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name   string
+		naming model.Naming
+		entry  model.ConvertTo
+		dest   string
+		err    error
+	}
+
+	conversion := &model.Conversion{
+		Fullpath:   "/files/images/photo.jpg",
+		Path:       "/files/images",
+		Filestem:   "photo",
+		Ext:        "jpg",
+		SourceHash: "3fa2b1c9d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f",
+	}
+
+	cases := []testcase{
+		{
+			name:  "Default naming",
+			entry: model.ConvertTo{Ext: "webp"},
+			dest:  "/files/images/photo.jpg.webp",
+		},
+		{
+			name:  "Default naming with suffix and replaced extension",
+			entry: model.ConvertTo{Ext: "webm", Optional: map[string]interface{}{"replace_orig_ext": true, "suffix": ".vp9"}},
+			dest:  "/files/images/photo.vp9.webm",
+		},
+		{
+			name:   "Output root mirrors the files directory",
+			naming: model.Naming{Root: "public/converted"},
+			entry:  model.ConvertTo{Ext: "webp"},
+			dest:   "/public/converted/images/photo.jpg.webp",
+		},
+		{
+			name:   "Template of the format",
+			naming: model.Naming{Templates: map[string]string{"webp": "{dir}/{stem}-{width}w.{fmt}"}},
+			entry:  model.ConvertTo{Ext: "webp", ConvConf: map[string]interface{}{"width": 800}},
+			dest:   "/files/images/photo-800w.webp",
+		},
+		{
+			name: "Template of the entry takes precedence",
+			naming: model.Naming{
+				Template:  "{dir}/{stem}_{ext}.{fmt}",
+				Templates: map[string]string{"webp": "{dir}/{stem}-{width}w.{fmt}"},
+			},
+			entry: model.ConvertTo{Ext: "webp", Optional: map[string]interface{}{"template": "{dir}/{stem}.{hash}.{fmt}"}},
+			dest:  "/files/images/photo.3fa2b1c9.webp",
+		},
+		{
+			name:   "Global template",
+			naming: model.Naming{Template: "{dir}/{stem}_{ext}.{fmt}"},
+			entry:  model.ConvertTo{Ext: "webp"},
+			dest:   "/files/images/photo_jpg.webp",
+		},
+		{
+			name:   "Unknown variable",
+			naming: model.Naming{Template: "{dir}/{name}.{fmt}"},
+			entry:  model.ConvertTo{Ext: "webp"},
+			err:    model.ErrInvalidOutputTemplate,
+		},
+		{
+			name:   "Variable without value",
+			naming: model.Naming{Template: "{dir}/{stem}-{width}w.{fmt}"},
+			entry:  model.ConvertTo{Ext: "webp"},
+			err:    model.ErrInvalidOutputTemplate,
+		},
+		{
+			name:   "Unclosed variable",
+			naming: model.Naming{Template: "{dir}/{stem.{fmt}"},
+			entry:  model.ConvertTo{Ext: "webp"},
+			err:    model.ErrInvalidOutputTemplate,
+		},
+		{
+			name:   "Wrong extension",
+			naming: model.Naming{Template: "{dir}/{stem}.{ext}"},
+			entry:  model.ConvertTo{Ext: "webp"},
+			err:    model.ErrInvalidOutputTemplate,
+		},
+		{
+			name:   "Parent directory",
+			naming: model.Naming{Template: "{dir}/../{stem}.{fmt}"},
+			entry:  model.ConvertTo{Ext: "webp"},
+			err:    model.ErrInvalidOutputTemplate,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dest, err := conversion.DestinationFullpath(tc.entry, tc.naming)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.dest, dest)
+		})
+	}
+}
+
+func TestNamingValidate(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name   string
+		naming model.Naming
+		entry  model.ConvertTo
+		err    error
+	}
+
+	info := &model.ConversionInfo{
+		Fullpath: "/files/images/photo.webp",
+		Path:     "/files/images",
+		Filestem: "photo",
+		Ext:      "webp",
+	}
+
+	cases := []testcase{
+		{
+			name:  "Default naming",
+			entry: model.ConvertTo{Ext: "avif"},
+		},
+		{
+			name:   "Source is overwritten",
+			naming: model.Naming{Template: "{dir}/{stem}.{fmt}"},
+			entry:  model.ConvertTo{Ext: "webp"},
+			err:    model.ErrInvalidOutputTemplate,
+		},
+		{
+			name:   "Name without the stem next to the source",
+			naming: model.Naming{Template: "{dir}/{hash}.{fmt}"},
+			entry:  model.ConvertTo{Ext: "avif"},
+			err:    model.ErrInvalidOutputTemplate,
+		},
+		{
+			name:   "Name without the stem under the output root",
+			naming: model.Naming{Root: "public", Template: "{dir}/{hash}.{fmt}"},
+			entry:  model.ConvertTo{Ext: "avif"},
+		},
+		{
+			name:  "Request template in the working directory",
+			entry: model.ConvertTo{Ext: "avif", Optional: map[string]interface{}{"template": "{stem}.{fmt}"}},
+			err:   model.ErrInvalidOutputTemplate,
+		},
+		{
+			name:  "Request template in another directory of the working directory",
+			entry: model.ConvertTo{Ext: "avif", Optional: map[string]interface{}{"template": "app/{stem}.{fmt}"}},
+			err:   model.ErrInvalidOutputTemplate,
+		},
+		{
+			name:  "Request template in another directory of the files directory",
+			entry: model.ConvertTo{Ext: "avif", Optional: map[string]interface{}{"template": "files/other/{stem}.{fmt}"}},
+			err:   model.ErrInvalidOutputTemplate,
+		},
+		{
+			name:  "Request template in a subdirectory of the source",
+			entry: model.ConvertTo{Ext: "avif", Optional: map[string]interface{}{"template": "{dir}/thumbs/{stem}.{fmt}"}},
+			err:   model.ErrInvalidOutputTemplate,
+		},
+		{
+			name:  "Request template next to the source",
+			entry: model.ConvertTo{Ext: "avif", Optional: map[string]interface{}{"template": "{dir}/{stem}@2x.{fmt}"}},
+		},
+		{
+			name:   "Request template in a subdirectory of the output root",
+			naming: model.Naming{Root: "public"},
+			entry:  model.ConvertTo{Ext: "avif", Optional: map[string]interface{}{"template": "thumbs{dir}/{stem}.{fmt}"}},
+		},
+		{
+			name:   "Request template outside the output root",
+			naming: model.Naming{Root: "public"},
+			entry:  model.ConvertTo{Ext: "avif", Optional: map[string]interface{}{"template": "{dir}/../../{stem}.{fmt}"}},
+			err:    model.ErrInvalidOutputTemplate,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := tc.naming.Validate(info, tc.entry)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package apikey
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/chistyakoviv/converter/internal/db"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+const (
+	tablename = "api_keys"
+
+	idColumn           = "id"
+	nameColumn         = "name"
+	prefixColumn       = "prefix"
+	keyHashColumn      = "key_hash"
+	scopesColumn       = "scopes"
+	pathPrefixesColumn = "path_prefixes"
+	createdAtColumn    = "created_at"
+	revokedAtColumn    = "revoked_at"
+)
+
+// The hash is never selected
+var columns = []string{
+	idColumn,
+	nameColumn,
+	prefixColumn,
+	scopesColumn,
+	pathPrefixesColumn,
+	createdAtColumn,
+	revokedAtColumn,
+}
+
+type repo struct {
+	db db.Client
+	sq sq.StatementBuilderType
+}
+
+func NewRepository(db db.Client, sq sq.StatementBuilderType) repository.ApiKeyRepository {
+	return &repo{
+		db: db,
+		sq: sq,
+	}
+}
+
+func (r *repo) Create(ctx context.Context, key *model.ApiKey, hash string) (int64, error) {
+	pathPrefixes := key.PathPrefixes
+	if pathPrefixes == nil {
+		pathPrefixes = []string{}
+	}
+
+	builder := r.sq.Insert(tablename).
+		Columns(
+			nameColumn,
+			prefixColumn,
+			keyHashColumn,
+			scopesColumn,
+			pathPrefixesColumn,
+			createdAtColumn,
+		).
+		Values(
+			key.Name,
+			key.Prefix,
+			hash,
+			key.Scopes,
+			pathPrefixes,
+			key.CreatedAt,
+		).
+		Suffix("RETURNING id")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return -1, err
+	}
+
+	query := db.Query{
+		Name:     "repository.api_keys.Create",
+		QueryRaw: sql,
+	}
+
+	var id int64
+	err = r.db.DB().QueryRow(ctx, query, args...).Scan(&id)
+	if err != nil {
+		return -1, fmt.Errorf("%s: %w", query.Name, err)
+	}
+
+	return id, nil
+}
+
+func (r *repo) FindByHash(ctx context.Context, hash string) (*model.ApiKey, error) {
+	builder := r.sq.
+		Select(columns...).
+		From(tablename).
+		Where(sq.Eq{keyHashColumn: hash}).
+		Limit(1)
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	query := db.Query{
+		Name:     "repository.api_keys.FindByHash",
+		QueryRaw: sql,
+	}
+
+	key, err := scan(r.db.DB().QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", query.Name, db.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", query.Name, err)
+	}
+
+	return key, nil
+}
+
+func (r *repo) FindAll(ctx context.Context) ([]*model.ApiKey, error) {
+	builder := r.sq.
+		Select(columns...).
+		From(tablename).
+		OrderBy(fmt.Sprintf("%s ASC", idColumn))
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	query := db.Query{
+		Name:     "repository.api_keys.FindAll",
+		QueryRaw: sql,
+	}
+
+	rows, err := r.db.DB().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", query.Name, err)
+	}
+	defer rows.Close()
+
+	keys := make([]*model.ApiKey, 0)
+	for rows.Next() {
+		key, err := scan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", query.Name, err)
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", query.Name, err)
+	}
+
+	return keys, nil
+}
+
+// Revoke keeps the time of the first revocation if the key is already revoked
+func (r *repo) Revoke(ctx context.Context, id int64) error {
+	builder := r.sq.
+		Update(tablename).
+		Set(revokedAtColumn, sq.Expr(fmt.Sprintf("COALESCE(%s, ?)", revokedAtColumn), time.Now())).
+		Where(sq.Eq{idColumn: id})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	query := db.Query{
+		Name:     "repository.api_keys.Revoke",
+		QueryRaw: sql,
+	}
+
+	tag, err := r.db.DB().Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", query.Name, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", query.Name, db.ErrNotFound)
+	}
+	return nil
+}
+
+func scan(row pgx.Row) (*model.ApiKey, error) {
+	var key model.ApiKey
+	err := row.Scan(
+		&key.Id,
+		&key.Name,
+		&key.Prefix,
+		&key.Scopes,
+		&key.PathPrefixes,
+		&key.CreatedAt,
+		&key.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
@@ -16,16 +16,29 @@ import (
 const (
 	tablename = "conversion_queue"
 
-	idColumn        = "id"
-	fullpathColumn  = "fullpath"
-	pathColumn      = "path"
-	filestemColumn  = "filestem"
-	extColumn       = "ext"
-	convertToColumn = "convert_to"
-	statusColumn    = "status"
-	errorCodeColumn = "error_code"
-	createdAtColumn = "created_at"
-	updatedAtColumn = "updated_at"
+	idColumn             = "id"
+	fullpathColumn       = "fullpath"
+	pathColumn           = "path"
+	filestemColumn       = "filestem"
+	extColumn            = "ext"
+	convertToColumn      = "convert_to"
+	statusColumn         = "status"
+	errorCodeColumn      = "error_code"
+	createdAtColumn      = "created_at"
+	updatedAtColumn      = "updated_at"
+	qualityResultsColumn = "quality_results"
+	sourceHashColumn     = "source_hash"
+	dedupOriginColumn    = "dedup_origin"
+	apiKeyIdColumn       = "api_key_id"
+	clientColumn         = "client"
+	sourceSizeColumn     = "source_size"
+	traceParentColumn    = "trace_parent"
+	configDigestsColumn  = "config_digests"
+
+	// Postgres allows at most 65535 parameters per statement, each row takes 12 of them
+	maxRowsPerInsert = 1000
+	// The same limit applies to the paths looked up by a single statement
+	maxPathsPerSelect = 10000
 )
 
 type repo struct {
@@ -49,6 +62,11 @@ func (r *repo) Create(ctx context.Context, file *model.ConversionInfo) (int64, e
 			filestemColumn,
 			extColumn,
 			convertToColumn,
+			sourceHashColumn,
+			apiKeyIdColumn,
+			clientColumn,
+			sourceSizeColumn,
+			traceParentColumn,
 			createdAtColumn,
 			updatedAtColumn,
 		).
@@ -58,6 +76,11 @@ func (r *repo) Create(ctx context.Context, file *model.ConversionInfo) (int64, e
 			file.Filestem,
 			file.Ext,
 			file.ConvertTo,
+			file.SourceHash,
+			file.ApiKeyId,
+			file.Client,
+			file.SourceSize,
+			file.TraceParent,
 			ts,
 			ts,
 		).
@@ -82,6 +105,68 @@ func (r *repo) Create(ctx context.Context, file *model.ConversionInfo) (int64, e
 	return id, nil
 }
 
+// Inserts the files in as few statements as possible, files already in the queue are skipped.
+// Returns the number of inserted rows.
+func (r *repo) CreateBatch(ctx context.Context, files []*model.ConversionInfo) (int64, error) {
+	query := db.Query{
+		Name: "repository.conversion_queue.CreateBatch",
+	}
+
+	var inserted int64
+	ts := time.Now()
+	for start := 0; start < len(files); start += maxRowsPerInsert {
+		end := min(start+maxRowsPerInsert, len(files))
+
+		builder := r.sq.Insert(tablename).
+			Columns(
+				fullpathColumn,
+				pathColumn,
+				filestemColumn,
+				extColumn,
+				convertToColumn,
+				sourceHashColumn,
+				apiKeyIdColumn,
+				clientColumn,
+				sourceSizeColumn,
+				traceParentColumn,
+				createdAtColumn,
+				updatedAtColumn,
+			).
+			Suffix(fmt.Sprintf("ON CONFLICT (%s) DO NOTHING", fullpathColumn))
+
+		for _, file := range files[start:end] {
+			builder = builder.Values(
+				file.Fullpath,
+				file.Path,
+				file.Filestem,
+				file.Ext,
+				file.ConvertTo,
+				file.SourceHash,
+				file.ApiKeyId,
+				file.Client,
+				file.SourceSize,
+				file.TraceParent,
+				ts,
+				ts,
+			)
+		}
+
+		sql, args, err := builder.ToSql()
+		if err != nil {
+			return inserted, err
+		}
+		query.QueryRaw = sql
+
+		tag, err := r.db.DB().Exec(ctx, query, args...)
+		if err != nil {
+			return inserted, fmt.Errorf("%s: %w", query.Name, err)
+		}
+		inserted += tag.RowsAffected()
+	}
+
+	return inserted, nil
+}
+
 func (r *repo) FindByFullpath(ctx context.Context, fullpath string) (*model.Conversion, error) {
 	builder := r.sq.Select("*").From(tablename).Where(sq.Eq{fullpathColumn: fullpath}).Limit(1)
 
@@ -107,6 +192,58 @@ func (r *repo) FindByFullpath(ctx context.Context, fullpath string) (*model.Conv
 		&file.ErrorCode,
 		&file.CreatedAt,
 		&file.UpdatedAt,
+		&file.QualityResults,
+		&file.SourceHash,
+		&file.DedupOrigin,
+		&file.ApiKeyId,
+		&file.Client,
+		&file.SourceSize,
+		&file.TraceParent,
+		&file.ConfigDigests,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", query.Name, db.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", query.Name, err)
+	}
+
+	return &file, nil
+}
+
+func (r *repo) FindById(ctx context.Context, id int64) (*model.Conversion, error) {
+	builder := r.sq.Select("*").From(tablename).Where(sq.Eq{idColumn: id}).Limit(1)
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	query := db.Query{
+		Name:     "repository.conversion_queue.FindById",
+		QueryRaw: sql,
+	}
+
+	var file model.Conversion
+	err = r.db.DB().QueryRow(ctx, query, args...).Scan(
+		&file.Id,
+		&file.Fullpath,
+		&file.Path,
+		&file.Filestem,
+		&file.Ext,
+		&file.ConvertTo,
+		&file.Status,
+		&file.ErrorCode,
+		&file.CreatedAt,
+		&file.UpdatedAt,
+		&file.QualityResults,
+		&file.SourceHash,
+		&file.DedupOrigin,
+		&file.ApiKeyId,
+		&file.Client,
+		&file.SourceSize,
+		&file.TraceParent,
+		&file.ConfigDigests,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, fmt.Errorf("%s: %w", query.Name, db.ErrNotFound)
@@ -118,6 +255,91 @@ func (r *repo) FindByFullpath(ctx context.Context, fullpath string) (*model.Conv
 	return &file, nil
 }
 
+func (r *repo) FindByFullpaths(ctx context.Context, fullpaths []string) ([]*model.Conversion, error) {
+	query := db.Query{
+		Name: "repository.conversion_queue.FindByFullpaths",
+	}
+
+	var files []*model.Conversion
+	for start := 0; start < len(fullpaths); start += maxPathsPerSelect {
+		end := min(start+maxPathsPerSelect, len(fullpaths))
+
+		builder := r.sq.Select("*").From(tablename).Where(sq.Eq{fullpathColumn: fullpaths[start:end]})
+
+		sql, args, err := builder.ToSql()
+		if err != nil {
+			return nil, err
+		}
+		query.QueryRaw = sql
+
+		rows, err := r.db.DB().Query(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", query.Name, err)
+		}
+
+		for rows.Next() {
+			var file model.Conversion
+			err = rows.Scan(
+				&file.Id,
+				&file.Fullpath,
+				&file.Path,
+				&file.Filestem,
+				&file.Ext,
+				&file.ConvertTo,
+				&file.Status,
+				&file.ErrorCode,
+				&file.CreatedAt,
+				&file.UpdatedAt,
+				&file.QualityResults,
+				&file.SourceHash,
+				&file.DedupOrigin,
+				&file.ApiKeyId,
+				&file.Client,
+				&file.SourceSize,
+				&file.TraceParent,
+				&file.ConfigDigests,
+			)
+			if err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("%s: %w", query.Name, err)
+			}
+			files = append(files, &file)
+		}
+		if err = rows.Err(); err != nil {
+			return nil, fmt.Errorf("%s: %w", query.Name, err)
+		}
+	}
+
+	return files, nil
+}
+
+// Returns the given paths that are already in the queue
+func (r *repo) FindExistingFullpaths(ctx context.Context, fullpaths []string) ([]string, error) {
+	builder := r.sq.Select(fullpathColumn).From(tablename).Where(sq.Eq{fullpathColumn: fullpaths})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	query := db.Query{
+		Name:     "repository.conversion_queue.FindExistingFullpaths",
+		QueryRaw: sql,
+	}
+
+	rows, err := r.db.DB().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", query.Name, err)
+	}
+
+	existing, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", query.Name, err)
+	}
+
+	return existing, nil
+}
+
 func (r *repo) FindOldestQueued(ctx context.Context) (*model.Conversion, error) {
 	builder := r.sq.
 		Select("*").
@@ -150,6 +372,14 @@ func (r *repo) FindOldestQueued(ctx context.Context) (*model.Conversion, error)
 		&file.ErrorCode,
 		&file.CreatedAt,
 		&file.UpdatedAt,
+		&file.QualityResults,
+		&file.SourceHash,
+		&file.DedupOrigin,
+		&file.ApiKeyId,
+		&file.Client,
+		&file.SourceSize,
+		&file.TraceParent,
+		&file.ConfigDigests,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, fmt.Errorf("%s: %w", query.Name, db.ErrNotFound)
@@ -209,3 +439,490 @@ func (r *repo) MarkAsCanceled(ctx context.Context, fullpath string, code uint32)
 	}
 	return err
 }
+
+func (r *repo) SaveQualityResults(ctx context.Context, fullpath string, results []model.QualityResult) error {
+	builder := r.sq.
+		Update(tablename).
+		Set(qualityResultsColumn, results).
+		Set(updatedAtColumn, time.Now()).
+		Where(sq.Eq{fullpathColumn: fullpath})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	query := db.Query{
+		Name:     "repository.conversion_queue.SaveQualityResults",
+		QueryRaw: sql,
+	}
+
+	_, err = r.db.DB().Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", query.Name, err)
+	}
+	return err
+}
+
+func (r *repo) SaveConfigDigests(ctx context.Context, fullpath string, digests map[string]string) error {
+	builder := r.sq.
+		Update(tablename).
+		Set(configDigestsColumn, digests).
+		Set(updatedAtColumn, time.Now()).
+		Where(sq.Eq{fullpathColumn: fullpath})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	query := db.Query{
+		Name:     "repository.conversion_queue.SaveConfigDigests",
+		QueryRaw: sql,
+	}
+
+	_, err = r.db.DB().Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", query.Name, err)
+	}
+	return err
+}
+
+func (r *repo) SaveSourceHash(ctx context.Context, fullpath string, hash string) error {
+	builder := r.sq.
+		Update(tablename).
+		Set(sourceHashColumn, hash).
+		Set(updatedAtColumn, time.Now()).
+		Where(sq.Eq{fullpathColumn: fullpath})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	query := db.Query{
+		Name:     "repository.conversion_queue.SaveSourceHash",
+		QueryRaw: sql,
+	}
+
+	_, err = r.db.DB().Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", query.Name, err)
+	}
+	return err
+}
+
+func (r *repo) SaveDedupOrigin(ctx context.Context, fullpath string, origin string) error {
+	builder := r.sq.
+		Update(tablename).
+		Set(dedupOriginColumn, origin).
+		Set(updatedAtColumn, time.Now()).
+		Where(sq.Eq{fullpathColumn: fullpath})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	query := db.Query{
+		Name:     "repository.conversion_queue.SaveDedupOrigin",
+		QueryRaw: sql,
+	}
+
+	_, err = r.db.DB().Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", query.Name, err)
+	}
+	return err
+}
+
+// Puts a processed conversion back to the queue, e.g. when its source file has changed
+func (r *repo) Requeue(ctx context.Context, fullpath string) error {
+	builder := r.sq.
+		Update(tablename).
+		Set(statusColumn, model.ConversionStatusPending).
+		Set(errorCodeColumn, 0).
+		Set(updatedAtColumn, time.Now()).
+		Where(sq.Eq{fullpathColumn: fullpath})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	query := db.Query{
+		Name:     "repository.conversion_queue.Requeue",
+		QueryRaw: sql,
+	}
+
+	_, err = r.db.DB().Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", query.Name, err)
+	}
+	return err
+}
+
+// Enqueues the canceled conversions again, returns the number of requeued conversions
+func (r *repo) RequeueCanceled(ctx context.Context) (int64, error) {
+	builder := r.sq.
+		Update(tablename).
+		Set(statusColumn, model.ConversionStatusPending).
+		Set(errorCodeColumn, 0).
+		Set(updatedAtColumn, time.Now()).
+		Where(sq.Eq{statusColumn: model.ConversionStatusCanceled})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return -1, err
+	}
+
+	query := db.Query{
+		Name:     "repository.conversion_queue.RequeueCanceled",
+		QueryRaw: sql,
+	}
+
+	tag, err := r.db.DB().Exec(ctx, query, args...)
+	if err != nil {
+		return -1, fmt.Errorf("%s: %w", query.Name, err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// Returns the number and the total source size of the pending conversions of the client
+func (r *repo) CountPendingByClient(ctx context.Context, client string) (*model.QueueUsage, error) {
+	builder := r.sq.
+		Select("COUNT(*)", fmt.Sprintf("COALESCE(SUM(%s), 0)", sourceSizeColumn)).
+		From(tablename).
+		Where(sq.Eq{
+			clientColumn: client,
+			statusColumn: model.ConversionStatusPending,
+		})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	query := db.Query{
+		Name:     "repository.conversion_queue.CountPendingByClient",
+		QueryRaw: sql,
+	}
+
+	var usage model.QueueUsage
+	err = r.db.DB().QueryRow(ctx, query, args...).Scan(&usage.Jobs, &usage.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", query.Name, err)
+	}
+
+	return &usage, nil
+}
+
+// Returns the number of conversions of the files under the directory
+func (r *repo) CountByPrefix(ctx context.Context, dir string) (int64, error) {
+	builder := r.sq.
+		Select("COUNT(*)").
+		From(tablename).
+		Where(sq.Like{fullpathColumn: db.DirPattern(dir)})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return -1, err
+	}
+
+	query := db.Query{
+		Name:     "repository.conversion_queue.CountByPrefix",
+		QueryRaw: sql,
+	}
+
+	var count int64
+	err = r.db.DB().QueryRow(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return -1, fmt.Errorf("%s: %w", query.Name, err)
+	}
+
+	return count, nil
+}
+
+func (r *repo) Delete(ctx context.Context, fullpath string) error {
+	builder := r.sq.
+		Delete(tablename).
+		Where(sq.Eq{fullpathColumn: fullpath})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	query := db.Query{
+		Name:     "repository.conversion_queue.Delete",
+		QueryRaw: sql,
+	}
+
+	_, err = r.db.DB().Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", query.Name, err)
+	}
+	return err
+}
+
+// Returns up to limit conversions with ids greater than the given one ordered by id,
+// so the whole queue can be walked in pages without holding a cursor
+func (r *repo) FindAfterId(ctx context.Context, id int64, limit uint64) ([]*model.Conversion, error) {
+	builder := r.sq.
+		Select("*").
+		From(tablename).
+		Where(sq.Gt{idColumn: id}).
+		OrderBy(fmt.Sprintf("%s ASC", idColumn)).
+		Limit(limit)
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	query := db.Query{
+		Name:     "repository.conversion_queue.FindAfterId",
+		QueryRaw: sql,
+	}
+
+	rows, err := r.db.DB().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", query.Name, err)
+	}
+	defer rows.Close()
+
+	var files []*model.Conversion
+	for rows.Next() {
+		var file model.Conversion
+		err = rows.Scan(
+			&file.Id,
+			&file.Fullpath,
+			&file.Path,
+			&file.Filestem,
+			&file.Ext,
+			&file.ConvertTo,
+			&file.Status,
+			&file.ErrorCode,
+			&file.CreatedAt,
+			&file.UpdatedAt,
+			&file.QualityResults,
+			&file.SourceHash,
+			&file.DedupOrigin,
+			&file.ApiKeyId,
+			&file.Client,
+			&file.SourceSize,
+			&file.TraceParent,
+			&file.ConfigDigests,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", query.Name, err)
+		}
+		files = append(files, &file)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", query.Name, err)
+	}
+
+	return files, nil
+}
+
+// Returns the conversions with any of the given statuses in the order they were enqueued
+func (r *repo) FindByStatus(ctx context.Context, statuses []int, limit uint64) ([]*model.Conversion, error) {
+	builder := r.sq.
+		Select("*").
+		From(tablename).
+		Where(sq.Eq{statusColumn: statuses}).
+		OrderBy(fmt.Sprintf("%s ASC", idColumn)).
+		Limit(limit)
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	query := db.Query{
+		Name:     "repository.conversion_queue.FindByStatus",
+		QueryRaw: sql,
+	}
+
+	rows, err := r.db.DB().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", query.Name, err)
+	}
+	defer rows.Close()
+
+	var files []*model.Conversion
+	for rows.Next() {
+		var file model.Conversion
+		err = rows.Scan(
+			&file.Id,
+			&file.Fullpath,
+			&file.Path,
+			&file.Filestem,
+			&file.Ext,
+			&file.ConvertTo,
+			&file.Status,
+			&file.ErrorCode,
+			&file.CreatedAt,
+			&file.UpdatedAt,
+			&file.QualityResults,
+			&file.SourceHash,
+			&file.DedupOrigin,
+			&file.ApiKeyId,
+			&file.Client,
+			&file.SourceSize,
+			&file.TraceParent,
+			&file.ConfigDigests,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", query.Name, err)
+		}
+		files = append(files, &file)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", query.Name, err)
+	}
+
+	return files, nil
+}
+
+// Returns the number of pending conversions
+func (r *repo) CountPending(ctx context.Context) (int64, error) {
+	builder := r.sq.
+		Select("COUNT(*)").
+		From(tablename).
+		Where(sq.Eq{statusColumn: model.ConversionStatusPending})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return -1, err
+	}
+
+	query := db.Query{
+		Name:     "repository.conversion_queue.CountPending",
+		QueryRaw: sql,
+	}
+
+	var count int64
+	err = r.db.DB().QueryRow(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return -1, fmt.Errorf("%s: %w", query.Name, err)
+	}
+
+	return count, nil
+}
+
+// Returns the number of processed conversions last updated before the given time
+func (r *repo) CountDoneBefore(ctx context.Context, before time.Time) (int64, error) {
+	builder := r.sq.
+		Select("COUNT(*)").
+		From(tablename).
+		Where(sq.Eq{statusColumn: model.ConversionStatusDone}).
+		Where(sq.Lt{updatedAtColumn: before})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return -1, err
+	}
+
+	query := db.Query{
+		Name:     "repository.conversion_queue.CountDoneBefore",
+		QueryRaw: sql,
+	}
+
+	var count int64
+	err = r.db.DB().QueryRow(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return -1, fmt.Errorf("%s: %w", query.Name, err)
+	}
+
+	return count, nil
+}
+
+// Removes the processed conversions last updated before the given time.
+// Returns the number of removed rows.
+func (r *repo) DeleteDoneBefore(ctx context.Context, before time.Time) (int64, error) {
+	builder := r.sq.
+		Delete(tablename).
+		Where(sq.Eq{statusColumn: model.ConversionStatusDone}).
+		Where(sq.Lt{updatedAtColumn: before})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return -1, err
+	}
+
+	query := db.Query{
+		Name:     "repository.conversion_queue.DeleteDoneBefore",
+		QueryRaw: sql,
+	}
+
+	tag, err := r.db.DB().Exec(ctx, query, args...)
+	if err != nil {
+		return -1, fmt.Errorf("%s: %w", query.Name, err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// Returns the done conversions of the sources with the given content ordered by id
+func (r *repo) FindDoneBySourceHash(ctx context.Context, hash string) ([]*model.Conversion, error) {
+	builder := r.sq.
+		Select("*").
+		From(tablename).
+		Where(sq.Eq{
+			sourceHashColumn: hash,
+			statusColumn:     model.ConversionStatusDone,
+		}).
+		OrderBy(fmt.Sprintf("%s ASC", idColumn))
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	query := db.Query{
+		Name:     "repository.conversion_queue.FindDoneBySourceHash",
+		QueryRaw: sql,
+	}
+
+	rows, err := r.db.DB().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", query.Name, err)
+	}
+	defer rows.Close()
+
+	var files []*model.Conversion
+	for rows.Next() {
+		var file model.Conversion
+		err = rows.Scan(
+			&file.Id,
+			&file.Fullpath,
+			&file.Path,
+			&file.Filestem,
+			&file.Ext,
+			&file.ConvertTo,
+			&file.Status,
+			&file.ErrorCode,
+			&file.CreatedAt,
+			&file.UpdatedAt,
+			&file.QualityResults,
+			&file.SourceHash,
+			&file.DedupOrigin,
+			&file.ApiKeyId,
+			&file.Client,
+			&file.SourceSize,
+			&file.TraceParent,
+			&file.ConfigDigests,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", query.Name, err)
+		}
+		files = append(files, &file)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", query.Name, err)
+	}
+
+	return files, nil
+}
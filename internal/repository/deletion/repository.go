@@ -22,6 +22,12 @@ const (
 	errorCodeColumn = "error_code"
 	createdAtColumn = "created_at"
 	updatedAtColumn = "updated_at"
+
+	deleteSourceColumn = "delete_source"
+	deleteRecordColumn = "delete_record"
+	apiKeyIdColumn     = "api_key_id"
+
+	conversionTablename = "conversion_queue"
 )
 
 type repo struct {
@@ -41,11 +47,17 @@ func (r *repo) Create(ctx context.Context, file *model.DeletionInfo) (int64, err
 	builder := r.sq.Insert(tablename).
 		Columns(
 			fullpathColumn,
+			deleteSourceColumn,
+			deleteRecordColumn,
+			apiKeyIdColumn,
 			createdAtColumn,
 			updatedAtColumn,
 		).
 		Values(
 			file.Fullpath,
+			file.DeleteSource,
+			file.DeleteRecord,
+			file.ApiKeyId,
 			ts,
 			ts,
 		).
@@ -70,6 +82,50 @@ func (r *repo) Create(ctx context.Context, file *model.DeletionInfo) (int64, err
 	return id, nil
 }
 
+// Enqueues the deletion of every converted file under the directory, files already in the queue are skipped.
+// Returns the number of enqueued files.
+func (r *repo) CreateByPrefix(ctx context.Context, dir string, file *model.DeletionInfo) (int64, error) {
+	ts := time.Now()
+	selectBuilder := r.sq.
+		Select(fullpathColumn).
+		Column("?", file.DeleteSource).
+		Column("?", file.DeleteRecord).
+		Column("?", file.ApiKeyId).
+		Column("?", ts).
+		Column("?", ts).
+		From(conversionTablename).
+		Where(sq.Like{fullpathColumn: db.DirPattern(dir)})
+
+	builder := r.sq.Insert(tablename).
+		Columns(
+			fullpathColumn,
+			deleteSourceColumn,
+			deleteRecordColumn,
+			apiKeyIdColumn,
+			createdAtColumn,
+			updatedAtColumn,
+		).
+		Select(selectBuilder).
+		Suffix(fmt.Sprintf("ON CONFLICT (%s) DO NOTHING", fullpathColumn))
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return -1, err
+	}
+
+	query := db.Query{
+		Name:     "repository.deletion_queue.CreateByPrefix",
+		QueryRaw: sql,
+	}
+
+	tag, err := r.db.DB().Exec(ctx, query, args...)
+	if err != nil {
+		return -1, fmt.Errorf("%s: %w", query.Name, err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
 func (r *repo) FindByFullpath(ctx context.Context, fullpath string) (*model.Deletion, error) {
 	builder := r.sq.
 		Select("*").
@@ -95,6 +151,9 @@ func (r *repo) FindByFullpath(ctx context.Context, fullpath string) (*model.Dele
 		&file.ErrorCode,
 		&file.CreatedAt,
 		&file.UpdatedAt,
+		&file.DeleteSource,
+		&file.DeleteRecord,
+		&file.ApiKeyId,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, fmt.Errorf("%s: %w", query.Name, db.ErrNotFound)
@@ -134,6 +193,9 @@ func (r *repo) FindOldestQueued(ctx context.Context) (*model.Deletion, error) {
 		&file.ErrorCode,
 		&file.CreatedAt,
 		&file.UpdatedAt,
+		&file.DeleteSource,
+		&file.DeleteRecord,
+		&file.ApiKeyId,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, fmt.Errorf("%s: %w", query.Name, db.ErrNotFound)
@@ -193,3 +255,104 @@ func (r *repo) MarkAsCanceled(ctx context.Context, fullpath string, code uint32)
 	}
 	return err
 }
+
+func (r *repo) Delete(ctx context.Context, fullpath string) error {
+	builder := r.sq.
+		Delete(tablename).
+		Where(sq.Eq{fullpathColumn: fullpath})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	query := db.Query{
+		Name:     "repository.deletion_queue.Delete",
+		QueryRaw: sql,
+	}
+
+	_, err = r.db.DB().Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", query.Name, err)
+	}
+	return err
+}
+
+// Returns the number of pending deletions
+func (r *repo) CountPending(ctx context.Context) (int64, error) {
+	builder := r.sq.
+		Select("COUNT(*)").
+		From(tablename).
+		Where(sq.Eq{statusColumn: model.DeletionStatusPending})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return -1, err
+	}
+
+	query := db.Query{
+		Name:     "repository.deletion_queue.CountPending",
+		QueryRaw: sql,
+	}
+
+	var count int64
+	err = r.db.DB().QueryRow(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return -1, fmt.Errorf("%s: %w", query.Name, err)
+	}
+
+	return count, nil
+}
+
+// Returns the number of processed deletions last updated before the given time
+func (r *repo) CountDoneBefore(ctx context.Context, before time.Time) (int64, error) {
+	builder := r.sq.
+		Select("COUNT(*)").
+		From(tablename).
+		Where(sq.Eq{statusColumn: model.DeletionStatusDone}).
+		Where(sq.Lt{updatedAtColumn: before})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return -1, err
+	}
+
+	query := db.Query{
+		Name:     "repository.deletion_queue.CountDoneBefore",
+		QueryRaw: sql,
+	}
+
+	var count int64
+	err = r.db.DB().QueryRow(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return -1, fmt.Errorf("%s: %w", query.Name, err)
+	}
+
+	return count, nil
+}
+
+// Removes the processed deletions last updated before the given time.
+// Returns the number of removed rows.
+func (r *repo) DeleteDoneBefore(ctx context.Context, before time.Time) (int64, error) {
+	builder := r.sq.
+		Delete(tablename).
+		Where(sq.Eq{statusColumn: model.DeletionStatusDone}).
+		Where(sq.Lt{updatedAtColumn: before})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return -1, err
+	}
+
+	query := db.Query{
+		Name:     "repository.deletion_queue.DeleteDoneBefore",
+		QueryRaw: sql,
+	}
+
+	tag, err := r.db.DB().Exec(ctx, query, args...)
+	if err != nil {
+		return -1, fmt.Errorf("%s: %w", query.Name, err)
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,259 @@
+// Code generated by mockery v2.50.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/chistyakoviv/converter/internal/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockApiKeyRepository is an autogenerated mock type for the ApiKeyRepository type
+type MockApiKeyRepository struct {
+	mock.Mock
+}
+
+type MockApiKeyRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockApiKeyRepository) EXPECT() *MockApiKeyRepository_Expecter {
+	return &MockApiKeyRepository_Expecter{mock: &_m.Mock}
+}
+
+// Create provides a mock function with given fields: ctx, key, hash
+func (_m *MockApiKeyRepository) Create(ctx context.Context, key *model.ApiKey, hash string) (int64, error) {
+	ret := _m.Called(ctx, key, hash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Create")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.ApiKey, string) (int64, error)); ok {
+		return rf(ctx, key, hash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *model.ApiKey, string) int64); ok {
+		r0 = rf(ctx, key, hash)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *model.ApiKey, string) error); ok {
+		r1 = rf(ctx, key, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockApiKeyRepository_Create_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Create'
+type MockApiKeyRepository_Create_Call struct {
+	*mock.Call
+}
+
+// Create is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key *model.ApiKey
+//   - hash string
+func (_e *MockApiKeyRepository_Expecter) Create(ctx interface{}, key interface{}, hash interface{}) *MockApiKeyRepository_Create_Call {
+	return &MockApiKeyRepository_Create_Call{Call: _e.mock.On("Create", ctx, key, hash)}
+}
+
+func (_c *MockApiKeyRepository_Create_Call) Run(run func(ctx context.Context, key *model.ApiKey, hash string)) *MockApiKeyRepository_Create_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.ApiKey), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockApiKeyRepository_Create_Call) Return(_a0 int64, _a1 error) *MockApiKeyRepository_Create_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockApiKeyRepository_Create_Call) RunAndReturn(run func(context.Context, *model.ApiKey, string) (int64, error)) *MockApiKeyRepository_Create_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindAll provides a mock function with given fields: ctx
+func (_m *MockApiKeyRepository) FindAll(ctx context.Context) ([]*model.ApiKey, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindAll")
+	}
+
+	var r0 []*model.ApiKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*model.ApiKey, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*model.ApiKey); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.ApiKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockApiKeyRepository_FindAll_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindAll'
+type MockApiKeyRepository_FindAll_Call struct {
+	*mock.Call
+}
+
+// FindAll is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockApiKeyRepository_Expecter) FindAll(ctx interface{}) *MockApiKeyRepository_FindAll_Call {
+	return &MockApiKeyRepository_FindAll_Call{Call: _e.mock.On("FindAll", ctx)}
+}
+
+func (_c *MockApiKeyRepository_FindAll_Call) Run(run func(ctx context.Context)) *MockApiKeyRepository_FindAll_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockApiKeyRepository_FindAll_Call) Return(_a0 []*model.ApiKey, _a1 error) *MockApiKeyRepository_FindAll_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockApiKeyRepository_FindAll_Call) RunAndReturn(run func(context.Context) ([]*model.ApiKey, error)) *MockApiKeyRepository_FindAll_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindByHash provides a mock function with given fields: ctx, hash
+func (_m *MockApiKeyRepository) FindByHash(ctx context.Context, hash string) (*model.ApiKey, error) {
+	ret := _m.Called(ctx, hash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindByHash")
+	}
+
+	var r0 *model.ApiKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.ApiKey, error)); ok {
+		return rf(ctx, hash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.ApiKey); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.ApiKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockApiKeyRepository_FindByHash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindByHash'
+type MockApiKeyRepository_FindByHash_Call struct {
+	*mock.Call
+}
+
+// FindByHash is a helper method to define mock.On call
+//   - ctx context.Context
+//   - hash string
+func (_e *MockApiKeyRepository_Expecter) FindByHash(ctx interface{}, hash interface{}) *MockApiKeyRepository_FindByHash_Call {
+	return &MockApiKeyRepository_FindByHash_Call{Call: _e.mock.On("FindByHash", ctx, hash)}
+}
+
+func (_c *MockApiKeyRepository_FindByHash_Call) Run(run func(ctx context.Context, hash string)) *MockApiKeyRepository_FindByHash_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockApiKeyRepository_FindByHash_Call) Return(_a0 *model.ApiKey, _a1 error) *MockApiKeyRepository_FindByHash_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockApiKeyRepository_FindByHash_Call) RunAndReturn(run func(context.Context, string) (*model.ApiKey, error)) *MockApiKeyRepository_FindByHash_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Revoke provides a mock function with given fields: ctx, id
+func (_m *MockApiKeyRepository) Revoke(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Revoke")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockApiKeyRepository_Revoke_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Revoke'
+type MockApiKeyRepository_Revoke_Call struct {
+	*mock.Call
+}
+
+// Revoke is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int64
+func (_e *MockApiKeyRepository_Expecter) Revoke(ctx interface{}, id interface{}) *MockApiKeyRepository_Revoke_Call {
+	return &MockApiKeyRepository_Revoke_Call{Call: _e.mock.On("Revoke", ctx, id)}
+}
+
+func (_c *MockApiKeyRepository_Revoke_Call) Run(run func(ctx context.Context, id int64)) *MockApiKeyRepository_Revoke_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *MockApiKeyRepository_Revoke_Call) Return(_a0 error) *MockApiKeyRepository_Revoke_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockApiKeyRepository_Revoke_Call) RunAndReturn(run func(context.Context, int64) error) *MockApiKeyRepository_Revoke_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockApiKeyRepository creates a new instance of MockApiKeyRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockApiKeyRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockApiKeyRepository {
+	mock := &MockApiKeyRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
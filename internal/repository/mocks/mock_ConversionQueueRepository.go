@@ -7,6 +7,8 @@ import (
 
 	model "github.com/chistyakoviv/converter/internal/model"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // MockConversionQueueRepository is an autogenerated mock type for the ConversionQueueRepository type
@@ -22,27 +24,773 @@ func (_m *MockConversionQueueRepository) EXPECT() *MockConversionQueueRepository
 	return &MockConversionQueueRepository_Expecter{mock: &_m.Mock}
 }
 
-// Create provides a mock function with given fields: ctx, file
-func (_m *MockConversionQueueRepository) Create(ctx context.Context, file *model.ConversionInfo) (int64, error) {
-	ret := _m.Called(ctx, file)
+// CountByPrefix provides a mock function with given fields: ctx, dir
+func (_m *MockConversionQueueRepository) CountByPrefix(ctx context.Context, dir string) (int64, error) {
+	ret := _m.Called(ctx, dir)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountByPrefix")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int64, error)); ok {
+		return rf(ctx, dir)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, dir)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, dir)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueRepository_CountByPrefix_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountByPrefix'
+type MockConversionQueueRepository_CountByPrefix_Call struct {
+	*mock.Call
+}
+
+// CountByPrefix is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dir string
+func (_e *MockConversionQueueRepository_Expecter) CountByPrefix(ctx interface{}, dir interface{}) *MockConversionQueueRepository_CountByPrefix_Call {
+	return &MockConversionQueueRepository_CountByPrefix_Call{Call: _e.mock.On("CountByPrefix", ctx, dir)}
+}
+
+func (_c *MockConversionQueueRepository_CountByPrefix_Call) Run(run func(ctx context.Context, dir string)) *MockConversionQueueRepository_CountByPrefix_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_CountByPrefix_Call) Return(_a0 int64, _a1 error) *MockConversionQueueRepository_CountByPrefix_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_CountByPrefix_Call) RunAndReturn(run func(context.Context, string) (int64, error)) *MockConversionQueueRepository_CountByPrefix_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CountDoneBefore provides a mock function with given fields: ctx, before
+func (_m *MockConversionQueueRepository) CountDoneBefore(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountDoneBefore")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueRepository_CountDoneBefore_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountDoneBefore'
+type MockConversionQueueRepository_CountDoneBefore_Call struct {
+	*mock.Call
+}
+
+// CountDoneBefore is a helper method to define mock.On call
+//   - ctx context.Context
+//   - before time.Time
+func (_e *MockConversionQueueRepository_Expecter) CountDoneBefore(ctx interface{}, before interface{}) *MockConversionQueueRepository_CountDoneBefore_Call {
+	return &MockConversionQueueRepository_CountDoneBefore_Call{Call: _e.mock.On("CountDoneBefore", ctx, before)}
+}
+
+func (_c *MockConversionQueueRepository_CountDoneBefore_Call) Run(run func(ctx context.Context, before time.Time)) *MockConversionQueueRepository_CountDoneBefore_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_CountDoneBefore_Call) Return(_a0 int64, _a1 error) *MockConversionQueueRepository_CountDoneBefore_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_CountDoneBefore_Call) RunAndReturn(run func(context.Context, time.Time) (int64, error)) *MockConversionQueueRepository_CountDoneBefore_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CountPending provides a mock function with given fields: ctx
+func (_m *MockConversionQueueRepository) CountPending(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountPending")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueRepository_CountPending_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountPending'
+type MockConversionQueueRepository_CountPending_Call struct {
+	*mock.Call
+}
+
+// CountPending is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockConversionQueueRepository_Expecter) CountPending(ctx interface{}) *MockConversionQueueRepository_CountPending_Call {
+	return &MockConversionQueueRepository_CountPending_Call{Call: _e.mock.On("CountPending", ctx)}
+}
+
+func (_c *MockConversionQueueRepository_CountPending_Call) Run(run func(ctx context.Context)) *MockConversionQueueRepository_CountPending_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_CountPending_Call) Return(_a0 int64, _a1 error) *MockConversionQueueRepository_CountPending_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_CountPending_Call) RunAndReturn(run func(context.Context) (int64, error)) *MockConversionQueueRepository_CountPending_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CountPendingByClient provides a mock function with given fields: ctx, client
+func (_m *MockConversionQueueRepository) CountPendingByClient(ctx context.Context, client string) (*model.QueueUsage, error) {
+	ret := _m.Called(ctx, client)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountPendingByClient")
+	}
+
+	var r0 *model.QueueUsage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.QueueUsage, error)); ok {
+		return rf(ctx, client)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.QueueUsage); ok {
+		r0 = rf(ctx, client)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.QueueUsage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, client)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueRepository_CountPendingByClient_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountPendingByClient'
+type MockConversionQueueRepository_CountPendingByClient_Call struct {
+	*mock.Call
+}
+
+// CountPendingByClient is a helper method to define mock.On call
+//   - ctx context.Context
+//   - client string
+func (_e *MockConversionQueueRepository_Expecter) CountPendingByClient(ctx interface{}, client interface{}) *MockConversionQueueRepository_CountPendingByClient_Call {
+	return &MockConversionQueueRepository_CountPendingByClient_Call{Call: _e.mock.On("CountPendingByClient", ctx, client)}
+}
+
+func (_c *MockConversionQueueRepository_CountPendingByClient_Call) Run(run func(ctx context.Context, client string)) *MockConversionQueueRepository_CountPendingByClient_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_CountPendingByClient_Call) Return(_a0 *model.QueueUsage, _a1 error) *MockConversionQueueRepository_CountPendingByClient_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_CountPendingByClient_Call) RunAndReturn(run func(context.Context, string) (*model.QueueUsage, error)) *MockConversionQueueRepository_CountPendingByClient_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Create provides a mock function with given fields: ctx, file
+func (_m *MockConversionQueueRepository) Create(ctx context.Context, file *model.ConversionInfo) (int64, error) {
+	ret := _m.Called(ctx, file)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Create")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.ConversionInfo) (int64, error)); ok {
+		return rf(ctx, file)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *model.ConversionInfo) int64); ok {
+		r0 = rf(ctx, file)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *model.ConversionInfo) error); ok {
+		r1 = rf(ctx, file)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueRepository_Create_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Create'
+type MockConversionQueueRepository_Create_Call struct {
+	*mock.Call
+}
+
+// Create is a helper method to define mock.On call
+//   - ctx context.Context
+//   - file *model.ConversionInfo
+func (_e *MockConversionQueueRepository_Expecter) Create(ctx interface{}, file interface{}) *MockConversionQueueRepository_Create_Call {
+	return &MockConversionQueueRepository_Create_Call{Call: _e.mock.On("Create", ctx, file)}
+}
+
+func (_c *MockConversionQueueRepository_Create_Call) Run(run func(ctx context.Context, file *model.ConversionInfo)) *MockConversionQueueRepository_Create_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.ConversionInfo))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_Create_Call) Return(_a0 int64, _a1 error) *MockConversionQueueRepository_Create_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_Create_Call) RunAndReturn(run func(context.Context, *model.ConversionInfo) (int64, error)) *MockConversionQueueRepository_Create_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateBatch provides a mock function with given fields: ctx, files
+func (_m *MockConversionQueueRepository) CreateBatch(ctx context.Context, files []*model.ConversionInfo) (int64, error) {
+	ret := _m.Called(ctx, files)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateBatch")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*model.ConversionInfo) (int64, error)); ok {
+		return rf(ctx, files)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []*model.ConversionInfo) int64); ok {
+		r0 = rf(ctx, files)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []*model.ConversionInfo) error); ok {
+		r1 = rf(ctx, files)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueRepository_CreateBatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateBatch'
+type MockConversionQueueRepository_CreateBatch_Call struct {
+	*mock.Call
+}
+
+// CreateBatch is a helper method to define mock.On call
+//   - ctx context.Context
+//   - files []*model.ConversionInfo
+func (_e *MockConversionQueueRepository_Expecter) CreateBatch(ctx interface{}, files interface{}) *MockConversionQueueRepository_CreateBatch_Call {
+	return &MockConversionQueueRepository_CreateBatch_Call{Call: _e.mock.On("CreateBatch", ctx, files)}
+}
+
+func (_c *MockConversionQueueRepository_CreateBatch_Call) Run(run func(ctx context.Context, files []*model.ConversionInfo)) *MockConversionQueueRepository_CreateBatch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]*model.ConversionInfo))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_CreateBatch_Call) Return(_a0 int64, _a1 error) *MockConversionQueueRepository_CreateBatch_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_CreateBatch_Call) RunAndReturn(run func(context.Context, []*model.ConversionInfo) (int64, error)) *MockConversionQueueRepository_CreateBatch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Delete provides a mock function with given fields: ctx, fullpath
+func (_m *MockConversionQueueRepository) Delete(ctx context.Context, fullpath string) error {
+	ret := _m.Called(ctx, fullpath)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, fullpath)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConversionQueueRepository_Delete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Delete'
+type MockConversionQueueRepository_Delete_Call struct {
+	*mock.Call
+}
+
+// Delete is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fullpath string
+func (_e *MockConversionQueueRepository_Expecter) Delete(ctx interface{}, fullpath interface{}) *MockConversionQueueRepository_Delete_Call {
+	return &MockConversionQueueRepository_Delete_Call{Call: _e.mock.On("Delete", ctx, fullpath)}
+}
+
+func (_c *MockConversionQueueRepository_Delete_Call) Run(run func(ctx context.Context, fullpath string)) *MockConversionQueueRepository_Delete_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_Delete_Call) Return(_a0 error) *MockConversionQueueRepository_Delete_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_Delete_Call) RunAndReturn(run func(context.Context, string) error) *MockConversionQueueRepository_Delete_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteDoneBefore provides a mock function with given fields: ctx, before
+func (_m *MockConversionQueueRepository) DeleteDoneBefore(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteDoneBefore")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueRepository_DeleteDoneBefore_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteDoneBefore'
+type MockConversionQueueRepository_DeleteDoneBefore_Call struct {
+	*mock.Call
+}
+
+// DeleteDoneBefore is a helper method to define mock.On call
+//   - ctx context.Context
+//   - before time.Time
+func (_e *MockConversionQueueRepository_Expecter) DeleteDoneBefore(ctx interface{}, before interface{}) *MockConversionQueueRepository_DeleteDoneBefore_Call {
+	return &MockConversionQueueRepository_DeleteDoneBefore_Call{Call: _e.mock.On("DeleteDoneBefore", ctx, before)}
+}
+
+func (_c *MockConversionQueueRepository_DeleteDoneBefore_Call) Run(run func(ctx context.Context, before time.Time)) *MockConversionQueueRepository_DeleteDoneBefore_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_DeleteDoneBefore_Call) Return(_a0 int64, _a1 error) *MockConversionQueueRepository_DeleteDoneBefore_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_DeleteDoneBefore_Call) RunAndReturn(run func(context.Context, time.Time) (int64, error)) *MockConversionQueueRepository_DeleteDoneBefore_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindAfterId provides a mock function with given fields: ctx, id, limit
+func (_m *MockConversionQueueRepository) FindAfterId(ctx context.Context, id int64, limit uint64) ([]*model.Conversion, error) {
+	ret := _m.Called(ctx, id, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindAfterId")
+	}
+
+	var r0 []*model.Conversion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, uint64) ([]*model.Conversion, error)); ok {
+		return rf(ctx, id, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, uint64) []*model.Conversion); ok {
+		r0 = rf(ctx, id, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Conversion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, uint64) error); ok {
+		r1 = rf(ctx, id, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueRepository_FindAfterId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindAfterId'
+type MockConversionQueueRepository_FindAfterId_Call struct {
+	*mock.Call
+}
+
+// FindAfterId is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int64
+//   - limit uint64
+func (_e *MockConversionQueueRepository_Expecter) FindAfterId(ctx interface{}, id interface{}, limit interface{}) *MockConversionQueueRepository_FindAfterId_Call {
+	return &MockConversionQueueRepository_FindAfterId_Call{Call: _e.mock.On("FindAfterId", ctx, id, limit)}
+}
+
+func (_c *MockConversionQueueRepository_FindAfterId_Call) Run(run func(ctx context.Context, id int64, limit uint64)) *MockConversionQueueRepository_FindAfterId_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64), args[2].(uint64))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_FindAfterId_Call) Return(_a0 []*model.Conversion, _a1 error) *MockConversionQueueRepository_FindAfterId_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_FindAfterId_Call) RunAndReturn(run func(context.Context, int64, uint64) ([]*model.Conversion, error)) *MockConversionQueueRepository_FindAfterId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindByFullpath provides a mock function with given fields: ctx, fullpath
+func (_m *MockConversionQueueRepository) FindByFullpath(ctx context.Context, fullpath string) (*model.Conversion, error) {
+	ret := _m.Called(ctx, fullpath)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindByFullpath")
+	}
+
+	var r0 *model.Conversion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.Conversion, error)); ok {
+		return rf(ctx, fullpath)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Conversion); ok {
+		r0 = rf(ctx, fullpath)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Conversion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, fullpath)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueRepository_FindByFullpath_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindByFullpath'
+type MockConversionQueueRepository_FindByFullpath_Call struct {
+	*mock.Call
+}
+
+// FindByFullpath is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fullpath string
+func (_e *MockConversionQueueRepository_Expecter) FindByFullpath(ctx interface{}, fullpath interface{}) *MockConversionQueueRepository_FindByFullpath_Call {
+	return &MockConversionQueueRepository_FindByFullpath_Call{Call: _e.mock.On("FindByFullpath", ctx, fullpath)}
+}
+
+func (_c *MockConversionQueueRepository_FindByFullpath_Call) Run(run func(ctx context.Context, fullpath string)) *MockConversionQueueRepository_FindByFullpath_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_FindByFullpath_Call) Return(_a0 *model.Conversion, _a1 error) *MockConversionQueueRepository_FindByFullpath_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_FindByFullpath_Call) RunAndReturn(run func(context.Context, string) (*model.Conversion, error)) *MockConversionQueueRepository_FindByFullpath_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindByFullpaths provides a mock function with given fields: ctx, fullpaths
+func (_m *MockConversionQueueRepository) FindByFullpaths(ctx context.Context, fullpaths []string) ([]*model.Conversion, error) {
+	ret := _m.Called(ctx, fullpaths)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindByFullpaths")
+	}
+
+	var r0 []*model.Conversion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) ([]*model.Conversion, error)); ok {
+		return rf(ctx, fullpaths)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []*model.Conversion); ok {
+		r0 = rf(ctx, fullpaths)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Conversion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, fullpaths)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueRepository_FindByFullpaths_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindByFullpaths'
+type MockConversionQueueRepository_FindByFullpaths_Call struct {
+	*mock.Call
+}
+
+// FindByFullpaths is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fullpaths []string
+func (_e *MockConversionQueueRepository_Expecter) FindByFullpaths(ctx interface{}, fullpaths interface{}) *MockConversionQueueRepository_FindByFullpaths_Call {
+	return &MockConversionQueueRepository_FindByFullpaths_Call{Call: _e.mock.On("FindByFullpaths", ctx, fullpaths)}
+}
+
+func (_c *MockConversionQueueRepository_FindByFullpaths_Call) Run(run func(ctx context.Context, fullpaths []string)) *MockConversionQueueRepository_FindByFullpaths_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]string))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_FindByFullpaths_Call) Return(_a0 []*model.Conversion, _a1 error) *MockConversionQueueRepository_FindByFullpaths_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_FindByFullpaths_Call) RunAndReturn(run func(context.Context, []string) ([]*model.Conversion, error)) *MockConversionQueueRepository_FindByFullpaths_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindById provides a mock function with given fields: ctx, id
+func (_m *MockConversionQueueRepository) FindById(ctx context.Context, id int64) (*model.Conversion, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindById")
+	}
+
+	var r0 *model.Conversion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*model.Conversion, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *model.Conversion); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Conversion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueRepository_FindById_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindById'
+type MockConversionQueueRepository_FindById_Call struct {
+	*mock.Call
+}
+
+// FindById is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int64
+func (_e *MockConversionQueueRepository_Expecter) FindById(ctx interface{}, id interface{}) *MockConversionQueueRepository_FindById_Call {
+	return &MockConversionQueueRepository_FindById_Call{Call: _e.mock.On("FindById", ctx, id)}
+}
+
+func (_c *MockConversionQueueRepository_FindById_Call) Run(run func(ctx context.Context, id int64)) *MockConversionQueueRepository_FindById_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_FindById_Call) Return(_a0 *model.Conversion, _a1 error) *MockConversionQueueRepository_FindById_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_FindById_Call) RunAndReturn(run func(context.Context, int64) (*model.Conversion, error)) *MockConversionQueueRepository_FindById_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindByStatus provides a mock function with given fields: ctx, statuses, limit
+func (_m *MockConversionQueueRepository) FindByStatus(ctx context.Context, statuses []int, limit uint64) ([]*model.Conversion, error) {
+	ret := _m.Called(ctx, statuses, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindByStatus")
+	}
+
+	var r0 []*model.Conversion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int, uint64) ([]*model.Conversion, error)); ok {
+		return rf(ctx, statuses, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int, uint64) []*model.Conversion); ok {
+		r0 = rf(ctx, statuses, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Conversion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int, uint64) error); ok {
+		r1 = rf(ctx, statuses, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueRepository_FindByStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindByStatus'
+type MockConversionQueueRepository_FindByStatus_Call struct {
+	*mock.Call
+}
+
+// FindByStatus is a helper method to define mock.On call
+//   - ctx context.Context
+//   - statuses []int
+//   - limit uint64
+func (_e *MockConversionQueueRepository_Expecter) FindByStatus(ctx interface{}, statuses interface{}, limit interface{}) *MockConversionQueueRepository_FindByStatus_Call {
+	return &MockConversionQueueRepository_FindByStatus_Call{Call: _e.mock.On("FindByStatus", ctx, statuses, limit)}
+}
+
+func (_c *MockConversionQueueRepository_FindByStatus_Call) Run(run func(ctx context.Context, statuses []int, limit uint64)) *MockConversionQueueRepository_FindByStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]int), args[2].(uint64))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_FindByStatus_Call) Return(_a0 []*model.Conversion, _a1 error) *MockConversionQueueRepository_FindByStatus_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_FindByStatus_Call) RunAndReturn(run func(context.Context, []int, uint64) ([]*model.Conversion, error)) *MockConversionQueueRepository_FindByStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindDoneBySourceHash provides a mock function with given fields: ctx, hash
+func (_m *MockConversionQueueRepository) FindDoneBySourceHash(ctx context.Context, hash string) ([]*model.Conversion, error) {
+	ret := _m.Called(ctx, hash)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Create")
+		panic("no return value specified for FindDoneBySourceHash")
 	}
 
-	var r0 int64
+	var r0 []*model.Conversion
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, *model.ConversionInfo) (int64, error)); ok {
-		return rf(ctx, file)
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]*model.Conversion, error)); ok {
+		return rf(ctx, hash)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *model.ConversionInfo) int64); ok {
-		r0 = rf(ctx, file)
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*model.Conversion); ok {
+		r0 = rf(ctx, hash)
 	} else {
-		r0 = ret.Get(0).(int64)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Conversion)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, *model.ConversionInfo) error); ok {
-		r1 = rf(ctx, file)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, hash)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -50,58 +798,58 @@ func (_m *MockConversionQueueRepository) Create(ctx context.Context, file *model
 	return r0, r1
 }
 
-// MockConversionQueueRepository_Create_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Create'
-type MockConversionQueueRepository_Create_Call struct {
+// MockConversionQueueRepository_FindDoneBySourceHash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindDoneBySourceHash'
+type MockConversionQueueRepository_FindDoneBySourceHash_Call struct {
 	*mock.Call
 }
 
-// Create is a helper method to define mock.On call
+// FindDoneBySourceHash is a helper method to define mock.On call
 //   - ctx context.Context
-//   - file *model.ConversionInfo
-func (_e *MockConversionQueueRepository_Expecter) Create(ctx interface{}, file interface{}) *MockConversionQueueRepository_Create_Call {
-	return &MockConversionQueueRepository_Create_Call{Call: _e.mock.On("Create", ctx, file)}
+//   - hash string
+func (_e *MockConversionQueueRepository_Expecter) FindDoneBySourceHash(ctx interface{}, hash interface{}) *MockConversionQueueRepository_FindDoneBySourceHash_Call {
+	return &MockConversionQueueRepository_FindDoneBySourceHash_Call{Call: _e.mock.On("FindDoneBySourceHash", ctx, hash)}
 }
 
-func (_c *MockConversionQueueRepository_Create_Call) Run(run func(ctx context.Context, file *model.ConversionInfo)) *MockConversionQueueRepository_Create_Call {
+func (_c *MockConversionQueueRepository_FindDoneBySourceHash_Call) Run(run func(ctx context.Context, hash string)) *MockConversionQueueRepository_FindDoneBySourceHash_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(*model.ConversionInfo))
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *MockConversionQueueRepository_Create_Call) Return(_a0 int64, _a1 error) *MockConversionQueueRepository_Create_Call {
+func (_c *MockConversionQueueRepository_FindDoneBySourceHash_Call) Return(_a0 []*model.Conversion, _a1 error) *MockConversionQueueRepository_FindDoneBySourceHash_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockConversionQueueRepository_Create_Call) RunAndReturn(run func(context.Context, *model.ConversionInfo) (int64, error)) *MockConversionQueueRepository_Create_Call {
+func (_c *MockConversionQueueRepository_FindDoneBySourceHash_Call) RunAndReturn(run func(context.Context, string) ([]*model.Conversion, error)) *MockConversionQueueRepository_FindDoneBySourceHash_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// FindByFullpath provides a mock function with given fields: ctx, fullpath
-func (_m *MockConversionQueueRepository) FindByFullpath(ctx context.Context, fullpath string) (*model.Conversion, error) {
-	ret := _m.Called(ctx, fullpath)
+// FindExistingFullpaths provides a mock function with given fields: ctx, fullpaths
+func (_m *MockConversionQueueRepository) FindExistingFullpaths(ctx context.Context, fullpaths []string) ([]string, error) {
+	ret := _m.Called(ctx, fullpaths)
 
 	if len(ret) == 0 {
-		panic("no return value specified for FindByFullpath")
+		panic("no return value specified for FindExistingFullpaths")
 	}
 
-	var r0 *model.Conversion
+	var r0 []string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.Conversion, error)); ok {
-		return rf(ctx, fullpath)
+	if rf, ok := ret.Get(0).(func(context.Context, []string) ([]string, error)); ok {
+		return rf(ctx, fullpaths)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Conversion); ok {
-		r0 = rf(ctx, fullpath)
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []string); ok {
+		r0 = rf(ctx, fullpaths)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*model.Conversion)
+			r0 = ret.Get(0).([]string)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, fullpath)
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, fullpaths)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -109,31 +857,31 @@ func (_m *MockConversionQueueRepository) FindByFullpath(ctx context.Context, ful
 	return r0, r1
 }
 
-// MockConversionQueueRepository_FindByFullpath_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindByFullpath'
-type MockConversionQueueRepository_FindByFullpath_Call struct {
+// MockConversionQueueRepository_FindExistingFullpaths_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindExistingFullpaths'
+type MockConversionQueueRepository_FindExistingFullpaths_Call struct {
 	*mock.Call
 }
 
-// FindByFullpath is a helper method to define mock.On call
+// FindExistingFullpaths is a helper method to define mock.On call
 //   - ctx context.Context
-//   - fullpath string
-func (_e *MockConversionQueueRepository_Expecter) FindByFullpath(ctx interface{}, fullpath interface{}) *MockConversionQueueRepository_FindByFullpath_Call {
-	return &MockConversionQueueRepository_FindByFullpath_Call{Call: _e.mock.On("FindByFullpath", ctx, fullpath)}
+//   - fullpaths []string
+func (_e *MockConversionQueueRepository_Expecter) FindExistingFullpaths(ctx interface{}, fullpaths interface{}) *MockConversionQueueRepository_FindExistingFullpaths_Call {
+	return &MockConversionQueueRepository_FindExistingFullpaths_Call{Call: _e.mock.On("FindExistingFullpaths", ctx, fullpaths)}
 }
 
-func (_c *MockConversionQueueRepository_FindByFullpath_Call) Run(run func(ctx context.Context, fullpath string)) *MockConversionQueueRepository_FindByFullpath_Call {
+func (_c *MockConversionQueueRepository_FindExistingFullpaths_Call) Run(run func(ctx context.Context, fullpaths []string)) *MockConversionQueueRepository_FindExistingFullpaths_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string))
+		run(args[0].(context.Context), args[1].([]string))
 	})
 	return _c
 }
 
-func (_c *MockConversionQueueRepository_FindByFullpath_Call) Return(_a0 *model.Conversion, _a1 error) *MockConversionQueueRepository_FindByFullpath_Call {
+func (_c *MockConversionQueueRepository_FindExistingFullpaths_Call) Return(_a0 []string, _a1 error) *MockConversionQueueRepository_FindExistingFullpaths_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockConversionQueueRepository_FindByFullpath_Call) RunAndReturn(run func(context.Context, string) (*model.Conversion, error)) *MockConversionQueueRepository_FindByFullpath_Call {
+func (_c *MockConversionQueueRepository_FindExistingFullpaths_Call) RunAndReturn(run func(context.Context, []string) ([]string, error)) *MockConversionQueueRepository_FindExistingFullpaths_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -291,6 +1039,301 @@ func (_c *MockConversionQueueRepository_MarkAsDone_Call) RunAndReturn(run func(c
 	return _c
 }
 
+// Requeue provides a mock function with given fields: ctx, fullpath
+func (_m *MockConversionQueueRepository) Requeue(ctx context.Context, fullpath string) error {
+	ret := _m.Called(ctx, fullpath)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Requeue")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, fullpath)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConversionQueueRepository_Requeue_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Requeue'
+type MockConversionQueueRepository_Requeue_Call struct {
+	*mock.Call
+}
+
+// Requeue is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fullpath string
+func (_e *MockConversionQueueRepository_Expecter) Requeue(ctx interface{}, fullpath interface{}) *MockConversionQueueRepository_Requeue_Call {
+	return &MockConversionQueueRepository_Requeue_Call{Call: _e.mock.On("Requeue", ctx, fullpath)}
+}
+
+func (_c *MockConversionQueueRepository_Requeue_Call) Run(run func(ctx context.Context, fullpath string)) *MockConversionQueueRepository_Requeue_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_Requeue_Call) Return(_a0 error) *MockConversionQueueRepository_Requeue_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_Requeue_Call) RunAndReturn(run func(context.Context, string) error) *MockConversionQueueRepository_Requeue_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RequeueCanceled provides a mock function with given fields: ctx
+func (_m *MockConversionQueueRepository) RequeueCanceled(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequeueCanceled")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueRepository_RequeueCanceled_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RequeueCanceled'
+type MockConversionQueueRepository_RequeueCanceled_Call struct {
+	*mock.Call
+}
+
+// RequeueCanceled is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockConversionQueueRepository_Expecter) RequeueCanceled(ctx interface{}) *MockConversionQueueRepository_RequeueCanceled_Call {
+	return &MockConversionQueueRepository_RequeueCanceled_Call{Call: _e.mock.On("RequeueCanceled", ctx)}
+}
+
+func (_c *MockConversionQueueRepository_RequeueCanceled_Call) Run(run func(ctx context.Context)) *MockConversionQueueRepository_RequeueCanceled_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_RequeueCanceled_Call) Return(_a0 int64, _a1 error) *MockConversionQueueRepository_RequeueCanceled_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_RequeueCanceled_Call) RunAndReturn(run func(context.Context) (int64, error)) *MockConversionQueueRepository_RequeueCanceled_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveConfigDigests provides a mock function with given fields: ctx, fullpath, digests
+func (_m *MockConversionQueueRepository) SaveConfigDigests(ctx context.Context, fullpath string, digests map[string]string) error {
+	ret := _m.Called(ctx, fullpath, digests)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveConfigDigests")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]string) error); ok {
+		r0 = rf(ctx, fullpath, digests)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConversionQueueRepository_SaveConfigDigests_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveConfigDigests'
+type MockConversionQueueRepository_SaveConfigDigests_Call struct {
+	*mock.Call
+}
+
+// SaveConfigDigests is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fullpath string
+//   - digests map[string]string
+func (_e *MockConversionQueueRepository_Expecter) SaveConfigDigests(ctx interface{}, fullpath interface{}, digests interface{}) *MockConversionQueueRepository_SaveConfigDigests_Call {
+	return &MockConversionQueueRepository_SaveConfigDigests_Call{Call: _e.mock.On("SaveConfigDigests", ctx, fullpath, digests)}
+}
+
+func (_c *MockConversionQueueRepository_SaveConfigDigests_Call) Run(run func(ctx context.Context, fullpath string, digests map[string]string)) *MockConversionQueueRepository_SaveConfigDigests_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(map[string]string))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_SaveConfigDigests_Call) Return(_a0 error) *MockConversionQueueRepository_SaveConfigDigests_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_SaveConfigDigests_Call) RunAndReturn(run func(context.Context, string, map[string]string) error) *MockConversionQueueRepository_SaveConfigDigests_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveDedupOrigin provides a mock function with given fields: ctx, fullpath, origin
+func (_m *MockConversionQueueRepository) SaveDedupOrigin(ctx context.Context, fullpath string, origin string) error {
+	ret := _m.Called(ctx, fullpath, origin)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveDedupOrigin")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, fullpath, origin)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConversionQueueRepository_SaveDedupOrigin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveDedupOrigin'
+type MockConversionQueueRepository_SaveDedupOrigin_Call struct {
+	*mock.Call
+}
+
+// SaveDedupOrigin is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fullpath string
+//   - origin string
+func (_e *MockConversionQueueRepository_Expecter) SaveDedupOrigin(ctx interface{}, fullpath interface{}, origin interface{}) *MockConversionQueueRepository_SaveDedupOrigin_Call {
+	return &MockConversionQueueRepository_SaveDedupOrigin_Call{Call: _e.mock.On("SaveDedupOrigin", ctx, fullpath, origin)}
+}
+
+func (_c *MockConversionQueueRepository_SaveDedupOrigin_Call) Run(run func(ctx context.Context, fullpath string, origin string)) *MockConversionQueueRepository_SaveDedupOrigin_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_SaveDedupOrigin_Call) Return(_a0 error) *MockConversionQueueRepository_SaveDedupOrigin_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_SaveDedupOrigin_Call) RunAndReturn(run func(context.Context, string, string) error) *MockConversionQueueRepository_SaveDedupOrigin_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveQualityResults provides a mock function with given fields: ctx, fullpath, results
+func (_m *MockConversionQueueRepository) SaveQualityResults(ctx context.Context, fullpath string, results []model.QualityResult) error {
+	ret := _m.Called(ctx, fullpath, results)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveQualityResults")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []model.QualityResult) error); ok {
+		r0 = rf(ctx, fullpath, results)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConversionQueueRepository_SaveQualityResults_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveQualityResults'
+type MockConversionQueueRepository_SaveQualityResults_Call struct {
+	*mock.Call
+}
+
+// SaveQualityResults is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fullpath string
+//   - results []model.QualityResult
+func (_e *MockConversionQueueRepository_Expecter) SaveQualityResults(ctx interface{}, fullpath interface{}, results interface{}) *MockConversionQueueRepository_SaveQualityResults_Call {
+	return &MockConversionQueueRepository_SaveQualityResults_Call{Call: _e.mock.On("SaveQualityResults", ctx, fullpath, results)}
+}
+
+func (_c *MockConversionQueueRepository_SaveQualityResults_Call) Run(run func(ctx context.Context, fullpath string, results []model.QualityResult)) *MockConversionQueueRepository_SaveQualityResults_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].([]model.QualityResult))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_SaveQualityResults_Call) Return(_a0 error) *MockConversionQueueRepository_SaveQualityResults_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_SaveQualityResults_Call) RunAndReturn(run func(context.Context, string, []model.QualityResult) error) *MockConversionQueueRepository_SaveQualityResults_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveSourceHash provides a mock function with given fields: ctx, fullpath, hash
+func (_m *MockConversionQueueRepository) SaveSourceHash(ctx context.Context, fullpath string, hash string) error {
+	ret := _m.Called(ctx, fullpath, hash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveSourceHash")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, fullpath, hash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConversionQueueRepository_SaveSourceHash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveSourceHash'
+type MockConversionQueueRepository_SaveSourceHash_Call struct {
+	*mock.Call
+}
+
+// SaveSourceHash is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fullpath string
+//   - hash string
+func (_e *MockConversionQueueRepository_Expecter) SaveSourceHash(ctx interface{}, fullpath interface{}, hash interface{}) *MockConversionQueueRepository_SaveSourceHash_Call {
+	return &MockConversionQueueRepository_SaveSourceHash_Call{Call: _e.mock.On("SaveSourceHash", ctx, fullpath, hash)}
+}
+
+func (_c *MockConversionQueueRepository_SaveSourceHash_Call) Run(run func(ctx context.Context, fullpath string, hash string)) *MockConversionQueueRepository_SaveSourceHash_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_SaveSourceHash_Call) Return(_a0 error) *MockConversionQueueRepository_SaveSourceHash_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConversionQueueRepository_SaveSourceHash_Call) RunAndReturn(run func(context.Context, string, string) error) *MockConversionQueueRepository_SaveSourceHash_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockConversionQueueRepository creates a new instance of MockConversionQueueRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockConversionQueueRepository(t interface {
@@ -7,6 +7,8 @@ import (
 
 	model "github.com/chistyakoviv/converter/internal/model"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // MockDeletionQueueRepository is an autogenerated mock type for the DeletionQueueRepository type
@@ -22,6 +24,119 @@ func (_m *MockDeletionQueueRepository) EXPECT() *MockDeletionQueueRepository_Exp
 	return &MockDeletionQueueRepository_Expecter{mock: &_m.Mock}
 }
 
+// CountDoneBefore provides a mock function with given fields: ctx, before
+func (_m *MockDeletionQueueRepository) CountDoneBefore(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountDoneBefore")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDeletionQueueRepository_CountDoneBefore_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountDoneBefore'
+type MockDeletionQueueRepository_CountDoneBefore_Call struct {
+	*mock.Call
+}
+
+// CountDoneBefore is a helper method to define mock.On call
+//   - ctx context.Context
+//   - before time.Time
+func (_e *MockDeletionQueueRepository_Expecter) CountDoneBefore(ctx interface{}, before interface{}) *MockDeletionQueueRepository_CountDoneBefore_Call {
+	return &MockDeletionQueueRepository_CountDoneBefore_Call{Call: _e.mock.On("CountDoneBefore", ctx, before)}
+}
+
+func (_c *MockDeletionQueueRepository_CountDoneBefore_Call) Run(run func(ctx context.Context, before time.Time)) *MockDeletionQueueRepository_CountDoneBefore_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockDeletionQueueRepository_CountDoneBefore_Call) Return(_a0 int64, _a1 error) *MockDeletionQueueRepository_CountDoneBefore_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDeletionQueueRepository_CountDoneBefore_Call) RunAndReturn(run func(context.Context, time.Time) (int64, error)) *MockDeletionQueueRepository_CountDoneBefore_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CountPending provides a mock function with given fields: ctx
+func (_m *MockDeletionQueueRepository) CountPending(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountPending")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDeletionQueueRepository_CountPending_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountPending'
+type MockDeletionQueueRepository_CountPending_Call struct {
+	*mock.Call
+}
+
+// CountPending is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockDeletionQueueRepository_Expecter) CountPending(ctx interface{}) *MockDeletionQueueRepository_CountPending_Call {
+	return &MockDeletionQueueRepository_CountPending_Call{Call: _e.mock.On("CountPending", ctx)}
+}
+
+func (_c *MockDeletionQueueRepository_CountPending_Call) Run(run func(ctx context.Context)) *MockDeletionQueueRepository_CountPending_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockDeletionQueueRepository_CountPending_Call) Return(_a0 int64, _a1 error) *MockDeletionQueueRepository_CountPending_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDeletionQueueRepository_CountPending_Call) RunAndReturn(run func(context.Context) (int64, error)) *MockDeletionQueueRepository_CountPending_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Create provides a mock function with given fields: ctx, file
 func (_m *MockDeletionQueueRepository) Create(ctx context.Context, file *model.DeletionInfo) (int64, error) {
 	ret := _m.Called(ctx, file)
@@ -79,6 +194,168 @@ func (_c *MockDeletionQueueRepository_Create_Call) RunAndReturn(run func(context
 	return _c
 }
 
+// CreateByPrefix provides a mock function with given fields: ctx, dir, file
+func (_m *MockDeletionQueueRepository) CreateByPrefix(ctx context.Context, dir string, file *model.DeletionInfo) (int64, error) {
+	ret := _m.Called(ctx, dir, file)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateByPrefix")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *model.DeletionInfo) (int64, error)); ok {
+		return rf(ctx, dir, file)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *model.DeletionInfo) int64); ok {
+		r0 = rf(ctx, dir, file)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *model.DeletionInfo) error); ok {
+		r1 = rf(ctx, dir, file)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDeletionQueueRepository_CreateByPrefix_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateByPrefix'
+type MockDeletionQueueRepository_CreateByPrefix_Call struct {
+	*mock.Call
+}
+
+// CreateByPrefix is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dir string
+//   - file *model.DeletionInfo
+func (_e *MockDeletionQueueRepository_Expecter) CreateByPrefix(ctx interface{}, dir interface{}, file interface{}) *MockDeletionQueueRepository_CreateByPrefix_Call {
+	return &MockDeletionQueueRepository_CreateByPrefix_Call{Call: _e.mock.On("CreateByPrefix", ctx, dir, file)}
+}
+
+func (_c *MockDeletionQueueRepository_CreateByPrefix_Call) Run(run func(ctx context.Context, dir string, file *model.DeletionInfo)) *MockDeletionQueueRepository_CreateByPrefix_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(*model.DeletionInfo))
+	})
+	return _c
+}
+
+func (_c *MockDeletionQueueRepository_CreateByPrefix_Call) Return(_a0 int64, _a1 error) *MockDeletionQueueRepository_CreateByPrefix_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDeletionQueueRepository_CreateByPrefix_Call) RunAndReturn(run func(context.Context, string, *model.DeletionInfo) (int64, error)) *MockDeletionQueueRepository_CreateByPrefix_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Delete provides a mock function with given fields: ctx, fullpath
+func (_m *MockDeletionQueueRepository) Delete(ctx context.Context, fullpath string) error {
+	ret := _m.Called(ctx, fullpath)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, fullpath)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDeletionQueueRepository_Delete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Delete'
+type MockDeletionQueueRepository_Delete_Call struct {
+	*mock.Call
+}
+
+// Delete is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fullpath string
+func (_e *MockDeletionQueueRepository_Expecter) Delete(ctx interface{}, fullpath interface{}) *MockDeletionQueueRepository_Delete_Call {
+	return &MockDeletionQueueRepository_Delete_Call{Call: _e.mock.On("Delete", ctx, fullpath)}
+}
+
+func (_c *MockDeletionQueueRepository_Delete_Call) Run(run func(ctx context.Context, fullpath string)) *MockDeletionQueueRepository_Delete_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockDeletionQueueRepository_Delete_Call) Return(_a0 error) *MockDeletionQueueRepository_Delete_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDeletionQueueRepository_Delete_Call) RunAndReturn(run func(context.Context, string) error) *MockDeletionQueueRepository_Delete_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteDoneBefore provides a mock function with given fields: ctx, before
+func (_m *MockDeletionQueueRepository) DeleteDoneBefore(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteDoneBefore")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDeletionQueueRepository_DeleteDoneBefore_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteDoneBefore'
+type MockDeletionQueueRepository_DeleteDoneBefore_Call struct {
+	*mock.Call
+}
+
+// DeleteDoneBefore is a helper method to define mock.On call
+//   - ctx context.Context
+//   - before time.Time
+func (_e *MockDeletionQueueRepository_Expecter) DeleteDoneBefore(ctx interface{}, before interface{}) *MockDeletionQueueRepository_DeleteDoneBefore_Call {
+	return &MockDeletionQueueRepository_DeleteDoneBefore_Call{Call: _e.mock.On("DeleteDoneBefore", ctx, before)}
+}
+
+func (_c *MockDeletionQueueRepository_DeleteDoneBefore_Call) Run(run func(ctx context.Context, before time.Time)) *MockDeletionQueueRepository_DeleteDoneBefore_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockDeletionQueueRepository_DeleteDoneBefore_Call) Return(_a0 int64, _a1 error) *MockDeletionQueueRepository_DeleteDoneBefore_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDeletionQueueRepository_DeleteDoneBefore_Call) RunAndReturn(run func(context.Context, time.Time) (int64, error)) *MockDeletionQueueRepository_DeleteDoneBefore_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // FindByFullpath provides a mock function with given fields: ctx, fullpath
 func (_m *MockDeletionQueueRepository) FindByFullpath(ctx context.Context, fullpath string) (*model.Deletion, error) {
 	ret := _m.Called(ctx, fullpath)
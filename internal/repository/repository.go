@@ -2,22 +2,54 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/chistyakoviv/converter/internal/model"
 )
 
 type ConversionQueueRepository interface {
 	Create(ctx context.Context, file *model.ConversionInfo) (int64, error)
+	CreateBatch(ctx context.Context, files []*model.ConversionInfo) (int64, error)
+	FindById(ctx context.Context, id int64) (*model.Conversion, error)
 	FindByFullpath(ctx context.Context, fullpath string) (*model.Conversion, error)
+	FindByFullpaths(ctx context.Context, fullpaths []string) ([]*model.Conversion, error)
+	FindExistingFullpaths(ctx context.Context, fullpaths []string) ([]string, error)
 	FindOldestQueued(ctx context.Context) (*model.Conversion, error)
+	FindByStatus(ctx context.Context, statuses []int, limit uint64) ([]*model.Conversion, error)
 	MarkAsDone(ctx context.Context, fullpath string) error
 	MarkAsCanceled(ctx context.Context, fullpath string, code uint32) error
+	SaveQualityResults(ctx context.Context, fullpath string, results []model.QualityResult) error
+	SaveConfigDigests(ctx context.Context, fullpath string, digests map[string]string) error
+	SaveSourceHash(ctx context.Context, fullpath string, hash string) error
+	SaveDedupOrigin(ctx context.Context, fullpath string, origin string) error
+	FindDoneBySourceHash(ctx context.Context, hash string) ([]*model.Conversion, error)
+	Requeue(ctx context.Context, fullpath string) error
+	RequeueCanceled(ctx context.Context) (int64, error)
+	CountByPrefix(ctx context.Context, dir string) (int64, error)
+	CountPendingByClient(ctx context.Context, client string) (*model.QueueUsage, error)
+	CountPending(ctx context.Context) (int64, error)
+	Delete(ctx context.Context, fullpath string) error
+	FindAfterId(ctx context.Context, id int64, limit uint64) ([]*model.Conversion, error)
+	CountDoneBefore(ctx context.Context, before time.Time) (int64, error)
+	DeleteDoneBefore(ctx context.Context, before time.Time) (int64, error)
 }
 
 type DeletionQueueRepository interface {
 	Create(ctx context.Context, file *model.DeletionInfo) (int64, error)
+	CreateByPrefix(ctx context.Context, dir string, file *model.DeletionInfo) (int64, error)
 	FindByFullpath(ctx context.Context, fullpath string) (*model.Deletion, error)
 	FindOldestQueued(ctx context.Context) (*model.Deletion, error)
 	MarkAsDone(ctx context.Context, fullpath string) error
 	MarkAsCanceled(ctx context.Context, fullpath string, code uint32) error
+	Delete(ctx context.Context, fullpath string) error
+	CountPending(ctx context.Context) (int64, error)
+	CountDoneBefore(ctx context.Context, before time.Time) (int64, error)
+	DeleteDoneBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type ApiKeyRepository interface {
+	Create(ctx context.Context, key *model.ApiKey, hash string) (int64, error)
+	FindByHash(ctx context.Context, hash string) (*model.ApiKey, error)
+	FindAll(ctx context.Context) ([]*model.ApiKey, error)
+	Revoke(ctx context.Context, id int64) error
 }
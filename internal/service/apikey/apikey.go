@@ -0,0 +1,141 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/constants"
+	"github.com/chistyakoviv/converter/internal/db"
+	"github.com/chistyakoviv/converter/internal/file"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/repository"
+	"github.com/chistyakoviv/converter/internal/service"
+)
+
+const (
+	// Tokens are prefixed to be recognizable, e.g. by secret scanners
+	tokenPrefix = "cvt_"
+	// Number of random bytes of a token
+	tokenSize = 32
+	// Number of characters of a token stored to tell the keys apart
+	displayedPrefixLength = 12
+)
+
+var scopes = []string{
+	model.ApiKeyScopeConvert,
+	model.ApiKeyScopeDelete,
+	model.ApiKeyScopeScan,
+	model.ApiKeyScopeAdmin,
+}
+
+type serv struct {
+	cfg              *config.Config
+	apiKeyRepository repository.ApiKeyRepository
+}
+
+func NewService(
+	cfg *config.Config,
+	apiKeyRepository repository.ApiKeyRepository,
+) service.ApiKeyService {
+	return &serv{
+		cfg:              cfg,
+		apiKeyRepository: apiKeyRepository,
+	}
+}
+
+func (s *serv) Create(ctx context.Context, info *model.ApiKeyInfo) (*model.ApiKey, string, error) {
+	for _, scope := range info.Scopes {
+		if !slices.Contains(scopes, scope) {
+			return nil, "", fmt.Errorf("%w: '%s'", ErrInvalidScope, scope)
+		}
+	}
+
+	pathPrefixes := make([]string, 0, len(info.PathPrefixes))
+	for _, prefix := range info.PathPrefixes {
+		fullpath, err := file.Canonicalize(prefix, constants.FilesRootDir)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w '%s': %w", ErrInvalidPathPrefix, prefix, err)
+		}
+		pathPrefixes = append(pathPrefixes, fullpath)
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := &model.ApiKey{
+		Name:         info.Name,
+		Prefix:       token[:displayedPrefixLength],
+		Scopes:       info.Scopes,
+		PathPrefixes: pathPrefixes,
+		CreatedAt:    time.Now().UTC(),
+	}
+	key.Id, err = s.apiKeyRepository.Create(ctx, key, hashToken(token))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return key, token, nil
+}
+
+func (s *serv) Authenticate(ctx context.Context, token string) (*model.ApiKey, error) {
+	if token == "" {
+		return nil, ErrUnauthorized
+	}
+
+	if s.cfg.Auth.AdminKey != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.Auth.AdminKey)) == 1 {
+		return &model.ApiKey{
+			Name:   "admin",
+			Scopes: []string{model.ApiKeyScopeAdmin},
+		}, nil
+	}
+
+	// Tokens are random, so a fast hash is enough to keep them secret
+	key, err := s.apiKeyRepository.FindByHash(ctx, hashToken(token))
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, ErrUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+	if key.IsRevoked() {
+		return nil, fmt.Errorf("key %d is revoked: %w", key.Id, ErrUnauthorized)
+	}
+
+	return key, nil
+}
+
+func (s *serv) List(ctx context.Context) ([]*model.ApiKey, error) {
+	return s.apiKeyRepository.FindAll(ctx)
+}
+
+func (s *serv) Revoke(ctx context.Context, id int64) error {
+	err := s.apiKeyRepository.Revoke(ctx, id)
+	if errors.Is(err, db.ErrNotFound) {
+		return fmt.Errorf("%w: %d", ErrKeyNotFound, id)
+	}
+	return err
+}
+
+func generateToken() (string, error) {
+	buf := make([]byte, tokenSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
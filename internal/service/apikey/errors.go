@@ -0,0 +1,11 @@
+package apikey
+
+import "errors"
+
+var (
+	ErrUnauthorized      = errors.New("invalid api key")
+	ErrInvalidScope      = errors.New("invalid scope")
+	ErrInvalidPathPrefix = errors.New("invalid path prefix")
+	ErrKeyNotFound       = errors.New("api key not found")
+	ErrPathNotAllowed    = errors.New("path is not allowed for the api key")
+)
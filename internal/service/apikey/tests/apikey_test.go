@@ -0,0 +1,216 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/db"
+	"github.com/chistyakoviv/converter/internal/model"
+	repositoryMocks "github.com/chistyakoviv/converter/internal/repository/mocks"
+	"github.com/chistyakoviv/converter/internal/service/apikey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	ctx      = context.Background()
+	adminKey = "configured-admin-key"
+	cfg      = &config.Config{Auth: config.Auth{Enabled: true, AdminKey: adminKey}}
+)
+
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestCreateApiKey(t *testing.T) {
+	type testcase struct {
+		name                 string
+		info                 *model.ApiKeyInfo
+		err                  error
+		pathPrefixes         []string
+		mockApiKeyRepository func(tc *testcase) *repositoryMocks.MockApiKeyRepository
+	}
+
+	cases := []testcase{
+		{
+			name: "Unknown scope",
+			info: &model.ApiKeyInfo{Name: "uploader", Scopes: []string{"convert", "upload"}},
+			err:  apikey.ErrInvalidScope,
+			mockApiKeyRepository: func(tc *testcase) *repositoryMocks.MockApiKeyRepository {
+				return repositoryMocks.NewMockApiKeyRepository(t)
+			},
+		},
+		{
+			name: "Path prefix outside the files directory",
+			info: &model.ApiKeyInfo{Name: "uploader", Scopes: []string{"convert"}, PathPrefixes: []string{"/etc"}},
+			err:  apikey.ErrInvalidPathPrefix,
+			mockApiKeyRepository: func(tc *testcase) *repositoryMocks.MockApiKeyRepository {
+				return repositoryMocks.NewMockApiKeyRepository(t)
+			},
+		},
+		{
+			name:         "Successful creation",
+			info:         &model.ApiKeyInfo{Name: "uploader", Scopes: []string{"convert", "delete"}, PathPrefixes: []string{"files//uploads/"}},
+			pathPrefixes: []string{"/files/uploads"},
+			mockApiKeyRepository: func(tc *testcase) *repositoryMocks.MockApiKeyRepository {
+				mockApiKeyRepository := repositoryMocks.NewMockApiKeyRepository(t)
+				mockApiKeyRepository.On("Create", ctx, mock.AnythingOfType("*model.ApiKey"), mock.AnythingOfType("string")).
+					Return(int64(3), nil).
+					Once()
+				return mockApiKeyRepository
+			},
+		},
+		{
+			name: "Failed insert",
+			info: &model.ApiKeyInfo{Name: "uploader", Scopes: []string{"scan"}},
+			err:  errors.New("unexpected error"),
+			mockApiKeyRepository: func(tc *testcase) *repositoryMocks.MockApiKeyRepository {
+				mockApiKeyRepository := repositoryMocks.NewMockApiKeyRepository(t)
+				mockApiKeyRepository.On("Create", ctx, mock.AnythingOfType("*model.ApiKey"), mock.AnythingOfType("string")).
+					Return(int64(-1), tc.err).
+					Once()
+				return mockApiKeyRepository
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockApiKeyRepository := tc.mockApiKeyRepository(&tc)
+
+			serv := apikey.NewService(cfg, mockApiKeyRepository)
+
+			key, token, err := serv.Create(ctx, tc.info)
+
+			if tc.err != nil {
+				if errors.Is(tc.err, apikey.ErrInvalidScope) || errors.Is(tc.err, apikey.ErrInvalidPathPrefix) {
+					assert.ErrorIs(t, err, tc.err)
+				} else {
+					assert.EqualError(t, err, tc.err.Error())
+				}
+				assert.Nil(t, key)
+				assert.Empty(t, token)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, int64(3), key.Id)
+				assert.Equal(t, tc.info.Scopes, key.Scopes)
+				assert.Equal(t, tc.pathPrefixes, key.PathPrefixes)
+				assert.True(t, strings.HasPrefix(token, key.Prefix))
+				// Only the hash of the token is stored
+				mockApiKeyRepository.AssertCalled(t, "Create", ctx, key, hash(token))
+			}
+
+			mockApiKeyRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthenticateApiKey(t *testing.T) {
+	var (
+		token     = "cvt_secret"
+		revokedAt = time.Now()
+	)
+
+	type testcase struct {
+		name                 string
+		token                string
+		err                  error
+		key                  *model.ApiKey
+		mockApiKeyRepository func(tc *testcase) *repositoryMocks.MockApiKeyRepository
+	}
+
+	cases := []testcase{
+		{
+			name: "Missing token",
+			err:  apikey.ErrUnauthorized,
+			mockApiKeyRepository: func(tc *testcase) *repositoryMocks.MockApiKeyRepository {
+				return repositoryMocks.NewMockApiKeyRepository(t)
+			},
+		},
+		{
+			name:  "Configured admin key",
+			token: adminKey,
+			key:   &model.ApiKey{Name: "admin", Scopes: []string{model.ApiKeyScopeAdmin}},
+			mockApiKeyRepository: func(tc *testcase) *repositoryMocks.MockApiKeyRepository {
+				return repositoryMocks.NewMockApiKeyRepository(t)
+			},
+		},
+		{
+			name:  "Unknown token",
+			token: token,
+			err:   apikey.ErrUnauthorized,
+			mockApiKeyRepository: func(tc *testcase) *repositoryMocks.MockApiKeyRepository {
+				mockApiKeyRepository := repositoryMocks.NewMockApiKeyRepository(t)
+				mockApiKeyRepository.On("FindByHash", ctx, hash(tc.token)).Return(nil, db.ErrNotFound).Once()
+				return mockApiKeyRepository
+			},
+		},
+		{
+			name:  "Revoked key",
+			token: token,
+			err:   apikey.ErrUnauthorized,
+			mockApiKeyRepository: func(tc *testcase) *repositoryMocks.MockApiKeyRepository {
+				mockApiKeyRepository := repositoryMocks.NewMockApiKeyRepository(t)
+				mockApiKeyRepository.On("FindByHash", ctx, hash(tc.token)).
+					Return(&model.ApiKey{Id: 1, RevokedAt: &revokedAt}, nil).
+					Once()
+				return mockApiKeyRepository
+			},
+		},
+		{
+			name:  "Valid key",
+			token: token,
+			key:   &model.ApiKey{Id: 1, Name: "uploader", Scopes: []string{model.ApiKeyScopeConvert}},
+			mockApiKeyRepository: func(tc *testcase) *repositoryMocks.MockApiKeyRepository {
+				mockApiKeyRepository := repositoryMocks.NewMockApiKeyRepository(t)
+				mockApiKeyRepository.On("FindByHash", ctx, hash(tc.token)).Return(tc.key, nil).Once()
+				return mockApiKeyRepository
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockApiKeyRepository := tc.mockApiKeyRepository(&tc)
+
+			serv := apikey.NewService(cfg, mockApiKeyRepository)
+
+			key, err := serv.Authenticate(ctx, tc.token)
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				assert.Nil(t, key)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.key, key)
+			}
+
+			mockApiKeyRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRevokeApiKey(t *testing.T) {
+	mockApiKeyRepository := repositoryMocks.NewMockApiKeyRepository(t)
+	mockApiKeyRepository.On("Revoke", ctx, int64(1)).Return(nil).Once()
+	mockApiKeyRepository.On("Revoke", ctx, int64(2)).Return(db.ErrNotFound).Once()
+
+	serv := apikey.NewService(cfg, mockApiKeyRepository)
+
+	assert.NoError(t, serv.Revoke(ctx, 1))
+	assert.ErrorIs(t, serv.Revoke(ctx, 2), apikey.ErrKeyNotFound)
+
+	mockApiKeyRepository.AssertExpectations(t)
+}
@@ -4,68 +4,225 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/converter"
 	"github.com/chistyakoviv/converter/internal/db"
 	"github.com/chistyakoviv/converter/internal/file"
 	"github.com/chistyakoviv/converter/internal/model"
 	"github.com/chistyakoviv/converter/internal/repository"
 	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/chistyakoviv/converter/internal/storage"
 )
 
 type serv struct {
 	cfg                  *config.Config
 	txManager            db.TxManager
 	conversionRepository repository.ConversionQueueRepository
+	storage              storage.Storage
 }
 
 func NewService(
 	cfg *config.Config,
 	txManager db.TxManager,
 	conversionRepository repository.ConversionQueueRepository,
+	storage storage.Storage,
 ) service.ConversionQueueService {
 	return &serv{
 		cfg:                  cfg,
 		txManager:            txManager,
 		conversionRepository: conversionRepository,
+		storage:              storage,
 	}
 }
 
 // The method may modify the conversion info
 func (s *serv) Add(ctx context.Context, info *model.ConversionInfo) (int64, error) {
-	src, err := info.AbsoluteSourcePath()
+	if err := s.check(ctx, info); err != nil {
+		return -1, err
+	}
+
+	var id int64
+
+	// Since it's not possible to preemptively check if a query violates constraints,
+	// use a transaction to first verify that `fullpath` does not already exist.
+	// If `fullpath` exists, return an appropriate error. Otherwise, proceed to
+	// insert a new row within the same transaction.
+	err := s.txManager.ReadCommitted(ctx, func(ctx context.Context) error {
+		var errTx error
+		id, errTx = s.create(ctx, info)
+
+		return errTx
+	})
+
 	if err != nil {
 		return -1, err
 	}
-	if !file.Exists(src) {
-		return -1, fmt.Errorf("%s: %w", info.Fullpath, ErrFileDoesNotExist)
+
+	return id, nil
+}
+
+// AddMany enqueues the conversions in a single transaction.
+// A conversion that cannot be enqueued doesn't prevent enqueuing the others, its error is reported in its result.
+// The quota of the client is checked item by item, so the items exceeding it are rejected.
+// An unexpected database error rolls back the whole batch and is returned.
+// The method may modify the conversion infos.
+func (s *serv) AddMany(ctx context.Context, infos []*model.ConversionInfo) ([]model.BatchResult, error) {
+	results := make([]model.BatchResult, len(infos))
+
+	// Files are checked before the transaction is started to keep it short
+	checked := make([]bool, len(infos))
+	for i, info := range infos {
+		if err := s.check(ctx, info); err != nil {
+			results[i] = model.BatchResult{Id: -1, Err: err}
+			continue
+		}
+		checked[i] = true
 	}
-	if !isSupported(info.Ext) {
-		return -1, fmt.Errorf("%s: %w", info.Ext, ErrFileTypeNotSupported)
+
+	err := s.txManager.ReadCommitted(ctx, func(ctx context.Context) error {
+		for i, info := range infos {
+			if !checked[i] {
+				continue
+			}
+			id, errTx := s.create(ctx, info)
+			if errors.Is(errTx, ErrPathAlreadyExist) || errors.Is(errTx, ErrQuotaExceeded) {
+				results[i] = model.BatchResult{Id: -1, Err: errTx}
+				continue
+			}
+			if errTx != nil {
+				return errTx
+			}
+			results[i] = model.BatchResult{Id: id}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
-	// Assign default format if no target formats are specified
-	if info.ConvertTo == nil {
-		var err error
-		var ok bool
+	return results, nil
+}
 
-		if ok, err = file.IsImage(info.Fullpath); ok {
-			info.ConvertTo = s.cfg.Defaults.Image.Formats
-		}
+// check verifies that the file exists and can be converted, assigns the default target formats
+// and the size of the source. The source is hashed by the converter, as it may change until it is converted.
+func (s *serv) check(ctx context.Context, info *model.ConversionInfo) error {
+	obj, err := s.storage.Stat(ctx, info.Fullpath)
+	if errors.Is(err, storage.ErrNotExist) {
+		return fmt.Errorf("%s: %w", info.Fullpath, ErrFileDoesNotExist)
+	}
+	if err != nil {
+		return err
+	}
+	info.SourceSize = obj.Size
+
+	// Sniff the file only if the target formats depend on its type
+	mediaType := file.MediaTypeUnknown
+	if (info.ConvertTo == nil || info.Preset != "") && isSupported(info.Ext) {
+		mediaType, err = storage.DetectMediaType(ctx, s.storage, info.Fullpath)
 		if err != nil {
-			return -1, fmt.Errorf("%w: %w", ErrFailedDetermineFileType, err)
+			return fmt.Errorf("%w: %w", ErrFailedDetermineFileType, err)
 		}
-		if ok, err = file.IsVideo(info.Fullpath); ok {
-			info.ConvertTo = s.cfg.Defaults.Video.Formats
+	}
+
+	return s.Prepare(info, mediaType)
+}
+
+// create inserts the conversion unless the file is already in the queue, must be called within a transaction
+func (s *serv) create(ctx context.Context, info *model.ConversionInfo) (int64, error) {
+	// Filesystem scanning may detect already converted files and attempt to queue them,
+	// which is undesirable behavior.
+	// TODO: Implement a solution to prevent re-conversion of already converted files.
+	_, err := s.conversionRepository.FindByFullpath(ctx, info.Fullpath)
+	if !errors.Is(err, db.ErrNotFound) {
+		if err == nil {
+			return -1, fmt.Errorf("add failed for '%s': %w", info.Fullpath, ErrPathAlreadyExist)
 		}
-		if err != nil {
-			return -1, fmt.Errorf("%w: %w", ErrFailedDetermineFileType, err)
+		return -1, err
+	}
+
+	if err = s.checkQuota(ctx, info); err != nil {
+		return -1, err
+	}
+
+	return s.conversionRepository.Create(ctx, info)
+}
+
+// checkQuota verifies that the client may enqueue one more conversion, must be called within a transaction
+// so the conversions of a batch enqueued before are counted.
+// Concurrent requests of the client may exceed the quota slightly, since pending rows are not locked.
+func (s *serv) checkQuota(ctx context.Context, info *model.ConversionInfo) error {
+	quota := s.cfg.Quota
+	if info.Client == "" || !quota.Enabled() {
+		return nil
+	}
+
+	usage, err := s.conversionRepository.CountPendingByClient(ctx, info.Client)
+	if err != nil {
+		return err
+	}
+
+	if quota.MaxPendingJobs > 0 && usage.Jobs >= quota.MaxPendingJobs {
+		return &QuotaError{
+			Client:     info.Client,
+			RetryAfter: quota.RetryAfter,
+			reason:     fmt.Sprintf("%d pending conversions", usage.Jobs),
+		}
+	}
+	if quota.MaxPendingBytes > 0 && usage.Bytes+info.SourceSize > quota.MaxPendingBytes {
+		return &QuotaError{
+			Client:     info.Client,
+			RetryAfter: quota.RetryAfter,
+			reason:     fmt.Sprintf("%d pending bytes, the file has %d bytes", usage.Bytes, info.SourceSize),
+		}
+	}
+
+	return nil
+}
+
+func (s *serv) Prepare(info *model.ConversionInfo, mediaType file.MediaType) error {
+	return Prepare(s.cfg, info, mediaType)
+}
+
+// Prepare checks that the file can be converted and assigns the target formats of the preset,
+// or the default target formats of the media type if none are specified. The conversion info may be modified.
+// Unlike adding to the queue, it needs no database, so files can be checked before they are converted offline.
+func Prepare(cfg *config.Config, info *model.ConversionInfo, mediaType file.MediaType) error {
+	if !isSupported(info.Ext) {
+		return fmt.Errorf("%s: %w", info.Ext, ErrFileTypeNotSupported)
+	}
+
+	dfs := cfg.CurrentDefaults()
+
+	// The formats of the preset are resolved on enqueue, so a reload does not change the pending conversions
+	if info.Preset != "" {
+		preset, ok := dfs.Presets[info.Preset]
+		if !ok {
+			return fmt.Errorf("%w '%s'", ErrUnknownPreset, info.Preset)
+		}
+		formats := preset.Formats(mediaType)
+		if len(formats) == 0 {
+			return fmt.Errorf("preset '%s' has no formats for the file: %w", info.Preset, ErrEmptyTargetFormatList)
+		}
+		info.ConvertTo = config.MergeFormats(formats, info.ConvertTo)
+	}
+
+	// Assign default format if no target formats are specified
+	if info.ConvertTo == nil {
+		switch mediaType {
+		case file.MediaTypeImage:
+			info.ConvertTo = dfs.Image.Formats
+		case file.MediaTypeVideo:
+			info.ConvertTo = dfs.Video.Formats
 		}
 
 		// Return error if no target formats are specified
 		if info.ConvertTo == nil {
-			return -1, fmt.Errorf("target formats not specified: %w", ErrEmptyTargetFormatList)
+			return fmt.Errorf("target formats not specified: %w", ErrEmptyTargetFormatList)
 		}
 	}
 
@@ -76,37 +233,52 @@ func (s *serv) Add(ctx context.Context, info *model.ConversionInfo) (int64, erro
 		}
 	}
 	if len(unsupportedFormats) > 0 {
-		return -1, fmt.Errorf("conversion from '%s' to %s: %w", info.Ext, strings.Join(unsupportedFormats, ", "), ErrInvalidConversionFormat)
+		return fmt.Errorf("conversion from '%s' to %s: %w", info.Ext, strings.Join(unsupportedFormats, ", "), ErrInvalidConversionFormat)
 	}
 
-	var id int64
+	// The encoder of the target format must accept every parameter
+	targetMediaType := file.MediaTypeImage
+	if VideoFormats[info.Ext] {
+		targetMediaType = file.MediaTypeVideo
+	}
+	for _, entry := range info.ConvertTo {
+		if err := converter.ValidateConfig(targetMediaType, entry.Ext, entry.ConvConf, cfg.Overlays); err != nil {
+			return fmt.Errorf("target format '%s': %w", entry.Key(), err)
+		}
+	}
 
-	// Since it's not possible to preemptively check if a query violates constraints,
-	// use a transaction to first verify that `fullpath` does not already exist.
-	// If `fullpath` exists, return an appropriate error. Otherwise, proceed to
-	// insert a new row within the same transaction.
-	err = s.txManager.ReadCommitted(ctx, func(ctx context.Context) error {
-		// Filesystem scanning may detect already converted files and attempt to queue them,
-		// which is undesirable behavior.
-		// TODO: Implement a solution to prevent re-conversion of already converted files.
-		var errTx error
-		_, errTx = s.conversionRepository.FindByFullpath(ctx, info.Fullpath)
-		if !errors.Is(errTx, db.ErrNotFound) {
-			if errTx == nil {
-				return fmt.Errorf("add failed for '%s': %w", info.Fullpath, ErrPathAlreadyExist)
-			}
-			return errTx
+	// Each target format must be written to its own file
+	naming := cfg.Naming()
+	destinations := make(map[string]bool, len(info.ConvertTo))
+	for _, entry := range info.ConvertTo {
+		dest, err := naming.Validate(info, entry)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidOutputName, err)
 		}
-		id, errTx = s.conversionRepository.Create(ctx, info)
+		if destinations[dest] {
+			return fmt.Errorf("%w: several target formats are written to '%s'", ErrInvalidOutputName, dest)
+		}
+		destinations[dest] = true
+	}
 
-		return errTx
-	})
+	return nil
+}
 
-	if err != nil {
-		return -1, err
+// AddBatch enqueues the prepared conversions, files already in the queue are skipped.
+// Returns the number of enqueued conversions.
+func (s *serv) AddBatch(ctx context.Context, infos []*model.ConversionInfo) (int64, error) {
+	if len(infos) == 0 {
+		return 0, nil
 	}
+	return s.conversionRepository.CreateBatch(ctx, infos)
+}
 
-	return id, nil
+// FindQueued returns the given paths that are already in the queue.
+func (s *serv) FindQueued(ctx context.Context, fullpaths []string) ([]string, error) {
+	if len(fullpaths) == 0 {
+		return nil, nil
+	}
+	return s.conversionRepository.FindExistingFullpaths(ctx, fullpaths)
 }
 
 func (s *serv) Pop(ctx context.Context) (*model.Conversion, error) {
@@ -117,6 +289,17 @@ func (s *serv) Get(ctx context.Context, fullpath string) (*model.Conversion, err
 	return s.conversionRepository.FindByFullpath(ctx, fullpath)
 }
 
+func (s *serv) GetById(ctx context.Context, id int64) (*model.Conversion, error) {
+	return s.conversionRepository.FindById(ctx, id)
+}
+
+func (s *serv) List(ctx context.Context, statuses []int, limit uint64) ([]*model.Conversion, error) {
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+	return s.conversionRepository.FindByStatus(ctx, statuses, limit)
+}
+
 func (s *serv) MarkAsDone(ctx context.Context, fullpath string) error {
 	return s.conversionRepository.MarkAsDone(ctx, fullpath)
 }
@@ -124,3 +307,169 @@ func (s *serv) MarkAsDone(ctx context.Context, fullpath string) error {
 func (s *serv) MarkAsCanceled(ctx context.Context, fullpath string, code uint32) error {
 	return s.conversionRepository.MarkAsCanceled(ctx, fullpath, code)
 }
+
+func (s *serv) CountPending(ctx context.Context) (int64, error) {
+	return s.conversionRepository.CountPending(ctx)
+}
+
+func (s *serv) Presets() []*model.Preset {
+	dfs := s.cfg.CurrentDefaults()
+	presets := make([]*model.Preset, 0, len(dfs.Presets))
+	for _, name := range dfs.PresetNames() {
+		preset := dfs.Presets[name]
+		presets = append(presets, &model.Preset{
+			Name:        name,
+			Extends:     preset.Extends,
+			Description: preset.Description,
+			Image:       preset.Image.Formats,
+			Video:       preset.Video.Formats,
+		})
+	}
+	return presets
+}
+
+func (s *serv) SaveQualityResults(ctx context.Context, fullpath string, results []model.QualityResult) error {
+	return s.conversionRepository.SaveQualityResults(ctx, fullpath, results)
+}
+
+func (s *serv) SaveConfigDigests(ctx context.Context, fullpath string, digests map[string]string) error {
+	return s.conversionRepository.SaveConfigDigests(ctx, fullpath, digests)
+}
+
+func (s *serv) SaveSourceHash(ctx context.Context, fullpath string, hash string) error {
+	return s.conversionRepository.SaveSourceHash(ctx, fullpath, hash)
+}
+
+func (s *serv) SaveDedupOrigin(ctx context.Context, fullpath string, origin string) error {
+	return s.conversionRepository.SaveDedupOrigin(ctx, fullpath, origin)
+}
+
+func (s *serv) FindDuplicates(ctx context.Context, hash string) ([]*model.Conversion, error) {
+	if hash == "" {
+		return nil, nil
+	}
+	return s.conversionRepository.FindDoneBySourceHash(ctx, hash)
+}
+
+// IsOutputShared reports whether another done conversion of the same content produces the same file,
+// e.g. if the output template names the converted files after the source hash only.
+// Only the conversions whose sources still exist keep the file, so it is removed with the last of them.
+func (s *serv) IsOutputShared(ctx context.Context, conversion *model.Conversion, dest string) (bool, error) {
+	duplicates, err := s.FindDuplicates(ctx, conversion.SourceHash)
+	if err != nil {
+		return false, err
+	}
+
+	for _, duplicate := range duplicates {
+		if duplicate.Fullpath == conversion.Fullpath || !s.isProducedBy(duplicate, dest) {
+			continue
+		}
+		exists, err := storage.Exists(ctx, s.storage, duplicate.Fullpath)
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *serv) Requeue(ctx context.Context, fullpath string) error {
+	return s.conversionRepository.Requeue(ctx, fullpath)
+}
+
+func (s *serv) RequeueCanceled(ctx context.Context) (int64, error) {
+	return s.conversionRepository.RequeueCanceled(ctx)
+}
+
+// FindSource returns the conversion that produces the file as one of its outputs.
+// Returns db.ErrNotFound if the file is not an output of any conversion.
+func (s *serv) FindSource(ctx context.Context, fullpath string) (*model.Conversion, error) {
+	for _, candidate := range SourceCandidates(fullpath) {
+		conversion, err := s.conversionRepository.FindByFullpath(ctx, candidate)
+		if errors.Is(err, db.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if s.isProducedBy(conversion, fullpath) {
+			return conversion, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no conversion produces '%s': %w", fullpath, db.ErrNotFound)
+}
+
+// FindSources looks up the conversions producing the files with a single query.
+// Returns the conversions keyed by the paths of their outputs, files that are not outputs are absent.
+func (s *serv) FindSources(ctx context.Context, fullpaths []string) (map[string]*model.Conversion, error) {
+	// Maps each candidate source to the files it may produce
+	outputs := make(map[string][]string)
+	for _, fullpath := range fullpaths {
+		for _, candidate := range SourceCandidates(fullpath) {
+			outputs[candidate] = append(outputs[candidate], fullpath)
+		}
+	}
+
+	sources := make(map[string]*model.Conversion)
+	if len(outputs) == 0 {
+		return sources, nil
+	}
+
+	candidates := make([]string, 0, len(outputs))
+	for candidate := range outputs {
+		candidates = append(candidates, candidate)
+	}
+
+	conversions, err := s.conversionRepository.FindByFullpaths(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, conversion := range conversions {
+		for _, fullpath := range outputs[conversion.Fullpath] {
+			if s.isProducedBy(conversion, fullpath) {
+				sources[fullpath] = conversion
+			}
+		}
+	}
+
+	return sources, nil
+}
+
+// SourceCandidates returns the paths of the files the given file may be converted from.
+// Outputs placed next to their sources are named after the source stem, so each prefix of the name
+// ending before a separator and followed by a source extension is a candidate.
+func SourceCandidates(fullpath string) []string {
+	var candidates []string
+
+	dir, name := filepath.Split(fullpath)
+	for i := 1; i < len(name); i++ {
+		if !strings.ContainsRune(model.OutputNameSeparators, rune(name[i])) {
+			continue
+		}
+		for ext := range FileTypeToFormatMap {
+			candidate := dir + name[:i] + "." + ext
+			if candidate == fullpath {
+				continue
+			}
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	return candidates
+}
+
+func (s *serv) isProducedBy(conversion *model.Conversion, fullpath string) bool {
+	naming := s.cfg.Naming()
+	for _, entry := range conversion.ConvertTo {
+		dest, err := conversion.DestinationFullpath(entry, naming)
+		if err == nil && dest == fullpath {
+			return true
+		}
+	}
+	return false
+}
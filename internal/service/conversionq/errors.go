@@ -1,6 +1,10 @@
 package conversionq
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 var (
 	ErrPathAlreadyExist        = errors.New("file with the specified path already exists")
@@ -9,4 +13,23 @@ var (
 	ErrFailedDetermineFileType = errors.New("failed to determine file type")
 	ErrInvalidConversionFormat = errors.New("cannot convert to the specified format")
 	ErrEmptyTargetFormatList   = errors.New("target format list is empty")
+	ErrInvalidOutputName       = errors.New("converted files cannot be named")
+	ErrUnknownPreset           = errors.New("unknown preset")
+	ErrQuotaExceeded           = errors.New("pending conversion quota exceeded")
 )
+
+// QuotaError is reported when a client has too many pending conversions, matches ErrQuotaExceeded
+type QuotaError struct {
+	Client string
+	// Suggested delay before the request is retried
+	RetryAfter time.Duration
+	reason     string
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s for '%s': %s", ErrQuotaExceeded, e.Client, e.reason)
+}
+
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
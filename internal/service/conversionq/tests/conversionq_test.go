@@ -2,16 +2,21 @@ package tests
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"testing"
 
 	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/converter"
 	"github.com/chistyakoviv/converter/internal/db"
 	dbMocks "github.com/chistyakoviv/converter/internal/db/mocks"
+	"github.com/chistyakoviv/converter/internal/file"
 	"github.com/chistyakoviv/converter/internal/model"
 	repositoryMocks "github.com/chistyakoviv/converter/internal/repository/mocks"
 	"github.com/chistyakoviv/converter/internal/service"
 	"github.com/chistyakoviv/converter/internal/service/conversionq"
+	"github.com/chistyakoviv/converter/internal/storage/local"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -222,6 +227,7 @@ func TestAddToConversionQueue(t *testing.T) {
 				}),
 				mockTxManager,
 				mockConversionRepository,
+				local.NewStorage(""),
 			)
 
 			id, err := serv.Add(ctx, tc.conversionInfo)
@@ -231,6 +237,8 @@ func TestAddToConversionQueue(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tc.id, id)
+				// The source is hashed by the converter
+				assert.Empty(t, tc.conversionInfo.SourceHash)
 			}
 
 			if tc.convertTo != nil {
@@ -243,6 +251,462 @@ func TestAddToConversionQueue(t *testing.T) {
 	}
 }
 
+func TestPrepareConversion(t *testing.T) {
+	var (
+		defaultCfg = config.MustLoad(&config.ConfigOptions{
+			ConfigPath:   configPath,
+			DefaultsPath: defaultsPath,
+		})
+	)
+
+	type testcase struct {
+		name      string
+		err       error
+		info      *model.ConversionInfo
+		mediaType file.MediaType
+		convertTo []model.ConvertTo
+	}
+
+	cases := []testcase{
+		{
+			name:      "Unsupported extension",
+			err:       conversionq.ErrFileTypeNotSupported,
+			info:      &model.ConversionInfo{Fullpath: "/files/other/test.txt", Ext: "txt"},
+			mediaType: file.MediaTypeUnknown,
+		},
+		{
+			name:      "Unknown media type without target formats",
+			err:       conversionq.ErrEmptyTargetFormatList,
+			info:      &model.ConversionInfo{Fullpath: "/files/images/gen.jpg", Ext: "jpg"},
+			mediaType: file.MediaTypeUnknown,
+		},
+		{
+			name:      "Wrong conversion targets",
+			err:       conversionq.ErrInvalidConversionFormat,
+			info:      &model.ConversionInfo{Fullpath: "/files/images/gen.jpg", Ext: "jpg", ConvertTo: []model.ConvertTo{{Ext: "mp4"}}},
+			mediaType: file.MediaTypeImage,
+		},
+		{
+			name:      "Invalid output template",
+			err:       conversionq.ErrInvalidOutputName,
+			info:      &model.ConversionInfo{Fullpath: "/files/images/gen.jpg", Path: "/files/images", Filestem: "gen", Ext: "jpg", ConvertTo: []model.ConvertTo{{Ext: "webp", Optional: map[string]interface{}{"template": "{dir}/{name}.{fmt}"}}}},
+			mediaType: file.MediaTypeImage,
+		},
+		{
+			name:      "Target formats written to the same file",
+			err:       conversionq.ErrInvalidOutputName,
+			info:      &model.ConversionInfo{Fullpath: "/files/images/gen.jpg", Path: "/files/images", Filestem: "gen", Ext: "jpg", ConvertTo: []model.ConvertTo{{Ext: "webp"}, {Ext: "webp", ConvConf: map[string]interface{}{"quality": 50}}}},
+			mediaType: file.MediaTypeImage,
+		},
+		{
+			name:      "Unknown encoder parameter",
+			err:       converter.ErrInvalidConversionConfig,
+			info:      &model.ConversionInfo{Fullpath: "/files/images/gen.jpg", Path: "/files/images", Filestem: "gen", Ext: "jpg", ConvertTo: []model.ConvertTo{{Ext: "webp", ConvConf: map[string]interface{}{"qualty": 80}}}},
+			mediaType: file.MediaTypeImage,
+		},
+		{
+			name:      "FFmpeg option outside the allow-list",
+			err:       converter.ErrInvalidConversionConfig,
+			info:      &model.ConversionInfo{Fullpath: "/files/videos/gen.mp4", Path: "/files/videos", Filestem: "gen", Ext: "mp4", ConvertTo: []model.ConvertTo{{Ext: "webm", ConvConf: map[string]interface{}{"i": "/etc/passwd"}}}},
+			mediaType: file.MediaTypeVideo,
+		},
+		{
+			name:      "Unknown preset",
+			err:       conversionq.ErrUnknownPreset,
+			info:      &model.ConversionInfo{Fullpath: "/files/images/gen.jpg", Path: "/files/images", Filestem: "gen", Ext: "jpg", Preset: "thumbnal"},
+			mediaType: file.MediaTypeImage,
+		},
+		{
+			name:      "Preset without formats for the media type",
+			err:       conversionq.ErrEmptyTargetFormatList,
+			info:      &model.ConversionInfo{Fullpath: "/files/videos/gen.mp4", Path: "/files/videos", Filestem: "gen", Ext: "mp4", Preset: "thumbnail"},
+			mediaType: file.MediaTypeVideo,
+		},
+		{
+			name:      "Conversion targets of an extended preset",
+			info:      &model.ConversionInfo{Fullpath: "/files/images/gen.jpg", Path: "/files/images", Filestem: "gen", Ext: "jpg", Preset: "thumbnail"},
+			mediaType: file.MediaTypeImage,
+			convertTo: []model.ConvertTo{{
+				Ext:      "webp",
+				ConvConf: map[string]interface{}{"quality": 60, "width": 320},
+				Optional: map[string]interface{}{"template": "{dir}/{stem}_{width}.{fmt}"},
+			}},
+		},
+		{
+			name: "Request formats merged over the preset",
+			info: &model.ConversionInfo{Fullpath: "/files/images/gen.jpg", Path: "/files/images", Filestem: "gen", Ext: "jpg", Preset: "web", ConvertTo: []model.ConvertTo{
+				{Ext: "webp", ConvConf: map[string]interface{}{"lossless": true}},
+				{Ext: "avif"},
+			}},
+			mediaType: file.MediaTypeImage,
+			convertTo: []model.ConvertTo{
+				{Ext: "webp", ConvConf: map[string]interface{}{"quality": 80, "lossless": true}},
+				{Ext: "avif"},
+			},
+		},
+		{
+			name:      "Default conversion targets for images",
+			info:      &model.ConversionInfo{Fullpath: "/files/images/gen.jpg", Path: "/files/images", Filestem: "gen", Ext: "jpg"},
+			mediaType: file.MediaTypeImage,
+			convertTo: defaultCfg.Defaults.Image.Formats,
+		},
+		{
+			name:      "Default conversion targets for videos",
+			info:      &model.ConversionInfo{Fullpath: "/files/videos/gen.mp4", Path: "/files/videos", Filestem: "gen", Ext: "mp4"},
+			mediaType: file.MediaTypeVideo,
+			convertTo: defaultCfg.Defaults.Video.Formats,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			serv := conversionq.NewService(
+				defaultCfg,
+				dbMocks.NewMockTxManager(t),
+				repositoryMocks.NewMockConversionQueueRepository(t),
+				local.NewStorage(""),
+			)
+
+			err := serv.Prepare(tc.info, tc.mediaType)
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.convertTo, tc.info.ConvertTo)
+			}
+		})
+	}
+}
+
+func TestAddManyToConversionQueue(t *testing.T) {
+	var (
+		conversionInfo = func(fullpath, path, filestem, ext string) *model.ConversionInfo {
+			return &model.ConversionInfo{Fullpath: fullpath, Path: path, Filestem: filestem, Ext: ext}
+		}
+		unexpectedErr = errors.New("unexpected error")
+	)
+
+	type testcase struct {
+		name  string
+		err   error
+		infos []*model.ConversionInfo
+		// Expected errors of the items, nil if an item is enqueued
+		errs                     []error
+		ids                      []int64
+		mockConversionRepository func(tc *testcase) *repositoryMocks.MockConversionQueueRepository
+	}
+
+	cases := []testcase{
+		{
+			name: "Items are enqueued independently",
+			infos: []*model.ConversionInfo{
+				conversionInfo("/files/images/gen.jpg", "/files/images", "gen", "jpg"),
+				conversionInfo("/files/absent.jpg", "/files", "absent", "jpg"),
+				conversionInfo("/files/other/test.txt", "/files/other", "test", "txt"),
+				conversionInfo("/files/images/gen.jpg", "/files/images", "gen", "jpg"),
+			},
+			errs: []error{nil, conversionq.ErrFileDoesNotExist, conversionq.ErrFileTypeNotSupported, conversionq.ErrPathAlreadyExist},
+			ids:  []int64{1, -1, -1, -1},
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+				mockConversionRepository.On("FindByFullpath", ctx, "/files/images/gen.jpg").Return(nil, db.ErrNotFound).Once()
+				mockConversionRepository.On("Create", ctx, tc.infos[0]).Return(int64(1), nil).Once()
+				// The same file is already enqueued within the transaction
+				mockConversionRepository.On("FindByFullpath", ctx, "/files/images/gen.jpg").Return(&model.Conversion{Id: 1}, nil).Once()
+				return mockConversionRepository
+			},
+		},
+		{
+			name: "Transaction is rolled back on unexpected errors",
+			err:  unexpectedErr,
+			infos: []*model.ConversionInfo{
+				conversionInfo("/files/images/gen.jpg", "/files/images", "gen", "jpg"),
+			},
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+				mockConversionRepository.On("FindByFullpath", ctx, "/files/images/gen.jpg").Return(nil, db.ErrNotFound).Once()
+				mockConversionRepository.On("Create", ctx, tc.infos[0]).Return(int64(-1), unexpectedErr).Once()
+				return mockConversionRepository
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockConversionRepository := tc.mockConversionRepository(&tc)
+			// The whole batch is enqueued in a single transaction
+			mockTxManager := dbMocks.NewMockTxManager(t)
+			mockTxManager.EXPECT().ReadCommitted(ctx, mock.Anything).
+				RunAndReturn(func(ctx context.Context, f db.TxHandler) error {
+					return f(ctx)
+				}).
+				Once()
+
+			serv := conversionq.NewService(
+				config.MustLoad(&config.ConfigOptions{
+					ConfigPath:   configPath,
+					DefaultsPath: defaultsPath,
+				}),
+				mockTxManager,
+				mockConversionRepository,
+				local.NewStorage(""),
+			)
+
+			results, err := serv.AddMany(ctx, tc.infos)
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				assert.Nil(t, results)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, results, len(tc.infos))
+				for i, result := range results {
+					assert.Equal(t, tc.ids[i], result.Id)
+					if tc.errs[i] == nil {
+						assert.NoError(t, result.Err)
+					} else {
+						assert.ErrorIs(t, result.Err, tc.errs[i])
+					}
+				}
+			}
+
+			mockConversionRepository.AssertExpectations(t)
+			mockTxManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestQuotaOfConversionQueue(t *testing.T) {
+	var (
+		conversionInfo = func(client string) *model.ConversionInfo {
+			return &model.ConversionInfo{Fullpath: "/files/images/gen.jpg", Path: "/files/images", Filestem: "gen", Ext: "jpg", Client: client}
+		}
+	)
+
+	type testcase struct {
+		name            string
+		err             error
+		info            *model.ConversionInfo
+		maxPendingJobs  int64
+		maxPendingBytes int64
+		usage           *model.QueueUsage
+	}
+
+	cases := []testcase{
+		{
+			name:           "Conversions without a client are not limited",
+			info:           conversionInfo(""),
+			maxPendingJobs: 1,
+		},
+		{
+			name:           "Conversion within the quota is enqueued",
+			info:           conversionInfo("key:1"),
+			maxPendingJobs: 2,
+			usage:          &model.QueueUsage{Jobs: 1, Bytes: 100},
+		},
+		{
+			name:           "Too many pending conversions",
+			err:            conversionq.ErrQuotaExceeded,
+			info:           conversionInfo("key:1"),
+			maxPendingJobs: 1,
+			usage:          &model.QueueUsage{Jobs: 1, Bytes: 100},
+		},
+		{
+			name:            "Too many pending bytes",
+			err:             conversionq.ErrQuotaExceeded,
+			info:            conversionInfo("ip:10.0.0.1"),
+			maxPendingBytes: 1024,
+			usage:           &model.QueueUsage{Jobs: 1, Bytes: 1000},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := config.MustLoad(&config.ConfigOptions{
+				ConfigPath:   configPath,
+				DefaultsPath: defaultsPath,
+			})
+			cfg.Quota.MaxPendingJobs = tc.maxPendingJobs
+			cfg.Quota.MaxPendingBytes = tc.maxPendingBytes
+
+			mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+			mockConversionRepository.On("FindByFullpath", ctx, tc.info.Fullpath).Return(nil, db.ErrNotFound).Once()
+			if tc.usage != nil {
+				mockConversionRepository.On("CountPendingByClient", ctx, tc.info.Client).Return(tc.usage, nil).Once()
+			}
+			if tc.err == nil {
+				mockConversionRepository.On("Create", ctx, tc.info).Return(int64(1), nil).Once()
+			}
+			mockTxManager := dbMocks.NewMockTxManager(t)
+			mockTxManager.EXPECT().ReadCommitted(ctx, mock.Anything).
+				RunAndReturn(func(ctx context.Context, f db.TxHandler) error {
+					return f(ctx)
+				}).
+				Once()
+
+			serv := conversionq.NewService(cfg, mockTxManager, mockConversionRepository, local.NewStorage(""))
+
+			id, err := serv.Add(ctx, tc.info)
+
+			// The size of the source is counted against the quota
+			assert.Positive(t, tc.info.SourceSize)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				var quotaErr *conversionq.QuotaError
+				if assert.ErrorAs(t, err, &quotaErr) {
+					assert.Equal(t, cfg.Quota.RetryAfter, quotaErr.RetryAfter)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(1), id)
+			}
+
+			mockConversionRepository.AssertExpectations(t)
+			mockTxManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAddBatchToConversionQueue(t *testing.T) {
+	var (
+		infos = []*model.ConversionInfo{
+			{Fullpath: "/files/images/gen.jpg", Path: "/files/images", Filestem: "gen", Ext: "jpg"},
+			{Fullpath: "/files/videos/gen.mp4", Path: "/files/videos", Filestem: "gen", Ext: "mp4"},
+		}
+		unexpectedErr = errors.New("unexpected error")
+	)
+
+	type testcase struct {
+		name                     string
+		err                      error
+		infos                    []*model.ConversionInfo
+		added                    int64
+		mockConversionRepository func(tc *testcase) *repositoryMocks.MockConversionQueueRepository
+	}
+
+	cases := []testcase{
+		{
+			name: "Empty batch",
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				return repositoryMocks.NewMockConversionQueueRepository(t)
+			},
+		},
+		{
+			name:  "Failed insert",
+			err:   unexpectedErr,
+			infos: infos,
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+				mockConversionRepository.On("CreateBatch", mock.AnythingOfType("context.backgroundCtx"), tc.infos).Return(int64(0), unexpectedErr).Once()
+				return mockConversionRepository
+			},
+		},
+		{
+			name:  "Successful addition of a batch with a queued file",
+			infos: infos,
+			added: 1,
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+				mockConversionRepository.On("CreateBatch", mock.AnythingOfType("context.backgroundCtx"), tc.infos).Return(int64(1), nil).Once()
+				return mockConversionRepository
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockConversionRepository := tc.mockConversionRepository(&tc)
+
+			serv := conversionq.NewService(
+				config.MustLoad(&config.ConfigOptions{
+					ConfigPath:   configPath,
+					DefaultsPath: defaultsPath,
+				}),
+				dbMocks.NewMockTxManager(t),
+				mockConversionRepository,
+				local.NewStorage(""),
+			)
+
+			added, err := serv.AddBatch(ctx, tc.infos)
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.added, added)
+			}
+
+			mockConversionRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFindQueuedInConversionQueue(t *testing.T) {
+	var (
+		fullpaths = []string{"/files/images/gen.jpg", "/files/videos/gen.mp4"}
+	)
+
+	type testcase struct {
+		name                     string
+		fullpaths                []string
+		queued                   []string
+		mockConversionRepository func(tc *testcase) *repositoryMocks.MockConversionQueueRepository
+	}
+
+	cases := []testcase{
+		{
+			name: "No paths",
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				return repositoryMocks.NewMockConversionQueueRepository(t)
+			},
+		},
+		{
+			name:      "Successful lookup of queued paths",
+			fullpaths: fullpaths,
+			queued:    fullpaths[:1],
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+				mockConversionRepository.On("FindExistingFullpaths", mock.AnythingOfType("context.backgroundCtx"), tc.fullpaths).Return(tc.queued, nil).Once()
+				return mockConversionRepository
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockConversionRepository := tc.mockConversionRepository(&tc)
+
+			serv := conversionq.NewService(
+				config.MustLoad(&config.ConfigOptions{
+					ConfigPath:   configPath,
+					DefaultsPath: defaultsPath,
+				}),
+				dbMocks.NewMockTxManager(t),
+				mockConversionRepository,
+				local.NewStorage(""),
+			)
+
+			queued, err := serv.FindQueued(ctx, tc.fullpaths)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.queued, queued)
+
+			mockConversionRepository.AssertExpectations(t)
+		})
+	}
+}
+
 func TestPopFromConversionQueue(t *testing.T) {
 	var (
 		conversion = &model.Conversion{
@@ -305,6 +769,7 @@ func TestPopFromConversionQueue(t *testing.T) {
 				}),
 				mockTxManager,
 				mockConversionRepository,
+				local.NewStorage(""),
 			)
 
 			conversion, err := serv.Pop(ctx)
@@ -387,6 +852,7 @@ func TestGetFromConversionQueue(t *testing.T) {
 				}),
 				mockTxManager,
 				mockConversionRepository,
+				local.NewStorage(""),
 			)
 
 			conversion, err := serv.Get(ctx, tc.path)
@@ -404,6 +870,89 @@ func TestGetFromConversionQueue(t *testing.T) {
 	}
 }
 
+func TestListConversionQueue(t *testing.T) {
+	var (
+		conversions = []*model.Conversion{
+			{
+				Id:       1,
+				Fullpath: "/files/images/gen.jpg",
+				Status:   model.ConversionStatusCanceled,
+			},
+		}
+	)
+
+	type testcase struct {
+		name                     string
+		err                      error
+		statuses                 []int
+		limit                    uint64
+		conversions              []*model.Conversion
+		mockConversionRepository func(tc *testcase) *repositoryMocks.MockConversionQueueRepository
+	}
+
+	cases := []testcase{
+		{
+			name:     "No statuses to list",
+			statuses: nil,
+			limit:    10,
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				return repositoryMocks.NewMockConversionQueueRepository(t)
+			},
+		},
+		{
+			name:     "Failed to list conversions",
+			err:      fmt.Errorf("failed"),
+			statuses: []int{model.ConversionStatusPending},
+			limit:    10,
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+				mockConversionRepository.On("FindByStatus", mock.AnythingOfType("context.backgroundCtx"), tc.statuses, tc.limit).Return(nil, tc.err)
+				return mockConversionRepository
+			},
+		},
+		{
+			name:        "Successful list of conversions",
+			statuses:    []int{model.ConversionStatusCanceled},
+			limit:       10,
+			conversions: conversions,
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+				mockConversionRepository.On("FindByStatus", mock.AnythingOfType("context.backgroundCtx"), tc.statuses, tc.limit).Return(conversions, nil)
+				return mockConversionRepository
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockConversionRepository := tc.mockConversionRepository(&tc)
+
+			serv := conversionq.NewService(
+				config.MustLoad(&config.ConfigOptions{
+					ConfigPath:   configPath,
+					DefaultsPath: defaultsPath,
+				}),
+				dbMocks.NewMockTxManager(t),
+				mockConversionRepository,
+				local.NewStorage(""),
+			)
+
+			conversions, err := serv.List(ctx, tc.statuses, tc.limit)
+
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.conversions, conversions)
+			}
+
+			mockConversionRepository.AssertExpectations(t)
+		})
+	}
+}
+
 func TestMarkAsDoneForConversionQueue(t *testing.T) {
 	type testcase struct {
 		name                     string
@@ -457,6 +1006,7 @@ func TestMarkAsDoneForConversionQueue(t *testing.T) {
 				}),
 				mockTxManager,
 				mockConversionRepository,
+				local.NewStorage(""),
 			)
 
 			err := serv.MarkAsDone(ctx, tc.path)
@@ -529,6 +1079,7 @@ func TestMarkAsCanceledForConversionQueue(t *testing.T) {
 				}),
 				mockTxManager,
 				mockConversionRepository,
+				local.NewStorage(""),
 			)
 
 			err := serv.MarkAsCanceled(ctx, tc.path, tc.code)
@@ -544,3 +1095,341 @@ func TestMarkAsCanceledForConversionQueue(t *testing.T) {
 		})
 	}
 }
+
+func TestFindSourceInConversionQueue(t *testing.T) {
+	var (
+		source = &model.Conversion{
+			Fullpath: "/files/images/photo.jpg",
+			Path:     "/files/images",
+			Filestem: "photo",
+			Ext:      "jpg",
+			ConvertTo: []model.ConvertTo{
+				{
+					Ext:      "jpg",
+					Optional: map[string]interface{}{"suffix": ".small"},
+				},
+				{
+					Ext:      "webp",
+					Optional: map[string]interface{}{"replace_orig_ext": true},
+				},
+			},
+		}
+		unknownErr = fmt.Errorf("unknown error")
+		notFound   = func(mockConversionRepository *repositoryMocks.MockConversionQueueRepository) {
+			mockConversionRepository.On("FindByFullpath", mock.AnythingOfType("context.backgroundCtx"), mock.Anything).Return(nil, db.ErrNotFound)
+		}
+	)
+
+	type testcase struct {
+		name                     string
+		err                      error
+		path                     string
+		source                   *model.Conversion
+		mockConversionRepository func(tc *testcase) *repositoryMocks.MockConversionQueueRepository
+	}
+
+	cases := []testcase{
+		{
+			name:   "Output with suffix",
+			path:   "/files/images/photo.jpg.small.jpg",
+			source: source,
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+				mockConversionRepository.On("FindByFullpath", mock.AnythingOfType("context.backgroundCtx"), "/files/images/photo.jpg").Return(source, nil)
+				notFound(mockConversionRepository)
+				return mockConversionRepository
+			},
+		},
+		{
+			name:   "Output with replaced extension",
+			path:   "/files/images/photo.webp",
+			source: source,
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+				mockConversionRepository.On("FindByFullpath", mock.AnythingOfType("context.backgroundCtx"), "/files/images/photo.jpg").Return(source, nil)
+				notFound(mockConversionRepository)
+				return mockConversionRepository
+			},
+		},
+		{
+			name: "Sibling that is not an output",
+			err:  db.ErrNotFound,
+			path: "/files/images/photo.png",
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+				mockConversionRepository.On("FindByFullpath", mock.AnythingOfType("context.backgroundCtx"), "/files/images/photo.jpg").Return(source, nil)
+				notFound(mockConversionRepository)
+				return mockConversionRepository
+			},
+		},
+		{
+			name: "Unknown error",
+			err:  unknownErr,
+			path: "/files/images/photo.webp",
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+				mockConversionRepository.On("FindByFullpath", mock.AnythingOfType("context.backgroundCtx"), mock.Anything).Return(nil, unknownErr)
+				return mockConversionRepository
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockConversionRepository := tc.mockConversionRepository(&tc)
+
+			serv := conversionq.NewService(
+				config.MustLoad(&config.ConfigOptions{
+					ConfigPath:   configPath,
+					DefaultsPath: defaultsPath,
+				}),
+				dbMocks.NewMockTxManager(t),
+				mockConversionRepository,
+				local.NewStorage(""),
+			)
+
+			conversion, err := serv.FindSource(ctx, tc.path)
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				assert.Nil(t, conversion)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.source, conversion)
+			}
+		})
+	}
+}
+
+func TestFindSourcesInConversionQueue(t *testing.T) {
+	var (
+		source = &model.Conversion{
+			Fullpath: "/files/images/photo.jpg",
+			Path:     "/files/images",
+			Filestem: "photo",
+			Ext:      "jpg",
+			ConvertTo: []model.ConvertTo{
+				{
+					Ext:      "jpg",
+					Optional: map[string]interface{}{"suffix": ".small"},
+				},
+				{
+					Ext:      "webp",
+					Optional: map[string]interface{}{"replace_orig_ext": true},
+				},
+			},
+		}
+		unknownErr = fmt.Errorf("unknown error")
+		// Candidates of all paths are looked up at once
+		candidatesOf = func(candidates ...string) interface{} {
+			return mock.MatchedBy(func(fullpaths []string) bool {
+				for _, candidate := range candidates {
+					if !slices.Contains(fullpaths, candidate) {
+						return false
+					}
+				}
+				return true
+			})
+		}
+	)
+
+	type testcase struct {
+		name                     string
+		err                      error
+		paths                    []string
+		sources                  map[string]*model.Conversion
+		mockConversionRepository func(tc *testcase) *repositoryMocks.MockConversionQueueRepository
+	}
+
+	cases := []testcase{
+		{
+			name:    "Paths without candidate sources",
+			paths:   []string{"/files/images/photo"},
+			sources: map[string]*model.Conversion{},
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				return repositoryMocks.NewMockConversionQueueRepository(t)
+			},
+		},
+		{
+			name:  "Outputs among other files",
+			paths: []string{"/files/images/photo.jpg.small.jpg", "/files/images/photo.webp", "/files/images/photo.png", "/files/images/other.jpg"},
+			sources: map[string]*model.Conversion{
+				"/files/images/photo.jpg.small.jpg": source,
+				"/files/images/photo.webp":          source,
+			},
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+				mockConversionRepository.
+					On("FindByFullpaths", mock.AnythingOfType("context.backgroundCtx"), candidatesOf("/files/images/photo.jpg", "/files/images/other.png")).
+					Return([]*model.Conversion{source}, nil).
+					Once()
+				return mockConversionRepository
+			},
+		},
+		{
+			name:  "Unknown error",
+			err:   unknownErr,
+			paths: []string{"/files/images/photo.webp"},
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+				mockConversionRepository.On("FindByFullpaths", mock.AnythingOfType("context.backgroundCtx"), mock.Anything).Return(nil, unknownErr).Once()
+				return mockConversionRepository
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockConversionRepository := tc.mockConversionRepository(&tc)
+
+			serv := conversionq.NewService(
+				config.MustLoad(&config.ConfigOptions{
+					ConfigPath:   configPath,
+					DefaultsPath: defaultsPath,
+				}),
+				dbMocks.NewMockTxManager(t),
+				mockConversionRepository,
+				local.NewStorage(""),
+			)
+
+			sources, err := serv.FindSources(ctx, tc.paths)
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				assert.Nil(t, sources)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.sources, sources)
+			}
+
+			mockConversionRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestIsOutputSharedInConversionQueue(t *testing.T) {
+	var (
+		hash       = "3fa2b1c9e0d4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0"
+		dest       = "/public/3fa2b1c9.webp"
+		unknownErr = fmt.Errorf("unknown error")
+		convertTo  = []model.ConvertTo{
+			{
+				Ext:      "webp",
+				Optional: map[string]interface{}{"template": "{hash}.{fmt}"},
+			},
+		}
+		conversion = &model.Conversion{
+			Fullpath:   "/files/images/removed.jpg",
+			Path:       "/files/images",
+			Filestem:   "removed",
+			Ext:        "jpg",
+			ConvertTo:  convertTo,
+			Status:     model.ConversionStatusDone,
+			SourceHash: hash,
+		}
+		existing = &model.Conversion{
+			Fullpath:   "/files/images/gen.jpg",
+			Path:       "/files/images",
+			Filestem:   "gen",
+			Ext:        "jpg",
+			ConvertTo:  convertTo,
+			Status:     model.ConversionStatusDone,
+			SourceHash: hash,
+		}
+		absent = &model.Conversion{
+			Fullpath:   "/files/absent.jpg",
+			Path:       "/files",
+			Filestem:   "absent",
+			Ext:        "jpg",
+			ConvertTo:  convertTo,
+			Status:     model.ConversionStatusDone,
+			SourceHash: hash,
+		}
+		namedAfterPath = &model.Conversion{
+			Fullpath:   "/files/images/gen.jpg",
+			Path:       "/files/images",
+			Filestem:   "gen",
+			Ext:        "jpg",
+			ConvertTo:  []model.ConvertTo{{Ext: "webp"}},
+			Status:     model.ConversionStatusDone,
+			SourceHash: hash,
+		}
+	)
+
+	type testcase struct {
+		name       string
+		err        error
+		shared     bool
+		conversion *model.Conversion
+		duplicates []*model.Conversion
+		repoErr    error
+	}
+
+	cases := []testcase{
+		{
+			name:       "Output produced by an existing source",
+			shared:     true,
+			conversion: conversion,
+			duplicates: []*model.Conversion{conversion, existing},
+		},
+		{
+			name:       "Output produced by removed sources only",
+			conversion: conversion,
+			duplicates: []*model.Conversion{absent, conversion},
+		},
+		{
+			name:       "Outputs named after the paths",
+			conversion: conversion,
+			duplicates: []*model.Conversion{conversion, namedAfterPath},
+		},
+		{
+			name:       "Unknown hash",
+			conversion: &model.Conversion{Fullpath: conversion.Fullpath, ConvertTo: convertTo},
+		},
+		{
+			name:       "Unknown error",
+			err:        unknownErr,
+			conversion: conversion,
+			repoErr:    unknownErr,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+			if tc.conversion.SourceHash != "" {
+				mockConversionRepository.On("FindDoneBySourceHash", ctx, hash).Return(tc.duplicates, tc.repoErr).Once()
+			}
+
+			cfg := config.MustLoad(&config.ConfigOptions{
+				ConfigPath:   configPath,
+				DefaultsPath: defaultsPath,
+			})
+			cfg.Output.Root = "public"
+
+			serv := conversionq.NewService(
+				cfg,
+				dbMocks.NewMockTxManager(t),
+				mockConversionRepository,
+				local.NewStorage(""),
+			)
+
+			shared, err := serv.IsOutputShared(ctx, tc.conversion, dest)
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.shared, shared)
+
+			mockConversionRepository.AssertExpectations(t)
+		})
+	}
+}
@@ -82,6 +82,94 @@ func (s *serv) Add(ctx context.Context, info *model.DeletionInfo) (int64, error)
 	return id, nil
 }
 
+// AddRecursive enqueues the deletion of every converted file under the directory with the options of the info.
+// Returns the number of enqueued files.
+func (s *serv) AddRecursive(ctx context.Context, info *model.DeletionInfo) (int64, error) {
+	var count int64
+
+	err := s.txManager.ReadCommitted(ctx, func(ctx context.Context) error {
+		var errTx error
+		count, errTx = s.addRecursive(ctx, info)
+
+		return errTx
+	})
+
+	if err != nil {
+		return -1, err
+	}
+
+	return count, nil
+}
+
+// AddMany enqueues the deletions in a single transaction, the recursive ones are expanded as with AddRecursive.
+// A deletion that cannot be enqueued doesn't prevent enqueuing the others, its error is reported in its result.
+// An unexpected database error rolls back the whole batch and is returned.
+func (s *serv) AddMany(ctx context.Context, infos []*model.DeletionInfo) ([]model.BatchResult, error) {
+	results := make([]model.BatchResult, len(infos))
+
+	err := s.txManager.ReadCommitted(ctx, func(ctx context.Context) error {
+		for i, info := range infos {
+			var (
+				id, count int64
+				errTx     error
+			)
+			if info.Recursive {
+				count, errTx = s.addRecursive(ctx, info)
+			} else {
+				errTx = AddTransaction(s, &id, info)(ctx)
+			}
+			if errors.Is(errTx, ErrPathAlreadyExist) || errors.Is(errTx, ErrFileDoesNotExist) {
+				results[i] = model.BatchResult{Id: -1, Err: errTx}
+				continue
+			}
+			if errTx != nil {
+				return errTx
+			}
+			results[i] = model.BatchResult{Id: id, Count: count}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// addRecursive must be called within a transaction
+func (s *serv) addRecursive(ctx context.Context, info *model.DeletionInfo) (int64, error) {
+	total, err := s.conversionRepository.CountByPrefix(ctx, info.Fullpath)
+	if err != nil {
+		return -1, err
+	}
+	if total == 0 {
+		return -1, fmt.Errorf("deletion failed for '%s': %w", info.Fullpath, ErrFileDoesNotExist)
+	}
+
+	count, err := s.deletionRepository.CreateByPrefix(ctx, info.Fullpath, info)
+	if err != nil {
+		return -1, err
+	}
+	// Every file under the directory is already being deleted
+	if count == 0 {
+		return -1, fmt.Errorf("deletion failed for '%s': %w", info.Fullpath, ErrPathAlreadyExist)
+	}
+
+	return count, nil
+}
+
+// Purge removes both the conversion and the deletion of the file, so the path can be enqueued again.
+func (s *serv) Purge(ctx context.Context, fullpath string) error {
+	return s.txManager.ReadCommitted(ctx, func(ctx context.Context) error {
+		if err := s.conversionRepository.Delete(ctx, fullpath); err != nil {
+			return err
+		}
+		return s.deletionRepository.Delete(ctx, fullpath)
+	})
+}
+
 func (s *serv) Pop(ctx context.Context) (*model.Deletion, error) {
 	return s.deletionRepository.FindOldestQueued(ctx)
 }
@@ -97,3 +185,7 @@ func (s *serv) MarkAsDone(ctx context.Context, fullpath string) error {
 func (s *serv) MarkAsCanceled(ctx context.Context, fullpath string, code uint32) error {
 	return s.deletionRepository.MarkAsCanceled(ctx, fullpath, code)
 }
+
+func (s *serv) CountPending(ctx context.Context) (int64, error) {
+	return s.deletionRepository.CountPending(ctx)
+}
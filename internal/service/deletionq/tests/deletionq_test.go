@@ -466,3 +466,250 @@ func TestMarkAsCanceledForDeletionQueue(t *testing.T) {
 		})
 	}
 }
+
+func TestAddRecursiveToDeletionQueue(t *testing.T) {
+	var (
+		deletionInfo = &model.DeletionInfo{
+			Fullpath:     "/files/images",
+			DeleteSource: true,
+		}
+	)
+
+	type testcase struct {
+		name                     string
+		err                      error
+		count                    int64
+		mockDeletionRepository   func(tc *testcase) *repositoryMocks.MockDeletionQueueRepository
+		mockConversionRepository func(tc *testcase) *repositoryMocks.MockConversionQueueRepository
+	}
+
+	cases := []testcase{
+		{
+			name: "Directory without conversions",
+			err:  deletionq.ErrFileDoesNotExist,
+			mockDeletionRepository: func(tc *testcase) *repositoryMocks.MockDeletionQueueRepository {
+				mockDeletionRepository := repositoryMocks.NewMockDeletionQueueRepository(t)
+				return mockDeletionRepository
+			},
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+				mockConversionRepository.On("CountByPrefix", ctx, deletionInfo.Fullpath).Return(int64(0), nil).Once()
+				return mockConversionRepository
+			},
+		},
+		{
+			name: "Every file is already being deleted",
+			err:  deletionq.ErrPathAlreadyExist,
+			mockDeletionRepository: func(tc *testcase) *repositoryMocks.MockDeletionQueueRepository {
+				mockDeletionRepository := repositoryMocks.NewMockDeletionQueueRepository(t)
+				mockDeletionRepository.On("CreateByPrefix", ctx, deletionInfo.Fullpath, deletionInfo).Return(int64(0), nil).Once()
+				return mockDeletionRepository
+			},
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+				mockConversionRepository.On("CountByPrefix", ctx, deletionInfo.Fullpath).Return(int64(2), nil).Once()
+				return mockConversionRepository
+			},
+		},
+		{
+			name:  "Successful recursive add to deletion queue",
+			count: 2,
+			mockDeletionRepository: func(tc *testcase) *repositoryMocks.MockDeletionQueueRepository {
+				mockDeletionRepository := repositoryMocks.NewMockDeletionQueueRepository(t)
+				mockDeletionRepository.On("CreateByPrefix", ctx, deletionInfo.Fullpath, deletionInfo).Return(tc.count, nil).Once()
+				return mockDeletionRepository
+			},
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+				mockConversionRepository.On("CountByPrefix", ctx, deletionInfo.Fullpath).Return(int64(3), nil).Once()
+				return mockConversionRepository
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockDeletionRepository := tc.mockDeletionRepository(&tc)
+			mockConversionRepository := tc.mockConversionRepository(&tc)
+			mockTxManager := dbMocks.NewMockTxManager(t)
+			mockTxManager.EXPECT().ReadCommitted(ctx, mock.Anything).
+				RunAndReturn(func(ctx context.Context, f db.TxHandler) error {
+					return f(ctx)
+				}).
+				Once()
+
+			serv := deletionq.NewService(
+				config.MustLoad(&config.ConfigOptions{
+					ConfigPath:   configPath,
+					DefaultsPath: defaultsPath,
+				}),
+				logger,
+				mockTxManager,
+				mockDeletionRepository,
+				mockConversionRepository,
+			)
+
+			count, err := serv.AddRecursive(ctx, deletionInfo)
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.count, count)
+			}
+
+			mockDeletionRepository.AssertExpectations(t)
+			mockConversionRepository.AssertExpectations(t)
+			mockTxManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAddManyToDeletionQueue(t *testing.T) {
+	var (
+		infos = []*model.DeletionInfo{
+			{Fullpath: "/files/images/gen.jpg", DeleteSource: true},
+			{Fullpath: "/files/images/absent.jpg"},
+			{Fullpath: "/files/videos", Recursive: true},
+			{Fullpath: "/files/images/queued.jpg"},
+		}
+		unexpectedErr = errors.New("unexpected error")
+	)
+
+	type testcase struct {
+		name string
+		err  error
+		// Expected results of the items, the errors are compared with errors.Is
+		results                  []model.BatchResult
+		mockDeletionRepository   func(tc *testcase) *repositoryMocks.MockDeletionQueueRepository
+		mockConversionRepository func(tc *testcase) *repositoryMocks.MockConversionQueueRepository
+	}
+
+	cases := []testcase{
+		{
+			name: "Items are enqueued independently",
+			results: []model.BatchResult{
+				{Id: 1},
+				{Id: -1, Err: deletionq.ErrFileDoesNotExist},
+				{Count: 3},
+				{Id: -1, Err: deletionq.ErrPathAlreadyExist},
+			},
+			mockDeletionRepository: func(tc *testcase) *repositoryMocks.MockDeletionQueueRepository {
+				mockDeletionRepository := repositoryMocks.NewMockDeletionQueueRepository(t)
+				mockDeletionRepository.On("FindByFullpath", ctx, infos[0].Fullpath).Return(nil, db.ErrNotFound).Once()
+				mockDeletionRepository.On("Create", ctx, infos[0]).Return(int64(1), nil).Once()
+				mockDeletionRepository.On("CreateByPrefix", ctx, infos[2].Fullpath, infos[2]).Return(int64(3), nil).Once()
+				mockDeletionRepository.On("FindByFullpath", ctx, infos[3].Fullpath).Return(&model.Deletion{Id: 2}, nil).Once()
+				return mockDeletionRepository
+			},
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+				mockConversionRepository.On("FindByFullpath", ctx, infos[0].Fullpath).Return(&model.Conversion{Id: 1}, nil).Once()
+				mockConversionRepository.On("FindByFullpath", ctx, infos[1].Fullpath).Return(nil, db.ErrNotFound).Once()
+				mockConversionRepository.On("CountByPrefix", ctx, infos[2].Fullpath).Return(int64(3), nil).Once()
+				mockConversionRepository.On("FindByFullpath", ctx, infos[3].Fullpath).Return(&model.Conversion{Id: 2}, nil).Once()
+				return mockConversionRepository
+			},
+		},
+		{
+			name: "Transaction is rolled back on unexpected errors",
+			err:  unexpectedErr,
+			mockDeletionRepository: func(tc *testcase) *repositoryMocks.MockDeletionQueueRepository {
+				mockDeletionRepository := repositoryMocks.NewMockDeletionQueueRepository(t)
+				mockDeletionRepository.On("FindByFullpath", ctx, infos[0].Fullpath).Return(nil, db.ErrNotFound).Once()
+				mockDeletionRepository.On("Create", ctx, infos[0]).Return(int64(-1), unexpectedErr).Once()
+				return mockDeletionRepository
+			},
+			mockConversionRepository: func(tc *testcase) *repositoryMocks.MockConversionQueueRepository {
+				mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+				mockConversionRepository.On("FindByFullpath", ctx, infos[0].Fullpath).Return(&model.Conversion{Id: 1}, nil).Once()
+				return mockConversionRepository
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockDeletionRepository := tc.mockDeletionRepository(&tc)
+			mockConversionRepository := tc.mockConversionRepository(&tc)
+			mockTxManager := dbMocks.NewMockTxManager(t)
+			mockTxManager.EXPECT().ReadCommitted(ctx, mock.Anything).
+				RunAndReturn(func(ctx context.Context, f db.TxHandler) error {
+					return f(ctx)
+				}).
+				Once()
+
+			serv := deletionq.NewService(
+				config.MustLoad(&config.ConfigOptions{
+					ConfigPath:   configPath,
+					DefaultsPath: defaultsPath,
+				}),
+				logger,
+				mockTxManager,
+				mockDeletionRepository,
+				mockConversionRepository,
+			)
+
+			results, err := serv.AddMany(ctx, infos)
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				assert.Nil(t, results)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, results, len(tc.results))
+				for i, result := range results {
+					assert.Equal(t, tc.results[i].Id, result.Id)
+					assert.Equal(t, tc.results[i].Count, result.Count)
+					if tc.results[i].Err == nil {
+						assert.NoError(t, result.Err)
+					} else {
+						assert.ErrorIs(t, result.Err, tc.results[i].Err)
+					}
+				}
+			}
+
+			mockDeletionRepository.AssertExpectations(t)
+			mockConversionRepository.AssertExpectations(t)
+			mockTxManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPurgeDeletionQueue(t *testing.T) {
+	var (
+		fullpath = "/files/images/gen.jpg"
+	)
+
+	mockDeletionRepository := repositoryMocks.NewMockDeletionQueueRepository(t)
+	mockDeletionRepository.On("Delete", ctx, fullpath).Return(nil).Once()
+	mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+	mockConversionRepository.On("Delete", ctx, fullpath).Return(nil).Once()
+	mockTxManager := dbMocks.NewMockTxManager(t)
+	mockTxManager.EXPECT().ReadCommitted(ctx, mock.Anything).
+		RunAndReturn(func(ctx context.Context, f db.TxHandler) error {
+			return f(ctx)
+		}).
+		Once()
+
+	serv := deletionq.NewService(
+		config.MustLoad(&config.ConfigOptions{
+			ConfigPath:   configPath,
+			DefaultsPath: defaultsPath,
+		}),
+		logger,
+		mockTxManager,
+		mockDeletionRepository,
+		mockConversionRepository,
+	)
+
+	assert.NoError(t, serv.Purge(ctx, fullpath))
+
+	mockDeletionRepository.AssertExpectations(t)
+	mockConversionRepository.AssertExpectations(t)
+	mockTxManager.AssertExpectations(t)
+}
@@ -0,0 +1,140 @@
+package diskguard
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/constants"
+	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/chistyakoviv/converter/internal/storage"
+)
+
+// Returns the number of free bytes on the filesystem of the directory, see file.FreeSpace
+type FreeSpaceFunc func(dir string) (uint64, error)
+
+type serv struct {
+	cfg       *config.Config
+	logger    *slog.Logger
+	freeSpace FreeSpaceFunc
+	mu        sync.Mutex
+	state     model.DiskState
+}
+
+func NewService(
+	cfg *config.Config,
+	logger *slog.Logger,
+	storage storage.Storage,
+	freeSpace FreeSpaceFunc,
+) service.DiskGuardService {
+	return &serv{
+		cfg:       cfg,
+		logger:    logger,
+		freeSpace: freeSpace,
+		state: model.DiskState{
+			Enabled: cfg.Disk.Enabled,
+			Path:    outputDir(cfg, storage),
+			Reserve: cfg.Disk.Reserve,
+		},
+	}
+}
+
+// Returns the directory the converters write to. The converted files of a remote storage
+// are written to temporary directories before they are uploaded.
+func outputDir(cfg *config.Config, s storage.Storage) string {
+	local, ok := s.(storage.Local)
+	if !ok {
+		return os.TempDir()
+	}
+
+	root := cfg.Naming().Root
+	if root == "" {
+		root = constants.FilesRootDir
+	}
+	dir, err := local.Path("/" + root)
+	if err != nil {
+		return root
+	}
+	return dir
+}
+
+// Check reports ErrInsufficientSpace if writing a file of the given size leaves less free space than the reserve.
+// The queue is then paused until the file fits.
+func (s *serv) Check(size uint64) error {
+	if !s.cfg.Disk.Enabled {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.check(size)
+}
+
+func (s *serv) check(size uint64) error {
+	free, err := s.freeSpace(s.state.Path)
+
+	checkedAt := time.Now()
+	s.state.CheckedAt = &checkedAt
+	s.state.Error = ""
+	if err != nil {
+		// The conversions are not blocked by a filesystem that cannot be checked
+		s.state.Error = err.Error()
+		s.logger.Error("failed to check free disk space", slog.String("path", s.state.Path), slogger.Err(err))
+		return nil
+	}
+	s.state.Free = free
+
+	if free < s.cfg.Disk.Reserve || free-s.cfg.Disk.Reserve < size {
+		if !s.state.Paused {
+			s.logger.Warn(
+				"conversions are paused due to low disk space",
+				slog.String("path", s.state.Path),
+				slog.Uint64("free", free),
+				slog.Uint64("reserve", s.cfg.Disk.Reserve),
+				slog.Uint64("required", size),
+			)
+		}
+		s.state.Paused = true
+		s.state.Required = size
+		return fmt.Errorf("%w: %d bytes free, %d bytes required and %d bytes reserved", ErrInsufficientSpace, free, size, s.cfg.Disk.Reserve)
+	}
+
+	if s.state.Paused {
+		s.logger.Info("conversions are resumed", slog.String("path", s.state.Path), slog.Uint64("free", free))
+	}
+	s.state.Paused = false
+	s.state.Required = 0
+	return nil
+}
+
+func (s *serv) IsPaused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state.Paused
+}
+
+// Resume checks the free space of the paused queue again, the file that did not fit must fit now.
+// Reports whether the queue is resumed.
+func (s *serv) Resume() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.state.Paused {
+		return false
+	}
+	return s.check(s.state.Required) == nil
+}
+
+func (s *serv) State() model.DiskState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state
+}
@@ -0,0 +1,7 @@
+package diskguard
+
+import "errors"
+
+var (
+	ErrInsufficientSpace = errors.New("insufficient disk space")
+)
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/file"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/service/diskguard"
+	"github.com/chistyakoviv/converter/internal/storage/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const reserve = 1000
+
+func newConfig(enabled bool) *config.Config {
+	return &config.Config{
+		Disk: config.Disk{
+			Enabled:     enabled,
+			Reserve:     reserve,
+			OutputRatio: 1,
+		},
+	}
+}
+
+func TestDiskGuard(t *testing.T) {
+	var free atomic.Uint64
+	free.Store(reserve + 500)
+	root := t.TempDir()
+
+	var checkedDir string
+	guard := diskguard.NewService(newConfig(true), dummy.NewDummyLogger(), local.NewStorage(root), func(dir string) (uint64, error) {
+		checkedDir = dir
+		return free.Load(), nil
+	})
+
+	// The converted files are written next to the sources
+	require.NoError(t, guard.Check(500))
+	assert.Equal(t, filepath.Join(root, "files"), checkedDir)
+	assert.False(t, guard.IsPaused())
+	assert.False(t, guard.Resume())
+
+	// The file does not fit without touching the reserve
+	assert.ErrorIs(t, guard.Check(501), diskguard.ErrInsufficientSpace)
+	assert.True(t, guard.IsPaused())
+
+	state := guard.State()
+	assert.True(t, state.Paused)
+	assert.Equal(t, uint64(reserve+500), state.Free)
+	assert.Equal(t, uint64(reserve), state.Reserve)
+	assert.Equal(t, uint64(501), state.Required)
+	assert.NotNil(t, state.CheckedAt)
+
+	// The queue is resumed only once the file fits
+	free.Store(reserve + 501 - 1)
+	assert.False(t, guard.Resume())
+	assert.True(t, guard.IsPaused())
+
+	free.Store(reserve + 501)
+	assert.True(t, guard.Resume())
+	assert.False(t, guard.IsPaused())
+	assert.Zero(t, guard.State().Required)
+
+	// Free space below the reserve pauses the queue regardless of the size
+	free.Store(reserve - 1)
+	assert.ErrorIs(t, guard.Check(0), diskguard.ErrInsufficientSpace)
+	assert.True(t, guard.IsPaused())
+}
+
+func TestDiskGuardDoesNotBlockConversions(t *testing.T) {
+	t.Run("Disabled guard", func(t *testing.T) {
+		guard := diskguard.NewService(newConfig(false), dummy.NewDummyLogger(), local.NewStorage(t.TempDir()), func(string) (uint64, error) {
+			return 0, nil
+		})
+
+		assert.NoError(t, guard.Check(1))
+		assert.False(t, guard.IsPaused())
+		assert.False(t, guard.State().Enabled)
+	})
+
+	t.Run("Filesystem cannot be checked", func(t *testing.T) {
+		guard := diskguard.NewService(newConfig(true), dummy.NewDummyLogger(), local.NewStorage(t.TempDir()), func(string) (uint64, error) {
+			return 0, errors.New("statfs failed")
+		})
+
+		assert.NoError(t, guard.Check(1))
+		assert.False(t, guard.IsPaused())
+		assert.Equal(t, "statfs failed", guard.State().Error)
+	})
+
+	t.Run("Free space of a missing directory", func(t *testing.T) {
+		free, err := file.FreeSpace(filepath.Join(t.TempDir(), "missing", "dir"))
+		require.NoError(t, err)
+		assert.Positive(t, free)
+	})
+}
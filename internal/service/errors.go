@@ -13,6 +13,9 @@ const (
 	ErrUnableToConvertFile
 	ErrInvalidConversionFormat
 	ErrWrongSourceFile
+	ErrInvalidOutputName
+	// The conversion stays pending until the disk space is freed
+	ErrInsufficientDiskSpace
 )
 
 // Deletion Errors: 100 - 199
@@ -0,0 +1,7 @@
+package gc
+
+import "errors"
+
+var (
+	ErrAlreadyRunning = errors.New("garbage collection in progress")
+)
@@ -0,0 +1,371 @@
+package gc
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/db"
+	"github.com/chistyakoviv/converter/internal/file"
+	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/repository"
+	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/chistyakoviv/converter/internal/storage"
+)
+
+const (
+	// Number of conversions checked for missing sources at once
+	pageSize = 1000
+	// Items beyond the limit are counted but not listed in the report
+	maxReportItems = 1000
+)
+
+type serv struct {
+	cfg                  *config.Config
+	logger               *slog.Logger
+	conversionRepository repository.ConversionQueueRepository
+	deletionRepository   repository.DeletionQueueRepository
+	// Tells the converted files shared by the sources of the same content
+	conversionQueueService service.ConversionQueueService
+	storage                storage.Storage
+	// Periodic and on demand runs must not overlap
+	running atomic.Bool
+}
+
+func NewService(
+	cfg *config.Config,
+	logger *slog.Logger,
+	conversionRepository repository.ConversionQueueRepository,
+	deletionRepository repository.DeletionQueueRepository,
+	conversionQueueService service.ConversionQueueService,
+	storage storage.Storage,
+) service.GCService {
+	return &serv{
+		cfg:                    cfg,
+		logger:                 logger,
+		conversionRepository:   conversionRepository,
+		deletionRepository:     deletionRepository,
+		conversionQueueService: conversionQueueService,
+		storage:                storage,
+	}
+}
+
+// Collect removes the outputs and conversions of the missing sources, stale temporary files under the local directory
+// and processed queue rows older than the retention periods. Each item is logged before it is removed,
+// in a dry run the items are only logged and reported.
+func (s *serv) Collect(ctx context.Context, rootDir string, opts model.GCOptions) (*model.GCReport, error) {
+	op := "service.GCService.Collect"
+
+	if !s.running.CompareAndSwap(false, true) {
+		return nil, ErrAlreadyRunning
+	}
+	defer s.running.Store(false)
+
+	logger := s.logger.With(slog.String("op", op), slog.Bool("dry_run", opts.DryRun))
+
+	report := &model.GCReport{
+		DryRun:    opts.DryRun,
+		Removed:   make(map[string]int64),
+		StartedAt: time.Now(),
+	}
+
+	if err := s.collectMissingSources(ctx, logger, report); err != nil {
+		return nil, err
+	}
+	// Converted files written to the output root leave their temporary files there
+	var outputRoot string
+	if root := s.cfg.Naming().Root; root != "" && s.cfg.Storage.Driver == config.StorageLocal {
+		outputRoot = filepath.Clean(strings.TrimPrefix(root, "/"))
+	}
+	if err := s.collectTmpFiles(ctx, logger, rootDir, outputRoot, false, report); err != nil {
+		return nil, err
+	}
+	// Only the converted files are written to the output root
+	if outputRoot != "" {
+		if _, err := os.Stat(outputRoot); err == nil {
+			if err = s.collectTmpFiles(ctx, logger, outputRoot, "", true, report); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := s.collectDoneConversions(ctx, logger, report); err != nil {
+		return nil, err
+	}
+	if err := s.collectDoneDeletions(ctx, logger, report); err != nil {
+		return nil, err
+	}
+
+	report.FinishedAt = time.Now()
+
+	attrs := make([]any, 0, len(report.Removed)+1)
+	for category, count := range report.Removed {
+		attrs = append(attrs, slog.Int64(category, count))
+	}
+	attrs = append(attrs, slog.Int64("errors", report.Errors))
+	logger.Info("garbage collection finished", attrs...)
+
+	return report, nil
+}
+
+// Walks the whole conversion queue in pages looking for the sources removed outside the API
+func (s *serv) collectMissingSources(ctx context.Context, logger *slog.Logger, report *model.GCReport) error {
+	var lastId int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		conversions, err := s.conversionRepository.FindAfterId(ctx, lastId, pageSize)
+		if err != nil {
+			return err
+		}
+		if len(conversions) == 0 {
+			return nil
+		}
+		lastId = conversions[len(conversions)-1].Id
+
+		for _, conversion := range conversions {
+			// Only a source that is known to be absent is collected
+			_, err := s.storage.Stat(ctx, conversion.Fullpath)
+			if !errors.Is(err, storage.ErrNotExist) {
+				continue
+			}
+
+			// A pending deletion removes the outputs by itself
+			deletion, err := s.deletionRepository.FindByFullpath(ctx, conversion.Fullpath)
+			if err != nil && !errors.Is(err, db.ErrNotFound) {
+				return err
+			}
+			if err == nil && deletion.IsPending() {
+				continue
+			}
+
+			if !s.collectOutputs(ctx, logger, conversion, report) {
+				// Keep the conversion so its outputs are removed by the next run
+				continue
+			}
+
+			s.addItem(logger, report, model.GCItem{Category: model.GCCategoryMissingSource, Path: conversion.Fullpath})
+			if report.DryRun {
+				continue
+			}
+			if err = s.conversionRepository.Delete(ctx, conversion.Fullpath); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Removes the outputs of the conversion, reports whether all of them are gone
+func (s *serv) collectOutputs(ctx context.Context, logger *slog.Logger, conversion *model.Conversion, report *model.GCReport) bool {
+	ok := true
+	naming := s.cfg.Naming()
+	for _, entry := range conversion.ConvertTo {
+		// Files named after the hash are not produced until the hash is computed
+		if naming.NeedsHash(entry) && conversion.SourceHash == "" {
+			continue
+		}
+		dest, err := conversion.DestinationFullpath(entry, naming)
+		if err != nil {
+			s.addError(logger, report, model.GCCategoryOrphanOutput, conversion.Fullpath, err)
+			ok = false
+			continue
+		}
+
+		_, err = s.storage.Stat(ctx, dest)
+		if errors.Is(err, storage.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			s.addError(logger, report, model.GCCategoryOrphanOutput, dest, err)
+			ok = false
+			continue
+		}
+
+		// The file is still needed by another source of the same content
+		shared, err := s.conversionQueueService.IsOutputShared(ctx, conversion, dest)
+		if err != nil {
+			s.addError(logger, report, model.GCCategoryOrphanOutput, dest, err)
+			ok = false
+			continue
+		}
+		if shared {
+			continue
+		}
+
+		s.addItem(logger, report, model.GCItem{Category: model.GCCategoryOrphanOutput, Path: dest})
+		if report.DryRun {
+			continue
+		}
+		if err = s.storage.Remove(ctx, dest); err != nil {
+			s.addError(logger, report, model.GCCategoryOrphanOutput, dest, err)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// Removes the temporary files left by interrupted conversions.
+// Files younger than the retention may still be written by a running conversion.
+// Outside of a dedicated directory only the temporary files of the known outputs and sources are removed,
+// other files, e.g. an uploaded photo.tmp.jpg, merely look like them. The skipped directory is walked on its own.
+func (s *serv) collectTmpFiles(
+	ctx context.Context,
+	logger *slog.Logger,
+	rootDir string,
+	skipDir string,
+	dedicated bool,
+	report *model.GCReport,
+) error {
+	cutoff := time.Now().Add(-s.cfg.GC.TmpRetention)
+
+	return filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			logger.Error("error accessing path", slog.String("path", path), slogger.Err(err))
+			return nil
+		}
+		if d.IsDir() {
+			if skipDir != "" && filepath.Clean(path) == skipDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !file.IsTmpFilePath(path) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			logger.Error("failed to get file info", slog.String("path", path), slogger.Err(err))
+			return nil
+		}
+		if info.ModTime().After(cutoff) {
+			return nil
+		}
+
+		fullpath, err := file.Trimwd(file.EnsureLeadingSlash(path))
+		if err != nil {
+			s.addError(logger, report, model.GCCategoryTmpFile, path, err)
+			return nil
+		}
+
+		if !dedicated {
+			known, err := s.isKnownFile(ctx, file.FromTmpFilePath(fullpath))
+			if err != nil {
+				s.addError(logger, report, model.GCCategoryTmpFile, fullpath, err)
+				return nil
+			}
+			if !known {
+				return nil
+			}
+		}
+
+		s.addItem(logger, report, model.GCItem{Category: model.GCCategoryTmpFile, Path: fullpath})
+		if report.DryRun {
+			return nil
+		}
+		if err = os.Remove(path); err != nil && !os.IsNotExist(err) {
+			s.addError(logger, report, model.GCCategoryTmpFile, fullpath, err)
+		}
+		return nil
+	})
+}
+
+// Reports whether the file is an output or a source of a conversion
+func (s *serv) isKnownFile(ctx context.Context, fullpath string) (bool, error) {
+	_, err := s.conversionQueueService.FindSource(ctx, fullpath)
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, db.ErrNotFound) {
+		return false, err
+	}
+
+	_, err = s.conversionRepository.FindByFullpath(ctx, fullpath)
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, db.ErrNotFound) {
+		return false, err
+	}
+	return false, nil
+}
+
+func (s *serv) collectDoneConversions(ctx context.Context, logger *slog.Logger, report *model.GCReport) error {
+	if s.cfg.GC.ConversionRetention == 0 {
+		return nil
+	}
+	before := time.Now().Add(-s.cfg.GC.ConversionRetention)
+
+	count, err := s.conversionRepository.CountDoneBefore(ctx, before)
+	if err != nil {
+		return err
+	}
+	logger.Info("garbage found", slog.String("category", model.GCCategoryDoneConversion), slog.Int64("count", count))
+
+	if !report.DryRun && count > 0 {
+		if count, err = s.conversionRepository.DeleteDoneBefore(ctx, before); err != nil {
+			return err
+		}
+	}
+	report.Removed[model.GCCategoryDoneConversion] = count
+
+	return nil
+}
+
+func (s *serv) collectDoneDeletions(ctx context.Context, logger *slog.Logger, report *model.GCReport) error {
+	if s.cfg.GC.DeletionRetention == 0 {
+		return nil
+	}
+	before := time.Now().Add(-s.cfg.GC.DeletionRetention)
+
+	count, err := s.deletionRepository.CountDoneBefore(ctx, before)
+	if err != nil {
+		return err
+	}
+	logger.Info("garbage found", slog.String("category", model.GCCategoryDoneDeletion), slog.Int64("count", count))
+
+	if !report.DryRun && count > 0 {
+		if count, err = s.deletionRepository.DeleteDoneBefore(ctx, before); err != nil {
+			return err
+		}
+	}
+	report.Removed[model.GCCategoryDoneDeletion] = count
+
+	return nil
+}
+
+func (s *serv) addItem(logger *slog.Logger, report *model.GCReport, item model.GCItem) {
+	if item.Error == "" {
+		logger.Info("garbage found", slog.String("category", item.Category), slog.String("path", item.Path))
+		report.Removed[item.Category]++
+	} else {
+		report.Errors++
+	}
+
+	if len(report.Items) >= maxReportItems {
+		report.Truncated = true
+		return
+	}
+	report.Items = append(report.Items, item)
+}
+
+func (s *serv) addError(logger *slog.Logger, report *model.GCReport, category string, path string, err error) {
+	logger.Error("failed to collect garbage", slog.String("category", category), slog.String("path", path), slogger.Err(err))
+	s.addItem(logger, report, model.GCItem{
+		Category: category,
+		Path:     path,
+		Error:    err.Error(),
+	})
+}
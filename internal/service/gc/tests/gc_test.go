@@ -0,0 +1,284 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/db"
+	"github.com/chistyakoviv/converter/internal/file"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	repositoryMocks "github.com/chistyakoviv/converter/internal/repository/mocks"
+	"github.com/chistyakoviv/converter/internal/service/gc"
+	serviceMocks "github.com/chistyakoviv/converter/internal/service/mocks"
+	"github.com/chistyakoviv/converter/internal/storage/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	ctx    = context.Background()
+	logger = dummy.NewDummyLogger()
+)
+
+// Creates the files of a collection in a directory relative to the working directory,
+// as the paths of conversions are relative to it
+type fixture struct {
+	rootDir  string
+	orphan   *model.Conversion
+	source   *model.Conversion
+	output   string
+	oldTmp   string
+	freshTmp string
+	// Uploaded file named like a temporary one
+	userTmp string
+}
+
+func newFixture(t *testing.T) *fixture {
+	rootDir, err := os.MkdirTemp(".", "gc")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(rootDir)
+	})
+
+	f := &fixture{
+		rootDir: rootDir,
+		orphan: &model.Conversion{
+			Id:        1,
+			Fullpath:  "/" + rootDir + "/removed.png",
+			Path:      "/" + rootDir,
+			Filestem:  "removed",
+			Ext:       "png",
+			ConvertTo: []model.ConvertTo{{Ext: "jpg"}, {Ext: "webp"}},
+			Status:    model.ConversionStatusDone,
+		},
+		source: &model.Conversion{
+			Id:        2,
+			Fullpath:  "/" + rootDir + "/photo.png",
+			Path:      "/" + rootDir,
+			Filestem:  "photo",
+			Ext:       "png",
+			ConvertTo: []model.ConvertTo{{Ext: "jpg"}},
+			Status:    model.ConversionStatusDone,
+		},
+		freshTmp: filepath.Join(rootDir, "running.tmp.jpg"),
+		userTmp:  filepath.Join(rootDir, "upload.tmp.jpg"),
+	}
+
+	// Only the first output of the orphan exists
+	f.output, err = f.orphan.AbsoluteDestinationPath(f.orphan.ConvertTo[0], model.Naming{})
+	require.NoError(t, err)
+	// The conversion of the source was interrupted
+	sourceOutput, err := f.source.DestinationFullpath(f.source.ConvertTo[0], model.Naming{})
+	require.NoError(t, err)
+	f.oldTmp = file.ToTmpFilePath(strings.TrimPrefix(sourceOutput, "/"))
+
+	for _, path := range []string{f.output, filepath.Join(rootDir, "photo.png"), f.oldTmp, f.freshTmp, f.userTmp} {
+		require.NoError(t, os.WriteFile(path, []byte("data"), 0o600))
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(f.oldTmp, old, old))
+	require.NoError(t, os.Chtimes(f.userTmp, old, old))
+
+	return f
+}
+
+// Expects the lookups of the stale temporary files, only the one of the source output belongs to a conversion
+func (f *fixture) expectTmpLookups(
+	conversionRepository *repositoryMocks.MockConversionQueueRepository,
+	conversionQueueService *serviceMocks.MockConversionQueueService,
+) {
+	conversionQueueService.On("FindSource", ctx, "/"+file.FromTmpFilePath(f.oldTmp)).Return(f.source, nil).Once()
+
+	userFile := "/" + file.FromTmpFilePath(f.userTmp)
+	conversionQueueService.On("FindSource", ctx, userFile).Return(nil, db.ErrNotFound).Once()
+	conversionRepository.On("FindByFullpath", ctx, userFile).Return(nil, db.ErrNotFound).Once()
+}
+
+func TestCollectGarbage(t *testing.T) {
+	type testcase struct {
+		name    string
+		dryRun  bool
+		removed map[string]int64
+	}
+
+	cases := []testcase{
+		{
+			name:   "Dry run reports the garbage",
+			dryRun: true,
+			removed: map[string]int64{
+				model.GCCategoryOrphanOutput:   1,
+				model.GCCategoryMissingSource:  1,
+				model.GCCategoryTmpFile:        1,
+				model.GCCategoryDoneConversion: 4,
+				model.GCCategoryDoneDeletion:   2,
+			},
+		},
+		{
+			name: "Garbage is removed",
+			removed: map[string]int64{
+				model.GCCategoryOrphanOutput:   1,
+				model.GCCategoryMissingSource:  1,
+				model.GCCategoryTmpFile:        1,
+				model.GCCategoryDoneConversion: 3,
+				model.GCCategoryDoneDeletion:   2,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := newFixture(t)
+
+			mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+			mockConversionRepository.On("FindAfterId", ctx, int64(0), uint64(1000)).
+				Return([]*model.Conversion{f.orphan, f.source}, nil).
+				Once()
+			mockConversionRepository.On("FindAfterId", ctx, f.source.Id, uint64(1000)).
+				Return([]*model.Conversion{}, nil).
+				Once()
+			mockConversionRepository.On("CountDoneBefore", ctx, mock.AnythingOfType("time.Time")).Return(int64(4), nil).Once()
+
+			mockDeletionRepository := repositoryMocks.NewMockDeletionQueueRepository(t)
+			mockDeletionRepository.On("FindByFullpath", ctx, f.orphan.Fullpath).Return(nil, db.ErrNotFound).Once()
+			mockDeletionRepository.On("CountDoneBefore", ctx, mock.AnythingOfType("time.Time")).Return(int64(2), nil).Once()
+
+			if !tc.dryRun {
+				mockConversionRepository.On("Delete", ctx, f.orphan.Fullpath).Return(nil).Once()
+				// Some conversions may have been removed between the statements
+				mockConversionRepository.On("DeleteDoneBefore", ctx, mock.AnythingOfType("time.Time")).Return(int64(3), nil).Once()
+				mockDeletionRepository.On("DeleteDoneBefore", ctx, mock.AnythingOfType("time.Time")).Return(int64(2), nil).Once()
+			}
+
+			mockConversionQueueService := serviceMocks.NewMockConversionQueueService(t)
+			mockConversionQueueService.On("IsOutputShared", ctx, f.orphan, mock.AnythingOfType("string")).Return(false, nil).Once()
+			f.expectTmpLookups(mockConversionRepository, mockConversionQueueService)
+
+			serv := gc.NewService(
+				&config.Config{
+					GC: config.GC{
+						TmpRetention:        time.Hour,
+						ConversionRetention: 24 * time.Hour,
+						DeletionRetention:   24 * time.Hour,
+					},
+				},
+				logger,
+				mockConversionRepository,
+				mockDeletionRepository,
+				mockConversionQueueService,
+				local.NewStorage(""),
+			)
+
+			report, err := serv.Collect(ctx, f.rootDir, model.GCOptions{DryRun: tc.dryRun})
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.dryRun, report.DryRun)
+			assert.Equal(t, tc.removed, report.Removed)
+			assert.Zero(t, report.Errors)
+			assert.Len(t, report.Items, 3)
+
+			_, err = os.Stat(f.output)
+			assert.Equal(t, tc.dryRun, err == nil)
+			_, err = os.Stat(f.oldTmp)
+			assert.Equal(t, tc.dryRun, err == nil)
+			// Files that may belong to a running conversion are kept
+			assert.FileExists(t, f.freshTmp)
+			// Files of the users are kept whatever their names are
+			assert.FileExists(t, f.userTmp)
+
+			mockConversionRepository.AssertExpectations(t)
+			mockDeletionRepository.AssertExpectations(t)
+			mockConversionQueueService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCollectGarbageSkipsPendingDeletions(t *testing.T) {
+	f := newFixture(t)
+
+	mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+	mockConversionRepository.On("FindAfterId", ctx, int64(0), uint64(1000)).
+		Return([]*model.Conversion{f.orphan}, nil).
+		Once()
+	mockConversionRepository.On("FindAfterId", ctx, f.orphan.Id, uint64(1000)).
+		Return([]*model.Conversion{}, nil).
+		Once()
+
+	mockDeletionRepository := repositoryMocks.NewMockDeletionQueueRepository(t)
+	mockDeletionRepository.On("FindByFullpath", ctx, f.orphan.Fullpath).
+		Return(&model.Deletion{Fullpath: f.orphan.Fullpath, Status: model.DeletionStatusPending}, nil).
+		Once()
+
+	mockConversionQueueService := serviceMocks.NewMockConversionQueueService(t)
+	f.expectTmpLookups(mockConversionRepository, mockConversionQueueService)
+
+	// Processed rows are kept forever without the retention
+	serv := gc.NewService(
+		&config.Config{GC: config.GC{TmpRetention: time.Hour}},
+		logger,
+		mockConversionRepository,
+		mockDeletionRepository,
+		mockConversionQueueService,
+		local.NewStorage(""),
+	)
+
+	report, err := serv.Collect(ctx, f.rootDir, model.GCOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]int64{model.GCCategoryTmpFile: 1}, report.Removed)
+	assert.FileExists(t, f.output)
+
+	mockConversionRepository.AssertExpectations(t)
+	mockDeletionRepository.AssertExpectations(t)
+}
+
+func TestCollectGarbageKeepsSharedOutputs(t *testing.T) {
+	f := newFixture(t)
+
+	mockConversionRepository := repositoryMocks.NewMockConversionQueueRepository(t)
+	mockConversionRepository.On("FindAfterId", ctx, int64(0), uint64(1000)).
+		Return([]*model.Conversion{f.orphan}, nil).
+		Once()
+	mockConversionRepository.On("FindAfterId", ctx, f.orphan.Id, uint64(1000)).
+		Return([]*model.Conversion{}, nil).
+		Once()
+	mockConversionRepository.On("Delete", ctx, f.orphan.Fullpath).Return(nil).Once()
+
+	mockDeletionRepository := repositoryMocks.NewMockDeletionQueueRepository(t)
+	mockDeletionRepository.On("FindByFullpath", ctx, f.orphan.Fullpath).Return(nil, db.ErrNotFound).Once()
+
+	// Another source of the same content produces the existing output
+	mockConversionQueueService := serviceMocks.NewMockConversionQueueService(t)
+	mockConversionQueueService.On("IsOutputShared", ctx, f.orphan, mock.AnythingOfType("string")).Return(true, nil).Once()
+	f.expectTmpLookups(mockConversionRepository, mockConversionQueueService)
+
+	serv := gc.NewService(
+		&config.Config{GC: config.GC{TmpRetention: time.Hour}},
+		logger,
+		mockConversionRepository,
+		mockDeletionRepository,
+		mockConversionQueueService,
+		local.NewStorage(""),
+	)
+
+	report, err := serv.Collect(ctx, f.rootDir, model.GCOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]int64{
+		model.GCCategoryMissingSource: 1,
+		model.GCCategoryTmpFile:       1,
+	}, report.Removed)
+	assert.FileExists(t, f.output)
+
+	mockConversionRepository.AssertExpectations(t)
+	mockDeletionRepository.AssertExpectations(t)
+	mockConversionQueueService.AssertExpectations(t)
+}
@@ -0,0 +1,202 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/converter"
+	"github.com/chistyakoviv/converter/internal/db"
+	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service"
+)
+
+// Names of the checks in the reports
+const (
+	CheckTasks      = "tasks"
+	CheckShutdown   = "shutdown"
+	CheckDB         = "db"
+	CheckMigrations = "migrations"
+	CheckFiles      = "files"
+	CheckFFmpeg     = "ffmpeg"
+	CheckLibvips    = "libvips"
+)
+
+var (
+	ErrShuttingDown  = errors.New("application is shutting down")
+	ErrNotProcessing = errors.New("queue processing is stopped")
+	ErrCheckTimeout  = errors.New("check timed out")
+)
+
+type check func(ctx context.Context) error
+
+type serv struct {
+	cfg            *config.Config
+	logger         *slog.Logger
+	db             db.Client
+	migrator       db.Migrator
+	rootDir        string
+	imageConverter converter.ImageConverter
+	videoConverter converter.VideoConverter
+	taskService    service.TaskService
+	shuttingDown   atomic.Bool
+}
+
+func NewService(
+	cfg *config.Config,
+	logger *slog.Logger,
+	db db.Client,
+	migrator db.Migrator,
+	rootDir string,
+	imageConverter converter.ImageConverter,
+	videoConverter converter.VideoConverter,
+	taskService service.TaskService,
+) service.HealthService {
+	return &serv{
+		cfg:            cfg,
+		logger:         logger,
+		db:             db,
+		migrator:       migrator,
+		rootDir:        rootDir,
+		imageConverter: imageConverter,
+		videoConverter: videoConverter,
+		taskService:    taskService,
+	}
+}
+
+// Live only checks the application itself, failing dependencies are reported by the readiness
+func (s *serv) Live(ctx context.Context) *model.HealthReport {
+	return s.run(ctx, map[string]check{
+		CheckTasks: s.checkTasks,
+	})
+}
+
+func (s *serv) Ready(ctx context.Context) *model.HealthReport {
+	return s.run(ctx, map[string]check{
+		CheckShutdown:   s.checkShutdown,
+		CheckTasks:      s.checkTasks,
+		CheckDB:         s.checkDB,
+		CheckMigrations: s.migrator.Check,
+		CheckFiles:      s.checkFiles,
+		CheckFFmpeg:     s.videoConverter.Check,
+		CheckLibvips:    s.imageConverter.Check,
+	})
+}
+
+func (s *serv) Shutdown() {
+	s.shuttingDown.Store(true)
+}
+
+// Runs the checks concurrently, each check is given the configured time to complete
+func (s *serv) run(ctx context.Context, checks map[string]check) *model.HealthReport {
+	const op = "service.HealthService.run"
+
+	logger := s.logger.With(slog.String("op", op))
+
+	report := &model.HealthReport{
+		Status: model.HealthStatusOK,
+		Checks: make(map[string]model.HealthCheck, len(checks)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, fn := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			err := s.runCheck(ctx, fn)
+			result := model.HealthCheck{
+				Status:   model.HealthStatusOK,
+				Duration: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				logger.Warn("health check failed", slog.String("check", name), slogger.Err(err))
+				result.Status = model.HealthStatusFailed
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if err != nil {
+				report.Status = model.HealthStatusFailed
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+// Checks ignoring the context, e.g. calls to libvips, are abandoned once the timeout expires
+func (s *serv) runCheck(ctx context.Context, fn check) error {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Health.CheckTimeout)
+	defer cancel()
+
+	// Buffered, so the abandoned check doesn't block
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ErrCheckTimeout
+	}
+}
+
+func (s *serv) checkShutdown(_ context.Context) error {
+	if s.shuttingDown.Load() {
+		return ErrShuttingDown
+	}
+	return nil
+}
+
+func (s *serv) checkTasks(_ context.Context) error {
+	if !s.taskService.IsProcessing() {
+		return ErrNotProcessing
+	}
+	return nil
+}
+
+func (s *serv) checkDB(ctx context.Context) error {
+	return s.db.DB().Ping(ctx)
+}
+
+// Checks that the files can be listed and written to the root directory
+func (s *serv) checkFiles(_ context.Context) error {
+	dir, err := os.Open(s.rootDir)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	if _, err = dir.Readdirnames(1); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	f, err := os.CreateTemp(s.rootDir, ".healthcheck-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	if err = f.Close(); err != nil {
+		_ = os.Remove(name)
+		return err
+	}
+
+	return os.Remove(name)
+}
@@ -0,0 +1,206 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	converterMocks "github.com/chistyakoviv/converter/internal/converter/mocks"
+	"github.com/chistyakoviv/converter/internal/db"
+	dbMocks "github.com/chistyakoviv/converter/internal/db/mocks"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service/health"
+	serviceMocks "github.com/chistyakoviv/converter/internal/service/mocks"
+)
+
+func newConfig() *config.Config {
+	return &config.Config{
+		Health: config.Health{
+			CheckTimeout: 100 * time.Millisecond,
+		},
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	logger := dummy.NewDummyLogger()
+
+	type testcase struct {
+		name         string
+		shuttingDown bool
+		processing   bool
+		pingErr      error
+		migrationErr error
+		rootDir      func(t *testing.T) string
+		ffmpegErr    error
+		// Delay of the libvips check, which ignores the context
+		libvipsDelay time.Duration
+		// Checks expected to fail along with the errors
+		failed map[string]string
+	}
+
+	cases := []testcase{
+		{
+			name:       "All checks pass",
+			processing: true,
+		},
+		{
+			name:         "Readiness fails while shutting down",
+			shuttingDown: true,
+			processing:   true,
+			failed:       map[string]string{health.CheckShutdown: health.ErrShuttingDown.Error()},
+		},
+		{
+			name:   "Queue processing is stopped",
+			failed: map[string]string{health.CheckTasks: health.ErrNotProcessing.Error()},
+		},
+		{
+			name:       "Database is unavailable",
+			processing: true,
+			pingErr:    errors.New("connection refused"),
+			failed:     map[string]string{health.CheckDB: "connection refused"},
+		},
+		{
+			name:         "Schema doesn't match the migrations",
+			processing:   true,
+			migrationErr: fmt.Errorf("schema version 20261019140000, latest migration 20261019150000: %w", db.ErrSchemaBehind),
+			failed: map[string]string{
+				health.CheckMigrations: "schema version 20261019140000, latest migration 20261019150000: schema is behind the migrations",
+			},
+		},
+		{
+			name:       "Files root is missing",
+			processing: true,
+			rootDir: func(t *testing.T) string {
+				return filepath.Join(t.TempDir(), "missing")
+			},
+			failed: map[string]string{health.CheckFiles: ""},
+		},
+		{
+			name:       "ffmpeg is not callable",
+			processing: true,
+			ffmpegErr:  errors.New("failed to run ffprobe: executable file not found in $PATH"),
+			failed:     map[string]string{health.CheckFFmpeg: "failed to run ffprobe: executable file not found in $PATH"},
+		},
+		{
+			name:         "Hanging check times out",
+			processing:   true,
+			libvipsDelay: time.Second,
+			failed:       map[string]string{health.CheckLibvips: health.ErrCheckTimeout.Error()},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockDB := dbMocks.NewMockDB(t)
+			mockDB.On("Ping", mock.Anything).Return(tc.pingErr).Once()
+			mockClient := dbMocks.NewMockClient(t)
+			mockClient.On("DB").Return(mockDB).Once()
+
+			mockMigrator := dbMocks.NewMockMigrator(t)
+			mockMigrator.On("Check", mock.Anything).Return(tc.migrationErr).Once()
+
+			mockImageConverter := converterMocks.NewMockImageConverter(t)
+			mockImageConverter.On("Check", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				time.Sleep(tc.libvipsDelay)
+			}).Once()
+			mockVideoConverter := converterMocks.NewMockVideoConverter(t)
+			mockVideoConverter.On("Check", mock.Anything).Return(tc.ffmpegErr).Once()
+
+			mockTaskService := serviceMocks.NewMockTaskService(t)
+			mockTaskService.On("IsProcessing").Return(tc.processing).Once()
+
+			rootDir := t.TempDir()
+			if tc.rootDir != nil {
+				rootDir = tc.rootDir(t)
+			}
+
+			healthService := health.NewService(
+				newConfig(),
+				logger,
+				mockClient,
+				mockMigrator,
+				rootDir,
+				mockImageConverter,
+				mockVideoConverter,
+				mockTaskService,
+			)
+			if tc.shuttingDown {
+				healthService.Shutdown()
+			}
+
+			report := healthService.Ready(context.Background())
+
+			require.Len(t, report.Checks, 7)
+			if len(tc.failed) == 0 {
+				assert.True(t, report.IsHealthy())
+			} else {
+				assert.False(t, report.IsHealthy())
+				assert.Equal(t, model.HealthStatusFailed, report.Status)
+			}
+			for name, result := range report.Checks {
+				msg, failed := tc.failed[name]
+				if !failed {
+					assert.Equal(t, model.HealthStatusOK, result.Status, name)
+					assert.Empty(t, result.Error, name)
+					continue
+				}
+				assert.Equal(t, model.HealthStatusFailed, result.Status, name)
+				if msg != "" {
+					assert.Equal(t, msg, result.Error, name)
+				} else {
+					assert.NotEmpty(t, result.Error, name)
+				}
+			}
+			// The probe file is removed
+			if tc.rootDir == nil {
+				entries, err := filepath.Glob(filepath.Join(rootDir, "*"))
+				require.NoError(t, err)
+				assert.Empty(t, entries)
+			}
+		})
+	}
+}
+
+func TestLiveness(t *testing.T) {
+	logger := dummy.NewDummyLogger()
+
+	for _, processing := range []bool{true, false} {
+		mockTaskService := serviceMocks.NewMockTaskService(t)
+		mockTaskService.On("IsProcessing").Return(processing).Once()
+
+		// The dependencies are not checked by the liveness
+		healthService := health.NewService(
+			newConfig(),
+			logger,
+			dbMocks.NewMockClient(t),
+			dbMocks.NewMockMigrator(t),
+			t.TempDir(),
+			converterMocks.NewMockImageConverter(t),
+			converterMocks.NewMockVideoConverter(t),
+			mockTaskService,
+		)
+		// Shutting down doesn't make the application dead
+		healthService.Shutdown()
+
+		report := healthService.Live(context.Background())
+
+		require.Len(t, report.Checks, 1)
+		assert.Equal(t, processing, report.IsHealthy())
+		if !processing {
+			assert.Equal(t, health.ErrNotProcessing.Error(), report.Checks[health.CheckTasks].Error)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+// Code generated by mockery v2.50.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/chistyakoviv/converter/internal/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockApiKeyService is an autogenerated mock type for the ApiKeyService type
+type MockApiKeyService struct {
+	mock.Mock
+}
+
+type MockApiKeyService_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockApiKeyService) EXPECT() *MockApiKeyService_Expecter {
+	return &MockApiKeyService_Expecter{mock: &_m.Mock}
+}
+
+// Authenticate provides a mock function with given fields: ctx, token
+func (_m *MockApiKeyService) Authenticate(ctx context.Context, token string) (*model.ApiKey, error) {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Authenticate")
+	}
+
+	var r0 *model.ApiKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.ApiKey, error)); ok {
+		return rf(ctx, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.ApiKey); ok {
+		r0 = rf(ctx, token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.ApiKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockApiKeyService_Authenticate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Authenticate'
+type MockApiKeyService_Authenticate_Call struct {
+	*mock.Call
+}
+
+// Authenticate is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+func (_e *MockApiKeyService_Expecter) Authenticate(ctx interface{}, token interface{}) *MockApiKeyService_Authenticate_Call {
+	return &MockApiKeyService_Authenticate_Call{Call: _e.mock.On("Authenticate", ctx, token)}
+}
+
+func (_c *MockApiKeyService_Authenticate_Call) Run(run func(ctx context.Context, token string)) *MockApiKeyService_Authenticate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockApiKeyService_Authenticate_Call) Return(_a0 *model.ApiKey, _a1 error) *MockApiKeyService_Authenticate_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockApiKeyService_Authenticate_Call) RunAndReturn(run func(context.Context, string) (*model.ApiKey, error)) *MockApiKeyService_Authenticate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Create provides a mock function with given fields: ctx, info
+func (_m *MockApiKeyService) Create(ctx context.Context, info *model.ApiKeyInfo) (*model.ApiKey, string, error) {
+	ret := _m.Called(ctx, info)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Create")
+	}
+
+	var r0 *model.ApiKey
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.ApiKeyInfo) (*model.ApiKey, string, error)); ok {
+		return rf(ctx, info)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *model.ApiKeyInfo) *model.ApiKey); ok {
+		r0 = rf(ctx, info)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.ApiKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *model.ApiKeyInfo) string); ok {
+		r1 = rf(ctx, info)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, *model.ApiKeyInfo) error); ok {
+		r2 = rf(ctx, info)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// MockApiKeyService_Create_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Create'
+type MockApiKeyService_Create_Call struct {
+	*mock.Call
+}
+
+// Create is a helper method to define mock.On call
+//   - ctx context.Context
+//   - info *model.ApiKeyInfo
+func (_e *MockApiKeyService_Expecter) Create(ctx interface{}, info interface{}) *MockApiKeyService_Create_Call {
+	return &MockApiKeyService_Create_Call{Call: _e.mock.On("Create", ctx, info)}
+}
+
+func (_c *MockApiKeyService_Create_Call) Run(run func(ctx context.Context, info *model.ApiKeyInfo)) *MockApiKeyService_Create_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.ApiKeyInfo))
+	})
+	return _c
+}
+
+func (_c *MockApiKeyService_Create_Call) Return(_a0 *model.ApiKey, _a1 string, _a2 error) *MockApiKeyService_Create_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *MockApiKeyService_Create_Call) RunAndReturn(run func(context.Context, *model.ApiKeyInfo) (*model.ApiKey, string, error)) *MockApiKeyService_Create_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// List provides a mock function with given fields: ctx
+func (_m *MockApiKeyService) List(ctx context.Context) ([]*model.ApiKey, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 []*model.ApiKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*model.ApiKey, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*model.ApiKey); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.ApiKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockApiKeyService_List_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'List'
+type MockApiKeyService_List_Call struct {
+	*mock.Call
+}
+
+// List is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockApiKeyService_Expecter) List(ctx interface{}) *MockApiKeyService_List_Call {
+	return &MockApiKeyService_List_Call{Call: _e.mock.On("List", ctx)}
+}
+
+func (_c *MockApiKeyService_List_Call) Run(run func(ctx context.Context)) *MockApiKeyService_List_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockApiKeyService_List_Call) Return(_a0 []*model.ApiKey, _a1 error) *MockApiKeyService_List_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockApiKeyService_List_Call) RunAndReturn(run func(context.Context) ([]*model.ApiKey, error)) *MockApiKeyService_List_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Revoke provides a mock function with given fields: ctx, id
+func (_m *MockApiKeyService) Revoke(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Revoke")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockApiKeyService_Revoke_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Revoke'
+type MockApiKeyService_Revoke_Call struct {
+	*mock.Call
+}
+
+// Revoke is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int64
+func (_e *MockApiKeyService_Expecter) Revoke(ctx interface{}, id interface{}) *MockApiKeyService_Revoke_Call {
+	return &MockApiKeyService_Revoke_Call{Call: _e.mock.On("Revoke", ctx, id)}
+}
+
+func (_c *MockApiKeyService_Revoke_Call) Run(run func(ctx context.Context, id int64)) *MockApiKeyService_Revoke_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *MockApiKeyService_Revoke_Call) Return(_a0 error) *MockApiKeyService_Revoke_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockApiKeyService_Revoke_Call) RunAndReturn(run func(context.Context, int64) error) *MockApiKeyService_Revoke_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockApiKeyService creates a new instance of MockApiKeyService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockApiKeyService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockApiKeyService {
+	mock := &MockApiKeyService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -5,8 +5,10 @@ package mocks
 import (
 	context "context"
 
-	model "github.com/chistyakoviv/converter/internal/model"
+	file "github.com/chistyakoviv/converter/internal/file"
 	mock "github.com/stretchr/testify/mock"
+
+	model "github.com/chistyakoviv/converter/internal/model"
 )
 
 // MockConversionQueueService is an autogenerated mock type for the ConversionQueueService type
@@ -79,29 +81,201 @@ func (_c *MockConversionQueueService_Add_Call) RunAndReturn(run func(context.Con
 	return _c
 }
 
-// Get provides a mock function with given fields: ctx, fullpath
-func (_m *MockConversionQueueService) Get(ctx context.Context, fullpath string) (*model.Conversion, error) {
-	ret := _m.Called(ctx, fullpath)
+// AddBatch provides a mock function with given fields: ctx, infos
+func (_m *MockConversionQueueService) AddBatch(ctx context.Context, infos []*model.ConversionInfo) (int64, error) {
+	ret := _m.Called(ctx, infos)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Get")
+		panic("no return value specified for AddBatch")
 	}
 
-	var r0 *model.Conversion
+	var r0 int64
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.Conversion, error)); ok {
-		return rf(ctx, fullpath)
+	if rf, ok := ret.Get(0).(func(context.Context, []*model.ConversionInfo) (int64, error)); ok {
+		return rf(ctx, infos)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Conversion); ok {
-		r0 = rf(ctx, fullpath)
+	if rf, ok := ret.Get(0).(func(context.Context, []*model.ConversionInfo) int64); ok {
+		r0 = rf(ctx, infos)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []*model.ConversionInfo) error); ok {
+		r1 = rf(ctx, infos)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueService_AddBatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddBatch'
+type MockConversionQueueService_AddBatch_Call struct {
+	*mock.Call
+}
+
+// AddBatch is a helper method to define mock.On call
+//   - ctx context.Context
+//   - infos []*model.ConversionInfo
+func (_e *MockConversionQueueService_Expecter) AddBatch(ctx interface{}, infos interface{}) *MockConversionQueueService_AddBatch_Call {
+	return &MockConversionQueueService_AddBatch_Call{Call: _e.mock.On("AddBatch", ctx, infos)}
+}
+
+func (_c *MockConversionQueueService_AddBatch_Call) Run(run func(ctx context.Context, infos []*model.ConversionInfo)) *MockConversionQueueService_AddBatch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]*model.ConversionInfo))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueService_AddBatch_Call) Return(_a0 int64, _a1 error) *MockConversionQueueService_AddBatch_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueService_AddBatch_Call) RunAndReturn(run func(context.Context, []*model.ConversionInfo) (int64, error)) *MockConversionQueueService_AddBatch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// AddMany provides a mock function with given fields: ctx, infos
+func (_m *MockConversionQueueService) AddMany(ctx context.Context, infos []*model.ConversionInfo) ([]model.BatchResult, error) {
+	ret := _m.Called(ctx, infos)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddMany")
+	}
+
+	var r0 []model.BatchResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*model.ConversionInfo) ([]model.BatchResult, error)); ok {
+		return rf(ctx, infos)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []*model.ConversionInfo) []model.BatchResult); ok {
+		r0 = rf(ctx, infos)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*model.Conversion)
+			r0 = ret.Get(0).([]model.BatchResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []*model.ConversionInfo) error); ok {
+		r1 = rf(ctx, infos)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueService_AddMany_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddMany'
+type MockConversionQueueService_AddMany_Call struct {
+	*mock.Call
+}
+
+// AddMany is a helper method to define mock.On call
+//   - ctx context.Context
+//   - infos []*model.ConversionInfo
+func (_e *MockConversionQueueService_Expecter) AddMany(ctx interface{}, infos interface{}) *MockConversionQueueService_AddMany_Call {
+	return &MockConversionQueueService_AddMany_Call{Call: _e.mock.On("AddMany", ctx, infos)}
+}
+
+func (_c *MockConversionQueueService_AddMany_Call) Run(run func(ctx context.Context, infos []*model.ConversionInfo)) *MockConversionQueueService_AddMany_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]*model.ConversionInfo))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueService_AddMany_Call) Return(_a0 []model.BatchResult, _a1 error) *MockConversionQueueService_AddMany_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueService_AddMany_Call) RunAndReturn(run func(context.Context, []*model.ConversionInfo) ([]model.BatchResult, error)) *MockConversionQueueService_AddMany_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CountPending provides a mock function with given fields: ctx
+func (_m *MockConversionQueueService) CountPending(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountPending")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueService_CountPending_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountPending'
+type MockConversionQueueService_CountPending_Call struct {
+	*mock.Call
+}
+
+// CountPending is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockConversionQueueService_Expecter) CountPending(ctx interface{}) *MockConversionQueueService_CountPending_Call {
+	return &MockConversionQueueService_CountPending_Call{Call: _e.mock.On("CountPending", ctx)}
+}
+
+func (_c *MockConversionQueueService_CountPending_Call) Run(run func(ctx context.Context)) *MockConversionQueueService_CountPending_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueService_CountPending_Call) Return(_a0 int64, _a1 error) *MockConversionQueueService_CountPending_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueService_CountPending_Call) RunAndReturn(run func(context.Context) (int64, error)) *MockConversionQueueService_CountPending_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindDuplicates provides a mock function with given fields: ctx, hash
+func (_m *MockConversionQueueService) FindDuplicates(ctx context.Context, hash string) ([]*model.Conversion, error) {
+	ret := _m.Called(ctx, hash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindDuplicates")
+	}
+
+	var r0 []*model.Conversion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]*model.Conversion, error)); ok {
+		return rf(ctx, hash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*model.Conversion); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Conversion)
 		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, fullpath)
+		r1 = rf(ctx, hash)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -109,153 +283,176 @@ func (_m *MockConversionQueueService) Get(ctx context.Context, fullpath string)
 	return r0, r1
 }
 
-// MockConversionQueueService_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
-type MockConversionQueueService_Get_Call struct {
+// MockConversionQueueService_FindDuplicates_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindDuplicates'
+type MockConversionQueueService_FindDuplicates_Call struct {
 	*mock.Call
 }
 
-// Get is a helper method to define mock.On call
+// FindDuplicates is a helper method to define mock.On call
 //   - ctx context.Context
-//   - fullpath string
-func (_e *MockConversionQueueService_Expecter) Get(ctx interface{}, fullpath interface{}) *MockConversionQueueService_Get_Call {
-	return &MockConversionQueueService_Get_Call{Call: _e.mock.On("Get", ctx, fullpath)}
+//   - hash string
+func (_e *MockConversionQueueService_Expecter) FindDuplicates(ctx interface{}, hash interface{}) *MockConversionQueueService_FindDuplicates_Call {
+	return &MockConversionQueueService_FindDuplicates_Call{Call: _e.mock.On("FindDuplicates", ctx, hash)}
 }
 
-func (_c *MockConversionQueueService_Get_Call) Run(run func(ctx context.Context, fullpath string)) *MockConversionQueueService_Get_Call {
+func (_c *MockConversionQueueService_FindDuplicates_Call) Run(run func(ctx context.Context, hash string)) *MockConversionQueueService_FindDuplicates_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *MockConversionQueueService_Get_Call) Return(_a0 *model.Conversion, _a1 error) *MockConversionQueueService_Get_Call {
+func (_c *MockConversionQueueService_FindDuplicates_Call) Return(_a0 []*model.Conversion, _a1 error) *MockConversionQueueService_FindDuplicates_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockConversionQueueService_Get_Call) RunAndReturn(run func(context.Context, string) (*model.Conversion, error)) *MockConversionQueueService_Get_Call {
+func (_c *MockConversionQueueService_FindDuplicates_Call) RunAndReturn(run func(context.Context, string) ([]*model.Conversion, error)) *MockConversionQueueService_FindDuplicates_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// MarkAsCanceled provides a mock function with given fields: ctx, fullpath, code
-func (_m *MockConversionQueueService) MarkAsCanceled(ctx context.Context, fullpath string, code uint32) error {
-	ret := _m.Called(ctx, fullpath, code)
+// FindQueued provides a mock function with given fields: ctx, fullpaths
+func (_m *MockConversionQueueService) FindQueued(ctx context.Context, fullpaths []string) ([]string, error) {
+	ret := _m.Called(ctx, fullpaths)
 
 	if len(ret) == 0 {
-		panic("no return value specified for MarkAsCanceled")
+		panic("no return value specified for FindQueued")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, uint32) error); ok {
-		r0 = rf(ctx, fullpath, code)
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) ([]string, error)); ok {
+		return rf(ctx, fullpaths)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []string); ok {
+		r0 = rf(ctx, fullpaths)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, fullpaths)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// MockConversionQueueService_MarkAsCanceled_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkAsCanceled'
-type MockConversionQueueService_MarkAsCanceled_Call struct {
+// MockConversionQueueService_FindQueued_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindQueued'
+type MockConversionQueueService_FindQueued_Call struct {
 	*mock.Call
 }
 
-// MarkAsCanceled is a helper method to define mock.On call
+// FindQueued is a helper method to define mock.On call
 //   - ctx context.Context
-//   - fullpath string
-//   - code uint32
-func (_e *MockConversionQueueService_Expecter) MarkAsCanceled(ctx interface{}, fullpath interface{}, code interface{}) *MockConversionQueueService_MarkAsCanceled_Call {
-	return &MockConversionQueueService_MarkAsCanceled_Call{Call: _e.mock.On("MarkAsCanceled", ctx, fullpath, code)}
+//   - fullpaths []string
+func (_e *MockConversionQueueService_Expecter) FindQueued(ctx interface{}, fullpaths interface{}) *MockConversionQueueService_FindQueued_Call {
+	return &MockConversionQueueService_FindQueued_Call{Call: _e.mock.On("FindQueued", ctx, fullpaths)}
 }
 
-func (_c *MockConversionQueueService_MarkAsCanceled_Call) Run(run func(ctx context.Context, fullpath string, code uint32)) *MockConversionQueueService_MarkAsCanceled_Call {
+func (_c *MockConversionQueueService_FindQueued_Call) Run(run func(ctx context.Context, fullpaths []string)) *MockConversionQueueService_FindQueued_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string), args[2].(uint32))
+		run(args[0].(context.Context), args[1].([]string))
 	})
 	return _c
 }
 
-func (_c *MockConversionQueueService_MarkAsCanceled_Call) Return(_a0 error) *MockConversionQueueService_MarkAsCanceled_Call {
-	_c.Call.Return(_a0)
+func (_c *MockConversionQueueService_FindQueued_Call) Return(_a0 []string, _a1 error) *MockConversionQueueService_FindQueued_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockConversionQueueService_MarkAsCanceled_Call) RunAndReturn(run func(context.Context, string, uint32) error) *MockConversionQueueService_MarkAsCanceled_Call {
+func (_c *MockConversionQueueService_FindQueued_Call) RunAndReturn(run func(context.Context, []string) ([]string, error)) *MockConversionQueueService_FindQueued_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// MarkAsDone provides a mock function with given fields: ctx, fullpath
-func (_m *MockConversionQueueService) MarkAsDone(ctx context.Context, fullpath string) error {
+// FindSource provides a mock function with given fields: ctx, fullpath
+func (_m *MockConversionQueueService) FindSource(ctx context.Context, fullpath string) (*model.Conversion, error) {
 	ret := _m.Called(ctx, fullpath)
 
 	if len(ret) == 0 {
-		panic("no return value specified for MarkAsDone")
+		panic("no return value specified for FindSource")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+	var r0 *model.Conversion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.Conversion, error)); ok {
+		return rf(ctx, fullpath)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Conversion); ok {
 		r0 = rf(ctx, fullpath)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Conversion)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, fullpath)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// MockConversionQueueService_MarkAsDone_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkAsDone'
-type MockConversionQueueService_MarkAsDone_Call struct {
+// MockConversionQueueService_FindSource_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindSource'
+type MockConversionQueueService_FindSource_Call struct {
 	*mock.Call
 }
 
-// MarkAsDone is a helper method to define mock.On call
+// FindSource is a helper method to define mock.On call
 //   - ctx context.Context
 //   - fullpath string
-func (_e *MockConversionQueueService_Expecter) MarkAsDone(ctx interface{}, fullpath interface{}) *MockConversionQueueService_MarkAsDone_Call {
-	return &MockConversionQueueService_MarkAsDone_Call{Call: _e.mock.On("MarkAsDone", ctx, fullpath)}
+func (_e *MockConversionQueueService_Expecter) FindSource(ctx interface{}, fullpath interface{}) *MockConversionQueueService_FindSource_Call {
+	return &MockConversionQueueService_FindSource_Call{Call: _e.mock.On("FindSource", ctx, fullpath)}
 }
 
-func (_c *MockConversionQueueService_MarkAsDone_Call) Run(run func(ctx context.Context, fullpath string)) *MockConversionQueueService_MarkAsDone_Call {
+func (_c *MockConversionQueueService_FindSource_Call) Run(run func(ctx context.Context, fullpath string)) *MockConversionQueueService_FindSource_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *MockConversionQueueService_MarkAsDone_Call) Return(_a0 error) *MockConversionQueueService_MarkAsDone_Call {
-	_c.Call.Return(_a0)
+func (_c *MockConversionQueueService_FindSource_Call) Return(_a0 *model.Conversion, _a1 error) *MockConversionQueueService_FindSource_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockConversionQueueService_MarkAsDone_Call) RunAndReturn(run func(context.Context, string) error) *MockConversionQueueService_MarkAsDone_Call {
+func (_c *MockConversionQueueService_FindSource_Call) RunAndReturn(run func(context.Context, string) (*model.Conversion, error)) *MockConversionQueueService_FindSource_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Pop provides a mock function with given fields: ctx
-func (_m *MockConversionQueueService) Pop(ctx context.Context) (*model.Conversion, error) {
-	ret := _m.Called(ctx)
+// FindSources provides a mock function with given fields: ctx, fullpaths
+func (_m *MockConversionQueueService) FindSources(ctx context.Context, fullpaths []string) (map[string]*model.Conversion, error) {
+	ret := _m.Called(ctx, fullpaths)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Pop")
+		panic("no return value specified for FindSources")
 	}
 
-	var r0 *model.Conversion
+	var r0 map[string]*model.Conversion
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) (*model.Conversion, error)); ok {
-		return rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, []string) (map[string]*model.Conversion, error)); ok {
+		return rf(ctx, fullpaths)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) *model.Conversion); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, []string) map[string]*model.Conversion); ok {
+		r0 = rf(ctx, fullpaths)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*model.Conversion)
+			r0 = ret.Get(0).(map[string]*model.Conversion)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, fullpaths)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -263,30 +460,809 @@ func (_m *MockConversionQueueService) Pop(ctx context.Context) (*model.Conversio
 	return r0, r1
 }
 
-// MockConversionQueueService_Pop_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Pop'
-type MockConversionQueueService_Pop_Call struct {
+// MockConversionQueueService_FindSources_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindSources'
+type MockConversionQueueService_FindSources_Call struct {
 	*mock.Call
 }
 
-// Pop is a helper method to define mock.On call
+// FindSources is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *MockConversionQueueService_Expecter) Pop(ctx interface{}) *MockConversionQueueService_Pop_Call {
-	return &MockConversionQueueService_Pop_Call{Call: _e.mock.On("Pop", ctx)}
+//   - fullpaths []string
+func (_e *MockConversionQueueService_Expecter) FindSources(ctx interface{}, fullpaths interface{}) *MockConversionQueueService_FindSources_Call {
+	return &MockConversionQueueService_FindSources_Call{Call: _e.mock.On("FindSources", ctx, fullpaths)}
 }
 
-func (_c *MockConversionQueueService_Pop_Call) Run(run func(ctx context.Context)) *MockConversionQueueService_Pop_Call {
+func (_c *MockConversionQueueService_FindSources_Call) Run(run func(ctx context.Context, fullpaths []string)) *MockConversionQueueService_FindSources_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context))
+		run(args[0].(context.Context), args[1].([]string))
 	})
 	return _c
 }
 
-func (_c *MockConversionQueueService_Pop_Call) Return(_a0 *model.Conversion, _a1 error) *MockConversionQueueService_Pop_Call {
+func (_c *MockConversionQueueService_FindSources_Call) Return(_a0 map[string]*model.Conversion, _a1 error) *MockConversionQueueService_FindSources_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockConversionQueueService_Pop_Call) RunAndReturn(run func(context.Context) (*model.Conversion, error)) *MockConversionQueueService_Pop_Call {
+func (_c *MockConversionQueueService_FindSources_Call) RunAndReturn(run func(context.Context, []string) (map[string]*model.Conversion, error)) *MockConversionQueueService_FindSources_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Get provides a mock function with given fields: ctx, fullpath
+func (_m *MockConversionQueueService) Get(ctx context.Context, fullpath string) (*model.Conversion, error) {
+	ret := _m.Called(ctx, fullpath)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 *model.Conversion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.Conversion, error)); ok {
+		return rf(ctx, fullpath)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Conversion); ok {
+		r0 = rf(ctx, fullpath)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Conversion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, fullpath)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueService_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
+type MockConversionQueueService_Get_Call struct {
+	*mock.Call
+}
+
+// Get is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fullpath string
+func (_e *MockConversionQueueService_Expecter) Get(ctx interface{}, fullpath interface{}) *MockConversionQueueService_Get_Call {
+	return &MockConversionQueueService_Get_Call{Call: _e.mock.On("Get", ctx, fullpath)}
+}
+
+func (_c *MockConversionQueueService_Get_Call) Run(run func(ctx context.Context, fullpath string)) *MockConversionQueueService_Get_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueService_Get_Call) Return(_a0 *model.Conversion, _a1 error) *MockConversionQueueService_Get_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueService_Get_Call) RunAndReturn(run func(context.Context, string) (*model.Conversion, error)) *MockConversionQueueService_Get_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetById provides a mock function with given fields: ctx, id
+func (_m *MockConversionQueueService) GetById(ctx context.Context, id int64) (*model.Conversion, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetById")
+	}
+
+	var r0 *model.Conversion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*model.Conversion, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *model.Conversion); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Conversion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueService_GetById_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetById'
+type MockConversionQueueService_GetById_Call struct {
+	*mock.Call
+}
+
+// GetById is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int64
+func (_e *MockConversionQueueService_Expecter) GetById(ctx interface{}, id interface{}) *MockConversionQueueService_GetById_Call {
+	return &MockConversionQueueService_GetById_Call{Call: _e.mock.On("GetById", ctx, id)}
+}
+
+func (_c *MockConversionQueueService_GetById_Call) Run(run func(ctx context.Context, id int64)) *MockConversionQueueService_GetById_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueService_GetById_Call) Return(_a0 *model.Conversion, _a1 error) *MockConversionQueueService_GetById_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueService_GetById_Call) RunAndReturn(run func(context.Context, int64) (*model.Conversion, error)) *MockConversionQueueService_GetById_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IsOutputShared provides a mock function with given fields: ctx, conversion, dest
+func (_m *MockConversionQueueService) IsOutputShared(ctx context.Context, conversion *model.Conversion, dest string) (bool, error) {
+	ret := _m.Called(ctx, conversion, dest)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsOutputShared")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Conversion, string) (bool, error)); ok {
+		return rf(ctx, conversion, dest)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Conversion, string) bool); ok {
+		r0 = rf(ctx, conversion, dest)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *model.Conversion, string) error); ok {
+		r1 = rf(ctx, conversion, dest)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueService_IsOutputShared_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsOutputShared'
+type MockConversionQueueService_IsOutputShared_Call struct {
+	*mock.Call
+}
+
+// IsOutputShared is a helper method to define mock.On call
+//   - ctx context.Context
+//   - conversion *model.Conversion
+//   - dest string
+func (_e *MockConversionQueueService_Expecter) IsOutputShared(ctx interface{}, conversion interface{}, dest interface{}) *MockConversionQueueService_IsOutputShared_Call {
+	return &MockConversionQueueService_IsOutputShared_Call{Call: _e.mock.On("IsOutputShared", ctx, conversion, dest)}
+}
+
+func (_c *MockConversionQueueService_IsOutputShared_Call) Run(run func(ctx context.Context, conversion *model.Conversion, dest string)) *MockConversionQueueService_IsOutputShared_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.Conversion), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueService_IsOutputShared_Call) Return(_a0 bool, _a1 error) *MockConversionQueueService_IsOutputShared_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueService_IsOutputShared_Call) RunAndReturn(run func(context.Context, *model.Conversion, string) (bool, error)) *MockConversionQueueService_IsOutputShared_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// List provides a mock function with given fields: ctx, statuses, limit
+func (_m *MockConversionQueueService) List(ctx context.Context, statuses []int, limit uint64) ([]*model.Conversion, error) {
+	ret := _m.Called(ctx, statuses, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 []*model.Conversion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int, uint64) ([]*model.Conversion, error)); ok {
+		return rf(ctx, statuses, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int, uint64) []*model.Conversion); ok {
+		r0 = rf(ctx, statuses, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Conversion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int, uint64) error); ok {
+		r1 = rf(ctx, statuses, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueService_List_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'List'
+type MockConversionQueueService_List_Call struct {
+	*mock.Call
+}
+
+// List is a helper method to define mock.On call
+//   - ctx context.Context
+//   - statuses []int
+//   - limit uint64
+func (_e *MockConversionQueueService_Expecter) List(ctx interface{}, statuses interface{}, limit interface{}) *MockConversionQueueService_List_Call {
+	return &MockConversionQueueService_List_Call{Call: _e.mock.On("List", ctx, statuses, limit)}
+}
+
+func (_c *MockConversionQueueService_List_Call) Run(run func(ctx context.Context, statuses []int, limit uint64)) *MockConversionQueueService_List_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]int), args[2].(uint64))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueService_List_Call) Return(_a0 []*model.Conversion, _a1 error) *MockConversionQueueService_List_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueService_List_Call) RunAndReturn(run func(context.Context, []int, uint64) ([]*model.Conversion, error)) *MockConversionQueueService_List_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MarkAsCanceled provides a mock function with given fields: ctx, fullpath, code
+func (_m *MockConversionQueueService) MarkAsCanceled(ctx context.Context, fullpath string, code uint32) error {
+	ret := _m.Called(ctx, fullpath, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkAsCanceled")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint32) error); ok {
+		r0 = rf(ctx, fullpath, code)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConversionQueueService_MarkAsCanceled_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkAsCanceled'
+type MockConversionQueueService_MarkAsCanceled_Call struct {
+	*mock.Call
+}
+
+// MarkAsCanceled is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fullpath string
+//   - code uint32
+func (_e *MockConversionQueueService_Expecter) MarkAsCanceled(ctx interface{}, fullpath interface{}, code interface{}) *MockConversionQueueService_MarkAsCanceled_Call {
+	return &MockConversionQueueService_MarkAsCanceled_Call{Call: _e.mock.On("MarkAsCanceled", ctx, fullpath, code)}
+}
+
+func (_c *MockConversionQueueService_MarkAsCanceled_Call) Run(run func(ctx context.Context, fullpath string, code uint32)) *MockConversionQueueService_MarkAsCanceled_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(uint32))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueService_MarkAsCanceled_Call) Return(_a0 error) *MockConversionQueueService_MarkAsCanceled_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConversionQueueService_MarkAsCanceled_Call) RunAndReturn(run func(context.Context, string, uint32) error) *MockConversionQueueService_MarkAsCanceled_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MarkAsDone provides a mock function with given fields: ctx, fullpath
+func (_m *MockConversionQueueService) MarkAsDone(ctx context.Context, fullpath string) error {
+	ret := _m.Called(ctx, fullpath)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkAsDone")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, fullpath)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConversionQueueService_MarkAsDone_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkAsDone'
+type MockConversionQueueService_MarkAsDone_Call struct {
+	*mock.Call
+}
+
+// MarkAsDone is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fullpath string
+func (_e *MockConversionQueueService_Expecter) MarkAsDone(ctx interface{}, fullpath interface{}) *MockConversionQueueService_MarkAsDone_Call {
+	return &MockConversionQueueService_MarkAsDone_Call{Call: _e.mock.On("MarkAsDone", ctx, fullpath)}
+}
+
+func (_c *MockConversionQueueService_MarkAsDone_Call) Run(run func(ctx context.Context, fullpath string)) *MockConversionQueueService_MarkAsDone_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueService_MarkAsDone_Call) Return(_a0 error) *MockConversionQueueService_MarkAsDone_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConversionQueueService_MarkAsDone_Call) RunAndReturn(run func(context.Context, string) error) *MockConversionQueueService_MarkAsDone_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Pop provides a mock function with given fields: ctx
+func (_m *MockConversionQueueService) Pop(ctx context.Context) (*model.Conversion, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Pop")
+	}
+
+	var r0 *model.Conversion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*model.Conversion, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *model.Conversion); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Conversion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueService_Pop_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Pop'
+type MockConversionQueueService_Pop_Call struct {
+	*mock.Call
+}
+
+// Pop is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockConversionQueueService_Expecter) Pop(ctx interface{}) *MockConversionQueueService_Pop_Call {
+	return &MockConversionQueueService_Pop_Call{Call: _e.mock.On("Pop", ctx)}
+}
+
+func (_c *MockConversionQueueService_Pop_Call) Run(run func(ctx context.Context)) *MockConversionQueueService_Pop_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueService_Pop_Call) Return(_a0 *model.Conversion, _a1 error) *MockConversionQueueService_Pop_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueService_Pop_Call) RunAndReturn(run func(context.Context) (*model.Conversion, error)) *MockConversionQueueService_Pop_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Prepare provides a mock function with given fields: info, mediaType
+func (_m *MockConversionQueueService) Prepare(info *model.ConversionInfo, mediaType file.MediaType) error {
+	ret := _m.Called(info, mediaType)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Prepare")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.ConversionInfo, file.MediaType) error); ok {
+		r0 = rf(info, mediaType)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConversionQueueService_Prepare_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Prepare'
+type MockConversionQueueService_Prepare_Call struct {
+	*mock.Call
+}
+
+// Prepare is a helper method to define mock.On call
+//   - info *model.ConversionInfo
+//   - mediaType file.MediaType
+func (_e *MockConversionQueueService_Expecter) Prepare(info interface{}, mediaType interface{}) *MockConversionQueueService_Prepare_Call {
+	return &MockConversionQueueService_Prepare_Call{Call: _e.mock.On("Prepare", info, mediaType)}
+}
+
+func (_c *MockConversionQueueService_Prepare_Call) Run(run func(info *model.ConversionInfo, mediaType file.MediaType)) *MockConversionQueueService_Prepare_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.ConversionInfo), args[1].(file.MediaType))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueService_Prepare_Call) Return(_a0 error) *MockConversionQueueService_Prepare_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConversionQueueService_Prepare_Call) RunAndReturn(run func(*model.ConversionInfo, file.MediaType) error) *MockConversionQueueService_Prepare_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Presets provides a mock function with no fields
+func (_m *MockConversionQueueService) Presets() []*model.Preset {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Presets")
+	}
+
+	var r0 []*model.Preset
+	if rf, ok := ret.Get(0).(func() []*model.Preset); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Preset)
+		}
+	}
+
+	return r0
+}
+
+// MockConversionQueueService_Presets_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Presets'
+type MockConversionQueueService_Presets_Call struct {
+	*mock.Call
+}
+
+// Presets is a helper method to define mock.On call
+func (_e *MockConversionQueueService_Expecter) Presets() *MockConversionQueueService_Presets_Call {
+	return &MockConversionQueueService_Presets_Call{Call: _e.mock.On("Presets")}
+}
+
+func (_c *MockConversionQueueService_Presets_Call) Run(run func()) *MockConversionQueueService_Presets_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueService_Presets_Call) Return(_a0 []*model.Preset) *MockConversionQueueService_Presets_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConversionQueueService_Presets_Call) RunAndReturn(run func() []*model.Preset) *MockConversionQueueService_Presets_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Requeue provides a mock function with given fields: ctx, fullpath
+func (_m *MockConversionQueueService) Requeue(ctx context.Context, fullpath string) error {
+	ret := _m.Called(ctx, fullpath)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Requeue")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, fullpath)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConversionQueueService_Requeue_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Requeue'
+type MockConversionQueueService_Requeue_Call struct {
+	*mock.Call
+}
+
+// Requeue is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fullpath string
+func (_e *MockConversionQueueService_Expecter) Requeue(ctx interface{}, fullpath interface{}) *MockConversionQueueService_Requeue_Call {
+	return &MockConversionQueueService_Requeue_Call{Call: _e.mock.On("Requeue", ctx, fullpath)}
+}
+
+func (_c *MockConversionQueueService_Requeue_Call) Run(run func(ctx context.Context, fullpath string)) *MockConversionQueueService_Requeue_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueService_Requeue_Call) Return(_a0 error) *MockConversionQueueService_Requeue_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConversionQueueService_Requeue_Call) RunAndReturn(run func(context.Context, string) error) *MockConversionQueueService_Requeue_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RequeueCanceled provides a mock function with given fields: ctx
+func (_m *MockConversionQueueService) RequeueCanceled(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequeueCanceled")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockConversionQueueService_RequeueCanceled_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RequeueCanceled'
+type MockConversionQueueService_RequeueCanceled_Call struct {
+	*mock.Call
+}
+
+// RequeueCanceled is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockConversionQueueService_Expecter) RequeueCanceled(ctx interface{}) *MockConversionQueueService_RequeueCanceled_Call {
+	return &MockConversionQueueService_RequeueCanceled_Call{Call: _e.mock.On("RequeueCanceled", ctx)}
+}
+
+func (_c *MockConversionQueueService_RequeueCanceled_Call) Run(run func(ctx context.Context)) *MockConversionQueueService_RequeueCanceled_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueService_RequeueCanceled_Call) Return(_a0 int64, _a1 error) *MockConversionQueueService_RequeueCanceled_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockConversionQueueService_RequeueCanceled_Call) RunAndReturn(run func(context.Context) (int64, error)) *MockConversionQueueService_RequeueCanceled_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveConfigDigests provides a mock function with given fields: ctx, fullpath, digests
+func (_m *MockConversionQueueService) SaveConfigDigests(ctx context.Context, fullpath string, digests map[string]string) error {
+	ret := _m.Called(ctx, fullpath, digests)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveConfigDigests")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]string) error); ok {
+		r0 = rf(ctx, fullpath, digests)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConversionQueueService_SaveConfigDigests_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveConfigDigests'
+type MockConversionQueueService_SaveConfigDigests_Call struct {
+	*mock.Call
+}
+
+// SaveConfigDigests is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fullpath string
+//   - digests map[string]string
+func (_e *MockConversionQueueService_Expecter) SaveConfigDigests(ctx interface{}, fullpath interface{}, digests interface{}) *MockConversionQueueService_SaveConfigDigests_Call {
+	return &MockConversionQueueService_SaveConfigDigests_Call{Call: _e.mock.On("SaveConfigDigests", ctx, fullpath, digests)}
+}
+
+func (_c *MockConversionQueueService_SaveConfigDigests_Call) Run(run func(ctx context.Context, fullpath string, digests map[string]string)) *MockConversionQueueService_SaveConfigDigests_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(map[string]string))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueService_SaveConfigDigests_Call) Return(_a0 error) *MockConversionQueueService_SaveConfigDigests_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConversionQueueService_SaveConfigDigests_Call) RunAndReturn(run func(context.Context, string, map[string]string) error) *MockConversionQueueService_SaveConfigDigests_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveDedupOrigin provides a mock function with given fields: ctx, fullpath, origin
+func (_m *MockConversionQueueService) SaveDedupOrigin(ctx context.Context, fullpath string, origin string) error {
+	ret := _m.Called(ctx, fullpath, origin)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveDedupOrigin")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, fullpath, origin)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConversionQueueService_SaveDedupOrigin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveDedupOrigin'
+type MockConversionQueueService_SaveDedupOrigin_Call struct {
+	*mock.Call
+}
+
+// SaveDedupOrigin is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fullpath string
+//   - origin string
+func (_e *MockConversionQueueService_Expecter) SaveDedupOrigin(ctx interface{}, fullpath interface{}, origin interface{}) *MockConversionQueueService_SaveDedupOrigin_Call {
+	return &MockConversionQueueService_SaveDedupOrigin_Call{Call: _e.mock.On("SaveDedupOrigin", ctx, fullpath, origin)}
+}
+
+func (_c *MockConversionQueueService_SaveDedupOrigin_Call) Run(run func(ctx context.Context, fullpath string, origin string)) *MockConversionQueueService_SaveDedupOrigin_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueService_SaveDedupOrigin_Call) Return(_a0 error) *MockConversionQueueService_SaveDedupOrigin_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConversionQueueService_SaveDedupOrigin_Call) RunAndReturn(run func(context.Context, string, string) error) *MockConversionQueueService_SaveDedupOrigin_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveQualityResults provides a mock function with given fields: ctx, fullpath, results
+func (_m *MockConversionQueueService) SaveQualityResults(ctx context.Context, fullpath string, results []model.QualityResult) error {
+	ret := _m.Called(ctx, fullpath, results)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveQualityResults")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []model.QualityResult) error); ok {
+		r0 = rf(ctx, fullpath, results)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConversionQueueService_SaveQualityResults_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveQualityResults'
+type MockConversionQueueService_SaveQualityResults_Call struct {
+	*mock.Call
+}
+
+// SaveQualityResults is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fullpath string
+//   - results []model.QualityResult
+func (_e *MockConversionQueueService_Expecter) SaveQualityResults(ctx interface{}, fullpath interface{}, results interface{}) *MockConversionQueueService_SaveQualityResults_Call {
+	return &MockConversionQueueService_SaveQualityResults_Call{Call: _e.mock.On("SaveQualityResults", ctx, fullpath, results)}
+}
+
+func (_c *MockConversionQueueService_SaveQualityResults_Call) Run(run func(ctx context.Context, fullpath string, results []model.QualityResult)) *MockConversionQueueService_SaveQualityResults_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].([]model.QualityResult))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueService_SaveQualityResults_Call) Return(_a0 error) *MockConversionQueueService_SaveQualityResults_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConversionQueueService_SaveQualityResults_Call) RunAndReturn(run func(context.Context, string, []model.QualityResult) error) *MockConversionQueueService_SaveQualityResults_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveSourceHash provides a mock function with given fields: ctx, fullpath, hash
+func (_m *MockConversionQueueService) SaveSourceHash(ctx context.Context, fullpath string, hash string) error {
+	ret := _m.Called(ctx, fullpath, hash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveSourceHash")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, fullpath, hash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConversionQueueService_SaveSourceHash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveSourceHash'
+type MockConversionQueueService_SaveSourceHash_Call struct {
+	*mock.Call
+}
+
+// SaveSourceHash is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fullpath string
+//   - hash string
+func (_e *MockConversionQueueService_Expecter) SaveSourceHash(ctx interface{}, fullpath interface{}, hash interface{}) *MockConversionQueueService_SaveSourceHash_Call {
+	return &MockConversionQueueService_SaveSourceHash_Call{Call: _e.mock.On("SaveSourceHash", ctx, fullpath, hash)}
+}
+
+func (_c *MockConversionQueueService_SaveSourceHash_Call) Run(run func(ctx context.Context, fullpath string, hash string)) *MockConversionQueueService_SaveSourceHash_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockConversionQueueService_SaveSourceHash_Call) Return(_a0 error) *MockConversionQueueService_SaveSourceHash_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConversionQueueService_SaveSourceHash_Call) RunAndReturn(run func(context.Context, string, string) error) *MockConversionQueueService_SaveSourceHash_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -79,6 +79,178 @@ func (_c *MockDeletionQueueService_Add_Call) RunAndReturn(run func(context.Conte
 	return _c
 }
 
+// AddMany provides a mock function with given fields: ctx, infos
+func (_m *MockDeletionQueueService) AddMany(ctx context.Context, infos []*model.DeletionInfo) ([]model.BatchResult, error) {
+	ret := _m.Called(ctx, infos)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddMany")
+	}
+
+	var r0 []model.BatchResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*model.DeletionInfo) ([]model.BatchResult, error)); ok {
+		return rf(ctx, infos)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []*model.DeletionInfo) []model.BatchResult); ok {
+		r0 = rf(ctx, infos)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.BatchResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []*model.DeletionInfo) error); ok {
+		r1 = rf(ctx, infos)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDeletionQueueService_AddMany_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddMany'
+type MockDeletionQueueService_AddMany_Call struct {
+	*mock.Call
+}
+
+// AddMany is a helper method to define mock.On call
+//   - ctx context.Context
+//   - infos []*model.DeletionInfo
+func (_e *MockDeletionQueueService_Expecter) AddMany(ctx interface{}, infos interface{}) *MockDeletionQueueService_AddMany_Call {
+	return &MockDeletionQueueService_AddMany_Call{Call: _e.mock.On("AddMany", ctx, infos)}
+}
+
+func (_c *MockDeletionQueueService_AddMany_Call) Run(run func(ctx context.Context, infos []*model.DeletionInfo)) *MockDeletionQueueService_AddMany_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]*model.DeletionInfo))
+	})
+	return _c
+}
+
+func (_c *MockDeletionQueueService_AddMany_Call) Return(_a0 []model.BatchResult, _a1 error) *MockDeletionQueueService_AddMany_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDeletionQueueService_AddMany_Call) RunAndReturn(run func(context.Context, []*model.DeletionInfo) ([]model.BatchResult, error)) *MockDeletionQueueService_AddMany_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// AddRecursive provides a mock function with given fields: ctx, info
+func (_m *MockDeletionQueueService) AddRecursive(ctx context.Context, info *model.DeletionInfo) (int64, error) {
+	ret := _m.Called(ctx, info)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddRecursive")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.DeletionInfo) (int64, error)); ok {
+		return rf(ctx, info)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *model.DeletionInfo) int64); ok {
+		r0 = rf(ctx, info)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *model.DeletionInfo) error); ok {
+		r1 = rf(ctx, info)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDeletionQueueService_AddRecursive_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddRecursive'
+type MockDeletionQueueService_AddRecursive_Call struct {
+	*mock.Call
+}
+
+// AddRecursive is a helper method to define mock.On call
+//   - ctx context.Context
+//   - info *model.DeletionInfo
+func (_e *MockDeletionQueueService_Expecter) AddRecursive(ctx interface{}, info interface{}) *MockDeletionQueueService_AddRecursive_Call {
+	return &MockDeletionQueueService_AddRecursive_Call{Call: _e.mock.On("AddRecursive", ctx, info)}
+}
+
+func (_c *MockDeletionQueueService_AddRecursive_Call) Run(run func(ctx context.Context, info *model.DeletionInfo)) *MockDeletionQueueService_AddRecursive_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.DeletionInfo))
+	})
+	return _c
+}
+
+func (_c *MockDeletionQueueService_AddRecursive_Call) Return(_a0 int64, _a1 error) *MockDeletionQueueService_AddRecursive_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDeletionQueueService_AddRecursive_Call) RunAndReturn(run func(context.Context, *model.DeletionInfo) (int64, error)) *MockDeletionQueueService_AddRecursive_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CountPending provides a mock function with given fields: ctx
+func (_m *MockDeletionQueueService) CountPending(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountPending")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDeletionQueueService_CountPending_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountPending'
+type MockDeletionQueueService_CountPending_Call struct {
+	*mock.Call
+}
+
+// CountPending is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockDeletionQueueService_Expecter) CountPending(ctx interface{}) *MockDeletionQueueService_CountPending_Call {
+	return &MockDeletionQueueService_CountPending_Call{Call: _e.mock.On("CountPending", ctx)}
+}
+
+func (_c *MockDeletionQueueService_CountPending_Call) Run(run func(ctx context.Context)) *MockDeletionQueueService_CountPending_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockDeletionQueueService_CountPending_Call) Return(_a0 int64, _a1 error) *MockDeletionQueueService_CountPending_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDeletionQueueService_CountPending_Call) RunAndReturn(run func(context.Context) (int64, error)) *MockDeletionQueueService_CountPending_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Get provides a mock function with given fields: ctx, fullpath
 func (_m *MockDeletionQueueService) Get(ctx context.Context, fullpath string) (*model.Deletion, error) {
 	ret := _m.Called(ctx, fullpath)
@@ -291,6 +463,53 @@ func (_c *MockDeletionQueueService_Pop_Call) RunAndReturn(run func(context.Conte
 	return _c
 }
 
+// Purge provides a mock function with given fields: ctx, fullpath
+func (_m *MockDeletionQueueService) Purge(ctx context.Context, fullpath string) error {
+	ret := _m.Called(ctx, fullpath)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Purge")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, fullpath)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDeletionQueueService_Purge_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Purge'
+type MockDeletionQueueService_Purge_Call struct {
+	*mock.Call
+}
+
+// Purge is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fullpath string
+func (_e *MockDeletionQueueService_Expecter) Purge(ctx interface{}, fullpath interface{}) *MockDeletionQueueService_Purge_Call {
+	return &MockDeletionQueueService_Purge_Call{Call: _e.mock.On("Purge", ctx, fullpath)}
+}
+
+func (_c *MockDeletionQueueService_Purge_Call) Run(run func(ctx context.Context, fullpath string)) *MockDeletionQueueService_Purge_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockDeletionQueueService_Purge_Call) Return(_a0 error) *MockDeletionQueueService_Purge_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDeletionQueueService_Purge_Call) RunAndReturn(run func(context.Context, string) error) *MockDeletionQueueService_Purge_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockDeletionQueueService creates a new instance of MockDeletionQueueService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockDeletionQueueService(t interface {
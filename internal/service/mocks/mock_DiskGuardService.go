@@ -0,0 +1,216 @@
+// Code generated by mockery v2.50.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	model "github.com/chistyakoviv/converter/internal/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockDiskGuardService is an autogenerated mock type for the DiskGuardService type
+type MockDiskGuardService struct {
+	mock.Mock
+}
+
+type MockDiskGuardService_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockDiskGuardService) EXPECT() *MockDiskGuardService_Expecter {
+	return &MockDiskGuardService_Expecter{mock: &_m.Mock}
+}
+
+// Check provides a mock function with given fields: size
+func (_m *MockDiskGuardService) Check(size uint64) error {
+	ret := _m.Called(size)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Check")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint64) error); ok {
+		r0 = rf(size)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDiskGuardService_Check_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Check'
+type MockDiskGuardService_Check_Call struct {
+	*mock.Call
+}
+
+// Check is a helper method to define mock.On call
+//   - size uint64
+func (_e *MockDiskGuardService_Expecter) Check(size interface{}) *MockDiskGuardService_Check_Call {
+	return &MockDiskGuardService_Check_Call{Call: _e.mock.On("Check", size)}
+}
+
+func (_c *MockDiskGuardService_Check_Call) Run(run func(size uint64)) *MockDiskGuardService_Check_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uint64))
+	})
+	return _c
+}
+
+func (_c *MockDiskGuardService_Check_Call) Return(_a0 error) *MockDiskGuardService_Check_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDiskGuardService_Check_Call) RunAndReturn(run func(uint64) error) *MockDiskGuardService_Check_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IsPaused provides a mock function with no fields
+func (_m *MockDiskGuardService) IsPaused() bool {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsPaused")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// MockDiskGuardService_IsPaused_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsPaused'
+type MockDiskGuardService_IsPaused_Call struct {
+	*mock.Call
+}
+
+// IsPaused is a helper method to define mock.On call
+func (_e *MockDiskGuardService_Expecter) IsPaused() *MockDiskGuardService_IsPaused_Call {
+	return &MockDiskGuardService_IsPaused_Call{Call: _e.mock.On("IsPaused")}
+}
+
+func (_c *MockDiskGuardService_IsPaused_Call) Run(run func()) *MockDiskGuardService_IsPaused_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockDiskGuardService_IsPaused_Call) Return(_a0 bool) *MockDiskGuardService_IsPaused_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDiskGuardService_IsPaused_Call) RunAndReturn(run func() bool) *MockDiskGuardService_IsPaused_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Resume provides a mock function with no fields
+func (_m *MockDiskGuardService) Resume() bool {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Resume")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// MockDiskGuardService_Resume_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Resume'
+type MockDiskGuardService_Resume_Call struct {
+	*mock.Call
+}
+
+// Resume is a helper method to define mock.On call
+func (_e *MockDiskGuardService_Expecter) Resume() *MockDiskGuardService_Resume_Call {
+	return &MockDiskGuardService_Resume_Call{Call: _e.mock.On("Resume")}
+}
+
+func (_c *MockDiskGuardService_Resume_Call) Run(run func()) *MockDiskGuardService_Resume_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockDiskGuardService_Resume_Call) Return(_a0 bool) *MockDiskGuardService_Resume_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDiskGuardService_Resume_Call) RunAndReturn(run func() bool) *MockDiskGuardService_Resume_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// State provides a mock function with no fields
+func (_m *MockDiskGuardService) State() model.DiskState {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for State")
+	}
+
+	var r0 model.DiskState
+	if rf, ok := ret.Get(0).(func() model.DiskState); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(model.DiskState)
+	}
+
+	return r0
+}
+
+// MockDiskGuardService_State_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'State'
+type MockDiskGuardService_State_Call struct {
+	*mock.Call
+}
+
+// State is a helper method to define mock.On call
+func (_e *MockDiskGuardService_Expecter) State() *MockDiskGuardService_State_Call {
+	return &MockDiskGuardService_State_Call{Call: _e.mock.On("State")}
+}
+
+func (_c *MockDiskGuardService_State_Call) Run(run func()) *MockDiskGuardService_State_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockDiskGuardService_State_Call) Return(_a0 model.DiskState) *MockDiskGuardService_State_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDiskGuardService_State_Call) RunAndReturn(run func() model.DiskState) *MockDiskGuardService_State_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockDiskGuardService creates a new instance of MockDiskGuardService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockDiskGuardService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockDiskGuardService {
+	mock := &MockDiskGuardService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
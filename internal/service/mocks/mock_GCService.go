@@ -0,0 +1,97 @@
+// Code generated by mockery v2.50.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/chistyakoviv/converter/internal/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockGCService is an autogenerated mock type for the GCService type
+type MockGCService struct {
+	mock.Mock
+}
+
+type MockGCService_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockGCService) EXPECT() *MockGCService_Expecter {
+	return &MockGCService_Expecter{mock: &_m.Mock}
+}
+
+// Collect provides a mock function with given fields: ctx, rootDir, opts
+func (_m *MockGCService) Collect(ctx context.Context, rootDir string, opts model.GCOptions) (*model.GCReport, error) {
+	ret := _m.Called(ctx, rootDir, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Collect")
+	}
+
+	var r0 *model.GCReport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.GCOptions) (*model.GCReport, error)); ok {
+		return rf(ctx, rootDir, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.GCOptions) *model.GCReport); ok {
+		r0 = rf(ctx, rootDir, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.GCReport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, model.GCOptions) error); ok {
+		r1 = rf(ctx, rootDir, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockGCService_Collect_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Collect'
+type MockGCService_Collect_Call struct {
+	*mock.Call
+}
+
+// Collect is a helper method to define mock.On call
+//   - ctx context.Context
+//   - rootDir string
+//   - opts model.GCOptions
+func (_e *MockGCService_Expecter) Collect(ctx interface{}, rootDir interface{}, opts interface{}) *MockGCService_Collect_Call {
+	return &MockGCService_Collect_Call{Call: _e.mock.On("Collect", ctx, rootDir, opts)}
+}
+
+func (_c *MockGCService_Collect_Call) Run(run func(ctx context.Context, rootDir string, opts model.GCOptions)) *MockGCService_Collect_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(model.GCOptions))
+	})
+	return _c
+}
+
+func (_c *MockGCService_Collect_Call) Return(_a0 *model.GCReport, _a1 error) *MockGCService_Collect_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockGCService_Collect_Call) RunAndReturn(run func(context.Context, string, model.GCOptions) (*model.GCReport, error)) *MockGCService_Collect_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockGCService creates a new instance of MockGCService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockGCService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockGCService {
+	mock := &MockGCService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
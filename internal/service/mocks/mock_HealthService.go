@@ -0,0 +1,165 @@
+// Code generated by mockery v2.50.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	model "github.com/chistyakoviv/converter/internal/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockHealthService is an autogenerated mock type for the HealthService type
+type MockHealthService struct {
+	mock.Mock
+}
+
+type MockHealthService_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockHealthService) EXPECT() *MockHealthService_Expecter {
+	return &MockHealthService_Expecter{mock: &_m.Mock}
+}
+
+// Live provides a mock function with given fields: ctx
+func (_m *MockHealthService) Live(ctx context.Context) *model.HealthReport {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Live")
+	}
+
+	var r0 *model.HealthReport
+	if rf, ok := ret.Get(0).(func(context.Context) *model.HealthReport); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.HealthReport)
+		}
+	}
+
+	return r0
+}
+
+// MockHealthService_Live_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Live'
+type MockHealthService_Live_Call struct {
+	*mock.Call
+}
+
+// Live is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockHealthService_Expecter) Live(ctx interface{}) *MockHealthService_Live_Call {
+	return &MockHealthService_Live_Call{Call: _e.mock.On("Live", ctx)}
+}
+
+func (_c *MockHealthService_Live_Call) Run(run func(ctx context.Context)) *MockHealthService_Live_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockHealthService_Live_Call) Return(_a0 *model.HealthReport) *MockHealthService_Live_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockHealthService_Live_Call) RunAndReturn(run func(context.Context) *model.HealthReport) *MockHealthService_Live_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Ready provides a mock function with given fields: ctx
+func (_m *MockHealthService) Ready(ctx context.Context) *model.HealthReport {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Ready")
+	}
+
+	var r0 *model.HealthReport
+	if rf, ok := ret.Get(0).(func(context.Context) *model.HealthReport); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.HealthReport)
+		}
+	}
+
+	return r0
+}
+
+// MockHealthService_Ready_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Ready'
+type MockHealthService_Ready_Call struct {
+	*mock.Call
+}
+
+// Ready is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockHealthService_Expecter) Ready(ctx interface{}) *MockHealthService_Ready_Call {
+	return &MockHealthService_Ready_Call{Call: _e.mock.On("Ready", ctx)}
+}
+
+func (_c *MockHealthService_Ready_Call) Run(run func(ctx context.Context)) *MockHealthService_Ready_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockHealthService_Ready_Call) Return(_a0 *model.HealthReport) *MockHealthService_Ready_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockHealthService_Ready_Call) RunAndReturn(run func(context.Context) *model.HealthReport) *MockHealthService_Ready_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Shutdown provides a mock function with no fields
+func (_m *MockHealthService) Shutdown() {
+	_m.Called()
+}
+
+// MockHealthService_Shutdown_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Shutdown'
+type MockHealthService_Shutdown_Call struct {
+	*mock.Call
+}
+
+// Shutdown is a helper method to define mock.On call
+func (_e *MockHealthService_Expecter) Shutdown() *MockHealthService_Shutdown_Call {
+	return &MockHealthService_Shutdown_Call{Call: _e.mock.On("Shutdown")}
+}
+
+func (_c *MockHealthService_Shutdown_Call) Run(run func()) *MockHealthService_Shutdown_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockHealthService_Shutdown_Call) Return() *MockHealthService_Shutdown_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockHealthService_Shutdown_Call) RunAndReturn(run func()) *MockHealthService_Shutdown_Call {
+	_c.Run(run)
+	return _c
+}
+
+// NewMockHealthService creates a new instance of MockHealthService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockHealthService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockHealthService {
+	mock := &MockHealthService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,127 @@
+// Code generated by mockery v2.50.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockReloaderService is an autogenerated mock type for the ReloaderService type
+type MockReloaderService struct {
+	mock.Mock
+}
+
+type MockReloaderService_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockReloaderService) EXPECT() *MockReloaderService_Expecter {
+	return &MockReloaderService_Expecter{mock: &_m.Mock}
+}
+
+// Reload provides a mock function with no fields
+func (_m *MockReloaderService) Reload() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Reload")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockReloaderService_Reload_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Reload'
+type MockReloaderService_Reload_Call struct {
+	*mock.Call
+}
+
+// Reload is a helper method to define mock.On call
+func (_e *MockReloaderService_Expecter) Reload() *MockReloaderService_Reload_Call {
+	return &MockReloaderService_Reload_Call{Call: _e.mock.On("Reload")}
+}
+
+func (_c *MockReloaderService_Reload_Call) Run(run func()) *MockReloaderService_Reload_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockReloaderService_Reload_Call) Return(_a0 error) *MockReloaderService_Reload_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockReloaderService_Reload_Call) RunAndReturn(run func() error) *MockReloaderService_Reload_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Watch provides a mock function with given fields: ctx
+func (_m *MockReloaderService) Watch(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Watch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockReloaderService_Watch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Watch'
+type MockReloaderService_Watch_Call struct {
+	*mock.Call
+}
+
+// Watch is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockReloaderService_Expecter) Watch(ctx interface{}) *MockReloaderService_Watch_Call {
+	return &MockReloaderService_Watch_Call{Call: _e.mock.On("Watch", ctx)}
+}
+
+func (_c *MockReloaderService_Watch_Call) Run(run func(ctx context.Context)) *MockReloaderService_Watch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockReloaderService_Watch_Call) Return(_a0 error) *MockReloaderService_Watch_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockReloaderService_Watch_Call) RunAndReturn(run func(context.Context) error) *MockReloaderService_Watch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockReloaderService creates a new instance of MockReloaderService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockReloaderService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockReloaderService {
+	mock := &MockReloaderService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
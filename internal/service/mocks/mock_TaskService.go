@@ -5,6 +5,7 @@ package mocks
 import (
 	context "context"
 
+	model "github.com/chistyakoviv/converter/internal/model"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -21,6 +22,167 @@ func (_m *MockTaskService) EXPECT() *MockTaskService_Expecter {
 	return &MockTaskService_Expecter{mock: &_m.Mock}
 }
 
+// CancelScan provides a mock function with given fields: id
+func (_m *MockTaskService) CancelScan(id string) (*model.Scan, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CancelScan")
+	}
+
+	var r0 *model.Scan
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*model.Scan, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *model.Scan); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Scan)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockTaskService_CancelScan_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CancelScan'
+type MockTaskService_CancelScan_Call struct {
+	*mock.Call
+}
+
+// CancelScan is a helper method to define mock.On call
+//   - id string
+func (_e *MockTaskService_Expecter) CancelScan(id interface{}) *MockTaskService_CancelScan_Call {
+	return &MockTaskService_CancelScan_Call{Call: _e.mock.On("CancelScan", id)}
+}
+
+func (_c *MockTaskService_CancelScan_Call) Run(run func(id string)) *MockTaskService_CancelScan_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockTaskService_CancelScan_Call) Return(_a0 *model.Scan, _a1 error) *MockTaskService_CancelScan_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockTaskService_CancelScan_Call) RunAndReturn(run func(string) (*model.Scan, error)) *MockTaskService_CancelScan_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetScan provides a mock function with given fields: id
+func (_m *MockTaskService) GetScan(id string) (*model.Scan, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetScan")
+	}
+
+	var r0 *model.Scan
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*model.Scan, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *model.Scan); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Scan)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockTaskService_GetScan_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetScan'
+type MockTaskService_GetScan_Call struct {
+	*mock.Call
+}
+
+// GetScan is a helper method to define mock.On call
+//   - id string
+func (_e *MockTaskService_Expecter) GetScan(id interface{}) *MockTaskService_GetScan_Call {
+	return &MockTaskService_GetScan_Call{Call: _e.mock.On("GetScan", id)}
+}
+
+func (_c *MockTaskService_GetScan_Call) Run(run func(id string)) *MockTaskService_GetScan_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockTaskService_GetScan_Call) Return(_a0 *model.Scan, _a1 error) *MockTaskService_GetScan_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockTaskService_GetScan_Call) RunAndReturn(run func(string) (*model.Scan, error)) *MockTaskService_GetScan_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IsProcessing provides a mock function with no fields
+func (_m *MockTaskService) IsProcessing() bool {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsProcessing")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// MockTaskService_IsProcessing_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsProcessing'
+type MockTaskService_IsProcessing_Call struct {
+	*mock.Call
+}
+
+// IsProcessing is a helper method to define mock.On call
+func (_e *MockTaskService_Expecter) IsProcessing() *MockTaskService_IsProcessing_Call {
+	return &MockTaskService_IsProcessing_Call{Call: _e.mock.On("IsProcessing")}
+}
+
+func (_c *MockTaskService_IsProcessing_Call) Run(run func()) *MockTaskService_IsProcessing_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockTaskService_IsProcessing_Call) Return(_a0 bool) *MockTaskService_IsProcessing_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockTaskService_IsProcessing_Call) RunAndReturn(run func() bool) *MockTaskService_IsProcessing_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // IsScanning provides a mock function with no fields
 func (_m *MockTaskService) IsScanning() bool {
 	ret := _m.Called()
@@ -99,22 +261,34 @@ func (_c *MockTaskService_ProcessQueues_Call) RunAndReturn(run func(context.Cont
 	return _c
 }
 
-// ProcessScanfs provides a mock function with given fields: ctx, rootDir
-func (_m *MockTaskService) ProcessScanfs(ctx context.Context, rootDir string) error {
-	ret := _m.Called(ctx, rootDir)
+// ProcessScanfs provides a mock function with given fields: ctx, rootDir, opts
+func (_m *MockTaskService) ProcessScanfs(ctx context.Context, rootDir string, opts model.ScanOptions) (*model.Scan, error) {
+	ret := _m.Called(ctx, rootDir, opts)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ProcessScanfs")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, rootDir)
+	var r0 *model.Scan
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.ScanOptions) (*model.Scan, error)); ok {
+		return rf(ctx, rootDir, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.ScanOptions) *model.Scan); ok {
+		r0 = rf(ctx, rootDir, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Scan)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, model.ScanOptions) error); ok {
+		r1 = rf(ctx, rootDir, opts)
 	} else {
-		r0 = ret.Error(0)
+		r1 = ret.Error(1)
 	}
 
-	return r0
+	return r0, r1
 }
 
 // MockTaskService_ProcessScanfs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ProcessScanfs'
@@ -125,23 +299,24 @@ type MockTaskService_ProcessScanfs_Call struct {
 // ProcessScanfs is a helper method to define mock.On call
 //   - ctx context.Context
 //   - rootDir string
-func (_e *MockTaskService_Expecter) ProcessScanfs(ctx interface{}, rootDir interface{}) *MockTaskService_ProcessScanfs_Call {
-	return &MockTaskService_ProcessScanfs_Call{Call: _e.mock.On("ProcessScanfs", ctx, rootDir)}
+//   - opts model.ScanOptions
+func (_e *MockTaskService_Expecter) ProcessScanfs(ctx interface{}, rootDir interface{}, opts interface{}) *MockTaskService_ProcessScanfs_Call {
+	return &MockTaskService_ProcessScanfs_Call{Call: _e.mock.On("ProcessScanfs", ctx, rootDir, opts)}
 }
 
-func (_c *MockTaskService_ProcessScanfs_Call) Run(run func(ctx context.Context, rootDir string)) *MockTaskService_ProcessScanfs_Call {
+func (_c *MockTaskService_ProcessScanfs_Call) Run(run func(ctx context.Context, rootDir string, opts model.ScanOptions)) *MockTaskService_ProcessScanfs_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string))
+		run(args[0].(context.Context), args[1].(string), args[2].(model.ScanOptions))
 	})
 	return _c
 }
 
-func (_c *MockTaskService_ProcessScanfs_Call) Return(_a0 error) *MockTaskService_ProcessScanfs_Call {
-	_c.Call.Return(_a0)
+func (_c *MockTaskService_ProcessScanfs_Call) Return(_a0 *model.Scan, _a1 error) *MockTaskService_ProcessScanfs_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockTaskService_ProcessScanfs_Call) RunAndReturn(run func(context.Context, string) error) *MockTaskService_ProcessScanfs_Call {
+func (_c *MockTaskService_ProcessScanfs_Call) RunAndReturn(run func(context.Context, string, model.ScanOptions) (*model.Scan, error)) *MockTaskService_ProcessScanfs_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -178,6 +353,66 @@ func (_c *MockTaskService_Shutdown_Call) RunAndReturn(run func()) *MockTaskServi
 	return _c
 }
 
+// StartScan provides a mock function with given fields: ctx, rootDir, opts
+func (_m *MockTaskService) StartScan(ctx context.Context, rootDir string, opts model.ScanOptions) (*model.Scan, error) {
+	ret := _m.Called(ctx, rootDir, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StartScan")
+	}
+
+	var r0 *model.Scan
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.ScanOptions) (*model.Scan, error)); ok {
+		return rf(ctx, rootDir, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.ScanOptions) *model.Scan); ok {
+		r0 = rf(ctx, rootDir, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Scan)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, model.ScanOptions) error); ok {
+		r1 = rf(ctx, rootDir, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockTaskService_StartScan_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StartScan'
+type MockTaskService_StartScan_Call struct {
+	*mock.Call
+}
+
+// StartScan is a helper method to define mock.On call
+//   - ctx context.Context
+//   - rootDir string
+//   - opts model.ScanOptions
+func (_e *MockTaskService_Expecter) StartScan(ctx interface{}, rootDir interface{}, opts interface{}) *MockTaskService_StartScan_Call {
+	return &MockTaskService_StartScan_Call{Call: _e.mock.On("StartScan", ctx, rootDir, opts)}
+}
+
+func (_c *MockTaskService_StartScan_Call) Run(run func(ctx context.Context, rootDir string, opts model.ScanOptions)) *MockTaskService_StartScan_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(model.ScanOptions))
+	})
+	return _c
+}
+
+func (_c *MockTaskService_StartScan_Call) Return(_a0 *model.Scan, _a1 error) *MockTaskService_StartScan_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockTaskService_StartScan_Call) RunAndReturn(run func(context.Context, string, model.ScanOptions) (*model.Scan, error)) *MockTaskService_StartScan_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // TryQueueConversion provides a mock function with no fields
 func (_m *MockTaskService) TryQueueConversion() bool {
 	ret := _m.Called()
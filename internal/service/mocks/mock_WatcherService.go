@@ -0,0 +1,115 @@
+// Code generated by mockery v2.50.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockWatcherService is an autogenerated mock type for the WatcherService type
+type MockWatcherService struct {
+	mock.Mock
+}
+
+type MockWatcherService_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockWatcherService) EXPECT() *MockWatcherService_Expecter {
+	return &MockWatcherService_Expecter{mock: &_m.Mock}
+}
+
+// Shutdown provides a mock function with no fields
+func (_m *MockWatcherService) Shutdown() {
+	_m.Called()
+}
+
+// MockWatcherService_Shutdown_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Shutdown'
+type MockWatcherService_Shutdown_Call struct {
+	*mock.Call
+}
+
+// Shutdown is a helper method to define mock.On call
+func (_e *MockWatcherService_Expecter) Shutdown() *MockWatcherService_Shutdown_Call {
+	return &MockWatcherService_Shutdown_Call{Call: _e.mock.On("Shutdown")}
+}
+
+func (_c *MockWatcherService_Shutdown_Call) Run(run func()) *MockWatcherService_Shutdown_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockWatcherService_Shutdown_Call) Return() *MockWatcherService_Shutdown_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockWatcherService_Shutdown_Call) RunAndReturn(run func()) *MockWatcherService_Shutdown_Call {
+	_c.Run(run)
+	return _c
+}
+
+// Watch provides a mock function with given fields: ctx, rootDir
+func (_m *MockWatcherService) Watch(ctx context.Context, rootDir string) error {
+	ret := _m.Called(ctx, rootDir)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Watch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, rootDir)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockWatcherService_Watch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Watch'
+type MockWatcherService_Watch_Call struct {
+	*mock.Call
+}
+
+// Watch is a helper method to define mock.On call
+//   - ctx context.Context
+//   - rootDir string
+func (_e *MockWatcherService_Expecter) Watch(ctx interface{}, rootDir interface{}) *MockWatcherService_Watch_Call {
+	return &MockWatcherService_Watch_Call{Call: _e.mock.On("Watch", ctx, rootDir)}
+}
+
+func (_c *MockWatcherService_Watch_Call) Run(run func(ctx context.Context, rootDir string)) *MockWatcherService_Watch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockWatcherService_Watch_Call) Return(_a0 error) *MockWatcherService_Watch_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockWatcherService_Watch_Call) RunAndReturn(run func(context.Context, string) error) *MockWatcherService_Watch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockWatcherService creates a new instance of MockWatcherService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockWatcherService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockWatcherService {
+	mock := &MockWatcherService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
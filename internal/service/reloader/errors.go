@@ -0,0 +1,8 @@
+package reloader
+
+import "errors"
+
+var (
+	ErrNoDefaultsFile = errors.New("defaults are not loaded from a file")
+	ErrNamingChanged  = errors.New("templates of the converted files cannot be changed without a restart")
+)
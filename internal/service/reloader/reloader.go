@@ -0,0 +1,175 @@
+package reloader
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/converter"
+	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/fsnotify/fsnotify"
+)
+
+type serv struct {
+	cfg    *config.Config
+	logger *slog.Logger
+	// Reloads triggered by a signal and by the watcher are applied one at a time
+	mu sync.Mutex
+	// Checksum of the file the defaults in effect are loaded from
+	checksum [sha256.Size]byte
+}
+
+func NewService(cfg *config.Config, logger *slog.Logger) service.ReloaderService {
+	s := &serv{
+		cfg:    cfg,
+		logger: logger,
+	}
+	// The defaults in effect are loaded on startup
+	s.checksum, _ = checksum(cfg.DefaultsPath)
+	return s
+}
+
+// Reload loads and validates the defaults before swapping them in.
+// Conversions that have already started finish with the previous defaults, the next ones use the new defaults.
+func (s *serv) Reload() error {
+	op := "service.ReloaderService.Reload"
+
+	logger := s.logger.With(slog.String("op", op), slog.String("path", s.cfg.DefaultsPath))
+
+	if s.cfg.DefaultsPath == "" {
+		return ErrNoDefaultsFile
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The checksum is taken first, so a change made while the file is loaded triggers another reload
+	sum, err := checksum(s.cfg.DefaultsPath)
+	if err != nil {
+		logger.Error("failed to reload defaults, keeping the current ones", slogger.Err(err))
+		return err
+	}
+	dfs, err := config.LoadDefaults(s.cfg.DefaultsPath)
+	if err == nil {
+		// Encoder parameters are checked as strictly as on startup
+		err = converter.ValidateDefaults(dfs, s.cfg.Overlays)
+	}
+	if err == nil {
+		err = s.checkNaming(dfs)
+	}
+	if err != nil {
+		logger.Error("failed to reload defaults, keeping the current ones", slogger.Err(err))
+		return err
+	}
+	s.checksum = sum
+
+	previous := s.cfg.SwapDefaults(dfs)
+
+	changes := previous.Diff(dfs)
+	for _, change := range changes {
+		logger.Info("default format "+change.Kind,
+			slog.String("preset", change.Preset),
+			slog.String("media", change.Media),
+			slog.String("key", change.Key),
+			slog.Any("before", change.Before),
+			slog.Any("after", change.After),
+		)
+	}
+	logger.Info("defaults reloaded", slog.Int("changes", len(changes)))
+
+	return nil
+}
+
+// The destinations of the converted files are derived from the naming in effect when they are
+// cleaned up or deleted, so changing a template would orphan the files named by the previous one
+func (s *serv) checkNaming(dfs *config.Defaults) error {
+	current := s.cfg.Naming().Templates
+	next := s.cfg.NamingOf(dfs).Templates
+	for key, template := range next {
+		if current[key] != template {
+			return fmt.Errorf("%w: template of format '%s' is '%s', was '%s'", ErrNamingChanged, key, template, current[key])
+		}
+	}
+	for key, template := range current {
+		if _, ok := next[key]; !ok {
+			return fmt.Errorf("%w: template of format '%s' is removed, was '%s'", ErrNamingChanged, key, template)
+		}
+	}
+	return nil
+}
+
+// Watch watches the directory of the file rather than the file itself,
+// since editors and mounted config maps replace the file instead of writing to it.
+func (s *serv) Watch(ctx context.Context) error {
+	if s.cfg.DefaultsPath == "" {
+		return ErrNoDefaultsFile
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err = watcher.Add(filepath.Dir(s.cfg.DefaultsPath)); err != nil {
+		return fmt.Errorf("failed to watch defaults: %w", err)
+	}
+
+	// Writing a file produces several events, the file is reloaded once they stop
+	debounce := time.NewTimer(s.cfg.Reload.Debounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case _, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			// Any entry of the directory may lead to the file through a symlink, so the content tells whether it has changed
+			debounce.Reset(s.cfg.Reload.Debounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			s.logger.Error("defaults watcher error", slogger.Err(err))
+		case <-debounce.C:
+			if s.changed() {
+				// The error is logged by the reload
+				_ = s.Reload()
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Reports whether the content of the file differs from the one the defaults in effect are loaded from
+func (s *serv) changed() bool {
+	sum, err := checksum(s.cfg.DefaultsPath)
+	if errors.Is(err, os.ErrNotExist) {
+		// The file is being replaced, the next event reloads it
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return err != nil || sum != s.checksum
+}
+
+func checksum(path string) ([sha256.Size]byte, error) {
+	// #nosec G304 -- the path comes from CLI flag or env variable
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
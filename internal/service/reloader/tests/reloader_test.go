@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/service/reloader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	initialDefaults = `
+image:
+  formats:
+    - ext: "webp"
+      conv_conf:
+        quality: 80
+    - ext: "avif"
+video:
+  formats:
+    - ext: "webm"
+`
+	nextDefaults = `
+image:
+  formats:
+    - ext: "webp"
+      conv_conf:
+        quality: 70
+    - ext: "jpg"
+video:
+  formats:
+    - ext: "webm"
+`
+	invalidDefaults = `
+image:
+  formats:
+    - ext: "webp"
+      optional:
+        template: "{unknown}.{fmt}"
+`
+	renamedDefaults = `
+image:
+  formats:
+    - ext: "webp"
+      conv_conf:
+        quality: 80
+      optional:
+        template: "{stem}.{fmt}"
+    - ext: "avif"
+video:
+  formats:
+    - ext: "webm"
+`
+	typoDefaults = `
+image:
+  formats:
+    - ext: "webp"
+      conv_conf:
+        qualty: 70
+`
+)
+
+func newConfig(t *testing.T, defaults string) *config.Config {
+	path := filepath.Join(t.TempDir(), "defaults.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(defaults), 0644))
+
+	dfs, err := config.LoadDefaults(path)
+	require.NoError(t, err)
+
+	return &config.Config{
+		Reload:       config.Reload{Watch: true, Debounce: 10 * time.Millisecond},
+		Defaults:     dfs,
+		DefaultsPath: path,
+	}
+}
+
+func TestReloadDefaults(t *testing.T) {
+	type testcase struct {
+		name     string
+		next     string
+		reloaded bool
+		err      error
+	}
+
+	cases := []testcase{
+		{
+			name:     "Valid defaults are swapped in",
+			next:     nextDefaults,
+			reloaded: true,
+		},
+		{
+			name: "Invalid defaults are rejected",
+			next: invalidDefaults,
+		},
+		{
+			name: "Changed templates are rejected",
+			next: renamedDefaults,
+			err:  reloader.ErrNamingChanged,
+		},
+		{
+			name: "Unknown encoder parameters are rejected",
+			next: typoDefaults,
+		},
+		{
+			name: "Malformed defaults are rejected",
+			next: "image:\n  formats:\n    - ext: [\"webp\"]\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := newConfig(t, initialDefaults)
+			initial := cfg.CurrentDefaults()
+			serv := reloader.NewService(cfg, dummy.NewDummyLogger())
+
+			require.NoError(t, os.WriteFile(cfg.DefaultsPath, []byte(tc.next), 0644))
+			err := serv.Reload()
+
+			if !tc.reloaded {
+				assert.Error(t, err)
+				if tc.err != nil {
+					assert.ErrorIs(t, err, tc.err)
+				}
+				assert.Same(t, initial, cfg.CurrentDefaults())
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotSame(t, initial, cfg.CurrentDefaults())
+			assert.Equal(t, "jpg", cfg.CurrentDefaults().Image.Formats[1].Ext)
+			// The defaults loaded on startup are left intact
+			assert.Same(t, initial, cfg.Defaults)
+		})
+	}
+}
+
+func TestReloadWithoutDefaultsFile(t *testing.T) {
+	serv := reloader.NewService(&config.Config{Defaults: &config.Defaults{}}, dummy.NewDummyLogger())
+
+	assert.ErrorIs(t, serv.Reload(), reloader.ErrNoDefaultsFile)
+	assert.ErrorIs(t, serv.Watch(context.Background()), reloader.ErrNoDefaultsFile)
+}
+
+func TestWatchDefaults(t *testing.T) {
+	cfg := newConfig(t, initialDefaults)
+	initial := cfg.CurrentDefaults()
+	serv := reloader.NewService(cfg, dummy.NewDummyLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- serv.Watch(ctx)
+	}()
+	defer func() {
+		cancel()
+		assert.NoError(t, <-done)
+	}()
+
+	// Give the watcher time to start
+	time.Sleep(50 * time.Millisecond)
+
+	// The file is replaced the way editors save it
+	tmp := cfg.DefaultsPath + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, []byte(nextDefaults), 0644))
+	require.NoError(t, os.Rename(tmp, cfg.DefaultsPath))
+
+	assert.Eventually(t, func() bool {
+		return cfg.CurrentDefaults() != initial
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "jpg", cfg.CurrentDefaults().Image.Formats[1].Ext)
+}
+
+func TestDiffDefaults(t *testing.T) {
+	previous := newConfig(t, initialDefaults).Defaults
+	next := newConfig(t, nextDefaults).Defaults
+
+	changes := previous.Diff(next)
+
+	kinds := make(map[string]string, len(changes))
+	for _, change := range changes {
+		kinds[change.Media+":"+change.Key] = change.Kind
+	}
+	assert.Equal(t, map[string]string{
+		"image:webp": config.DefaultsChanged,
+		"image:jpg":  config.DefaultsAdded,
+		"image:avif": config.DefaultsRemoved,
+	}, kinds)
+	assert.Empty(t, next.Diff(next))
+}
@@ -3,30 +3,110 @@ package service
 import (
 	"context"
 
+	"github.com/chistyakoviv/converter/internal/file"
 	"github.com/chistyakoviv/converter/internal/model"
 )
 
 type ConversionQueueService interface {
 	Add(ctx context.Context, info *model.ConversionInfo) (int64, error)
+	// Enqueues the conversions in a single transaction, reporting the outcome of each of them
+	AddMany(ctx context.Context, infos []*model.ConversionInfo) ([]model.BatchResult, error)
+	Prepare(info *model.ConversionInfo, mediaType file.MediaType) error
+	AddBatch(ctx context.Context, infos []*model.ConversionInfo) (int64, error)
+	FindQueued(ctx context.Context, fullpaths []string) ([]string, error)
 	Pop(ctx context.Context) (*model.Conversion, error)
 	Get(ctx context.Context, fullpath string) (*model.Conversion, error)
+	GetById(ctx context.Context, id int64) (*model.Conversion, error)
+	// Returns at most limit conversions with any of the given statuses in the order they were enqueued
+	List(ctx context.Context, statuses []int, limit uint64) ([]*model.Conversion, error)
 	MarkAsDone(ctx context.Context, fullpath string) error
 	MarkAsCanceled(ctx context.Context, fullpath string, code uint32) error
+	SaveQualityResults(ctx context.Context, fullpath string, results []model.QualityResult) error
+	SaveConfigDigests(ctx context.Context, fullpath string, digests map[string]string) error
+	SaveSourceHash(ctx context.Context, fullpath string, hash string) error
+	SaveDedupOrigin(ctx context.Context, fullpath string, origin string) error
+	// Returns the done conversions of the sources with the given content
+	FindDuplicates(ctx context.Context, hash string) ([]*model.Conversion, error)
+	// Reports whether the converted file of the conversion is also produced by another existing source
+	IsOutputShared(ctx context.Context, conversion *model.Conversion, dest string) (bool, error)
+	Requeue(ctx context.Context, fullpath string) error
+	// Enqueues the canceled conversions again, returns the number of requeued conversions
+	RequeueCanceled(ctx context.Context) (int64, error)
+	FindSource(ctx context.Context, fullpath string) (*model.Conversion, error)
+	FindSources(ctx context.Context, fullpaths []string) (map[string]*model.Conversion, error)
+	CountPending(ctx context.Context) (int64, error)
+	// Returns the presets in effect ordered by name
+	Presets() []*model.Preset
 }
 
 type DeletionQueueService interface {
 	Add(ctx context.Context, info *model.DeletionInfo) (int64, error)
+	AddRecursive(ctx context.Context, info *model.DeletionInfo) (int64, error)
+	// Enqueues the deletions in a single transaction, reporting the outcome of each of them
+	AddMany(ctx context.Context, infos []*model.DeletionInfo) ([]model.BatchResult, error)
+	Purge(ctx context.Context, fullpath string) error
 	Pop(ctx context.Context) (*model.Deletion, error)
 	Get(ctx context.Context, fullpath string) (*model.Deletion, error)
 	MarkAsDone(ctx context.Context, fullpath string) error
 	MarkAsCanceled(ctx context.Context, fullpath string, code uint32) error
+	CountPending(ctx context.Context) (int64, error)
 }
 
 type TaskService interface {
 	TryQueueConversion() bool
 	TryQueueDeletion() bool
 	ProcessQueues(ctx context.Context)
-	ProcessScanfs(ctx context.Context, rootDir string) error
+	// Reports whether the queue processing goroutine is running
+	IsProcessing() bool
+	// Starts scanning in the background
+	StartScan(ctx context.Context, rootDir string, opts model.ScanOptions) (*model.Scan, error)
+	ProcessScanfs(ctx context.Context, rootDir string, opts model.ScanOptions) (*model.Scan, error)
+	GetScan(id string) (*model.Scan, error)
+	CancelScan(id string) (*model.Scan, error)
 	IsScanning() bool
 	Shutdown()
 }
+
+type HealthService interface {
+	// Checks that the application is running, it must be restarted if the check fails
+	Live(ctx context.Context) *model.HealthReport
+	// Checks that the application and its dependencies are able to serve requests and process the queues
+	Ready(ctx context.Context) *model.HealthReport
+	// Fails the readiness, so no new requests are routed to the application while it shuts down
+	Shutdown()
+}
+
+type GCService interface {
+	Collect(ctx context.Context, rootDir string, opts model.GCOptions) (*model.GCReport, error)
+}
+
+type DiskGuardService interface {
+	// Reports an error if writing a file of the given size leaves less free space than the reserve,
+	// conversions are paused until the file fits
+	Check(size uint64) error
+	IsPaused() bool
+	// Checks the free space of the paused queue again, reports whether the queue is resumed
+	Resume() bool
+	State() model.DiskState
+}
+
+type ApiKeyService interface {
+	// Returns the created key along with the token, the token cannot be retrieved later
+	Create(ctx context.Context, info *model.ApiKeyInfo) (*model.ApiKey, string, error)
+	// Returns the key the token belongs to, revoked keys are rejected
+	Authenticate(ctx context.Context, token string) (*model.ApiKey, error)
+	List(ctx context.Context) ([]*model.ApiKey, error)
+	Revoke(ctx context.Context, id int64) error
+}
+
+type ReloaderService interface {
+	// Loads the defaults from their file again, the defaults in effect are kept if the new ones are invalid
+	Reload() error
+	// Reloads the defaults whenever their file changes, blocks until the context is done
+	Watch(ctx context.Context) error
+}
+
+type WatcherService interface {
+	Watch(ctx context.Context, rootDir string) error
+	Shutdown()
+}
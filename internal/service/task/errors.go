@@ -4,4 +4,7 @@ import "errors"
 
 var (
 	ErrScanAlreadyRunning = errors.New("scanning in progress")
+	ErrScanNotFound       = errors.New("scan not found")
+	ErrScanNotRunning     = errors.New("scan is not running")
+	ErrInvalidScanOptions = errors.New("invalid scan options")
 )
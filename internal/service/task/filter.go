@@ -0,0 +1,215 @@
+package task
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/file"
+	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service/conversionq"
+)
+
+// SourceFilter decides which files under the root directory are sources to convert.
+// It is shared by the scans and the watcher, so both skip the same files.
+// The ignore files are cached, so the filter is used by a single goroutine, only Sniff may be called concurrently.
+type SourceFilter struct {
+	cfg     *config.Config
+	logger  *slog.Logger
+	rootDir string
+	// Symlinks are confined to the resolved root, which may be a symlink itself
+	realRoot string
+	wd       string
+	ignores  map[string]*file.IgnoreRules
+	onError  func(error)
+}
+
+// NewSourceFilter resolves the root directory, the errors of reading the ignore files are passed to onError.
+func NewSourceFilter(cfg *config.Config, logger *slog.Logger, rootDir string, onError func(error)) (*SourceFilter, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	realRoot, err := filepath.EvalSymlinks(rootDir)
+	if err == nil {
+		realRoot, err = filepath.Abs(realRoot)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve root directory: %w", err)
+	}
+
+	return &SourceFilter{
+		cfg:      cfg,
+		logger:   logger,
+		rootDir:  filepath.Clean(rootDir),
+		realRoot: realRoot,
+		wd:       wd,
+		ignores:  make(map[string]*file.IgnoreRules),
+		onError:  onError,
+	}, nil
+}
+
+// SkipDir reports whether the walked directory is ignored, so its files are not walked.
+func (f *SourceFilter) SkipDir(path string) bool {
+	return f.isIgnored(path, true)
+}
+
+// Skip returns the reason the walked file is skipped before it is opened or an empty string.
+func (f *SourceFilter) Skip(path string, mode fs.FileMode) string {
+	if f.isIgnored(path, false) {
+		return model.ScanSkipIgnored
+	}
+	if mode&fs.ModeSymlink != 0 && !f.isAllowedSymlink(path) {
+		return model.ScanSkipSymlink
+	}
+	return ""
+}
+
+// SkipPath is the same as Skip for a file found without walking, e.g. by an event,
+// so the directories between the root and the file are checked as well.
+func (f *SourceFilter) SkipPath(path string) (string, error) {
+	rel, err := filepath.Rel(f.rootDir, filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	if rel != "." && filepath.IsLocal(rel) {
+		dir := f.rootDir
+		for _, name := range strings.Split(filepath.ToSlash(rel), "/") {
+			dir = filepath.Join(dir, name)
+			if f.SkipDir(dir) {
+				return model.ScanSkipIgnored, nil
+			}
+		}
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return "", err
+	}
+
+	return f.Skip(path, info.Mode()), nil
+}
+
+// Sniff returns the path of the file relative to the working directory and its media type.
+// The reason is not empty if the file is skipped.
+func (f *SourceFilter) Sniff(path string) (string, file.MediaType, string, error) {
+	// Paths must start with "/"
+	src, err := file.Trimwd(file.EnsureLeadingSlash(path))
+	if err != nil {
+		return "", file.MediaTypeUnknown, "", fmt.Errorf("failed to trim working directory: %w", err)
+	}
+
+	// Outputs of the default formats are recognized without querying the database
+	if f.isDefaultOutput(src) {
+		return src, file.MediaTypeUnknown, model.ScanSkipGenerated, nil
+	}
+
+	mediaType, err := file.DetectMediaType(src)
+	if err != nil {
+		return src, file.MediaTypeUnknown, "", err
+	}
+	if mediaType == file.MediaTypeUnknown {
+		return src, mediaType, model.ScanSkipUnsupportedType, nil
+	}
+
+	return src, mediaType, "", nil
+}
+
+// ResetIgnores forgets the read ignore files, so their changes are taken into account.
+func (f *SourceFilter) ResetIgnores() {
+	clear(f.ignores)
+}
+
+// Reports whether the path matches the ignore file of any directory between the root and the path
+func (f *SourceFilter) isIgnored(path string, isDir bool) bool {
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		rules, ok := f.ignores[dir]
+		if !ok {
+			var err error
+			rules, err = file.ReadIgnoreFile(dir)
+			if err != nil {
+				f.onError(fmt.Errorf("%s: %w", filepath.Join(dir, file.IgnoreFileName), err))
+			}
+			f.ignores[dir] = rules
+		}
+
+		if rules != nil {
+			rel, err := filepath.Rel(dir, path)
+			if err == nil && rules.Match(rel, isDir) {
+				return true
+			}
+		}
+
+		// Ignore files above the root are not taken into account
+		if dir == f.rootDir || dir == "." || dir == string(filepath.Separator) {
+			return false
+		}
+	}
+}
+
+// Reports whether the symlink is converted according to the symlink policy.
+// Only symlinks to regular files are converted, since walks do not descend into symlinked directories.
+func (f *SourceFilter) isAllowedSymlink(path string) bool {
+	if f.cfg.Scan.Symlinks == config.SymlinksSkip {
+		return false
+	}
+
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		f.logger.Debug("broken symlink", slog.String("path", path), slogger.Err(err))
+		return false
+	}
+	info, err := os.Stat(target)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	if f.cfg.Scan.Symlinks == config.SymlinksFollow {
+		return true
+	}
+
+	target, err = filepath.Abs(target)
+	return err == nil && file.IsWithin(f.realRoot, target)
+}
+
+// Reports whether the file is an output of an existing sibling converted with the default formats
+func (f *SourceFilter) isDefaultOutput(fullpath string) bool {
+	dfs := f.cfg.CurrentDefaults()
+	if dfs == nil {
+		return false
+	}
+
+	naming := f.cfg.NamingOf(dfs)
+	for _, candidate := range conversionq.SourceCandidates(fullpath) {
+		var formats []model.ConvertTo
+		ext := file.Ext(candidate)
+		switch {
+		case conversionq.ImageFormats[ext]:
+			formats = dfs.Image.Formats
+		case conversionq.VideoFormats[ext]:
+			formats = dfs.Video.Formats
+		}
+
+		info := file.ExtractInfo(candidate)
+		source := &model.Conversion{
+			Fullpath: info.Fullpath,
+			Path:     info.Path,
+			Filestem: info.Filestem,
+			Ext:      info.Ext,
+		}
+		for _, entry := range formats {
+			dest, err := source.AbsoluteDestinationPath(entry, naming, f.wd)
+			// The name is compared first, so only the files that look like outputs are checked on disk
+			if err == nil && dest == f.wd+fullpath && file.Exists(f.wd+candidate) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
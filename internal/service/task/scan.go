@@ -0,0 +1,573 @@
+package task
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/file"
+	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/metrics"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service/conversionq"
+	"github.com/chistyakoviv/converter/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// Finished scans are kept in memory until this number of scans is exceeded
+	maxScans = 100
+	// Only the first errors are reported, the rest are counted
+	maxScanErrorMessages = 10
+	// Used if the batch size is not configured
+	defaultScanBatchSize = 500
+	// Number of walked paths buffered per worker
+	scanQueueFactor = 16
+)
+
+type scanTask struct {
+	scan   model.Scan
+	cancel context.CancelFunc
+}
+
+func (s *serv) IsScanning() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.isScanning
+}
+
+// StartScan starts scanning in the background and returns the initial state of the scan.
+func (s *serv) StartScan(ctx context.Context, rootDir string, opts model.ScanOptions) (*model.Scan, error) {
+	scanDir, err := validateScanOptions(rootDir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// The scan outlives the request that started it, so only the application context is inherited
+	ctx, cancel := context.WithCancel(ctx)
+
+	task, err := s.registerScan(opts, cancel)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	go func() {
+		defer cancel()
+		s.runScan(ctx, rootDir, scanDir, task)
+	}()
+
+	return s.GetScan(task.scan.Id)
+}
+
+// ProcessScanfs scans the directory and returns the final state of the scan.
+func (s *serv) ProcessScanfs(ctx context.Context, rootDir string, opts model.ScanOptions) (*model.Scan, error) {
+	scanDir, err := validateScanOptions(rootDir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	task, err := s.registerScan(opts, cancel)
+	if err != nil {
+		return nil, err
+	}
+
+	s.runScan(ctx, rootDir, scanDir, task)
+
+	scan, err := s.GetScan(task.scan.Id)
+	if err != nil {
+		return nil, err
+	}
+	if scan.Status == model.ScanStatusFailed {
+		return scan, fmt.Errorf("failed to scan directory: %s", strings.Join(scan.ErrorMessages, "; "))
+	}
+
+	return scan, nil
+}
+
+// GetScan returns a snapshot of the scan.
+func (s *serv) GetScan(id string) (*model.Scan, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	task, ok := s.scans[id]
+	if !ok {
+		return nil, fmt.Errorf("scan '%s': %w", id, ErrScanNotFound)
+	}
+
+	return snapshotScan(&task.scan), nil
+}
+
+// CancelScan stops the running scan, the files enqueued so far stay in the queue.
+func (s *serv) CancelScan(id string) (*model.Scan, error) {
+	s.mu.RLock()
+	task, ok := s.scans[id]
+	if ok && !task.scan.IsRunning() {
+		s.mu.RUnlock()
+		return nil, fmt.Errorf("scan '%s': %w", id, ErrScanNotRunning)
+	}
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("scan '%s': %w", id, ErrScanNotFound)
+	}
+
+	task.cancel()
+
+	return s.GetScan(id)
+}
+
+func (s *serv) registerScan(opts model.ScanOptions, cancel context.CancelFunc) (*scanTask, error) {
+	id, err := newScanId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate scan id: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isScanning {
+		return nil, fmt.Errorf("another scan is already in progress: %w", ErrScanAlreadyRunning)
+	}
+	s.isScanning = true
+	metrics.ScansRunning.Set(1)
+
+	task := &scanTask{
+		scan: model.Scan{
+			Id:        id,
+			Status:    model.ScanStatusRunning,
+			Options:   opts,
+			Skipped:   make(map[string]int64),
+			StartedAt: time.Now(),
+		},
+		cancel: cancel,
+	}
+	s.scans[id] = task
+	s.scanIds = append(s.scanIds, id)
+
+	// Forget the oldest scans, only one scan is running at a time, so they are finished
+	for len(s.scanIds) > maxScans {
+		delete(s.scans, s.scanIds[0])
+		s.scanIds = s.scanIds[1:]
+	}
+
+	return task, nil
+}
+
+// Scans the directory in a pipeline: the walk filters the files, the workers sniff
+// their types in parallel and the found media files are enqueued in batches
+func (s *serv) runScan(ctx context.Context, rootDir string, scanDir string, task *scanTask) {
+	op := "service.TaskService.ProcessScanfs"
+
+	logger := s.logger.With(slog.String("op", op), slog.String("scan_id", task.scan.Id))
+	opts := task.scan.Options
+
+	ctx, span := tracing.Tracer().Start(ctx, "scan", trace.WithAttributes(
+		attribute.String("scan.id", task.scan.Id),
+		attribute.String("scan.dir", scanDir),
+	))
+	defer span.End()
+
+	workers := s.cfg.Scan.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	batchSize := s.cfg.Scan.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultScanBatchSize
+	}
+
+	// The root is resolved before the workers are started, so a failed scan leaves nothing running
+	filter, err := NewSourceFilter(s.cfg, logger, rootDir, s.ignoreErrorHandler(logger, task))
+	if err != nil {
+		s.finishScan(logger, task, model.ScanStatusFailed, err)
+		return
+	}
+
+	// Bounded channels keep the walk from running far ahead of the workers
+	paths := make(chan string, workers*scanQueueFactor)
+	infos := make(chan *model.ConversionInfo, batchSize)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				// Drain the channel, so the walk is not blocked
+				if ctx.Err() != nil {
+					continue
+				}
+				if info := s.sniffScanEntry(logger, task, filter, path); info != nil {
+					infos <- info
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(infos)
+	}()
+
+	batched := make(chan struct{})
+	go func() {
+		defer close(batched)
+		s.batchScanEntries(ctx, logger, task, infos, batchSize)
+	}()
+
+	// Walk through the directory
+	err = filepath.WalkDir(scanDir, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		if err != nil {
+			logger.Error("Error accessing path", slogger.Err(err))
+			s.recordScanError(task, err)
+			return nil
+		}
+
+		if d.IsDir() {
+			if path == scanDir {
+				return nil
+			}
+			// Files of a directory are one level deeper than the directory itself
+			if opts.MaxDepth > 0 && depth(scanDir, path) >= opts.MaxDepth {
+				return fs.SkipDir
+			}
+			// Ignored directories are not descended into, so their files are not counted
+			if filter.SkipDir(path) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		s.updateScan(task, func(scan *model.Scan) { scan.Visited++ })
+		metrics.ScanVisited.Inc()
+
+		if reason := filter.Skip(path, d.Type()); reason != "" {
+			s.skipScanEntry(task, reason)
+			return nil
+		}
+
+		if reason, err := filterScanEntry(rootDir, path, d, opts); reason != "" || err != nil {
+			if err != nil {
+				logger.Error("failed to get file info", slogger.Err(err))
+				s.recordScanError(task, err)
+				return nil
+			}
+			s.skipScanEntry(task, reason)
+			return nil
+		}
+
+		// Files which extensions cannot be converted are not opened at all
+		if _, ok := conversionq.FileTypeToFormatMap[file.Ext(path)]; !ok {
+			s.skipScanEntry(task, model.ScanSkipUnsupportedType)
+			return nil
+		}
+
+		select {
+		case paths <- path:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	close(paths)
+	<-batched
+
+	switch {
+	case errors.Is(err, context.Canceled) || (err == nil && ctx.Err() != nil):
+		s.finishScan(logger, task, model.ScanStatusCanceled, nil)
+	case err != nil:
+		s.finishScan(logger, task, model.ScanStatusFailed, fmt.Errorf("failed to scan directory: %w", err))
+	default:
+		s.finishScan(logger, task, model.ScanStatusDone, nil)
+	}
+}
+
+func (s *serv) finishScan(logger *slog.Logger, task *scanTask, status string, err error) {
+	if err != nil {
+		s.recordScanError(task, err)
+	}
+
+	finishedAt := time.Now()
+	var enqueued int64
+
+	s.mu.Lock()
+	task.scan.Status = status
+	task.scan.FinishedAt = &finishedAt
+	enqueued = task.scan.Enqueued
+	s.isScanning = false
+	s.mu.Unlock()
+
+	metrics.ScansRunning.Set(0)
+	metrics.Scans.WithLabelValues(status).Inc()
+
+	logger.Info("scan finished", slog.String("status", status), slog.Int64("enqueued", enqueued))
+}
+
+// Returns the conversion of the file or nil if the file is skipped
+func (s *serv) sniffScanEntry(logger *slog.Logger, task *scanTask, filter *SourceFilter, path string) *model.ConversionInfo {
+	logger.Debug("Try to enqueue file", slog.String("path", path))
+
+	src, mediaType, reason, err := filter.Sniff(path)
+	if err != nil {
+		logger.Error("failed to determine file type", slog.String("path", path), slogger.Err(err))
+		s.recordScanError(task, err)
+		return nil
+	}
+	if reason != "" {
+		s.skipScanEntry(task, reason)
+		return nil
+	}
+
+	info := model.ToConversionInfoFromFileInfo(file.ExtractInfo(src))
+	if err = s.conversionQueueService.Prepare(info, mediaType); err != nil {
+		logger.Error("failed to prepare conversion while scanning filesystem", slog.String("path", path), slogger.Err(err))
+		s.recordScanError(task, err)
+		return nil
+	}
+
+	if id := task.scan.Options.ApiKeyId; id != 0 {
+		info.ApiKeyId = sql.NullInt64{Int64: id, Valid: true}
+	}
+
+	return info
+}
+
+func (s *serv) batchScanEntries(
+	ctx context.Context,
+	logger *slog.Logger,
+	task *scanTask,
+	infos <-chan *model.ConversionInfo,
+	batchSize int,
+) {
+	batch := make([]*model.ConversionInfo, 0, batchSize)
+	// The conversions of the found files are traced as a part of the scan
+	traceParent := tracing.TraceParent(ctx)
+
+	for info := range infos {
+		info.TraceParent = traceParent
+		batch = append(batch, info)
+		if len(batch) == batchSize {
+			s.flushScanBatch(ctx, logger, task, batch)
+			batch = batch[:0]
+		}
+	}
+
+	s.flushScanBatch(ctx, logger, task, batch)
+}
+
+func (s *serv) flushScanBatch(ctx context.Context, logger *slog.Logger, task *scanTask, batch []*model.ConversionInfo) {
+	// The files of a canceled scan are dropped
+	if len(batch) == 0 || ctx.Err() != nil {
+		return
+	}
+
+	fullpaths := make([]string, 0, len(batch))
+	for _, info := range batch {
+		fullpaths = append(fullpaths, info.Fullpath)
+	}
+
+	// Outputs of the formats given in requests are recognized by the conversions of their sources
+	sources, err := s.conversionQueueService.FindSources(ctx, fullpaths)
+	if err != nil {
+		logger.Error("failed to find sources while scanning filesystem", slog.Int("batch_size", len(batch)), slogger.Err(err))
+		s.recordScanError(task, err)
+		return
+	}
+	if len(sources) > 0 {
+		batch = slices.DeleteFunc(batch, func(info *model.ConversionInfo) bool {
+			_, ok := sources[info.Fullpath]
+			return ok
+		})
+		fullpaths = slices.DeleteFunc(fullpaths, func(fullpath string) bool {
+			_, ok := sources[fullpath]
+			return ok
+		})
+		s.updateScan(task, func(scan *model.Scan) { scan.Skipped[model.ScanSkipGenerated] += int64(len(sources)) })
+		metrics.ScanSkipped.WithLabelValues(model.ScanSkipGenerated).Add(float64(len(sources)))
+		if len(batch) == 0 {
+			return
+		}
+	}
+
+	var enqueued int64
+
+	if task.scan.Options.DryRun {
+		var queued []string
+		queued, err = s.conversionQueueService.FindQueued(ctx, fullpaths)
+		enqueued = int64(len(batch) - len(queued))
+	} else {
+		enqueued, err = s.conversionQueueService.AddBatch(ctx, batch)
+	}
+
+	if err != nil {
+		logger.Error("failed to enqueue conversions while scanning filesystem", slog.Int("batch_size", len(batch)), slogger.Err(err))
+		s.recordScanError(task, err)
+		return
+	}
+
+	s.updateScan(task, func(scan *model.Scan) {
+		scan.Enqueued += enqueued
+		if skipped := int64(len(batch)) - enqueued; skipped > 0 {
+			scan.Skipped[model.ScanSkipAlreadyQueued] += skipped
+		}
+	})
+	if !task.scan.Options.DryRun {
+		metrics.ScanEnqueued.Add(float64(enqueued))
+	}
+	metrics.ScanSkipped.WithLabelValues(model.ScanSkipAlreadyQueued).Add(float64(int64(len(batch)) - enqueued))
+
+	// Start processing the files without waiting for the scan to complete
+	if enqueued > 0 && !task.scan.Options.DryRun {
+		s.TryQueueConversion()
+	}
+}
+
+func (s *serv) ignoreErrorHandler(logger *slog.Logger, task *scanTask) func(error) {
+	return func(err error) {
+		logger.Error("failed to read ignore file", slogger.Err(err))
+		s.recordScanError(task, err)
+	}
+}
+
+func (s *serv) updateScan(task *scanTask, update func(scan *model.Scan)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(&task.scan)
+}
+
+func (s *serv) skipScanEntry(task *scanTask, reason string) {
+	s.updateScan(task, func(scan *model.Scan) { scan.Skipped[reason]++ })
+	metrics.ScanSkipped.WithLabelValues(reason).Inc()
+}
+
+func (s *serv) recordScanError(task *scanTask, err error) {
+	metrics.ScanErrors.Inc()
+	s.updateScan(task, func(scan *model.Scan) {
+		scan.Errors++
+		if len(scan.ErrorMessages) < maxScanErrorMessages {
+			scan.ErrorMessages = append(scan.ErrorMessages, err.Error())
+		}
+	})
+}
+
+// Returns the reason the file is skipped or an empty string if it passes the filters
+func filterScanEntry(rootDir string, path string, d fs.DirEntry, opts model.ScanOptions) (string, error) {
+	if opts.ModifiedSince != nil {
+		info, err := d.Info()
+		if err != nil {
+			return "", err
+		}
+		if info.ModTime().Before(*opts.ModifiedSince) {
+			return model.ScanSkipNotModified, nil
+		}
+	}
+
+	rel, err := filepath.Rel(rootDir, path)
+	if err != nil {
+		return "", err
+	}
+	rel = filepath.ToSlash(rel)
+
+	for _, pattern := range opts.Exclude {
+		if matchScanPattern(pattern, rel) {
+			return model.ScanSkipExcluded, nil
+		}
+	}
+
+	if len(opts.Include) == 0 {
+		return "", nil
+	}
+	for _, pattern := range opts.Include {
+		if matchScanPattern(pattern, rel) {
+			return "", nil
+		}
+	}
+
+	return model.ScanSkipNotIncluded, nil
+}
+
+// A pattern without a slash is matched against the file name, otherwise against the relative path
+func matchScanPattern(pattern string, rel string) bool {
+	name := rel
+	if !strings.Contains(pattern, "/") {
+		name = filepath.Base(rel)
+	}
+	// Patterns are validated before the scan starts
+	ok, _ := filepath.Match(pattern, name)
+	return ok
+}
+
+// Returns the directory to scan
+func validateScanOptions(rootDir string, opts model.ScanOptions) (string, error) {
+	// Cleaning the path as an absolute one drops any leading "..", so it cannot escape the root
+	scanDir := filepath.Join(rootDir, filepath.Clean("/"+opts.Path))
+
+	info, err := os.Stat(scanDir)
+	if err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%w: directory '%s' does not exist", ErrInvalidScanOptions, opts.Path)
+	}
+
+	if opts.MaxDepth < 0 {
+		return "", fmt.Errorf("%w: max depth must not be negative", ErrInvalidScanOptions)
+	}
+
+	for _, patterns := range [][]string{opts.Include, opts.Exclude} {
+		for _, pattern := range patterns {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return "", fmt.Errorf("%w: malformed pattern '%s'", ErrInvalidScanOptions, pattern)
+			}
+		}
+	}
+
+	return scanDir, nil
+}
+
+// Returns the number of path elements between the directories
+func depth(baseDir string, dir string) int {
+	rel, err := filepath.Rel(baseDir, dir)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(filepath.ToSlash(rel), "/") + 1
+}
+
+func snapshotScan(scan *model.Scan) *model.Scan {
+	snapshot := *scan
+
+	snapshot.Skipped = make(map[string]int64, len(scan.Skipped))
+	for reason, count := range scan.Skipped {
+		snapshot.Skipped[reason] = count
+	}
+	snapshot.ErrorMessages = append([]string(nil), scan.ErrorMessages...)
+
+	return &snapshot
+}
+
+func newScanId() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
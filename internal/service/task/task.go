@@ -3,31 +3,41 @@ package task
 import (
 	"context"
 	"errors"
-	"fmt"
-	"io/fs"
 	"log/slog"
-	"os"
-	"path/filepath"
+	"strconv"
 	"sync"
+	"sync/atomic"
 
+	"github.com/chistyakoviv/converter/internal/config"
 	"github.com/chistyakoviv/converter/internal/converter"
 	"github.com/chistyakoviv/converter/internal/db"
-	"github.com/chistyakoviv/converter/internal/file"
 	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/metrics"
 	"github.com/chistyakoviv/converter/internal/model"
 	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/chistyakoviv/converter/internal/storage"
+	"github.com/chistyakoviv/converter/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type serv struct {
+	cfg                    *config.Config
 	logger                 *slog.Logger
 	conversionQueueService service.ConversionQueueService
 	deletionQueueService   service.DeletionQueueService
 	converter              converter.Converter
+	diskGuard              service.DiskGuardService
+	storage                storage.Storage
 	conversionQueue        chan struct{}
 	deletionQueue          chan struct{}
 	doneOnce               sync.Once
+	processing             atomic.Bool // Whether the queue processing goroutine is running
 	mu                     sync.RWMutex
 	isScanning             bool
+	scans                  map[string]*scanTask
+	scanIds                []string // Scan ids in the order they are started
 	done                   chan struct{}
 }
 
@@ -37,22 +47,34 @@ type serv struct {
 * To prevent this, use buffered channels to allow tasks to be queued even when there is no active receiver.
 **/
 func NewService(
+	cfg *config.Config,
 	logger *slog.Logger,
 	conversionQueueService service.ConversionQueueService,
 	deletionQueueService service.DeletionQueueService,
 	converter converter.Converter,
+	diskGuard service.DiskGuardService,
+	storage storage.Storage,
 ) service.TaskService {
 	return &serv{
+		cfg:                    cfg,
 		logger:                 logger,
 		conversionQueueService: conversionQueueService,
 		deletionQueueService:   deletionQueueService,
 		converter:              converter,
+		diskGuard:              diskGuard,
+		storage:                storage,
 		conversionQueue:        make(chan struct{}, 1),
 		deletionQueue:          make(chan struct{}, 1),
+		scans:                  make(map[string]*scanTask),
 		done:                   make(chan struct{}),
 	}
 }
 
+// Reports whether the queues are being processed, i.e. ProcessQueues hasn't returned
+func (s *serv) IsProcessing() bool {
+	return s.processing.Load()
+}
+
 // Try to add a conversion task only if the queue is not full
 func (s *serv) TryQueueConversion() bool {
 	select {
@@ -78,6 +100,9 @@ func (s *serv) TryQueueDeletion() bool {
 }
 
 func (s *serv) ProcessQueues(ctx context.Context) {
+	s.processing.Store(true)
+	defer s.processing.Store(false)
+
 	for {
 		select {
 		case <-s.conversionQueue:
@@ -99,6 +124,15 @@ func (s *serv) processConversion(ctx context.Context) error {
 
 	logger := s.logger.With(slog.String("op", op))
 	for {
+		// Conversions stay pending until the disk space is freed, the queue is resumed by the periodic checks
+		if s.diskGuard.IsPaused() {
+			return nil
+		}
+		if err := s.diskGuard.Check(0); err != nil {
+			logger.Debug("conversions are paused", slogger.Err(err))
+			return nil
+		}
+
 		// It is safe to ask for a task outside a transaction
 		// because there is no contention for resources,
 		// as the operation is processed in a single thread (monitor goroutine).
@@ -111,38 +145,105 @@ func (s *serv) processConversion(ctx context.Context) error {
 			return err
 		}
 
-		_, err = s.deletionQueueService.Get(ctx, fileInfo.Fullpath)
-		if err == nil {
-			// Mark the task as canceled if the file is in the deletion queue.
-			doneErr := s.conversionQueueService.MarkAsCanceled(ctx, fileInfo.Fullpath, service.ErrFileQueuedForDeletion)
-			if doneErr != nil {
-				logger.Error("failed to mark conversion task as done", slogger.Err(doneErr))
-				return doneErr
-			}
-			continue
-		}
-		if !errors.Is(err, db.ErrNotFound) {
-			logger.Error("failed to get deletion task while executing conversion task", slogger.Err(err))
+		// The job is traced as a part of the request that enqueued it
+		jobCtx, span := tracing.Tracer().Start(
+			tracing.ContextWithTraceParent(ctx, fileInfo.TraceParent),
+			"conversion",
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(
+				attribute.Int64("conversion.id", fileInfo.Id),
+				attribute.String("conversion.path", fileInfo.Fullpath),
+			),
+		)
+		postponed, err := s.convertQueued(jobCtx, logger, fileInfo)
+		tracing.End(span, err)
+		if err != nil || postponed {
 			return err
 		}
+	}
+}
+
+// Converts the file popped from the queue and records the outcome.
+// Returns true if the queue must be stopped until the disk space is freed.
+func (s *serv) convertQueued(ctx context.Context, logger *slog.Logger, fileInfo *model.Conversion) (bool, error) {
+	_, err := s.deletionQueueService.Get(ctx, fileInfo.Fullpath)
+	if err == nil {
+		// Mark the task as canceled if the file is in the deletion queue.
+		doneErr := s.conversionQueueService.MarkAsCanceled(ctx, fileInfo.Fullpath, service.ErrFileQueuedForDeletion)
+		if doneErr != nil {
+			logger.Error("failed to mark conversion task as done", slogger.Err(doneErr))
+			return false, doneErr
+		}
+		observeTask(metrics.Conversions, metrics.StatusCanceled, service.ErrFileQueuedForDeletion)
+		return false, nil
+	}
+	if !errors.Is(err, db.ErrNotFound) {
+		logger.Error("failed to get deletion task while executing conversion task", slogger.Err(err))
+		return false, err
+	}
+
+	sourceHash, dedupOrigin := fileInfo.SourceHash, fileInfo.DedupOrigin
+	err = s.converter.Convert(ctx, fileInfo)
+	if err != nil {
+		// The conversion is retried once the queue is resumed
+		if converterErr := service.GetConverterError(err); converterErr != nil && converterErr.Code() == service.ErrInsufficientDiskSpace {
+			logger.Warn("conversion is postponed due to low disk space", slog.String("path", fileInfo.Fullpath), slogger.Err(err))
+			observeTask(metrics.Conversions, metrics.StatusPostponed, 0)
+			return true, nil
+		}
+
+		logger.Error("failed to convert file from conversion queue", slogger.Err(err))
+		tracing.Fail(trace.SpanFromContext(ctx), err)
+		code := service.GetConverterError(err).Code()
+		cancelErr := s.conversionQueueService.MarkAsCanceled(ctx, fileInfo.Fullpath, code)
+		if cancelErr != nil {
+			logger.Error("failed to mark conversion task as canceled", slogger.Err(cancelErr))
+			return false, cancelErr
+		}
+		observeTask(metrics.Conversions, metrics.StatusCanceled, code)
+		return false, nil
+	}
 
-		err = s.converter.Convert(ctx, fileInfo)
+	if len(fileInfo.QualityResults) > 0 {
+		err = s.conversionQueueService.SaveQualityResults(ctx, fileInfo.Fullpath, fileInfo.QualityResults)
 		if err != nil {
-			logger.Error("failed to convert file from conversion queue", slogger.Err(err))
-			cancelErr := s.conversionQueueService.MarkAsCanceled(ctx, fileInfo.Fullpath, service.GetConverterError(err).Code())
-			if cancelErr != nil {
-				logger.Error("failed to mark conversion task as canceled", slogger.Err(cancelErr))
-				return cancelErr
-			}
-			continue
+			logger.Error("failed to save quality results", slogger.Err(err))
+			return false, err
 		}
+	}
 
-		err = s.conversionQueueService.MarkAsDone(ctx, fileInfo.Fullpath)
+	if len(fileInfo.ConfigDigests) > 0 {
+		err = s.conversionQueueService.SaveConfigDigests(ctx, fileInfo.Fullpath, fileInfo.ConfigDigests)
 		if err != nil {
-			logger.Error("failed to mark conversion task as done", slogger.Err(err))
-			return err
+			logger.Error("failed to save config digests", slogger.Err(err))
+			return false, err
 		}
 	}
+
+	// The hash is computed anew by the converter since the source may have changed after it was enqueued
+	if fileInfo.SourceHash != sourceHash {
+		err = s.conversionQueueService.SaveSourceHash(ctx, fileInfo.Fullpath, fileInfo.SourceHash)
+		if err != nil {
+			logger.Error("failed to save source hash", slogger.Err(err))
+			return false, err
+		}
+	}
+
+	if fileInfo.DedupOrigin != dedupOrigin {
+		err = s.conversionQueueService.SaveDedupOrigin(ctx, fileInfo.Fullpath, fileInfo.DedupOrigin)
+		if err != nil {
+			logger.Error("failed to save dedup origin", slogger.Err(err))
+			return false, err
+		}
+	}
+
+	err = s.conversionQueueService.MarkAsDone(ctx, fileInfo.Fullpath)
+	if err != nil {
+		logger.Error("failed to mark conversion task as done", slogger.Err(err))
+		return false, err
+	}
+	observeTask(metrics.Conversions, metrics.StatusDone, 0)
+	return false, nil
 }
 
 func (s *serv) processDeletion(ctx context.Context) error {
@@ -167,30 +268,45 @@ func (s *serv) processDeletion(ctx context.Context) error {
 				logger.Error("failed to mark deletion task as canceled", slogger.Err(err))
 				return err
 			}
+			observeTask(metrics.Deletions, metrics.StatusCanceled, service.ErrFailedToRemoveFile)
 			continue
 		}
 		if err != nil {
 			logger.Error("failed to get conversion task while executing deletion task", slogger.Err(err))
 			return err
 		}
-		if fileInfo.IsPending() {
-			// Mark the task as done if the file is not converted, as there’s no need to delete unconverted files.
-			doneErr := s.deletionQueueService.MarkAsDone(ctx, file.Fullpath)
-			if doneErr != nil {
-				logger.Error("failed to mark deletion task as done", slogger.Err(doneErr))
-				return doneErr
-			}
-			continue
-		}
 
 		var removeErrs []error
-		for _, entry := range fileInfo.ConvertTo {
-			dest, err := fileInfo.AbsoluteDestinationPath(entry)
-			if err != nil {
-				return err
+		// There’s no need to delete outputs of unconverted files, as they do not exist.
+		if !fileInfo.IsPending() {
+			naming := s.cfg.Naming()
+			for _, entry := range fileInfo.ConvertTo {
+				// Files named after the hash are not produced until the hash is computed
+				if naming.NeedsHash(entry) && fileInfo.SourceHash == "" {
+					continue
+				}
+				dest, err := fileInfo.DestinationFullpath(entry, naming)
+				if err != nil {
+					removeErrs = append(removeErrs, err)
+					continue
+				}
+				// Another source of the same content may produce the same file
+				shared, err := s.conversionQueueService.IsOutputShared(ctx, fileInfo, dest)
+				if err != nil {
+					removeErrs = append(removeErrs, err)
+					continue
+				}
+				if shared {
+					continue
+				}
+				// The absence of a file is not considered an error.
+				if err := s.storage.Remove(ctx, dest); err != nil {
+					removeErrs = append(removeErrs, err)
+				}
 			}
-			// The absence of a file is not considered an error.
-			if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+		}
+		if file.DeleteSource {
+			if err := s.storage.Remove(ctx, fileInfo.Fullpath); err != nil {
 				removeErrs = append(removeErrs, err)
 			}
 		}
@@ -202,6 +318,18 @@ func (s *serv) processDeletion(ctx context.Context) error {
 				logger.Error("failed to mark deletion task as canceled", slogger.Err(err))
 				return err
 			}
+			observeTask(metrics.Deletions, metrics.StatusCanceled, service.ErrFailedToRemoveFile)
+			continue
+		}
+
+		if file.DeleteRecord {
+			// Forget the file instead of marking the task as done, so the path can be converted again
+			err = s.deletionQueueService.Purge(ctx, file.Fullpath)
+			if err != nil {
+				logger.Error("failed to purge deletion task", slogger.Err(err))
+				return err
+			}
+			observeTask(metrics.Deletions, metrics.StatusPurged, 0)
 			continue
 		}
 
@@ -210,76 +338,13 @@ func (s *serv) processDeletion(ctx context.Context) error {
 			logger.Error("failed to mark deletion task as done", slogger.Err(err))
 			return err
 		}
+		observeTask(metrics.Deletions, metrics.StatusDone, 0)
 	}
 }
 
-func (s *serv) IsScanning() bool {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return s.isScanning
-}
-
-func (s *serv) ProcessScanfs(ctx context.Context, rootDir string) error {
-	s.mu.Lock()
-	if s.isScanning {
-		s.mu.Unlock()
-		return fmt.Errorf("another scan is already in progress: %w", ErrScanAlreadyRunning)
-	}
-
-	s.isScanning = true
-	s.mu.Unlock()
-
-	// Walk through the directory
-	err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			s.logger.Error("Error accessing path", slogger.Err(err))
-			return nil
-		}
-
-		// Paths must start with "/"
-		path = file.EnsureLeadingSlash(path)
-
-		// Perform enqueuing if the file is not a directory
-		if !d.IsDir() {
-			s.logger.Debug("Try to enqueue file", slog.String("path", path))
-
-			imageOk, filetypeErr := file.IsImage(path)
-			if filetypeErr != nil {
-				s.logger.Error("failed to determine image type", slogger.Err(filetypeErr))
-				return nil
-			}
-			videoOk, filetypeErr := file.IsVideo(path)
-			if filetypeErr != nil {
-				s.logger.Error("failed to determine video type", slogger.Err(filetypeErr))
-				return nil
-			}
-
-			if imageOk || videoOk {
-				src, err := file.Trimwd(path)
-				if err != nil {
-					s.logger.Error("failed to trim working directory", slogger.Err(err))
-					return nil
-				}
-				finfo := file.ExtractInfo(src)
-				_, err = s.conversionQueueService.Add(ctx, model.ToConversionInfoFromFileInfo(finfo))
-				if err != nil {
-					s.logger.Error("failed to enqueue conversion while scanning filesystem", slogger.Err(err))
-				}
-			}
-		}
-
-		return nil
-	})
-
-	s.mu.Lock()
-	s.isScanning = false
-	s.mu.Unlock()
-
-	if err != nil {
-		return fmt.Errorf("failed to scan directory: %w", err)
-	}
-
-	return nil
+// Counts the processed task, the code is the error code of a canceled task
+func observeTask(counter *prometheus.CounterVec, status string, code uint32) {
+	counter.WithLabelValues(status, strconv.FormatUint(uint64(code), 10)).Inc()
 }
 
 func (s *serv) Shutdown() {
@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/db"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service"
+	serviceMocks "github.com/chistyakoviv/converter/internal/service/mocks"
+	"github.com/chistyakoviv/converter/internal/service/task"
+	"github.com/chistyakoviv/converter/internal/storage/local"
+	"github.com/stretchr/testify/mock"
+)
+
+// Returns the disk guard of a disk with enough free space
+func newDiskGuard(t *testing.T) *serviceMocks.MockDiskGuardService {
+	mockDiskGuard := serviceMocks.NewMockDiskGuardService(t)
+	mockDiskGuard.On("IsPaused").Return(false).Maybe()
+	mockDiskGuard.On("Check", mock.AnythingOfType("uint64")).Return(nil).Maybe()
+	return mockDiskGuard
+}
+
+func TestTaskServiceProcessQueuesWithLowDiskSpace(t *testing.T) {
+	var (
+		errInsufficientSpace = errors.New("insufficient disk space")
+		conversion           = &model.Conversion{
+			Id:        1,
+			Fullpath:  "/path/to/video.mp4",
+			Path:      "/path/to",
+			Filestem:  "video",
+			Ext:       "mp4",
+			ConvertTo: []model.ConvertTo{{Ext: "webm"}},
+			Status:    model.ConversionStatusPending,
+			CreatedAt: time.Now(),
+		}
+	)
+
+	type testcase struct {
+		name string
+		// Each case ends with the call to the disk guard that closes the channel
+		mockDiskGuard         func(tc *testcase, processed chan struct{}) *serviceMocks.MockDiskGuardService
+		mockConversionService func(tc *testcase) *serviceMocks.MockConversionQueueService
+		mockDeletionService   func(tc *testcase) *serviceMocks.MockDeletionQueueService
+		mockConverterService  func(tc *testcase, processed chan struct{}) *serviceMocks.MockConverterService
+	}
+
+	cases := []testcase{
+		{
+			name: "Paused queue is not processed",
+			mockDiskGuard: func(tc *testcase, processed chan struct{}) *serviceMocks.MockDiskGuardService {
+				mockDiskGuard := serviceMocks.NewMockDiskGuardService(t)
+				mockDiskGuard.On("IsPaused").Run(func(mock.Arguments) { close(processed) }).Return(true).Once()
+				return mockDiskGuard
+			},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				return serviceMocks.NewMockConversionQueueService(t)
+			},
+			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
+				return serviceMocks.NewMockDeletionQueueService(t)
+			},
+			mockConverterService: func(tc *testcase, processed chan struct{}) *serviceMocks.MockConverterService {
+				return serviceMocks.NewMockConverterService(t)
+			},
+		},
+		{
+			name: "Queue is paused when the reserve is reached",
+			mockDiskGuard: func(tc *testcase, processed chan struct{}) *serviceMocks.MockDiskGuardService {
+				mockDiskGuard := serviceMocks.NewMockDiskGuardService(t)
+				mockDiskGuard.On("IsPaused").Return(false).Once()
+				mockDiskGuard.On("Check", uint64(0)).Run(func(mock.Arguments) { close(processed) }).Return(errInsufficientSpace).Once()
+				return mockDiskGuard
+			},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				return serviceMocks.NewMockConversionQueueService(t)
+			},
+			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
+				return serviceMocks.NewMockDeletionQueueService(t)
+			},
+			mockConverterService: func(tc *testcase, processed chan struct{}) *serviceMocks.MockConverterService {
+				return serviceMocks.NewMockConverterService(t)
+			},
+		},
+		{
+			name: "Conversion stays pending if its output does not fit",
+			mockDiskGuard: func(tc *testcase, processed chan struct{}) *serviceMocks.MockDiskGuardService {
+				mockDiskGuard := serviceMocks.NewMockDiskGuardService(t)
+				mockDiskGuard.On("IsPaused").Return(false).Once()
+				mockDiskGuard.On("Check", uint64(0)).Return(nil).Once()
+				return mockDiskGuard
+			},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("Pop", mock.AnythingOfType("*context.cancelCtx")).Return(conversion, nil).Once()
+				return mockConversionService
+			},
+			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
+				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
+				mockDeletionService.On("Get", mock.AnythingOfType("*context.valueCtx"), conversion.Fullpath).Return(nil, db.ErrNotFound).Once()
+				return mockDeletionService
+			},
+			mockConverterService: func(tc *testcase, processed chan struct{}) *serviceMocks.MockConverterService {
+				mockConverterService := serviceMocks.NewMockConverterService(t)
+				mockConverterService.On("Convert", mock.AnythingOfType("*context.valueCtx"), conversion).
+					Run(func(mock.Arguments) { close(processed) }).
+					Return(service.NewConverterError(errInsufficientSpace.Error(), service.ErrInsufficientDiskSpace)).
+					Once()
+				return mockConverterService
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			processed := make(chan struct{})
+
+			mockDiskGuard := tc.mockDiskGuard(&tc, processed)
+			mockConversionService := tc.mockConversionService(&tc)
+			mockDeletionService := tc.mockDeletionService(&tc)
+			mockConverterService := tc.mockConverterService(&tc, processed)
+
+			taskService := task.NewService(
+				&config.Config{},
+				dummy.NewDummyLogger(),
+				mockConversionService,
+				mockDeletionService,
+				mockConverterService,
+				mockDiskGuard,
+				local.NewStorage(""),
+			)
+			taskService.TryQueueConversion()
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				taskService.ProcessQueues(ctx)
+			}()
+
+			<-processed
+			cancel()
+			wg.Wait()
+
+			// Neither the conversion is canceled nor the next one is popped
+			mockDiskGuard.AssertExpectations(t)
+			mockConversionService.AssertExpectations(t)
+			mockDeletionService.AssertExpectations(t)
+			mockConverterService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/file"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service"
+	serviceMocks "github.com/chistyakoviv/converter/internal/service/mocks"
+	"github.com/chistyakoviv/converter/internal/service/task"
+	"github.com/chistyakoviv/converter/internal/storage/local"
+)
+
+const (
+	benchDirs        = 20
+	benchFilesPerDir = 100
+	benchDbRoundTrip = 200 * time.Microsecond
+	benchOtherPerDir = 10
+)
+
+// Simulates the database round trip of each statement, the rest of the queue is not used by the scan
+type fakeConversionQueueService struct {
+	service.ConversionQueueService
+}
+
+func (f *fakeConversionQueueService) Prepare(info *model.ConversionInfo, mediaType file.MediaType) error {
+	info.ConvertTo = []model.ConvertTo{{Ext: "webp"}}
+	return nil
+}
+
+func (f *fakeConversionQueueService) AddBatch(ctx context.Context, infos []*model.ConversionInfo) (int64, error) {
+	time.Sleep(benchDbRoundTrip)
+	return int64(len(infos)), nil
+}
+
+func BenchmarkProcessScanfs(b *testing.B) {
+	// File types are sniffed relative to the working directory, so the tree cannot live in a temp dir
+	rootDir, err := os.MkdirTemp(".", "scan-bench-")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		_ = os.RemoveAll(rootDir)
+	})
+
+	files := generateScanTree(b, rootDir)
+
+	cases := []struct {
+		workers   int
+		batchSize int
+	}{
+		{workers: 1, batchSize: 1},
+		{workers: 1, batchSize: 500},
+		{workers: 4, batchSize: 1},
+		{workers: 4, batchSize: 500},
+		{workers: 0, batchSize: 500},
+	}
+
+	for _, tc := range cases {
+		b.Run(fmt.Sprintf("workers=%d/batch=%d", tc.workers, tc.batchSize), func(b *testing.B) {
+			cfg := &config.Config{Scan: config.Scan{Workers: tc.workers, BatchSize: tc.batchSize}}
+
+			for b.Loop() {
+				taskService := task.NewService(
+					cfg,
+					dummy.NewDummyLogger(),
+					&fakeConversionQueueService{},
+					serviceMocks.NewMockDeletionQueueService(b),
+					serviceMocks.NewMockConverterService(b),
+					serviceMocks.NewMockDiskGuardService(b),
+					local.NewStorage(""),
+				)
+
+				scan, err := taskService.ProcessScanfs(context.Background(), rootDir, model.ScanOptions{})
+				if err != nil {
+					b.Fatal(err)
+				}
+				if scan.Enqueued != int64(files) {
+					b.Fatalf("expected %d enqueued files, got %d", files, scan.Enqueued)
+				}
+			}
+
+			b.ReportMetric(float64(files)*float64(b.N)/b.Elapsed().Seconds(), "files/s")
+		})
+	}
+}
+
+// Generates media files with valid signatures along with files of other types
+func generateScanTree(b *testing.B, rootDir string) int {
+	b.Helper()
+
+	png := make([]byte, 512)
+	copy(png, []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A})
+
+	var files int
+	for d := range benchDirs {
+		dir := filepath.Join(rootDir, fmt.Sprintf("dir-%d", d), "nested")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			b.Fatal(err)
+		}
+		for f := range benchFilesPerDir {
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("image-%d.png", f)), png, 0o644); err != nil {
+				b.Fatal(err)
+			}
+			files++
+		}
+		for f := range benchOtherPerDir {
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("notes-%d.txt", f)), []byte("notes"), 0o644); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	return files
+}
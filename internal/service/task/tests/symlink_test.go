@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/file"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	serviceMocks "github.com/chistyakoviv/converter/internal/service/mocks"
+	"github.com/chistyakoviv/converter/internal/service/task"
+	"github.com/chistyakoviv/converter/internal/storage/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskServiceScanSymlinks(t *testing.T) {
+	dir, err := os.MkdirTemp(".", "symlinks")
+	require.NoError(t, err)
+	rootDir := filepath.Clean(dir)
+	t.Cleanup(func() { os.RemoveAll(rootDir) })
+
+	image, err := os.ReadFile("files/images/gen.jpg")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "inside.jpg"), image, 0o600))
+
+	outside, err := filepath.Abs("files/images/gen.jpg")
+	require.NoError(t, err)
+	require.NoError(t, os.Symlink("inside.jpg", filepath.Join(rootDir, "link-inside.jpg")))
+	require.NoError(t, os.Symlink(outside, filepath.Join(rootDir, "link-outside.jpg")))
+	require.NoError(t, os.Symlink("missing.jpg", filepath.Join(rootDir, "broken.jpg")))
+
+	infoOf := func(name string) *model.ConversionInfo {
+		return model.ToConversionInfoFromFileInfo(file.ExtractInfo("/" + rootDir + "/" + name))
+	}
+
+	type testcase struct {
+		name     string
+		policy   string
+		enqueued []*model.ConversionInfo
+		skipped  int64
+	}
+
+	cases := []testcase{
+		{
+			name:     "Symlinks are skipped",
+			policy:   config.SymlinksSkip,
+			enqueued: []*model.ConversionInfo{infoOf("inside.jpg")},
+			skipped:  3,
+		},
+		{
+			name:     "Symlinks are confined to the root",
+			policy:   config.SymlinksConfine,
+			enqueued: []*model.ConversionInfo{infoOf("inside.jpg"), infoOf("link-inside.jpg")},
+			skipped:  2,
+		},
+		{
+			name:     "Symlinks are followed",
+			policy:   config.SymlinksFollow,
+			enqueued: []*model.ConversionInfo{infoOf("inside.jpg"), infoOf("link-inside.jpg"), infoOf("link-outside.jpg")},
+			skipped:  1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+
+			mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+			for _, info := range tc.enqueued {
+				mockConversionService.On("Prepare", info, file.MediaTypeImage).Return(nil).Once()
+			}
+			mockConversionService.On("FindSources", mock.AnythingOfType("*context.valueCtx"), fullpathsOf(tc.enqueued...)).
+				Return(map[string]*model.Conversion{}, nil).
+				Once()
+			mockConversionService.On("AddBatch", mock.AnythingOfType("*context.valueCtx"), batchOf(tc.enqueued...)).
+				Return(int64(len(tc.enqueued)), nil).
+				Once()
+
+			taskService := task.NewService(
+				&config.Config{Scan: config.Scan{Workers: 2, BatchSize: 10, Symlinks: tc.policy}},
+				dummy.NewDummyLogger(),
+				mockConversionService,
+				serviceMocks.NewMockDeletionQueueService(t),
+				serviceMocks.NewMockConverterService(t),
+				serviceMocks.NewMockDiskGuardService(t),
+				local.NewStorage(""),
+			)
+
+			scan, err := taskService.ProcessScanfs(ctx, rootDir, model.ScanOptions{})
+			require.NoError(t, err)
+
+			assert.Equal(t, model.ScanStatusDone, scan.Status)
+			assert.Equal(t, int64(len(tc.enqueued)), scan.Enqueued)
+			assert.Equal(t, tc.skipped, scan.Skipped[model.ScanSkipSymlink])
+
+			mockConversionService.AssertExpectations(t)
+		})
+	}
+}
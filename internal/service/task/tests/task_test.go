@@ -4,22 +4,29 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"runtime"
+	"slices"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/chistyakoviv/converter/internal/config"
 	"github.com/chistyakoviv/converter/internal/db"
+	"github.com/chistyakoviv/converter/internal/file"
 	"github.com/chistyakoviv/converter/internal/logger/dummy"
 	"github.com/chistyakoviv/converter/internal/model"
 	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/chistyakoviv/converter/internal/service/conversionq"
 	serviceMocks "github.com/chistyakoviv/converter/internal/service/mocks"
 	"github.com/chistyakoviv/converter/internal/service/task"
+	"github.com/chistyakoviv/converter/internal/storage/local"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
 func TestTaskServiceProcessQueues(t *testing.T) {
 	var (
+		cfg                   = &config.Config{}
 		logger                = dummy.NewDummyLogger()
 		conversionPendingInfo = &model.Conversion{
 			Id:       1,
@@ -61,6 +68,16 @@ func TestTaskServiceProcessQueues(t *testing.T) {
 			CreatedAt: time.Now(),
 			UpdatedAt: sql.NullTime{},
 		}
+		purgeDeletionInfo = &model.Deletion{
+			Id:           1,
+			Fullpath:     "/path/to/file.ext",
+			Status:       model.DeletionStatusPending,
+			ErrorCode:    0,
+			CreatedAt:    time.Now(),
+			UpdatedAt:    sql.NullTime{},
+			DeleteSource: true,
+			DeleteRecord: true,
+		}
 	)
 
 	type testcase struct {
@@ -132,7 +149,7 @@ func TestTaskServiceProcessQueues(t *testing.T) {
 			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
 				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
 				mockConversionService.On("Pop", mock.AnythingOfType("*context.cancelCtx")).Return(tc.fileInfo, nil).Once()
-				mockConversionService.On("MarkAsCanceled", mock.AnythingOfType("*context.cancelCtx"), tc.fileInfo.Fullpath, service.ErrFileQueuedForDeletion).
+				mockConversionService.On("MarkAsCanceled", mock.AnythingOfType("*context.valueCtx"), tc.fileInfo.Fullpath, service.ErrFileQueuedForDeletion).
 					Return(nil).
 					Once()
 				mockConversionService.On("Pop", mock.AnythingOfType("*context.cancelCtx")).Return(nil, db.ErrNotFound).Once()
@@ -140,7 +157,7 @@ func TestTaskServiceProcessQueues(t *testing.T) {
 			},
 			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
 				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
-				mockDeletionService.On("Get", mock.AnythingOfType("*context.cancelCtx"), tc.fileInfo.Fullpath).Return(tc.deletionInfo, nil).Once()
+				mockDeletionService.On("Get", mock.AnythingOfType("*context.valueCtx"), tc.fileInfo.Fullpath).Return(tc.deletionInfo, nil).Once()
 				return mockDeletionService
 			},
 			mockConverterService: func(tc *testcase) *serviceMocks.MockConverterService {
@@ -160,7 +177,7 @@ func TestTaskServiceProcessQueues(t *testing.T) {
 			},
 			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
 				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
-				mockDeletionService.On("Get", mock.AnythingOfType("*context.cancelCtx"), tc.fileInfo.Fullpath).Return(tc.deletionInfo, errors.New("unknown error")).Once()
+				mockDeletionService.On("Get", mock.AnythingOfType("*context.valueCtx"), tc.fileInfo.Fullpath).Return(tc.deletionInfo, errors.New("unknown error")).Once()
 				return mockDeletionService
 			},
 			mockConverterService: func(tc *testcase) *serviceMocks.MockConverterService {
@@ -176,7 +193,7 @@ func TestTaskServiceProcessQueues(t *testing.T) {
 			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
 				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
 				mockConversionService.On("Pop", mock.AnythingOfType("*context.cancelCtx")).Return(tc.fileInfo, nil).Once()
-				mockConversionService.On("MarkAsCanceled", mock.AnythingOfType("*context.cancelCtx"), tc.fileInfo.Fullpath, service.ErrUnableToConvertFile).
+				mockConversionService.On("MarkAsCanceled", mock.AnythingOfType("*context.valueCtx"), tc.fileInfo.Fullpath, service.ErrUnableToConvertFile).
 					Return(nil).
 					Once()
 				mockConversionService.On("Pop", mock.AnythingOfType("*context.cancelCtx")).Return(nil, db.ErrNotFound).Once()
@@ -184,12 +201,12 @@ func TestTaskServiceProcessQueues(t *testing.T) {
 			},
 			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
 				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
-				mockDeletionService.On("Get", mock.AnythingOfType("*context.cancelCtx"), tc.fileInfo.Fullpath).Return(tc.deletionInfo, db.ErrNotFound).Once()
+				mockDeletionService.On("Get", mock.AnythingOfType("*context.valueCtx"), tc.fileInfo.Fullpath).Return(tc.deletionInfo, db.ErrNotFound).Once()
 				return mockDeletionService
 			},
 			mockConverterService: func(tc *testcase) *serviceMocks.MockConverterService {
 				mockConverterService := serviceMocks.NewMockConverterService(t)
-				mockConverterService.On("Convert", mock.AnythingOfType("*context.cancelCtx"), tc.fileInfo).Return(service.NewConverterError("unknown error", service.ErrUnableToConvertFile)).Once()
+				mockConverterService.On("Convert", mock.AnythingOfType("*context.valueCtx"), tc.fileInfo).Return(service.NewConverterError("unknown error", service.ErrUnableToConvertFile)).Once()
 				return mockConverterService
 			},
 		},
@@ -201,7 +218,146 @@ func TestTaskServiceProcessQueues(t *testing.T) {
 			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
 				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
 				mockConversionService.On("Pop", mock.AnythingOfType("*context.cancelCtx")).Return(tc.fileInfo, nil).Once()
-				mockConversionService.On("MarkAsDone", mock.AnythingOfType("*context.cancelCtx"), tc.fileInfo.Fullpath).
+				mockConversionService.On("MarkAsDone", mock.AnythingOfType("*context.valueCtx"), tc.fileInfo.Fullpath).
+					Return(nil).
+					Once()
+				mockConversionService.On("Pop", mock.AnythingOfType("*context.cancelCtx")).Return(nil, db.ErrNotFound).Once()
+				return mockConversionService
+			},
+			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
+				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
+				mockDeletionService.On("Get", mock.AnythingOfType("*context.valueCtx"), tc.fileInfo.Fullpath).Return(tc.deletionInfo, db.ErrNotFound).Once()
+				return mockDeletionService
+			},
+			mockConverterService: func(tc *testcase) *serviceMocks.MockConverterService {
+				mockConverterService := serviceMocks.NewMockConverterService(t)
+				mockConverterService.On("Convert", mock.AnythingOfType("*context.valueCtx"), tc.fileInfo).Return(nil).Once()
+				return mockConverterService
+			},
+		},
+		{
+			name:                "Save quality results of successful conversion",
+			conversionQeueueLen: 1,
+			fileInfo: &model.Conversion{
+				Id:       2,
+				Fullpath: "/path/to/image.jpg",
+				Path:     "/path/to",
+				Filestem: "image",
+				Ext:      "jpg",
+				ConvertTo: []model.ConvertTo{
+					{
+						Ext: "webp",
+					},
+				},
+				Status:    model.ConversionStatusPending,
+				CreatedAt: time.Now(),
+			},
+			deletionInfo: deletionInfo,
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("Pop", mock.AnythingOfType("*context.cancelCtx")).Return(tc.fileInfo, nil).Once()
+				mockConversionService.On(
+					"SaveQualityResults",
+					mock.AnythingOfType("*context.valueCtx"),
+					tc.fileInfo.Fullpath,
+					[]model.QualityResult{{Key: "webp", Metric: "ssim", Quality: 70, Score: 0.985, Attempts: 4}},
+				).
+					Return(nil).
+					Once()
+				mockConversionService.On("MarkAsDone", mock.AnythingOfType("*context.valueCtx"), tc.fileInfo.Fullpath).
+					Return(nil).
+					Once()
+				mockConversionService.On("Pop", mock.AnythingOfType("*context.cancelCtx")).Return(nil, db.ErrNotFound).Once()
+				return mockConversionService
+			},
+			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
+				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
+				mockDeletionService.On("Get", mock.AnythingOfType("*context.valueCtx"), tc.fileInfo.Fullpath).Return(tc.deletionInfo, db.ErrNotFound).Once()
+				return mockDeletionService
+			},
+			mockConverterService: func(tc *testcase) *serviceMocks.MockConverterService {
+				mockConverterService := serviceMocks.NewMockConverterService(t)
+				mockConverterService.On("Convert", mock.AnythingOfType("*context.valueCtx"), tc.fileInfo).
+					Run(func(args mock.Arguments) {
+						// The converter records the results on the conversion
+						info := args.Get(1).(*model.Conversion)
+						info.QualityResults = []model.QualityResult{{Key: "webp", Metric: "ssim", Quality: 70, Score: 0.985, Attempts: 4}}
+					}).
+					Return(nil).
+					Once()
+				return mockConverterService
+			},
+		},
+		{
+			name:                "Save source hash computed by the converter",
+			conversionQeueueLen: 1,
+			fileInfo: &model.Conversion{
+				Id:       3,
+				Fullpath: "/path/to/image.jpg",
+				Path:     "/path/to",
+				Filestem: "image",
+				Ext:      "jpg",
+				ConvertTo: []model.ConvertTo{
+					{
+						Ext:      "webp",
+						Optional: map[string]interface{}{"template": "{dir}/{stem}.{hash}.{fmt}"},
+					},
+				},
+				Status:    model.ConversionStatusPending,
+				CreatedAt: time.Now(),
+			},
+			deletionInfo: deletionInfo,
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("Pop", mock.AnythingOfType("*context.cancelCtx")).Return(tc.fileInfo, nil).Once()
+				mockConversionService.On("SaveSourceHash", mock.AnythingOfType("*context.valueCtx"), tc.fileInfo.Fullpath, "3fa2b1c9").
+					Return(nil).
+					Once()
+				mockConversionService.On("MarkAsDone", mock.AnythingOfType("*context.valueCtx"), tc.fileInfo.Fullpath).
+					Return(nil).
+					Once()
+				mockConversionService.On("Pop", mock.AnythingOfType("*context.cancelCtx")).Return(nil, db.ErrNotFound).Once()
+				return mockConversionService
+			},
+			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
+				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
+				mockDeletionService.On("Get", mock.AnythingOfType("*context.valueCtx"), tc.fileInfo.Fullpath).Return(tc.deletionInfo, db.ErrNotFound).Once()
+				return mockDeletionService
+			},
+			mockConverterService: func(tc *testcase) *serviceMocks.MockConverterService {
+				mockConverterService := serviceMocks.NewMockConverterService(t)
+				mockConverterService.On("Convert", mock.AnythingOfType("*context.valueCtx"), tc.fileInfo).
+					Run(func(args mock.Arguments) {
+						info := args.Get(1).(*model.Conversion)
+						info.SourceHash = "3fa2b1c9"
+					}).
+					Return(nil).
+					Once()
+				return mockConverterService
+			},
+		},
+		{
+			name:                "Save dedup origin of copied converted files",
+			conversionQeueueLen: 1,
+			fileInfo: &model.Conversion{
+				Id:         4,
+				Fullpath:   "/path/to/copy.jpg",
+				Path:       "/path/to",
+				Filestem:   "copy",
+				Ext:        "jpg",
+				ConvertTo:  []model.ConvertTo{{Ext: "webp"}},
+				Status:     model.ConversionStatusPending,
+				CreatedAt:  time.Now(),
+				SourceHash: "3fa2b1c9",
+			},
+			deletionInfo: deletionInfo,
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("Pop", mock.AnythingOfType("*context.cancelCtx")).Return(tc.fileInfo, nil).Once()
+				mockConversionService.On("SaveDedupOrigin", mock.AnythingOfType("*context.valueCtx"), tc.fileInfo.Fullpath, "/path/to/image.jpg").
+					Return(nil).
+					Once()
+				mockConversionService.On("MarkAsDone", mock.AnythingOfType("*context.valueCtx"), tc.fileInfo.Fullpath).
 					Return(nil).
 					Once()
 				mockConversionService.On("Pop", mock.AnythingOfType("*context.cancelCtx")).Return(nil, db.ErrNotFound).Once()
@@ -209,12 +365,18 @@ func TestTaskServiceProcessQueues(t *testing.T) {
 			},
 			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
 				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
-				mockDeletionService.On("Get", mock.AnythingOfType("*context.cancelCtx"), tc.fileInfo.Fullpath).Return(tc.deletionInfo, db.ErrNotFound).Once()
+				mockDeletionService.On("Get", mock.AnythingOfType("*context.valueCtx"), tc.fileInfo.Fullpath).Return(tc.deletionInfo, db.ErrNotFound).Once()
 				return mockDeletionService
 			},
 			mockConverterService: func(tc *testcase) *serviceMocks.MockConverterService {
 				mockConverterService := serviceMocks.NewMockConverterService(t)
-				mockConverterService.On("Convert", mock.AnythingOfType("*context.cancelCtx"), tc.fileInfo).Return(nil).Once()
+				mockConverterService.On("Convert", mock.AnythingOfType("*context.valueCtx"), tc.fileInfo).
+					Run(func(args mock.Arguments) {
+						info := args.Get(1).(*model.Conversion)
+						info.DedupOrigin = "/path/to/image.jpg"
+					}).
+					Return(nil).
+					Once()
 				return mockConverterService
 			},
 		},
@@ -328,6 +490,63 @@ func TestTaskServiceProcessQueues(t *testing.T) {
 			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
 				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
 				mockConversionService.On("Get", mock.AnythingOfType("*context.cancelCtx"), tc.deletionInfo.Fullpath).Return(tc.fileInfo, nil).Once()
+				mockConversionService.On("IsOutputShared", mock.AnythingOfType("*context.cancelCtx"), tc.fileInfo, "/path/to/file.ext.jpg").
+					Return(false, nil).
+					Once()
+				return mockConversionService
+			},
+			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
+				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
+				mockDeletionService.On("Pop", mock.AnythingOfType("*context.cancelCtx")).Return(tc.deletionInfo, nil).Once()
+				mockDeletionService.On("MarkAsDone", mock.AnythingOfType("*context.cancelCtx"), tc.deletionInfo.Fullpath).
+					Return(nil).
+					Once()
+				mockDeletionService.On("Pop", mock.AnythingOfType("*context.cancelCtx")).Return(nil, db.ErrNotFound).Once()
+				return mockDeletionService
+			},
+			mockConverterService: func(tc *testcase) *serviceMocks.MockConverterService {
+				mockConverterService := serviceMocks.NewMockConverterService(t)
+				return mockConverterService
+			},
+		},
+		{
+			name:             "Successful deletion task execution with removal of the source and the record",
+			deletionQueueLen: 1,
+			fileInfo:         conversionDoneInfo,
+			deletionInfo:     purgeDeletionInfo,
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("Get", mock.AnythingOfType("*context.cancelCtx"), tc.deletionInfo.Fullpath).Return(tc.fileInfo, nil).Once()
+				mockConversionService.On("IsOutputShared", mock.AnythingOfType("*context.cancelCtx"), tc.fileInfo, "/path/to/file.ext.jpg").
+					Return(false, nil).
+					Once()
+				return mockConversionService
+			},
+			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
+				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
+				mockDeletionService.On("Pop", mock.AnythingOfType("*context.cancelCtx")).Return(tc.deletionInfo, nil).Once()
+				mockDeletionService.On("Purge", mock.AnythingOfType("*context.cancelCtx"), tc.deletionInfo.Fullpath).
+					Return(nil).
+					Once()
+				mockDeletionService.On("Pop", mock.AnythingOfType("*context.cancelCtx")).Return(nil, db.ErrNotFound).Once()
+				return mockDeletionService
+			},
+			mockConverterService: func(tc *testcase) *serviceMocks.MockConverterService {
+				mockConverterService := serviceMocks.NewMockConverterService(t)
+				return mockConverterService
+			},
+		},
+		{
+			name:             "Keep converted files shared with another source of the same content",
+			deletionQueueLen: 1,
+			fileInfo:         conversionDoneInfo,
+			deletionInfo:     deletionInfo,
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("Get", mock.AnythingOfType("*context.cancelCtx"), tc.deletionInfo.Fullpath).Return(tc.fileInfo, nil).Once()
+				mockConversionService.On("IsOutputShared", mock.AnythingOfType("*context.cancelCtx"), tc.fileInfo, "/path/to/file.ext.jpg").
+					Return(true, nil).
+					Once()
 				return mockConversionService
 			},
 			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
@@ -359,10 +578,13 @@ func TestTaskServiceProcessQueues(t *testing.T) {
 			mockConverterService := tc.mockConverterService(&tc)
 
 			taskService := task.NewService(
+				cfg,
 				logger,
 				mockConversionService,
 				mockDeletionService,
 				mockConverterService,
+				newDiskGuard(t),
+				local.NewStorage(""),
 			)
 
 			for i := 0; i < tc.conversionQeueueLen; i++ {
@@ -412,68 +634,252 @@ func TestTaskServiceProcessScanfs(t *testing.T) {
 	var (
 		successId int64 = 1
 		logger          = dummy.NewDummyLogger()
+		future          = time.Now().Add(time.Hour)
+		jpgInfo         = &model.ConversionInfo{
+			Fullpath: "/files/images/gen.jpg",
+			Path:     "/files/images",
+			Filestem: "gen",
+			Ext:      "jpg",
+		}
+		pngInfo = &model.ConversionInfo{
+			Fullpath: "/files/images/gen.png",
+			Path:     "/files/images",
+			Filestem: "gen",
+			Ext:      "png",
+		}
+		mp4Info = &model.ConversionInfo{
+			Fullpath: "/files/videos/gen.mp4",
+			Path:     "/files/videos",
+			Filestem: "gen",
+			Ext:      "mp4",
+		}
+		unexpectedErr = errors.New("unexpected error")
+		noSources     = map[string]*model.Conversion{}
 	)
 
 	type testcase struct {
 		name                  string
+		rootDir               string
+		defaults              *config.Defaults
+		opts                  model.ScanOptions
+		batchSize             int
+		err                   error
+		visited               int64
+		enqueued              int64
+		errors                int64
+		skipped               map[string]int64
 		mockConversionService func(tc *testcase) *serviceMocks.MockConversionQueueService
-		mockDeletionService   func(tc *testcase) *serviceMocks.MockDeletionQueueService
-		mockConverterService  func(tc *testcase) *serviceMocks.MockConverterService
 	}
 
 	cases := []testcase{
 		{
-			name: "Successful scanfs task execution",
+			name:     "Successful scanfs task execution",
+			visited:  4,
+			enqueued: 3,
+			skipped:  map[string]int64{model.ScanSkipUnsupportedType: 1},
 			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
 				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("Prepare", jpgInfo, file.MediaTypeImage).Return(nil).Once()
+				mockConversionService.On("Prepare", pngInfo, file.MediaTypeImage).Return(nil).Once()
+				mockConversionService.On("Prepare", mp4Info, file.MediaTypeVideo).Return(nil).Once()
+				mockConversionService.On("FindSources", mock.AnythingOfType("*context.valueCtx"), fullpathsOf(jpgInfo, pngInfo, mp4Info)).Return(noSources, nil).Once()
 				mockConversionService.
-					On(
-						"Add",
-						mock.AnythingOfType("*context.cancelCtx"),
-						&model.ConversionInfo{
-							Fullpath: "/files/images/gen.jpg",
-							Path:     "/files/images",
-							Filestem: "gen",
-							Ext:      "jpg",
-						},
-					).
-					Return(successId, nil).
+					On("AddBatch", mock.AnythingOfType("*context.valueCtx"), batchOf(jpgInfo, pngInfo, mp4Info)).
+					Return(int64(3), nil).
 					Once()
+				return mockConversionService
+			},
+		},
+		{
+			name:      "Files are enqueued in batches",
+			batchSize: 1,
+			visited:   4,
+			enqueued:  3,
+			skipped:   map[string]int64{model.ScanSkipUnsupportedType: 1},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				for _, info := range []*model.ConversionInfo{jpgInfo, pngInfo, mp4Info} {
+					mockConversionService.On("Prepare", info, mock.AnythingOfType("file.MediaType")).Return(nil).Once()
+					mockConversionService.On("FindSources", mock.AnythingOfType("*context.valueCtx"), fullpathsOf(info)).Return(noSources, nil).Once()
+					mockConversionService.
+						On("AddBatch", mock.AnythingOfType("*context.valueCtx"), batchOf(info)).
+						Return(successId, nil).
+						Once()
+				}
+				return mockConversionService
+			},
+		},
+		{
+			name:     "Scan of subdirectory with include patterns",
+			opts:     model.ScanOptions{Path: "images", Include: []string{"*.png"}},
+			visited:  2,
+			enqueued: 1,
+			skipped:  map[string]int64{model.ScanSkipNotIncluded: 1},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("Prepare", pngInfo, file.MediaTypeImage).Return(nil).Once()
+				mockConversionService.On("FindSources", mock.AnythingOfType("*context.valueCtx"), fullpathsOf(pngInfo)).Return(noSources, nil).Once()
 				mockConversionService.
-					On(
-						"Add",
-						mock.AnythingOfType("*context.cancelCtx"),
-						&model.ConversionInfo{
-							Fullpath: "/files/images/gen.png",
-							Path:     "/files/images",
-							Filestem: "gen",
-							Ext:      "png",
-						},
-					).
+					On("AddBatch", mock.AnythingOfType("*context.valueCtx"), batchOf(pngInfo)).
 					Return(successId, nil).
 					Once()
+				return mockConversionService
+			},
+		},
+		{
+			name:     "Scan with exclude patterns",
+			opts:     model.ScanOptions{Exclude: []string{"videos/*", "*.txt"}},
+			visited:  4,
+			enqueued: 2,
+			skipped:  map[string]int64{model.ScanSkipExcluded: 2},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("Prepare", jpgInfo, file.MediaTypeImage).Return(nil).Once()
+				mockConversionService.On("Prepare", pngInfo, file.MediaTypeImage).Return(nil).Once()
+				mockConversionService.On("FindSources", mock.AnythingOfType("*context.valueCtx"), fullpathsOf(jpgInfo, pngInfo)).Return(noSources, nil).Once()
 				mockConversionService.
-					On(
-						"Add",
-						mock.AnythingOfType("*context.cancelCtx"),
-						&model.ConversionInfo{
-							Fullpath: "/files/videos/gen.mp4",
-							Path:     "/files/videos",
-							Filestem: "gen",
-							Ext:      "mp4",
-						},
-					).
+					On("AddBatch", mock.AnythingOfType("*context.valueCtx"), batchOf(jpgInfo, pngInfo)).
+					Return(int64(2), nil).
+					Once()
+				return mockConversionService
+			},
+		},
+		{
+			name:     "Scan with max depth",
+			opts:     model.ScanOptions{MaxDepth: 1},
+			visited:  0,
+			enqueued: 0,
+			skipped:  map[string]int64{},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				return serviceMocks.NewMockConversionQueueService(t)
+			},
+		},
+		{
+			name:     "Scan of files modified since the given time",
+			opts:     model.ScanOptions{ModifiedSince: &future},
+			visited:  4,
+			enqueued: 0,
+			skipped:  map[string]int64{model.ScanSkipNotModified: 4},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				return serviceMocks.NewMockConversionQueueService(t)
+			},
+		},
+		{
+			name:     "Already queued files are skipped",
+			opts:     model.ScanOptions{Path: "images"},
+			visited:  2,
+			enqueued: 1,
+			skipped:  map[string]int64{model.ScanSkipAlreadyQueued: 1},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("Prepare", jpgInfo, file.MediaTypeImage).Return(nil).Once()
+				mockConversionService.On("Prepare", pngInfo, file.MediaTypeImage).Return(nil).Once()
+				mockConversionService.On("FindSources", mock.AnythingOfType("*context.valueCtx"), fullpathsOf(jpgInfo, pngInfo)).Return(noSources, nil).Once()
+				mockConversionService.
+					On("AddBatch", mock.AnythingOfType("*context.valueCtx"), batchOf(jpgInfo, pngInfo)).
 					Return(successId, nil).
 					Once()
 				return mockConversionService
 			},
-			mockDeletionService: func(tc *testcase) *serviceMocks.MockDeletionQueueService {
-				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
-				return mockDeletionService
+		},
+		{
+			name:     "Dry run does not enqueue files",
+			opts:     model.ScanOptions{DryRun: true},
+			visited:  4,
+			enqueued: 2,
+			skipped:  map[string]int64{model.ScanSkipUnsupportedType: 1, model.ScanSkipAlreadyQueued: 1},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("Prepare", jpgInfo, file.MediaTypeImage).Return(nil).Once()
+				mockConversionService.On("Prepare", pngInfo, file.MediaTypeImage).Return(nil).Once()
+				mockConversionService.On("Prepare", mp4Info, file.MediaTypeVideo).Return(nil).Once()
+				mockConversionService.On("FindSources", mock.AnythingOfType("*context.valueCtx"), fullpathsOf(jpgInfo, pngInfo, mp4Info)).Return(noSources, nil).Once()
+				mockConversionService.
+					On("FindQueued", mock.AnythingOfType("*context.valueCtx"), fullpathsOf(jpgInfo, pngInfo, mp4Info)).
+					Return([]string{jpgInfo.Fullpath}, nil).
+					Once()
+				return mockConversionService
 			},
-			mockConverterService: func(tc *testcase) *serviceMocks.MockConverterService {
-				mockConverterService := serviceMocks.NewMockConverterService(t)
-				return mockConverterService
+		},
+		{
+			name:     "Files that cannot be converted are reported as errors",
+			opts:     model.ScanOptions{Path: "videos"},
+			visited:  1,
+			enqueued: 0,
+			errors:   1,
+			skipped:  map[string]int64{},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("Prepare", mp4Info, file.MediaTypeVideo).Return(conversionq.ErrInvalidConversionFormat).Once()
+				return mockConversionService
+			},
+		},
+		{
+			name:     "Failed batches are reported as errors",
+			opts:     model.ScanOptions{Path: "videos"},
+			visited:  1,
+			enqueued: 0,
+			errors:   1,
+			skipped:  map[string]int64{},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("Prepare", mp4Info, file.MediaTypeVideo).Return(nil).Once()
+				mockConversionService.On("FindSources", mock.AnythingOfType("*context.valueCtx"), fullpathsOf(mp4Info)).Return(noSources, nil).Once()
+				mockConversionService.
+					On("AddBatch", mock.AnythingOfType("*context.valueCtx"), batchOf(mp4Info)).
+					Return(int64(0), unexpectedErr).
+					Once()
+				return mockConversionService
+			},
+		},
+		{
+			name:    "Generated and ignored files are skipped",
+			rootDir: "scan",
+			defaults: &config.Defaults{
+				Image: config.ImageDefaults{Formats: []model.ConvertTo{{Ext: "jpg", Optional: map[string]interface{}{"suffix": ".small"}}}},
+			},
+			visited:  8,
+			enqueued: 2,
+			skipped: map[string]int64{
+				model.ScanSkipIgnored:         2,
+				model.ScanSkipUnsupportedType: 2,
+				model.ScanSkipGenerated:       2,
+			},
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				photoInfo := &model.ConversionInfo{Fullpath: "/scan/photos/photo.png", Path: "/scan/photos", Filestem: "photo", Ext: "png"}
+				clipInfo := &model.ConversionInfo{Fullpath: "/scan/videos/clip.mp4", Path: "/scan/videos", Filestem: "clip", Ext: "mp4"}
+				// The output of a format given in a request is recognized by the conversion of its source
+				variantInfo := &model.ConversionInfo{Fullpath: "/scan/videos/clip.vp9.webm", Path: "/scan/videos", Filestem: "clip.vp9", Ext: "webm"}
+
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("Prepare", photoInfo, file.MediaTypeImage).Return(nil).Once()
+				mockConversionService.On("Prepare", clipInfo, file.MediaTypeVideo).Return(nil).Once()
+				mockConversionService.On("Prepare", variantInfo, file.MediaTypeVideo).Return(nil).Once()
+				mockConversionService.
+					On("FindSources", mock.AnythingOfType("*context.valueCtx"), fullpathsOf(photoInfo, clipInfo, variantInfo)).
+					Return(map[string]*model.Conversion{variantInfo.Fullpath: {Id: successId, Fullpath: clipInfo.Fullpath}}, nil).
+					Once()
+				mockConversionService.
+					On("AddBatch", mock.AnythingOfType("*context.valueCtx"), batchOf(photoInfo, clipInfo)).
+					Return(int64(2), nil).
+					Once()
+				return mockConversionService
+			},
+		},
+		{
+			name: "Failed scan: directory does not exist",
+			opts: model.ScanOptions{Path: "missing"},
+			err:  task.ErrInvalidScanOptions,
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				return serviceMocks.NewMockConversionQueueService(t)
+			},
+		},
+		{
+			name: "Failed scan: malformed pattern",
+			opts: model.ScanOptions{Include: []string{"["}},
+			err:  task.ErrInvalidScanOptions,
+			mockConversionService: func(tc *testcase) *serviceMocks.MockConversionQueueService {
+				return serviceMocks.NewMockConversionQueueService(t)
 			},
 		},
 	}
@@ -487,21 +893,45 @@ func TestTaskServiceProcessScanfs(t *testing.T) {
 			ctx, cancel := context.WithCancel(context.Background())
 
 			mockConversionService := tc.mockConversionService(&tc)
-			mockDeletionService := tc.mockDeletionService(&tc)
-			mockConverterService := tc.mockConverterService(&tc)
+			mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
+			mockConverterService := serviceMocks.NewMockConverterService(t)
+
+			rootDir := tc.rootDir
+			if rootDir == "" {
+				rootDir = "files"
+			}
 
 			taskService := task.NewService(
+				&config.Config{Scan: config.Scan{Workers: 2, BatchSize: tc.batchSize}, Defaults: tc.defaults},
 				logger,
 				mockConversionService,
 				mockDeletionService,
 				mockConverterService,
+				newDiskGuard(t),
+				local.NewStorage(""),
 			)
 
-			err := taskService.ProcessScanfs(ctx, "files")
+			scan, err := taskService.ProcessScanfs(ctx, rootDir, tc.opts)
 
 			cancel()
 
-			assert.NoError(t, err)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				assert.Nil(t, scan)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, model.ScanStatusDone, scan.Status)
+				assert.Equal(t, tc.visited, scan.Visited)
+				assert.Equal(t, tc.enqueued, scan.Enqueued)
+				assert.Equal(t, tc.skipped, scan.Skipped)
+				assert.Equal(t, tc.errors, scan.Errors)
+				assert.NotNil(t, scan.FinishedAt)
+				assert.False(t, taskService.IsScanning())
+
+				found, err := taskService.GetScan(scan.Id)
+				assert.NoError(t, err)
+				assert.Equal(t, scan, found)
+			}
 
 			mockConversionService.AssertExpectations(t)
 			mockDeletionService.AssertExpectations(t)
@@ -509,3 +939,95 @@ func TestTaskServiceProcessScanfs(t *testing.T) {
 		})
 	}
 }
+
+func TestTaskServiceScans(t *testing.T) {
+	var (
+		cfg    = &config.Config{}
+		logger = dummy.NewDummyLogger()
+	)
+
+	taskService := task.NewService(
+		cfg,
+		logger,
+		serviceMocks.NewMockConversionQueueService(t),
+		serviceMocks.NewMockDeletionQueueService(t),
+		serviceMocks.NewMockConverterService(t),
+		serviceMocks.NewMockDiskGuardService(t),
+		local.NewStorage(""),
+	)
+
+	_, err := taskService.GetScan("unknown")
+	assert.ErrorIs(t, err, task.ErrScanNotFound)
+
+	_, err = taskService.CancelScan("unknown")
+	assert.ErrorIs(t, err, task.ErrScanNotFound)
+
+	// Nothing matches, so the scan finishes without touching the queue
+	scan, err := taskService.ProcessScanfs(context.Background(), "files", model.ScanOptions{Include: []string{"*.none"}})
+	assert.NoError(t, err)
+
+	_, err = taskService.CancelScan(scan.Id)
+	assert.ErrorIs(t, err, task.ErrScanNotRunning)
+
+	// A scan started with a canceled context stops before enqueuing anything
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	scan, err = taskService.ProcessScanfs(ctx, "files", model.ScanOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, model.ScanStatusCanceled, scan.Status)
+	assert.Zero(t, scan.Enqueued)
+}
+
+func TestTaskServiceScanUnresolvableRoot(t *testing.T) {
+	taskService := task.NewService(
+		&config.Config{Scan: config.Scan{Workers: 4}},
+		dummy.NewDummyLogger(),
+		serviceMocks.NewMockConversionQueueService(t),
+		serviceMocks.NewMockDeletionQueueService(t),
+		serviceMocks.NewMockConverterService(t),
+		serviceMocks.NewMockDiskGuardService(t),
+		local.NewStorage(""),
+	)
+
+	// The cleaned root exists, but a missing element of the path cannot be resolved
+	goroutines := runtime.NumGoroutine()
+	scan, err := taskService.ProcessScanfs(context.Background(), "missing/..", model.ScanOptions{})
+
+	assert.ErrorContains(t, err, "failed to resolve root directory")
+	assert.Equal(t, model.ScanStatusFailed, scan.Status)
+	// The failed scan leaves no workers behind
+	assert.LessOrEqual(t, runtime.NumGoroutine(), goroutines)
+}
+
+// Files are sniffed in parallel, so a batch may list them in any order.
+// The sources are not hashed until they are converted.
+func batchOf(infos ...*model.ConversionInfo) interface{} {
+	return mock.MatchedBy(func(batch []*model.ConversionInfo) bool {
+		if len(batch) != len(infos) {
+			return false
+		}
+		for _, info := range infos {
+			if !slices.ContainsFunc(batch, func(entry *model.ConversionInfo) bool {
+				return assert.ObjectsAreEqual(info, entry)
+			}) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func fullpathsOf(infos ...*model.ConversionInfo) interface{} {
+	return mock.MatchedBy(func(fullpaths []string) bool {
+		if len(fullpaths) != len(infos) {
+			return false
+		}
+		for _, info := range infos {
+			if !slices.Contains(fullpaths, info.Fullpath) {
+				return false
+			}
+		}
+		return true
+	})
+}
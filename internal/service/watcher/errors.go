@@ -0,0 +1,7 @@
+package watcher
+
+import "errors"
+
+var (
+	ErrWatchLimitExceeded = errors.New("inotify watch limit exceeded")
+)
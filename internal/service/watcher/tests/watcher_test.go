@@ -0,0 +1,241 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/db"
+	"github.com/chistyakoviv/converter/internal/file"
+	"github.com/chistyakoviv/converter/internal/logger/dummy"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service/conversionq"
+	"github.com/chistyakoviv/converter/internal/service/deletionq"
+	serviceMocks "github.com/chistyakoviv/converter/internal/service/mocks"
+	"github.com/chistyakoviv/converter/internal/service/watcher"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Enough for the file type to be sniffed
+var jpegHead = []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	// #nosec G306 -- this is test code and wide permissions are intentional
+	require.NoError(t, os.WriteFile(path, data, 0644))
+}
+
+func hasSuffix(suffix string) interface{} {
+	return mock.MatchedBy(func(fullpath string) bool {
+		return strings.HasSuffix(fullpath, suffix)
+	})
+}
+
+func TestWatcherService(t *testing.T) {
+	var (
+		logger = dummy.NewDummyLogger()
+		cfg    = &config.Config{
+			Watcher: config.Watcher{
+				Enabled:      true,
+				Debounce:     20 * time.Millisecond,
+				ScanInterval: time.Minute,
+			},
+			Scan: config.Scan{Symlinks: config.SymlinksSkip},
+			Defaults: &config.Defaults{
+				Image: config.ImageDefaults{Formats: []model.ConvertTo{{Ext: "jpg", Optional: map[string]interface{}{"suffix": ".small"}}}},
+			},
+		}
+	)
+
+	type testcase struct {
+		name                  string
+		setup                 func(t *testing.T, root string)
+		act                   func(t *testing.T, root string)
+		mockConversionService func(tc *testcase, done chan<- struct{}) *serviceMocks.MockConversionQueueService
+		mockDeletionService   func(tc *testcase, done chan<- struct{}) *serviceMocks.MockDeletionQueueService
+	}
+
+	cases := []testcase{
+		{
+			name: "Enqueue new file",
+			act: func(t *testing.T, root string) {
+				writeFile(t, root+"/new.jpg", jpegHead)
+			},
+			mockConversionService: func(tc *testcase, done chan<- struct{}) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("FindSource", mock.Anything, hasSuffix("/new.jpg")).Return(nil, db.ErrNotFound).Once()
+				mockConversionService.On("Add", mock.Anything, mock.MatchedBy(func(info *model.ConversionInfo) bool {
+					return strings.HasSuffix(info.Fullpath, "/new.jpg") && info.Ext == "jpg" && info.Filestem == "new"
+				})).Return(int64(1), nil).Once().Run(func(args mock.Arguments) { close(done) })
+				return mockConversionService
+			},
+			mockDeletionService: func(tc *testcase, done chan<- struct{}) *serviceMocks.MockDeletionQueueService {
+				return serviceMocks.NewMockDeletionQueueService(t)
+			},
+		},
+		{
+			name: "Requeue changed file",
+			setup: func(t *testing.T, root string) {
+				writeFile(t, root+"/changed.jpg", jpegHead)
+			},
+			act: func(t *testing.T, root string) {
+				writeFile(t, root+"/changed.jpg", append(jpegHead, 0x00))
+			},
+			mockConversionService: func(tc *testcase, done chan<- struct{}) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("FindSource", mock.Anything, hasSuffix("/changed.jpg")).Return(nil, db.ErrNotFound).Once()
+				mockConversionService.On("Add", mock.Anything, mock.Anything).Return(int64(-1), fmt.Errorf("add failed: %w", conversionq.ErrPathAlreadyExist)).Once()
+				mockConversionService.On("Requeue", mock.Anything, hasSuffix("/changed.jpg")).Return(nil).Once().Run(func(args mock.Arguments) { close(done) })
+				return mockConversionService
+			},
+			mockDeletionService: func(tc *testcase, done chan<- struct{}) *serviceMocks.MockDeletionQueueService {
+				return serviceMocks.NewMockDeletionQueueService(t)
+			},
+		},
+		{
+			name: "Enqueue deletion of removed file",
+			setup: func(t *testing.T, root string) {
+				writeFile(t, root+"/removed.jpg", jpegHead)
+			},
+			act: func(t *testing.T, root string) {
+				require.NoError(t, os.Remove(root+"/removed.jpg"))
+			},
+			mockConversionService: func(tc *testcase, done chan<- struct{}) *serviceMocks.MockConversionQueueService {
+				return serviceMocks.NewMockConversionQueueService(t)
+			},
+			mockDeletionService: func(tc *testcase, done chan<- struct{}) *serviceMocks.MockDeletionQueueService {
+				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
+				mockDeletionService.On("Add", mock.Anything, mock.MatchedBy(func(info *model.DeletionInfo) bool {
+					return strings.HasSuffix(info.Fullpath, "/removed.jpg")
+				})).Return(int64(1), nil).Once().Run(func(args mock.Arguments) { close(done) })
+				return mockDeletionService
+			},
+		},
+		{
+			name: "Skip unknown removed file",
+			act: func(t *testing.T, root string) {
+				writeFile(t, root+"/unknown.jpg", jpegHead)
+				require.NoError(t, os.Remove(root+"/unknown.jpg"))
+				writeFile(t, root+"/next.jpg", jpegHead)
+			},
+			mockConversionService: func(tc *testcase, done chan<- struct{}) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("FindSource", mock.Anything, hasSuffix("/next.jpg")).Return(nil, db.ErrNotFound).Once()
+				mockConversionService.On("Add", mock.Anything, mock.Anything).Return(int64(1), nil).Once().Run(func(args mock.Arguments) { close(done) })
+				return mockConversionService
+			},
+			mockDeletionService: func(tc *testcase, done chan<- struct{}) *serviceMocks.MockDeletionQueueService {
+				mockDeletionService := serviceMocks.NewMockDeletionQueueService(t)
+				mockDeletionService.On("Add", mock.Anything, mock.Anything).Return(int64(-1), fmt.Errorf("deletion failed: %w", deletionq.ErrFileDoesNotExist)).Maybe()
+				return mockDeletionService
+			},
+		},
+		{
+			name: "Skip converted and foreign files in new directory",
+			act: func(t *testing.T, root string) {
+				// #nosec G301 -- this is test code and wide permissions are intentional
+				require.NoError(t, os.Mkdir(root+"/sub", 0777))
+				writeFile(t, root+"/sub/photo.jpg.small.jpg", jpegHead)
+				writeFile(t, root+"/sub/fake.jpg", []byte("not an image"))
+				writeFile(t, root+"/sub/notes.txt", []byte("notes"))
+				writeFile(t, root+"/sub/photo.tmp.jpg", jpegHead)
+				writeFile(t, root+"/sub/other.jpg", jpegHead)
+			},
+			mockConversionService: func(tc *testcase, done chan<- struct{}) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("FindSource", mock.Anything, hasSuffix("/sub/photo.jpg.small.jpg")).Return(&model.Conversion{}, nil).Once()
+				mockConversionService.On("FindSource", mock.Anything, hasSuffix("/sub/other.jpg")).Return(nil, db.ErrNotFound).Once()
+				mockConversionService.On("Add", mock.Anything, mock.MatchedBy(func(info *model.ConversionInfo) bool {
+					return strings.HasSuffix(info.Fullpath, "/sub/other.jpg")
+				})).Return(int64(1), nil).Once().Run(func(args mock.Arguments) { close(done) })
+				return mockConversionService
+			},
+			mockDeletionService: func(tc *testcase, done chan<- struct{}) *serviceMocks.MockDeletionQueueService {
+				return serviceMocks.NewMockDeletionQueueService(t)
+			},
+		},
+		{
+			name: "Skip ignored, generated and symlinked files",
+			setup: func(t *testing.T, root string) {
+				writeFile(t, root+"/"+file.IgnoreFileName, []byte("ignored/\n*.raw.jpg\n"))
+				writeFile(t, root+"/photo.jpg", jpegHead)
+			},
+			act: func(t *testing.T, root string) {
+				// #nosec G301 -- this is test code and wide permissions are intentional
+				require.NoError(t, os.Mkdir(root+"/ignored", 0777))
+				writeFile(t, root+"/ignored/nested.jpg", jpegHead)
+				writeFile(t, root+"/photo.raw.jpg", jpegHead)
+				// The output of the default format is recognized without querying the queue
+				writeFile(t, root+"/photo.jpg.small.jpg", jpegHead)
+				require.NoError(t, os.Symlink("photo.jpg", root+"/link.jpg"))
+				writeFile(t, root+"/other.jpg", jpegHead)
+			},
+			mockConversionService: func(tc *testcase, done chan<- struct{}) *serviceMocks.MockConversionQueueService {
+				mockConversionService := serviceMocks.NewMockConversionQueueService(t)
+				mockConversionService.On("FindSource", mock.Anything, hasSuffix("/other.jpg")).Return(nil, db.ErrNotFound).Once()
+				mockConversionService.On("Add", mock.Anything, mock.MatchedBy(func(info *model.ConversionInfo) bool {
+					return strings.HasSuffix(info.Fullpath, "/other.jpg")
+				})).Return(int64(1), nil).Once().Run(func(args mock.Arguments) { close(done) })
+				return mockConversionService
+			},
+			mockDeletionService: func(tc *testcase, done chan<- struct{}) *serviceMocks.MockDeletionQueueService {
+				return serviceMocks.NewMockDeletionQueueService(t)
+			},
+		},
+	}
+
+	for i, tc := range cases {
+		tc := tc
+		root := fmt.Sprintf("files/watched-%d", i)
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// #nosec G301 -- this is test code and wide permissions are intentional
+			require.NoError(t, os.MkdirAll(root, 0777))
+			t.Cleanup(func() {
+				require.NoError(t, os.RemoveAll(root), "Failed to remove watched dir")
+			})
+
+			if tc.setup != nil {
+				tc.setup(t, root)
+			}
+
+			done := make(chan struct{})
+			taskService := serviceMocks.NewMockTaskService(t)
+			taskService.On("TryQueueConversion").Return(true).Maybe()
+			taskService.On("TryQueueDeletion").Return(true).Maybe()
+
+			service := watcher.NewService(
+				cfg,
+				logger,
+				tc.mockConversionService(&tc, done),
+				tc.mockDeletionService(&tc, done),
+				taskService,
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			stopped := make(chan error)
+			go func() {
+				stopped <- service.Watch(ctx, root)
+			}()
+
+			// Let the watches be added
+			time.Sleep(100 * time.Millisecond)
+			tc.act(t, root)
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Error("file was not enqueued")
+			}
+
+			cancel()
+			require.NoError(t, <-stopped)
+		})
+	}
+}
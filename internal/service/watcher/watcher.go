@@ -0,0 +1,409 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/db"
+	"github.com/chistyakoviv/converter/internal/file"
+	"github.com/chistyakoviv/converter/internal/lib/slogger"
+	"github.com/chistyakoviv/converter/internal/model"
+	"github.com/chistyakoviv/converter/internal/service"
+	"github.com/chistyakoviv/converter/internal/service/conversionq"
+	"github.com/chistyakoviv/converter/internal/service/deletionq"
+	"github.com/chistyakoviv/converter/internal/service/task"
+	"github.com/fsnotify/fsnotify"
+)
+
+// A file seen in an event, it is enqueued once it has not changed for two debounce periods
+type entry struct {
+	lastEvent time.Time
+	checked   bool
+	size      int64
+	modTime   time.Time
+}
+
+type serv struct {
+	cfg                    *config.Config
+	logger                 *slog.Logger
+	conversionQueueService service.ConversionQueueService
+	deletionQueueService   service.DeletionQueueService
+	taskService            service.TaskService
+	// Accessed only by the watching goroutine
+	pending  map[string]*entry
+	filter   *task.SourceFilter
+	doneOnce sync.Once
+	done     chan struct{}
+}
+
+func NewService(
+	cfg *config.Config,
+	logger *slog.Logger,
+	conversionQueueService service.ConversionQueueService,
+	deletionQueueService service.DeletionQueueService,
+	taskService service.TaskService,
+) service.WatcherService {
+	return &serv{
+		cfg:                    cfg,
+		logger:                 logger,
+		conversionQueueService: conversionQueueService,
+		deletionQueueService:   deletionQueueService,
+		taskService:            taskService,
+		pending:                make(map[string]*entry),
+		done:                   make(chan struct{}),
+	}
+}
+
+// Watch blocks until the context is done or the service is shut down.
+// New and changed media files are enqueued for conversion and removed ones for deletion.
+// If the filesystem cannot be watched because of the inotify limits,
+// the directory is periodically scanned for changes instead.
+func (s *serv) Watch(ctx context.Context, rootDir string) error {
+	op := "service.WatcherService.Watch"
+
+	logger := s.logger.With(slog.String("op", op))
+	since := time.Now()
+
+	// The same files are skipped as by the scans
+	filter, err := task.NewSourceFilter(s.cfg, s.logger, rootDir, func(err error) {
+		logger.Error("failed to read ignore file", slogger.Err(err))
+	})
+	if err != nil {
+		return err
+	}
+	s.filter = filter
+
+	err = s.watch(ctx, rootDir, since)
+	if !errors.Is(err, ErrWatchLimitExceeded) {
+		return err
+	}
+
+	logger.Warn("falling back to incremental scans", slog.String("interval", s.cfg.Watcher.ScanInterval.String()), slogger.Err(err))
+
+	return s.poll(ctx, rootDir, since)
+}
+
+func (s *serv) watch(ctx context.Context, rootDir string, since time.Time) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", wrapWatchError(err))
+	}
+	defer watcher.Close()
+
+	// Inotify watches are not recursive, so each directory is watched separately
+	if err = s.addRecursive(watcher, rootDir); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(s.cfg.Watcher.Debounce / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if err = s.handleEvent(watcher, event); err != nil {
+				return err
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				// Some events are lost, look for the changes on disk
+				s.logger.Warn("watcher event queue overflow, scanning for changes", slog.String("root", rootDir))
+				scanStarted := time.Now()
+				s.scan(rootDir, since)
+				since = scanStarted
+				continue
+			}
+			s.logger.Error("watcher error", slogger.Err(err))
+		case <-ticker.C:
+			s.flush(ctx)
+		case <-ctx.Done():
+			return nil
+		case <-s.done:
+			return nil
+		}
+	}
+}
+
+// Scans the directory for the files modified since the previous scan.
+// Removed files are detected by comparing the files found by consecutive scans.
+func (s *serv) poll(ctx context.Context, rootDir string, since time.Time) error {
+	seen := s.scan(rootDir, since)
+	since = time.Now()
+
+	scanTicker := time.NewTicker(s.cfg.Watcher.ScanInterval)
+	defer scanTicker.Stop()
+
+	flushTicker := time.NewTicker(s.cfg.Watcher.Debounce / 2)
+	defer flushTicker.Stop()
+
+	for {
+		select {
+		case <-scanTicker.C:
+			scanStarted := time.Now()
+			found := s.scan(rootDir, since)
+			for path := range seen {
+				if _, ok := found[path]; !ok {
+					s.touch(path)
+				}
+			}
+			seen = found
+			since = scanStarted
+		case <-flushTicker.C:
+			s.flush(ctx)
+		case <-ctx.Done():
+			return nil
+		case <-s.done:
+			return nil
+		}
+	}
+}
+
+func (s *serv) handleEvent(watcher *fsnotify.Watcher, event fsnotify.Event) error {
+	switch {
+	case event.Has(fsnotify.Create):
+		info, err := os.Stat(event.Name)
+		if err == nil && info.IsDir() {
+			if err = s.addRecursive(watcher, event.Name); err != nil {
+				return err
+			}
+			// Files may have been created before the directory was watched
+			s.scan(event.Name, time.Time{})
+			return nil
+		}
+		s.touch(event.Name)
+	case event.Has(fsnotify.Write), event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+		// Whether the file is gone is checked when the debounce period ends,
+		// so a file replaced by a rename is treated as changed
+		s.touch(event.Name)
+	}
+	return nil
+}
+
+func (s *serv) addRecursive(watcher *fsnotify.Watcher, rootDir string) error {
+	return filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			s.logger.Error("error accessing path", slog.String("path", path), slogger.Err(err))
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if err = watcher.Add(path); err != nil {
+			err = wrapWatchError(err)
+			if errors.Is(err, ErrWatchLimitExceeded) {
+				return err
+			}
+			s.logger.Error("failed to watch directory", slog.String("path", path), slogger.Err(err))
+			return fs.SkipDir
+		}
+		return nil
+	})
+}
+
+// Marks the media files modified after the given time as changed and returns all media files found
+func (s *serv) scan(rootDir string, since time.Time) map[string]struct{} {
+	found := make(map[string]struct{})
+	s.filter.ResetIgnores()
+
+	err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			s.logger.Error("error accessing path", slog.String("path", path), slogger.Err(err))
+			return nil
+		}
+		if d.IsDir() {
+			// The files are checked against the ignore files once more before they are enqueued
+			if path != rootDir && s.filter.SkipDir(path) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !isMediaPath(path) {
+			return nil
+		}
+
+		found[path] = struct{}{}
+
+		info, err := d.Info()
+		if err != nil {
+			s.logger.Error("failed to get file info", slog.String("path", path), slogger.Err(err))
+			return nil
+		}
+		if info.ModTime().After(since) {
+			s.touch(path)
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to scan directory", slog.String("root", rootDir), slogger.Err(err))
+	}
+
+	return found
+}
+
+// Postpones processing of the file until its changes settle
+func (s *serv) touch(path string) {
+	if !isMediaPath(path) {
+		return
+	}
+	s.pending[path] = &entry{lastEvent: time.Now()}
+}
+
+// Enqueues the files that have not changed for the debounce period
+func (s *serv) flush(ctx context.Context) {
+	var queuedConversion, queuedDeletion bool
+
+	s.filter.ResetIgnores()
+	now := time.Now()
+	for path, e := range s.pending {
+		if now.Sub(e.lastEvent) < s.cfg.Watcher.Debounce {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			delete(s.pending, path)
+			queuedDeletion = s.enqueueDeletion(ctx, path) || queuedDeletion
+			continue
+		}
+		if err != nil {
+			delete(s.pending, path)
+			s.logger.Error("failed to get file info", slog.String("path", path), slogger.Err(err))
+			continue
+		}
+
+		// The file may still be being written, wait for one more period
+		if !e.checked || info.Size() != e.size || !info.ModTime().Equal(e.modTime) {
+			e.checked = true
+			e.size = info.Size()
+			e.modTime = info.ModTime()
+			e.lastEvent = now
+			continue
+		}
+
+		delete(s.pending, path)
+		queuedConversion = s.enqueueConversion(ctx, path) || queuedConversion
+	}
+
+	// Try to process the files immediately
+	if queuedConversion {
+		s.taskService.TryQueueConversion()
+	}
+	if queuedDeletion {
+		s.taskService.TryQueueDeletion()
+	}
+}
+
+func (s *serv) enqueueConversion(ctx context.Context, path string) bool {
+	logger := s.logger.With(slog.String("path", path))
+
+	reason, err := s.filter.SkipPath(path)
+	if err != nil {
+		logger.Error("failed to get file info", slogger.Err(err))
+		return false
+	}
+	if reason != "" {
+		logger.Debug("skip watched file", slog.String("reason", reason))
+		return false
+	}
+
+	fullpath, _, reason, err := s.filter.Sniff(path)
+	if err != nil {
+		logger.Error("failed to determine file type", slogger.Err(err))
+		return false
+	}
+	if reason != "" {
+		logger.Debug("skip watched file", slog.String("reason", reason))
+		return false
+	}
+
+	// Converted files are written next to their sources and must not be converted again
+	_, err = s.conversionQueueService.FindSource(ctx, fullpath)
+	if err == nil {
+		logger.Debug("skip converted file")
+		return false
+	}
+	if !errors.Is(err, db.ErrNotFound) {
+		logger.Error("failed to find source of file", slogger.Err(err))
+		return false
+	}
+
+	_, err = s.conversionQueueService.Add(ctx, model.ToConversionInfoFromFileInfo(file.ExtractInfo(fullpath)))
+	if errors.Is(err, conversionq.ErrPathAlreadyExist) {
+		// The source has changed, convert it again
+		err = s.conversionQueueService.Requeue(ctx, fullpath)
+	}
+	if err != nil {
+		logger.Error("failed to enqueue conversion of watched file", slogger.Err(err))
+		return false
+	}
+
+	logger.Debug("conversion enqueued")
+	return true
+}
+
+func (s *serv) enqueueDeletion(ctx context.Context, path string) bool {
+	logger := s.logger.With(slog.String("path", path))
+
+	fullpath, err := toFullpath(path)
+	if err != nil {
+		logger.Error("failed to trim working directory", slogger.Err(err))
+		return false
+	}
+
+	_, err = s.deletionQueueService.Add(ctx, &model.DeletionInfo{Fullpath: fullpath})
+	if errors.Is(err, deletionq.ErrFileDoesNotExist) || errors.Is(err, deletionq.ErrPathAlreadyExist) {
+		// The file has never been converted or is already being deleted
+		logger.Debug("skip deletion of watched file", slogger.Err(err))
+		return false
+	}
+	if err != nil {
+		logger.Error("failed to enqueue deletion of watched file", slogger.Err(err))
+		return false
+	}
+
+	logger.Debug("deletion enqueued")
+	return true
+}
+
+func (s *serv) Shutdown() {
+	s.doneOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// Paths must start with "/" and be relative to the working directory, the same as for the scan
+func toFullpath(path string) (string, error) {
+	return file.Trimwd(file.EnsureLeadingSlash(path))
+}
+
+// Only files that can be converted are tracked, the type is sniffed before enqueuing
+func isMediaPath(path string) bool {
+	if file.IsTmpFilePath(path) {
+		return false
+	}
+	_, ok := conversionq.FileTypeToFormatMap[file.Ext(path)]
+	return ok
+}
+
+// Inotify reports ENOSPC when the watch limit is reached and EMFILE when the instance limit is reached
+func wrapWatchError(err error) error {
+	if errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EMFILE) {
+		return fmt.Errorf("%w: %w", ErrWatchLimitExceeded, err)
+	}
+	return err
+}
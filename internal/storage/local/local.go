@@ -0,0 +1,167 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/chistyakoviv/converter/internal/file"
+	"github.com/chistyakoviv/converter/internal/storage"
+)
+
+const (
+	filePermissions = 0644
+	dirPermissions  = 0755
+)
+
+type store struct {
+	root string
+}
+
+// NewStorage keeps files under the root directory, the working directory is used if the root is empty.
+func NewStorage(root string) storage.Storage {
+	return &store{
+		root: root,
+	}
+}
+
+func (s *store) Path(key string) (string, error) {
+	root := s.root
+	if root == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get working directory: %w", err)
+		}
+		root = wd
+	}
+	return root + file.EnsureLeadingSlash(key), nil
+}
+
+func (s *store) Stat(ctx context.Context, key string) (*storage.Object, error) {
+	path, err := s.Path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) || (err == nil && info.IsDir()) {
+		return nil, fmt.Errorf("%s: %w", key, storage.ErrNotExist)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &storage.Object{
+		Key:     key,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}, nil
+}
+
+func (s *store) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.Path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s: %w", key, storage.ErrNotExist)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// The file is written next to the destination and renamed, so readers never see a partial file
+func (s *store) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	path, err := s.Path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), dirPermissions); err != nil {
+		return err
+	}
+
+	tmpPath := file.ToTmpFilePath(path)
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, filePermissions)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write '%s': %w", key, err)
+	}
+
+	if err = os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to rename tmp file '%s': %w", tmpPath, err)
+	}
+
+	return nil
+}
+
+// The destination is a hard link to the source if possible, both are then independent of each other
+// since files are replaced rather than modified in place. A copy is made otherwise, e.g. across file systems.
+func (s *store) Copy(ctx context.Context, srcKey string, dstKey string) error {
+	src, err := s.Path(srcKey)
+	if err != nil {
+		return err
+	}
+	dst, err := s.Path(dstKey)
+	if err != nil {
+		return err
+	}
+
+	if _, err = os.Stat(src); os.IsNotExist(err) {
+		return fmt.Errorf("%s: %w", srcKey, storage.ErrNotExist)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(dst), dirPermissions); err != nil {
+		return err
+	}
+
+	// The link is created next to the destination and renamed, so an existing destination is replaced atomically
+	tmpPath := file.ToTmpFilePath(dst)
+	_ = os.Remove(tmpPath)
+	if err = os.Link(src, tmpPath); err == nil {
+		if err = os.Rename(tmpPath, dst); err != nil {
+			_ = os.Remove(tmpPath)
+			return fmt.Errorf("failed to rename tmp file '%s': %w", tmpPath, err)
+		}
+		return nil
+	}
+
+	r, err := s.Open(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	return s.Put(ctx, dstKey, r, -1)
+}
+
+func (s *store) Remove(ctx context.Context, key string) error {
+	path, err := s.Path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chistyakoviv/converter/internal/storage"
+	"github.com/chistyakoviv/converter/internal/storage/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	s := local.NewStorage(root)
+
+	key := "/files/images/photo.jpg"
+
+	_, err := s.Stat(ctx, key)
+	assert.ErrorIs(t, err, storage.ErrNotExist)
+	_, err = s.Open(ctx, key)
+	assert.ErrorIs(t, err, storage.ErrNotExist)
+
+	// Missing directories are created
+	require.NoError(t, s.Put(ctx, key, strings.NewReader("image data"), -1))
+	assert.FileExists(t, filepath.Join(root, "files/images/photo.jpg"))
+	assert.NoFileExists(t, filepath.Join(root, "files/images/photo.tmp.jpg"))
+
+	object, err := s.Stat(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("image data")), object.Size)
+
+	r, err := s.Open(ctx, key)
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, "image data", string(data))
+
+	// Directories are not files
+	_, err = s.Stat(ctx, "/files/images")
+	assert.ErrorIs(t, err, storage.ErrNotExist)
+
+	require.NoError(t, s.Remove(ctx, key))
+	assert.NoFileExists(t, filepath.Join(root, "files/images/photo.jpg"))
+	assert.NoError(t, s.Remove(ctx, key))
+}
+
+func TestLocalStorageIsAccessedInPlace(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	s := local.NewStorage(root)
+
+	src := "/files/images/photo.png"
+	require.NoError(t, s.Put(ctx, src, strings.NewReader("source"), -1))
+
+	srcPath, release, err := storage.Fetch(ctx, s, src)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "files/images/photo.png"), srcPath)
+	release()
+	// Releasing a file of the local storage does not remove it
+	assert.FileExists(t, srcPath)
+
+	destPath, store, release, err := storage.Output(s, "/files/images/thumbs/photo.png.webp")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "files/images/thumbs/photo.png.webp"), destPath)
+	require.NoError(t, os.WriteFile(destPath, []byte("output"), 0o600))
+	require.NoError(t, store(ctx))
+	release()
+	assert.FileExists(t, destPath)
+}
+
+func TestLocalStorageCopy(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	s := local.NewStorage(root)
+
+	src := "/files/images/photo.png.webp"
+	dest := "/files/copies/photo.png.webp"
+
+	assert.ErrorIs(t, storage.Copy(ctx, s, src, dest), storage.ErrNotExist)
+
+	require.NoError(t, s.Put(ctx, src, strings.NewReader("output"), -1))
+	require.NoError(t, s.Put(ctx, dest, strings.NewReader("stale"), -1))
+	require.NoError(t, storage.Copy(ctx, s, src, dest))
+
+	data, err := os.ReadFile(filepath.Join(root, "files/copies/photo.png.webp"))
+	require.NoError(t, err)
+	assert.Equal(t, "output", string(data))
+	assert.NoFileExists(t, filepath.Join(root, "files/copies/photo.png.tmp.webp"))
+
+	// Replacing the source does not change the copy
+	require.NoError(t, s.Put(ctx, src, strings.NewReader("replaced"), -1))
+	data, err = os.ReadFile(filepath.Join(root, "files/copies/photo.png.webp"))
+	require.NoError(t, err)
+	assert.Equal(t, "output", string(data))
+}
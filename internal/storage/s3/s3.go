@@ -0,0 +1,119 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/storage"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+type store struct {
+	client   *minio.Client
+	bucket   string
+	prefix   string
+	partSize uint64
+}
+
+// NewStorage keeps files in a bucket of an S3 compatible object storage.
+func NewStorage(cfg config.S3Storage) (storage.Storage, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		// The region is known in advance, so it is not requested before the first call
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client: %w", err)
+	}
+
+	return &store{
+		client:   client,
+		bucket:   cfg.Bucket,
+		prefix:   cfg.Prefix,
+		partSize: cfg.PartSize,
+	}, nil
+}
+
+// Object names have no leading slash
+func (s *store) objectName(key string) string {
+	return strings.TrimPrefix(path.Join(s.prefix, key), "/")
+}
+
+func (s *store) Stat(ctx context.Context, key string) (*storage.Object, error) {
+	info, err := s.client.StatObject(ctx, s.bucket, s.objectName(key), minio.StatObjectOptions{})
+	if err != nil {
+		return nil, wrapError(key, err)
+	}
+
+	return &storage.Object{
+		Key:     key,
+		Size:    info.Size,
+		ModTime: info.LastModified,
+	}, nil
+}
+
+func (s *store) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, s.objectName(key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, wrapError(key, err)
+	}
+
+	// The object is requested lazily, so a missing object is detected before reading
+	if _, err = object.Stat(); err != nil {
+		_ = object.Close()
+		return nil, wrapError(key, err)
+	}
+
+	return object, nil
+}
+
+// Files larger than the part size and files of unknown size are uploaded in parts
+func (s *store) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	_, err := s.client.PutObject(ctx, s.bucket, s.objectName(key), r, size, minio.PutObjectOptions{
+		PartSize: s.partSize,
+	})
+	if err != nil {
+		return wrapError(key, err)
+	}
+
+	return nil
+}
+
+// Objects are copied by the storage itself
+func (s *store) Copy(ctx context.Context, srcKey string, dstKey string) error {
+	_, err := s.client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: s.bucket, Object: s.objectName(dstKey)},
+		minio.CopySrcOptions{Bucket: s.bucket, Object: s.objectName(srcKey)},
+	)
+	if err != nil {
+		return wrapError(srcKey, err)
+	}
+
+	return nil
+}
+
+// S3 does not report an error for a missing object
+func (s *store) Remove(ctx context.Context, key string) error {
+	err := s.client.RemoveObject(ctx, s.bucket, s.objectName(key), minio.RemoveObjectOptions{})
+	if err != nil {
+		return wrapError(key, err)
+	}
+
+	return nil
+}
+
+func wrapError(key string, err error) error {
+	// A missing bucket is a misconfiguration rather than a missing file
+	resp := minio.ToErrorResponse(err)
+	if resp.StatusCode == http.StatusNotFound && resp.Code != "NoSuchBucket" {
+		return fmt.Errorf("%s: %w", key, storage.ErrNotExist)
+	}
+	return fmt.Errorf("%s: %w", key, err)
+}
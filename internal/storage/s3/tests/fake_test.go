@@ -0,0 +1,206 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// An in-process stand-in for an S3 compatible storage supporting the calls made by the storage,
+// including multipart uploads. Requests are not authenticated.
+type fakeS3 struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string][]byte
+	uploads map[string]map[int][]byte
+	// Number of parts of the completed multipart uploads by object name
+	parts  map[string]int
+	nextId int
+}
+
+func newFakeS3(bucket string) *fakeS3 {
+	return &fakeS3{
+		bucket:  bucket,
+		objects: make(map[string][]byte),
+		uploads: make(map[string]map[int][]byte),
+		parts:   make(map[string]int),
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket, name, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != f.bucket {
+		writeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	query := r.URL.Query()
+	uploadId := query.Get("uploadId")
+
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.nextId++
+		id := strconv.Itoa(f.nextId)
+		f.uploads[id] = make(map[int][]byte)
+		writeXML(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			Bucket   string
+			Key      string
+			UploadId string
+		}{Bucket: bucket, Key: name, UploadId: id})
+	case r.Method == http.MethodPut && uploadId != "":
+		parts, ok := f.uploads[uploadId]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		number, _ := strconv.Atoi(query.Get("partNumber"))
+		data, err := readBody(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		parts[number] = data
+		w.Header().Set("ETag", etag(data))
+	case r.Method == http.MethodPost && uploadId != "":
+		parts, ok := f.uploads[uploadId]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		numbers := make([]int, 0, len(parts))
+		for number := range parts {
+			numbers = append(numbers, number)
+		}
+		sort.Ints(numbers)
+		var data []byte
+		for _, number := range numbers {
+			data = append(data, parts[number]...)
+		}
+		delete(f.uploads, uploadId)
+		f.objects[name] = data
+		f.parts[name] = len(parts)
+		writeXML(w, struct {
+			XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+			Bucket  string
+			Key     string
+			ETag    string
+		}{Bucket: bucket, Key: name, ETag: etag(data)})
+	case r.Method == http.MethodDelete && uploadId != "":
+		delete(f.uploads, uploadId)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		source, _ := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+		_, sourceName, _ := strings.Cut(strings.TrimPrefix(source, "/"), "/")
+		data, ok := f.objects[sourceName]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		f.objects[name] = bytes.Clone(data)
+		writeXML(w, struct {
+			XMLName      xml.Name `xml:"CopyObjectResult"`
+			ETag         string
+			LastModified string
+		}{ETag: etag(data), LastModified: time.Now().UTC().Format(time.RFC3339)})
+	case r.Method == http.MethodPut:
+		data, err := readBody(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		f.objects[name] = data
+		w.Header().Set("ETag", etag(data))
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		data, ok := f.objects[name]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("ETag", etag(data))
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(data)
+		}
+	case r.Method == http.MethodDelete:
+		delete(f.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+func (f *fakeS3) object(name string) ([]byte, int, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, ok := f.objects[name]
+	return data, f.parts[name], ok
+}
+
+// Bodies of uploads over plain HTTP are signed chunk by chunk
+func readBody(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var data []byte
+	reader := bufio.NewReader(r.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return data, nil
+		}
+		chunk := make([]byte, size)
+		if _, err = io.ReadFull(reader, chunk); err != nil {
+			return nil, err
+		}
+		data = append(data, chunk...)
+		// Skip the line break following the chunk
+		if _, err = reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func etag(data []byte) string {
+	sum := md5.Sum(data)
+	return fmt.Sprintf("\"%s\"", hex.EncodeToString(sum[:]))
+}
+
+func writeXML(w http.ResponseWriter, v any) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	_ = xml.NewEncoder(&buf).Encode(v)
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = w.Write(buf.Bytes())
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, "%s<Error><Code>%s</Code><Message>%s</Message></Error>", xml.Header, code, code)
+}
@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"github.com/chistyakoviv/converter/internal/storage"
+	"github.com/chistyakoviv/converter/internal/storage/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bucket = "media"
+
+func newStorage(t *testing.T) (storage.Storage, *fakeS3) {
+	fake := newFakeS3(bucket)
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	s, err := s3.NewStorage(config.S3Storage{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Region:    "us-east-1",
+		Bucket:    bucket,
+		AccessKey: "access",
+		SecretKey: "secret",
+		Prefix:    "converter",
+		PartSize:  config.MinS3PartSize,
+	})
+	require.NoError(t, err)
+
+	return s, fake
+}
+
+func TestS3Storage(t *testing.T) {
+	ctx := context.Background()
+	s, fake := newStorage(t)
+
+	key := "/files/images/photo.jpg"
+	data := []byte("image data")
+
+	_, err := s.Stat(ctx, key)
+	assert.ErrorIs(t, err, storage.ErrNotExist)
+	_, err = s.Open(ctx, key)
+	assert.ErrorIs(t, err, storage.ErrNotExist)
+
+	require.NoError(t, s.Put(ctx, key, bytes.NewReader(data), int64(len(data))))
+
+	stored, parts, ok := fake.object("converter/files/images/photo.jpg")
+	require.True(t, ok)
+	assert.Equal(t, data, stored)
+	assert.Zero(t, parts)
+
+	object, err := s.Stat(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), object.Size)
+
+	r, err := s.Open(ctx, key)
+	require.NoError(t, err)
+	read, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, data, read)
+
+	require.NoError(t, s.Remove(ctx, key))
+	_, err = s.Stat(ctx, key)
+	assert.ErrorIs(t, err, storage.ErrNotExist)
+
+	// Removing a missing file is not an error
+	assert.NoError(t, s.Remove(ctx, key))
+}
+
+func TestS3StorageMultipartUpload(t *testing.T) {
+	ctx := context.Background()
+	s, fake := newStorage(t)
+
+	key := "/files/videos/clip.webm"
+	data := bytes.Repeat([]byte("0123456789abcdef"), (2*config.MinS3PartSize+config.MinS3PartSize/2)/16)
+
+	// The size of a stream is unknown, so it is uploaded in parts
+	require.NoError(t, s.Put(ctx, key, io.MultiReader(bytes.NewReader(data)), -1))
+
+	stored, parts, ok := fake.object("converter/files/videos/clip.webm")
+	require.True(t, ok)
+	assert.Equal(t, 3, parts)
+	assert.True(t, bytes.Equal(data, stored))
+}
+
+func TestS3StorageLocalCopies(t *testing.T) {
+	ctx := context.Background()
+	s, fake := newStorage(t)
+
+	src := "/files/images/photo.png"
+	dest := "/files/images/photo.png.webp"
+	require.NoError(t, s.Put(ctx, src, strings.NewReader("source"), -1))
+
+	srcPath, releaseSrc, err := storage.Fetch(ctx, s, src)
+	require.NoError(t, err)
+	data, err := os.ReadFile(srcPath)
+	require.NoError(t, err)
+	assert.Equal(t, "source", string(data))
+	assert.True(t, strings.HasSuffix(srcPath, ".png"))
+
+	destPath, store, releaseDest, err := storage.Output(s, dest)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(destPath, []byte("output"), 0o600))
+	require.NoError(t, store(ctx))
+
+	stored, _, ok := fake.object("converter/files/images/photo.png.webp")
+	require.True(t, ok)
+	assert.Equal(t, "output", string(stored))
+
+	// Local copies are removed once released
+	releaseSrc()
+	releaseDest()
+	assert.NoFileExists(t, srcPath)
+	assert.NoFileExists(t, destPath)
+}
+
+func TestS3StorageCopy(t *testing.T) {
+	ctx := context.Background()
+	s, fake := newStorage(t)
+
+	src := "/files/images/photo.png.webp"
+	dest := "/files/copies/photo.png.webp"
+
+	assert.ErrorIs(t, storage.Copy(ctx, s, src, dest), storage.ErrNotExist)
+
+	require.NoError(t, s.Put(ctx, src, strings.NewReader("output"), -1))
+	require.NoError(t, storage.Copy(ctx, s, src, dest))
+
+	stored, _, ok := fake.object("converter/files/copies/photo.png.webp")
+	require.True(t, ok)
+	assert.Equal(t, "output", string(stored))
+}
@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/chistyakoviv/converter/internal/file"
+)
+
+const dirPermissions = 0755
+
+var ErrNotExist = errors.New("file does not exist")
+
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Storage keeps the sources and their converted files.
+// Keys are the paths of files with a leading slash, the same as the paths of conversions.
+type Storage interface {
+	// Returns ErrNotExist if there is no such file
+	Stat(ctx context.Context, key string) (*Object, error)
+	// Returns ErrNotExist if there is no such file
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Streams the reader to the file replacing the existing one, the size is -1 if unknown
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// The absence of a file is not considered an error
+	Remove(ctx context.Context, key string) error
+}
+
+// Copier is implemented by the storages that copy files without streaming them through the application.
+type Copier interface {
+	// Replaces the destination with a copy of the source, returns ErrNotExist if there is no source
+	Copy(ctx context.Context, srcKey string, dstKey string) error
+}
+
+// Local is implemented by the storages keeping files on the local disk,
+// so the converters can access them without copying.
+type Local interface {
+	Path(key string) (string, error)
+}
+
+func Exists(ctx context.Context, s Storage, key string) (bool, error) {
+	_, err := s.Stat(ctx, key)
+	if errors.Is(err, ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Copy copies the file within the storage, the file is streamed if the storage cannot copy it itself.
+func Copy(ctx context.Context, s Storage, srcKey string, dstKey string) error {
+	if copier, ok := s.(Copier); ok {
+		return copier.Copy(ctx, srcKey, dstKey)
+	}
+
+	obj, err := s.Stat(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	r, err := s.Open(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	return s.Put(ctx, dstKey, r, obj.Size)
+}
+
+// DetectMediaType reads only the head of the file to detect its type.
+func DetectMediaType(ctx context.Context, s Storage, key string) (file.MediaType, error) {
+	r, err := s.Open(ctx, key)
+	if err != nil {
+		return file.MediaTypeUnknown, err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	return file.DetectReaderMediaType(r)
+}
+
+// Fetch returns a path of the file on the local disk, as the converters work with files only.
+// Files of a remote storage are downloaded to a temporary directory removed by the release function.
+func Fetch(ctx context.Context, s Storage, key string) (string, func(), error) {
+	if local, ok := s.(Local); ok {
+		localPath, err := local.Path(key)
+		return localPath, func() {}, err
+	}
+
+	dir, release, err := scratchDir()
+	if err != nil {
+		return "", nil, err
+	}
+
+	localPath := filepath.Join(dir, path.Base(key))
+	if err = download(ctx, s, key, localPath); err != nil {
+		release()
+		return "", nil, err
+	}
+
+	return localPath, release, nil
+}
+
+// Output returns a path on the local disk a converter writes the file to and a function storing the written file.
+// Files of a local storage are written in place, so storing them does nothing.
+// The temporary directory of a remote storage is removed by the release function.
+func Output(s Storage, key string) (string, func(ctx context.Context) error, func(), error) {
+	if local, ok := s.(Local); ok {
+		localPath, err := local.Path(key)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		// Outputs may be placed in a directory that does not exist yet
+		if err = os.MkdirAll(filepath.Dir(localPath), dirPermissions); err != nil {
+			return "", nil, nil, err
+		}
+		return localPath, func(context.Context) error { return nil }, func() {}, nil
+	}
+
+	dir, release, err := scratchDir()
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	localPath := filepath.Join(dir, path.Base(key))
+	store := func(ctx context.Context) error {
+		return upload(ctx, s, key, localPath)
+	}
+
+	return localPath, store, release, nil
+}
+
+func scratchDir() (string, func(), error) {
+	dir, err := os.MkdirTemp("", "converter-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	return dir, func() {
+		_ = os.RemoveAll(dir)
+	}, nil
+}
+
+func download(ctx context.Context, s Storage, key string, localPath string) error {
+	r, err := s.Open(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	f, err := os.Create(localPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to download '%s': %w", key, err)
+	}
+
+	return f.Close()
+}
+
+func upload(ctx context.Context, s Storage, key string, localPath string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if err = s.Put(ctx, key, f, info.Size()); err != nil {
+		return fmt.Errorf("failed to upload '%s': %w", key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/chistyakoviv/converter/internal/tracing"
+)
+
+func newTracer() (trace.Tracer, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	return provider.Tracer("tests"), exporter
+}
+
+func TestTraceParent(t *testing.T) {
+	tracer, exporter := newTracer()
+
+	// The job enqueued by the request is a child of the request span
+	reqCtx, reqSpan := tracer.Start(context.Background(), "request")
+	traceParent := tracing.TraceParent(reqCtx)
+	reqSpan.End()
+
+	jobCtx, jobSpan := tracer.Start(tracing.ContextWithTraceParent(context.Background(), traceParent), "conversion")
+	jobSpan.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, spans[0].SpanContext.TraceID(), trace.SpanContextFromContext(jobCtx).TraceID())
+	assert.Equal(t, spans[0].SpanContext.SpanID(), spans[1].Parent.SpanID())
+	assert.True(t, spans[1].Parent.IsRemote())
+}
+
+func TestTraceParentOfUntracedContext(t *testing.T) {
+	ctx := context.Background()
+
+	assert.Empty(t, tracing.TraceParent(ctx))
+	assert.Equal(t, ctx, tracing.ContextWithTraceParent(ctx, ""))
+	assert.False(t, trace.SpanContextFromContext(tracing.ContextWithTraceParent(ctx, "invalid")).IsValid())
+}
+
+func TestWithSpanOf(t *testing.T) {
+	tracer, _ := newTracer()
+
+	type key struct{}
+	appCtx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "app"))
+	defer cancel()
+
+	// Untraced requests leave the application context unchanged
+	assert.Equal(t, appCtx, tracing.WithSpanOf(appCtx, context.Background()))
+
+	reqCtx, span := tracer.Start(context.Background(), "request")
+	defer span.End()
+
+	ctx := tracing.WithSpanOf(appCtx, reqCtx)
+	assert.Equal(t, span.SpanContext(), trace.SpanContextFromContext(ctx))
+	assert.Equal(t, "app", ctx.Value(key{}))
+
+	// The application context is not canceled along with the request
+	reqCtx, reqCancel := context.WithCancel(reqCtx)
+	reqCancel()
+	assert.NoError(t, tracing.WithSpanOf(appCtx, reqCtx).Err())
+}
+
+func TestEnd(t *testing.T) {
+	tracer, exporter := newTracer()
+
+	_, span := tracer.Start(context.Background(), "ok")
+	tracing.End(span, nil)
+	_, span = tracer.Start(context.Background(), "failed")
+	tracing.End(span, errors.New("unable to convert file"))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+	assert.Equal(t, "unable to convert file", spans[1].Status.Description)
+	require.Len(t, spans[1].Events, 1)
+	assert.Equal(t, "exception", spans[1].Events[0].Name)
+}
@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chistyakoviv/converter/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/chistyakoviv/converter"
+	serviceName         = "converter"
+	// Key of the W3C trace context header
+	traceParentKey = "traceparent"
+)
+
+// Tracer returns the tracer of the global provider, which discards spans unless the tracing is enabled
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// NewProvider creates a provider exporting spans to the OTLP collector over HTTP
+// and installs it along with the W3C propagator as the global ones.
+// The provider must be shut down to flush the remaining spans.
+func NewProvider(ctx context.Context, cfg *config.Config) (*sdktrace.TracerProvider, error) {
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Tracing.Endpoint)}
+	if cfg.Tracing.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", serviceName),
+			attribute.String("deployment.environment.name", cfg.Env),
+		)),
+		// Requests of traced clients are sampled as decided by the client
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.Tracing.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider, nil
+}
+
+// WithSpanOf returns the context with the span of another context, e.g. of a request.
+// Handlers run services with the application context, so they are not canceled along with the request,
+// but their spans must belong to the trace of the request.
+func WithSpanOf(ctx context.Context, other context.Context) context.Context {
+	span := trace.SpanFromContext(other)
+	if !span.SpanContext().IsValid() {
+		return ctx
+	}
+	return trace.ContextWithSpan(ctx, span)
+}
+
+// TraceParent returns the W3C traceparent of the span of the context, empty if the context isn't traced.
+// It is stored with the tasks, so their processing is traced back to the request that enqueued them.
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier[traceParentKey]
+}
+
+// ContextWithTraceParent returns the context with the remote span described by the traceparent,
+// the context is returned unchanged if the traceparent is empty or invalid
+func ContextWithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if traceParent == "" {
+		return ctx
+	}
+	return propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{traceParentKey: traceParent})
+}
+
+// Fail records the error on the span and marks the span as failed
+func Fail(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// End records the error on the span if any and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		Fail(span, err)
+	}
+	span.End()
+}
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE conversion_queue ADD COLUMN IF NOT EXISTS quality_results JSONB NOT NULL DEFAULT '[]'::jsonb; -- Chosen encoder quality and perceptual score per target format
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE conversion_queue DROP COLUMN IF EXISTS quality_results;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE deletion_queue ADD COLUMN IF NOT EXISTS delete_source BOOLEAN NOT NULL DEFAULT false; -- Remove the source file along with the converted files
+ALTER TABLE deletion_queue ADD COLUMN IF NOT EXISTS delete_record BOOLEAN NOT NULL DEFAULT false; -- Remove the conversion and deletion rows once done, so the path can be enqueued again
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE deletion_queue DROP COLUMN IF EXISTS delete_record;
+ALTER TABLE deletion_queue DROP COLUMN IF EXISTS delete_source;
+-- +goose StatementEnd
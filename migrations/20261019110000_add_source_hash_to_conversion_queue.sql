@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE conversion_queue ADD COLUMN IF NOT EXISTS source_hash VARCHAR(64) NOT NULL DEFAULT ''; -- SHA-256 of the source used by hashed output names
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE conversion_queue DROP COLUMN IF EXISTS source_hash;
+-- +goose StatementEnd
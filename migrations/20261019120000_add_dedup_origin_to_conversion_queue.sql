@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE conversion_queue ADD COLUMN IF NOT EXISTS dedup_origin VARCHAR(255) NOT NULL DEFAULT ''; -- Source whose converted files were copied instead of converting the file
+CREATE INDEX IF NOT EXISTS conversion_queue_source_hash_idx ON conversion_queue (source_hash);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS conversion_queue_source_hash_idx;
+ALTER TABLE conversion_queue DROP COLUMN IF EXISTS dedup_origin;
+-- +goose StatementEnd
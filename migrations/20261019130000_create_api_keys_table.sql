@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id               SERIAL PRIMARY KEY,
+    name             VARCHAR(255) NOT NULL,
+    prefix           VARCHAR(16) NOT NULL, -- The beginning of the key, allows to tell the keys apart
+    key_hash         CHAR(64) NOT NULL UNIQUE, -- SHA-256 of the key, the key itself is never stored
+    scopes           TEXT[] NOT NULL,
+    path_prefixes    TEXT[] NOT NULL DEFAULT '{}',
+    created_at       TIMESTAMP NOT NULL DEFAULT NOW(),
+    revoked_at       TIMESTAMP
+);
+ALTER TABLE conversion_queue ADD COLUMN IF NOT EXISTS api_key_id INTEGER REFERENCES api_keys (id); -- Key that enqueued the conversion
+ALTER TABLE deletion_queue ADD COLUMN IF NOT EXISTS api_key_id INTEGER REFERENCES api_keys (id); -- Key that enqueued the deletion
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE deletion_queue DROP COLUMN IF EXISTS api_key_id;
+ALTER TABLE conversion_queue DROP COLUMN IF EXISTS api_key_id;
+DROP TABLE IF EXISTS api_keys;
+-- +goose StatementEnd
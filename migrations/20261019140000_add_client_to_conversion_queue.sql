@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE conversion_queue ADD COLUMN IF NOT EXISTS client VARCHAR(64) NOT NULL DEFAULT ''; -- Client that enqueued the conversion, empty for scans and the watcher
+ALTER TABLE conversion_queue ADD COLUMN IF NOT EXISTS source_size BIGINT NOT NULL DEFAULT 0; -- Size of the source when it was enqueued
+CREATE INDEX IF NOT EXISTS conversion_queue_client_idx ON conversion_queue (client) WHERE status = 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS conversion_queue_client_idx;
+ALTER TABLE conversion_queue DROP COLUMN IF EXISTS source_size;
+ALTER TABLE conversion_queue DROP COLUMN IF EXISTS client;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE conversion_queue ADD COLUMN IF NOT EXISTS trace_parent VARCHAR(55) NOT NULL DEFAULT ''; -- W3C traceparent of the request that enqueued the conversion
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE conversion_queue DROP COLUMN IF EXISTS trace_parent;
+-- +goose StatementEnd
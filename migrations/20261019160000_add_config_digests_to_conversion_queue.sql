@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE conversion_queue ADD COLUMN IF NOT EXISTS config_digests JSONB NOT NULL DEFAULT '{}'::jsonb; -- Digest of the settings each converted file was produced with per target format
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE conversion_queue DROP COLUMN IF EXISTS config_digests;
+-- +goose StatementEnd
@@ -0,0 +1,8 @@
+// Package migrations embeds the schema migrations, so they are applied by the binary itself.
+// New migrations are created with goose, see the migration target of the Makefile.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS